@@ -74,6 +74,21 @@ create table project (
  update_time timestamp,
  deleted tinyint (1) DEFAULT 0 NOT NULL,
  public tinyint (1) DEFAULT 0 NOT NULL,
+ # the quotas of the project, 0 means no limit
+ storage_limit bigint DEFAULT 0 NOT NULL,
+ repo_limit int DEFAULT 0 NOT NULL,
+ # the target the project caches images of, 0 means the project is not a proxy cache,
+ # and the seconds after which the cached tags are revalidated against the target
+ proxy_target_id int DEFAULT 0 NOT NULL,
+ proxy_ttl int DEFAULT 0 NOT NULL,
+ # only the images signed by the trusted keys of the project can be pulled if it's set
+ require_signature tinyint (1) DEFAULT 0 NOT NULL,
+ # the images pushed to the project are scanned for vulnerabilities if it's set
+ scan_on_push tinyint (1) DEFAULT 0 NOT NULL,
+ # the images with vulnerabilities of the severity or higher can not be pulled, empty means no limit,
+ # and whether the images which have never been scanned can not be pulled
+ vulnerability_severity varchar(16) DEFAULT '' NOT NULL,
+ block_unscanned tinyint (1) DEFAULT 0 NOT NULL,
  primary key (project_id),
  FOREIGN KEY (owner_id) REFERENCES user(user_id),
  UNIQUE (name)
@@ -120,6 +135,8 @@ create table repository (
  description text,
  pull_count int DEFAULT 0 NOT NULL,
  star_count int DEFAULT 0 NOT NULL,
+ # the total size of the layers referenced by the tags of the repository
+ size bigint DEFAULT 0 NOT NULL,
  creation_time timestamp default CURRENT_TIMESTAMP,
  update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
  primary key (repository_id),
@@ -136,6 +153,26 @@ create table replication_policy (
  deleted tinyint (1) DEFAULT 0 NOT NULL,
  cron_str varchar(256),
  start_time timestamp NULL,
+ /*
+ direction indicates how the images are replicated,
+ "push" means from the project to the target,
+ "pull" means from the target to the project
+ */
+ direction varchar(8) NOT NULL DEFAULT 'push',
+ /* the comma separated repositories, optionally with tags, pulled from the target in pull mode */
+ repositories text,
+ /*
+ the filters of push mode: the globs which the names of repositories, without the project,
+ must and must not match, the regular expressions which the tags must and must not match,
+ and the user who pushed the tags
+ */
+ repo_filter varchar(256),
+ repo_exclude_filter varchar(256),
+ tag_filter varchar(256),
+ tag_exclude_filter varchar(256),
+ pushed_by varchar(255),
+ /* the time the last scheduled run was triggered, it's claimed by a conditional update so a run is triggered by only one job service instance */
+ last_scheduled_time timestamp NULL,
  creation_time timestamp default CURRENT_TIMESTAMP,
  update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
  PRIMARY KEY (id)
@@ -165,13 +202,305 @@ create table replication_job (
  repository varchar(256) NOT NULL,
  operation  varchar(64) NOT NULL,
  tags   varchar(16384),
+ /* the ID of the request which created the job, it's written into the job log */
+ request_id varchar(64) NOT NULL default '',
+ /* the queued jobs are handled in the order of priority desc, schedule_time */
+ priority int NOT NULL default 0,
+ /* the job can't be handled before schedule_time, e.g. the job waiting for retrying */
+ schedule_time timestamp default CURRENT_TIMESTAMP,
+ /* the running job is claimed again once its lease expires, e.g. the job service crashed */
+ lease_expiration timestamp NULL,
+ /* the ID of the job service instance which claimed the job and renews its lease */
+ lease_owner varchar(64) NOT NULL default '',
+ /* set when the job is requested to be stopped, the owner stops it once it sees the flag */
+ stop_requested tinyint(1) NOT NULL default 0,
+ /* the blob upload sessions in JSON, saved when the uploads fail so the job resumes them when it's retried */
+ upload_sessions text,
  creation_time timestamp default CURRENT_TIMESTAMP,
  update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
  PRIMARY KEY (id),
  INDEX policy (policy_id),
- INDEX poid_uptime (policy_id, update_time)
+ INDEX poid_uptime (policy_id, update_time),
+ INDEX queue (status, priority, schedule_time)
+ );
+ 
+create table retention_policy (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ /*
+ keep_latest is the number of the newest tags kept in every repository,
+ 0 means no limit
+ */
+ keep_latest int NOT NULL DEFAULT 0,
+ /* the tags matching keep_pattern are always kept */
+ keep_pattern varchar(256),
+ /*
+ max_age_days is the number of days after which a tag can be deleted,
+ 0 means no limit
+ */
+ max_age_days int NOT NULL DEFAULT 0,
+ enabled tinyint(1) NOT NULL DEFAULT 1,
+ cron_str varchar(256),
+ /* the user who created the policy, the tags deleted by the policy are recorded in access log as deleted by the user */
+ creator_id int NOT NULL DEFAULT 0,
+ /* the time the last scheduled run was triggered, it's claimed by a conditional update so a run is triggered by only one job service instance */
+ last_scheduled_time timestamp NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (project_id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id)
+ );
+
+create table retention_job (
+ id int NOT NULL AUTO_INCREMENT,
+ policy_id int NOT NULL,
+ status varchar(64) NOT NULL,
+ dry_run tinyint(1) NOT NULL DEFAULT 0,
+ result text,
+ /* the running job is claimed again once its lease expires, e.g. the job service crashed */
+ lease_expiration timestamp NULL,
+ /* the ID of the job service instance which claimed the job and renews its lease */
+ lease_owner varchar(64) NOT NULL default '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ INDEX retention_job_policy (policy_id),
+ INDEX retention_job_status (status)
+ );
+ 
+create table gc_job (
+ id int NOT NULL AUTO_INCREMENT,
+ status varchar(64) NOT NULL,
+ dry_run tinyint(1) NOT NULL DEFAULT 0,
+ /* the number of blobs unlinked from the repositories, or to be unlinked if it is a dry run */
+ blobs int NOT NULL DEFAULT 0,
+ /* the size of the blobs unlinked, the space is reclaimed once the offline garbage collection of registry is run */
+ unlinked_bytes bigint NOT NULL DEFAULT 0,
+ /* the running job is claimed again once its lease expires, e.g. the job service crashed */
+ lease_expiration timestamp NULL,
+ /* the ID of the job service instance which claimed the job and renews its lease */
+ lease_owner varchar(64) NOT NULL default '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ INDEX gc_job_status (status)
+ );
+ 
+create table webhook (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ target_url varchar(256) NOT NULL,
+ /* the secret used to sign the payload, it is encrypted */
+ secret varchar(256),
+ /* the comma separated types of events the webhook subscribes */
+ event_types varchar(256) NOT NULL,
+ enabled tinyint(1) NOT NULL DEFAULT 1,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id)
+ );
+
+create table webhook_delivery (
+ id int NOT NULL AUTO_INCREMENT,
+ webhook_id int NOT NULL,
+ event_type varchar(64) NOT NULL,
+ payload text NOT NULL,
+ status varchar(64) NOT NULL,
+ attempts int NOT NULL DEFAULT 0,
+ response_code int NOT NULL DEFAULT 0,
+ last_error varchar(512),
+ next_attempt_time timestamp default CURRENT_TIMESTAMP,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ INDEX webhook_delivery_webhook (webhook_id),
+ INDEX webhook_delivery_status (status, next_attempt_time)
+ );
+
+create table robot (
+ id int NOT NULL AUTO_INCREMENT,
+ name varchar(64) NOT NULL,
+ project_id int NOT NULL,
+ description varchar(1024),
+ /* the secret is hashed with the salt as the password of user */
+ secret varchar(40) NOT NULL,
+ salt varchar(40) NOT NULL,
+ /* the comma separated actions the robot is allowed to perform */
+ actions varchar(64) NOT NULL,
+ expires_at timestamp NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (project_id, name),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (creator_id) REFERENCES user(user_id)
+ );
+
+create table signing_key (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ name varchar(64) NOT NULL,
+ /* the PEM encoded public key trusted by the project */
+ public_key text NOT NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (project_id, name),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (creator_id) REFERENCES user(user_id)
+ );
+
+create table signature (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ /* the digest of the signed manifest */
+ digest varchar(128) NOT NULL,
+ /* the signing key which the signature is verified with */
+ key_id int NOT NULL,
+ /* the base64 encoded signature */
+ signature text NOT NULL,
+ /* the name of the user or robot account which attached the signature */
+ creator varchar(255) NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (key_id, digest),
+ INDEX project_digest (project_id, digest),
+ FOREIGN KEY (key_id) REFERENCES signing_key(id)
+ );
+
+create table scan_job (
+ id int NOT NULL AUTO_INCREMENT,
+ repository varchar(256) NOT NULL,
+ tag varchar(128) NOT NULL,
+ /* the digest of the manifest the tag refers to when it is scanned */
+ digest varchar(128),
+ status varchar(64) NOT NULL,
+ /* the running job is claimed again once its lease expires, e.g. the job service crashed */
+ lease_expiration timestamp NULL,
+ /* the ID of the job service instance which claimed the job and renews its lease */
+ lease_owner varchar(64) NOT NULL default '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ INDEX scan_job_repo_tag (repository, tag),
+ INDEX scan_job_status (status)
+ );
+ 
+create table scan_result (
+ id int NOT NULL AUTO_INCREMENT,
+ /* the digest of the scanned manifest, the result is shared by the tags referring to it */
+ digest varchar(128) NOT NULL,
+ job_id int NOT NULL,
+ scanner varchar(64) NOT NULL,
+ /* the highest severity of the vulnerabilities */
+ severity varchar(16) NOT NULL,
+ /* the vulnerabilities found in JSON */
+ vulnerabilities mediumtext NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (digest)
+ );
+ 
+create table cve_allowlist (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ /* the vulnerability ignored by the pull policy of the project until it expires */
+ cve_id varchar(64) NOT NULL,
+ reason varchar(1024),
+ expires_at timestamp NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (project_id, cve_id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id)
+ );
+ 
+create table oidc_user (
+ id int NOT NULL AUTO_INCREMENT,
+ user_id int NOT NULL,
+ /* the "sub" claim of the ID token issued by the OIDC provider */
+ subject varchar(255) NOT NULL,
+ /* the CLI secret is hashed with the salt as the password of user */
+ secret varchar(40) NOT NULL DEFAULT '',
+ salt varchar(40) NOT NULL DEFAULT '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (user_id),
+ UNIQUE (subject),
+ FOREIGN KEY (user_id) REFERENCES user(user_id)
+ );
+
+create table oidc_group_role (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ /* the group in the groups claim of the ID token */
+ group_name varchar(128) NOT NULL,
+ role int NOT NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (project_id, group_name),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (role) REFERENCES role(role_id)
+ );
+
+/* the project members added according to the groups of OIDC or LDAP users, they
+   are removed once the user is no longer in a group the project grants a role to */
+create table group_member_grant (
+ project_id int NOT NULL,
+ user_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (project_id, user_id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (user_id) REFERENCES user(user_id)
  );
  
+create table project_ldap_group (
+ id int NOT NULL AUTO_INCREMENT,
+ project_id int NOT NULL,
+ /* the normalized DN of the LDAP group whose members are granted the role */
+ group_dn varchar(255) NOT NULL,
+ role int NOT NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ UNIQUE (project_id, group_dn),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (role) REFERENCES role(role_id)
+ );
+
+/* the audit trail of the operations on users, projects, project members, replication
+   targets and policies and of the logins, the operations on images are recorded
+   in access_log */
+create table audit_log (
+ id int NOT NULL AUTO_INCREMENT,
+ actor_id int NOT NULL default 0,
+ actor varchar(255) NOT NULL default '',
+ resource_type varchar(64) NOT NULL,
+ resource_id varchar(128) NOT NULL default '',
+ action varchar(64) NOT NULL,
+ before_summary varchar(1024) NOT NULL default '',
+ after_summary varchar(1024) NOT NULL default '',
+ source_ip varchar(64) NOT NULL default '',
+ op_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id),
+ INDEX audit_log_optime (op_time),
+ INDEX audit_log_resource (resource_type, resource_id)
+ );
+
+create table job_service_instance (
+ /* the ID of the instance, the hostname of it by default */
+ id varchar(64) NOT NULL,
+ heartbeat_time timestamp default CURRENT_TIMESTAMP,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id)
+ );
+
 create table properties (
  k varchar(64) NOT NULL,
  v varchar(128) NOT NULL,
@@ -182,4 +511,4 @@ CREATE TABLE IF NOT EXISTS `alembic_version` (
     `version_num` varchar(32) NOT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
-insert into alembic_version values ('0.4.0');
+insert into alembic_version values ('0.5.0');
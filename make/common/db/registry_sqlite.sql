@@ -72,6 +72,19 @@ create table project (
  update_time timestamp,
  deleted tinyint (1) DEFAULT 0 NOT NULL,
  public tinyint (1) DEFAULT 0 NOT NULL,
+/* the quotas of the project, 0 means no limit */
+ storage_limit bigint DEFAULT 0 NOT NULL,
+ repo_limit int DEFAULT 0 NOT NULL,
+/*
+ the target the project caches images of, 0 means the project is not a proxy cache,
+ and the seconds after which the cached tags are revalidated against the target
+*/
+ proxy_target_id int DEFAULT 0 NOT NULL,
+ proxy_ttl int DEFAULT 0 NOT NULL,
+ require_signature tinyint (1) DEFAULT 0 NOT NULL,
+ scan_on_push tinyint (1) DEFAULT 0 NOT NULL,
+ vulnerability_severity varchar(16) DEFAULT '' NOT NULL,
+ block_unscanned tinyint (1) DEFAULT 0 NOT NULL,
  FOREIGN KEY (owner_id) REFERENCES user(user_id),
  UNIQUE (name)
 );
@@ -117,6 +130,8 @@ create table repository (
  description text,
  pull_count int DEFAULT 0 NOT NULL,
  star_count int DEFAULT 0 NOT NULL,
+/* the total size of the layers referenced by the tags of the repository */
+ size bigint DEFAULT 0 NOT NULL,
  creation_time timestamp default CURRENT_TIMESTAMP,
  update_time timestamp default CURRENT_TIMESTAMP,
  FOREIGN KEY (owner_id) REFERENCES user(user_id),
@@ -134,6 +149,14 @@ create table replication_policy (
  deleted tinyint (1) DEFAULT 0 NOT NULL,
  cron_str varchar(256),
  start_time timestamp NULL,
+ direction varchar(8) NOT NULL DEFAULT 'push',
+ repositories text,
+ repo_filter varchar(256),
+ repo_exclude_filter varchar(256),
+ tag_filter varchar(256),
+ tag_exclude_filter varchar(256),
+ pushed_by varchar(255),
+ last_scheduled_time timestamp NULL,
  creation_time timestamp default CURRENT_TIMESTAMP,
  update_time timestamp default CURRENT_TIMESTAMP
  );
@@ -161,13 +184,253 @@ create table replication_job (
  repository varchar(256) NOT NULL,
  operation  varchar(64) NOT NULL,
  tags   varchar(16384),
+ request_id varchar(64) NOT NULL default '',
+ priority int NOT NULL default 0,
+ schedule_time timestamp default CURRENT_TIMESTAMP,
+ lease_expiration timestamp NULL,
+ lease_owner varchar(64) NOT NULL default '',
+ stop_requested tinyint(1) NOT NULL default 0,
+ upload_sessions text,
  creation_time timestamp default CURRENT_TIMESTAMP,
  update_time timestamp default CURRENT_TIMESTAMP
  );
 
 CREATE INDEX policy ON replication_job (policy_id);
 CREATE INDEX poid_uptime ON replication_job (policy_id, update_time);
+CREATE INDEX rep_job_queue ON replication_job (status, priority, schedule_time);
+ 
+create table retention_policy (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ /*
+ keep_latest is the number of the newest tags kept in every repository,
+ 0 means no limit
+ */
+ keep_latest int NOT NULL DEFAULT 0,
+ /* the tags matching keep_pattern are always kept */
+ keep_pattern varchar(256),
+ /*
+ max_age_days is the number of days after which a tag can be deleted,
+ 0 means no limit
+ */
+ max_age_days int NOT NULL DEFAULT 0,
+ enabled tinyint(1) NOT NULL DEFAULT 1,
+ cron_str varchar(256),
+ creator_id int NOT NULL DEFAULT 0,
+ last_scheduled_time timestamp NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (project_id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id)
+ );
+
+create table retention_job (
+ id INTEGER PRIMARY KEY,
+ policy_id int NOT NULL,
+ status varchar(64) NOT NULL,
+ dry_run tinyint(1) NOT NULL DEFAULT 0,
+ result text,
+ lease_expiration timestamp NULL,
+ lease_owner varchar(64) NOT NULL default '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP
+ );
+
+CREATE INDEX retention_job_policy ON retention_job (policy_id);
+CREATE INDEX retention_job_status ON retention_job (status);
+ 
+create table gc_job (
+ id INTEGER PRIMARY KEY,
+ status varchar(64) NOT NULL,
+ dry_run tinyint(1) NOT NULL DEFAULT 0,
+ blobs int NOT NULL DEFAULT 0,
+ unlinked_bytes bigint NOT NULL DEFAULT 0,
+ lease_expiration timestamp NULL,
+ lease_owner varchar(64) NOT NULL default '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP
+ );
+
+CREATE INDEX gc_job_status ON gc_job (status);
  
+create table webhook (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ target_url varchar(256) NOT NULL,
+ secret varchar(256),
+ event_types varchar(256) NOT NULL,
+ enabled tinyint(1) NOT NULL DEFAULT 1,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP,
+ FOREIGN KEY (project_id) REFERENCES project(project_id)
+ );
+
+create table webhook_delivery (
+ id INTEGER PRIMARY KEY,
+ webhook_id int NOT NULL,
+ event_type varchar(64) NOT NULL,
+ payload text NOT NULL,
+ status varchar(64) NOT NULL,
+ attempts int NOT NULL DEFAULT 0,
+ response_code int NOT NULL DEFAULT 0,
+ last_error varchar(512),
+ next_attempt_time timestamp default CURRENT_TIMESTAMP,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP
+ );
+
+CREATE INDEX webhook_delivery_webhook ON webhook_delivery (webhook_id);
+CREATE INDEX webhook_delivery_status ON webhook_delivery (status, next_attempt_time);
+
+create table robot (
+ id INTEGER PRIMARY KEY,
+ name varchar(64) NOT NULL,
+ project_id int NOT NULL,
+ description varchar(1024),
+ secret varchar(40) NOT NULL,
+ salt varchar(40) NOT NULL,
+ actions varchar(64) NOT NULL,
+ expires_at timestamp NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (project_id, name),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (creator_id) REFERENCES user(user_id)
+ );
+
+create table signing_key (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ name varchar(64) NOT NULL,
+ public_key text NOT NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (project_id, name),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (creator_id) REFERENCES user(user_id)
+ );
+
+create table signature (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ digest varchar(128) NOT NULL,
+ key_id int NOT NULL,
+ signature text NOT NULL,
+ creator varchar(255) NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (key_id, digest),
+ FOREIGN KEY (key_id) REFERENCES signing_key(id)
+ );
+
+create table scan_job (
+ id INTEGER PRIMARY KEY,
+ repository varchar(256) NOT NULL,
+ tag varchar(128) NOT NULL,
+ digest varchar(128),
+ status varchar(64) NOT NULL,
+ lease_expiration timestamp NULL,
+ lease_owner varchar(64) NOT NULL default '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP
+ );
+
+CREATE INDEX scan_job_repo_tag ON scan_job (repository, tag);
+CREATE INDEX scan_job_status ON scan_job (status);
+
+create table scan_result (
+ id INTEGER PRIMARY KEY,
+ digest varchar(128) NOT NULL,
+ job_id int NOT NULL,
+ scanner varchar(64) NOT NULL,
+ severity varchar(16) NOT NULL,
+ vulnerabilities text NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (digest)
+ );
+
+create table cve_allowlist (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ cve_id varchar(64) NOT NULL,
+ reason varchar(1024),
+ expires_at timestamp NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (project_id, cve_id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id)
+ );
+
+create table oidc_user (
+ id INTEGER PRIMARY KEY,
+ user_id int NOT NULL,
+ subject varchar(255) NOT NULL,
+ secret varchar(40) NOT NULL DEFAULT '',
+ salt varchar(40) NOT NULL DEFAULT '',
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ update_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (user_id),
+ UNIQUE (subject),
+ FOREIGN KEY (user_id) REFERENCES user(user_id)
+ );
+
+create table oidc_group_role (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ group_name varchar(128) NOT NULL,
+ role int NOT NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (project_id, group_name),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (role) REFERENCES role(role_id)
+ );
+
+create table group_member_grant (
+ project_id int NOT NULL,
+ user_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (project_id, user_id),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (user_id) REFERENCES user(user_id)
+ );
+
+create table project_ldap_group (
+ id INTEGER PRIMARY KEY,
+ project_id int NOT NULL,
+ group_dn varchar(255) NOT NULL,
+ role int NOT NULL,
+ creator_id int NOT NULL,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ UNIQUE (project_id, group_dn),
+ FOREIGN KEY (project_id) REFERENCES project(project_id),
+ FOREIGN KEY (role) REFERENCES role(role_id)
+ );
+
+create table audit_log (
+ id INTEGER PRIMARY KEY,
+ actor_id int NOT NULL default 0,
+ actor varchar(255) NOT NULL default '',
+ resource_type varchar(64) NOT NULL,
+ resource_id varchar(128) NOT NULL default '',
+ action varchar(64) NOT NULL,
+ before_summary varchar(1024) NOT NULL default '',
+ after_summary varchar(1024) NOT NULL default '',
+ source_ip varchar(64) NOT NULL default '',
+ op_time timestamp default CURRENT_TIMESTAMP
+ );
+
+CREATE INDEX audit_log_optime ON audit_log (op_time);
+CREATE INDEX audit_log_resource ON audit_log (resource_type, resource_id);
+
+create table job_service_instance (
+ id varchar(64) NOT NULL,
+ heartbeat_time timestamp default CURRENT_TIMESTAMP,
+ creation_time timestamp default CURRENT_TIMESTAMP,
+ PRIMARY KEY (id)
+ );
+
 create table properties (
  k varchar(64) NOT NULL,
  v varchar(128) NOT NULL,
@@ -50,6 +50,7 @@ services:
     volumes:
       - ./common/config/ui/app.conf:/etc/ui/app.conf
       - ./common/config/ui/private_key.pem:/etc/ui/private_key.pem
+      - ./common/config/ui/ldap_ca.crt:/etc/ui/ldap_ca.crt
       - /data:/harbor_storage
     depends_on:
       - log
@@ -66,6 +67,8 @@ services:
     restart: always
     volumes:
       - /data/job_logs:/var/log/jobs
+      - /data/registry:/storage
+      - /data/cve:/cve
       - ./common/config/jobservice/app.conf:/etc/jobservice/app.conf
     depends_on:
       - ui
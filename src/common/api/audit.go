@@ -0,0 +1,88 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/context"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// Audit records the audit log of the operation handled in the request context, the
+// source IP is taken from the request and the actor's name is looked up if it's not
+// set. The failure is only logged as the operation has been done
+func Audit(ctx *context.Context, auditLog models.AuditLog) {
+	if ctx != nil {
+		auditLog.SourceIP = SourceIP(ctx)
+	}
+
+	if len(auditLog.Actor) == 0 && auditLog.ActorID != 0 {
+		user, err := dao.GetUser(models.User{UserID: auditLog.ActorID})
+		if err != nil {
+			log.Errorf("failed to get user %d for audit log: %v", auditLog.ActorID, err)
+		} else if user != nil {
+			auditLog.Actor = user.Username
+		}
+	}
+
+	if _, err := dao.AddAuditLog(auditLog); err != nil {
+		log.Errorf("failed to add audit log %+v: %v", auditLog, err)
+	}
+}
+
+// Audit records the audit log of the operation handled by the API
+func (b *BaseAPI) Audit(auditLog models.AuditLog) {
+	Audit(b.Ctx, auditLog)
+}
+
+// AuditLogin records the login of the user, it's a failed login of the principal if
+// the user is nil
+func AuditLogin(ctx *context.Context, user *models.User, principal string) {
+	if user == nil {
+		Audit(ctx, models.AuditLog{
+			Actor:        principal,
+			ResourceType: models.AuditResourceUser,
+			Action:       models.AuditActionLoginFailed,
+		})
+		return
+	}
+	Audit(ctx, models.AuditLog{
+		ActorID:      user.UserID,
+		Actor:        user.Username,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   strconv.Itoa(user.UserID),
+		Action:       models.AuditActionLogin,
+	})
+}
+
+// SourceIP returns the IP the request came from. The header "X-Real-IP" is set by the
+// proxy to the address of the client, while the first entry of "X-Forwarded-For" is
+// sent by the client and can't be trusted
+func SourceIP(ctx *context.Context) string {
+	if ip := strings.TrimSpace(ctx.Request.Header.Get("X-Real-IP")); len(ip) != 0 {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		return ctx.Request.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,57 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestSourceIP(t *testing.T) {
+	cases := []struct {
+		remoteAddr   string
+		realIP       string
+		forwardedFor string
+		expected     string
+	}{
+		{"10.0.0.1:50000", "", "", "10.0.0.1"},
+		{"10.0.0.1:50000", "192.168.0.1", "1.2.3.4, 192.168.0.1", "192.168.0.1"},
+		{"10.0.0.1:50000", "", "1.2.3.4", "10.0.0.1"},
+		{"10.0.0.1", "", "", "10.0.0.1"},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "/api/users", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.RemoteAddr = c.remoteAddr
+		if len(c.realIP) != 0 {
+			req.Header.Set("X-Real-IP", c.realIP)
+		}
+		if len(c.forwardedFor) != 0 {
+			req.Header.Set("X-Forwarded-For", c.forwardedFor)
+		}
+		ctx := context.NewContext()
+		ctx.Reset(nil, req)
+
+		if ip := SourceIP(ctx); ip != c.expected {
+			t.Errorf("unexpected source IP: %s != %s", ip, c.expected)
+		}
+	}
+}
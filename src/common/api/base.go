@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/astaxie/beego/validation"
 	"github.com/vmware/harbor/src/common/config"
@@ -109,6 +110,11 @@ func (b *BaseAPI) ValidateUser() int {
 // It returns the user ID, whether need further verification(when the id is from session) and if the action is successful
 func (b *BaseAPI) GetUserIDForRequest() (int, bool, bool) {
 	username, password, ok := b.Ctx.Request.BasicAuth()
+	if ok && strings.HasPrefix(username, models.RobotPrefix) {
+		// robot accounts can only access the APIs which call GetRobot
+		log.Warningf("Request with credentials of robot account %s is not allowed", username)
+		return 0, false, false
+	}
 	if ok {
 		log.Infof("Requst with Basic Authentication header, username: %s", username)
 		user, err := auth.Login(models.AuthModel{
@@ -123,6 +129,7 @@ func (b *BaseAPI) GetUserIDForRequest() (int, bool, bool) {
 			// User login successfully no further check required.
 			return user.UserID, false, true
 		}
+		AuditLogin(b.Ctx, nil, username)
 	}
 	sessionUserID, ok := b.GetSession("userId").(int)
 	if ok {
@@ -133,6 +140,28 @@ func (b *BaseAPI) GetUserIDForRequest() (int, bool, bool) {
 	return 0, false, false
 }
 
+// GetRobot returns the robot account if the request is authenticated with the
+// credentials of a robot account, and nil if it isn't. The request is aborted if
+// the credentials are invalid or the robot account is expired.
+func (b *BaseAPI) GetRobot() *models.Robot {
+	username, secret, ok := b.Ctx.Request.BasicAuth()
+	if !ok || !strings.HasPrefix(username, models.RobotPrefix) {
+		return nil
+	}
+
+	robot, err := auth.LoginRobot(username, secret)
+	if err != nil {
+		log.Errorf("failed to authenticate robot account %s: %v", username, err)
+		b.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if robot == nil {
+		log.Warningf("invalid credentials or expired robot account: %s", username)
+		AuditLogin(b.Ctx, nil, username)
+		b.CustomAbort(http.StatusUnauthorized, "")
+	}
+	return robot
+}
+
 // Redirect does redirection to resource URI with http header status code.
 func (b *BaseAPI) Redirect(statusCode int, resouceID string) {
 	requestURI := b.Ctx.Request.RequestURI
@@ -0,0 +1,210 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// the route label of the requests matching no route registered through Router or
+// Handler, e.g. the static files
+const unknownRoute = "other"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "harbor_http_requests_total",
+		Help: "The count of the HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "code"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "harbor_http_request_duration_seconds",
+		Help:    "The latency of the HTTP requests until the header of the response is written.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// routes holds the patterns of the registered routes by HTTP method, they are
+// matched against the requests the same way as the router of beego does, so the
+// route label of a request is the pattern of the route it matches
+var routes = map[string]*beego.Tree{}
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpRequestDuration)
+}
+
+// Router registers the route to beego as beego.Router does and records its
+// pattern, which labels the requests matching the route in the metrics
+func Router(pattern string, c beego.ControllerInterface, mappingMethods ...string) {
+	beego.Router(pattern, c, mappingMethods...)
+	for _, method := range routeMethods(mappingMethods...) {
+		addRoute(method, pattern)
+	}
+}
+
+// Handler registers the handler to beego as beego.Handler does and records its
+// pattern, which labels the requests matching the route in the metrics
+func Handler(pattern string, h http.Handler) {
+	beego.Handler(pattern, h)
+	for _, method := range routeMethods() {
+		addRoute(method, pattern)
+	}
+}
+
+// routeMethods returns the HTTP methods of the mapping of beego.Router, e.g.
+// "get:List;post,put:Save", all the methods are returned if no mapping is
+// specified or the mapping contains "*"
+func routeMethods(mappingMethods ...string) []string {
+	all := []string{}
+	for method := range beego.HTTPMETHOD {
+		all = append(all, method)
+	}
+	if len(mappingMethods) == 0 {
+		return all
+	}
+
+	methods := []string{}
+	for _, mapping := range strings.Split(mappingMethods[0], ";") {
+		for _, method := range strings.Split(strings.Split(mapping, ":")[0], ",") {
+			if method == "*" {
+				return all
+			}
+			methods = append(methods, strings.ToUpper(method))
+		}
+	}
+	return methods
+}
+
+func addRoute(method, pattern string) {
+	tree, ok := routes[method]
+	if !ok {
+		tree = beego.NewTree()
+		routes[method] = tree
+	}
+	p := pattern
+	if !beego.BConfig.RouterCaseSensitive {
+		p = strings.ToLower(p)
+	}
+	tree.AddRouter(p, pattern)
+}
+
+// matchRoute returns the pattern of the route matched by the request, or
+// unknownRoute if the request matches none
+func matchRoute(r *http.Request) string {
+	tree, ok := routes[r.Method]
+	if !ok {
+		return unknownRoute
+	}
+	path := r.URL.Path
+	if !beego.BConfig.RouterCaseSensitive {
+		path = strings.ToLower(path)
+	}
+	// the tree sets the values of the wildcards to the context passed in, a new
+	// one is used so the context of the request is left untouched
+	if pattern, ok := tree.Match(path, context.NewContext()).(string); ok {
+		return pattern
+	}
+	return unknownRoute
+}
+
+// InsertMetricsFilters inserts the filters instrumenting the requests, it should be
+// called before the other filters are inserted
+func InsertMetricsFilters() {
+	beego.InsertFilter("*", beego.BeforeRouter, MetricsFilter)
+	beego.InsertFilter("*", beego.FinishRouter, MetricsFinishFilter, false)
+}
+
+// MetricsFilter is the beego filter instrumenting the requests, it must be
+// inserted before the router and run before the other filters, so the requests
+// rejected by them are counted
+func MetricsFilter(ctx *context.Context) {
+	ctx.ResponseWriter.ResponseWriter = &instrumentedWriter{
+		ResponseWriter: ctx.ResponseWriter.ResponseWriter,
+		ctx:            ctx,
+		start:          time.Now(),
+	}
+}
+
+// MetricsFinishFilter is the beego filter counting the requests for which
+// nothing is written by the controllers. It must be inserted after the router
+// without returning on output
+func MetricsFinishFilter(ctx *context.Context) {
+	if w, ok := ctx.ResponseWriter.ResponseWriter.(*instrumentedWriter); ok {
+		w.observe(http.StatusOK)
+	}
+}
+
+// instrumentedWriter observes the request when the header of the response is written
+type instrumentedWriter struct {
+	http.ResponseWriter
+	ctx      *context.Context
+	start    time.Time
+	observed bool
+}
+
+func (w *instrumentedWriter) WriteHeader(code int) {
+	w.observe(code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *instrumentedWriter) Write(p []byte) (int, error) {
+	w.observe(http.StatusOK)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *instrumentedWriter) observe(code int) {
+	if w.observed {
+		return
+	}
+	w.observed = true
+
+	method := w.ctx.Request.Method
+	route := matchRoute(w.ctx.Request)
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(w.start).Seconds())
+}
+
+// Hijack implements http.Hijacker
+func (w *instrumentedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("webserver doesn't support hijacking")
+	}
+	return hj.Hijack()
+}
+
+// Flush implements http.Flusher
+func (w *instrumentedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CloseNotify implements http.CloseNotifier
+func (w *instrumentedWriter) CloseNotify() <-chan bool {
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
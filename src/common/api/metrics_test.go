@@ -0,0 +1,116 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func init() {
+	addRoute("GET", "/api/projects/:pid([0-9]+)/members/?:mid")
+	addRoute("GET", "/api/users/?:id")
+	addRoute("GET", "/api/users/:id([0-9]+)/password")
+	addRoute("DELETE", "/api/targets/:id([0-9]+)")
+}
+
+func counterValue(t *testing.T, c *prometheus.CounterVec, labels ...string) float64 {
+	m := &dto.Metric{}
+	if err := c.WithLabelValues(labels...).Write(m); err != nil {
+		t.Fatalf("failed to read the counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestRouteMethods(t *testing.T) {
+	cases := []struct {
+		mapping  []string
+		expected []string
+	}{
+		{[]string{"get:List;post,put:Save"}, []string{"GET", "POST", "PUT"}},
+		{[]string{"delete:Delete"}, []string{"DELETE"}},
+		{[]string{"*:Any"}, nil},
+		{nil, nil},
+	}
+	for _, c := range cases {
+		methods := routeMethods(c.mapping...)
+		sort.Strings(methods)
+		if c.expected == nil {
+			if len(methods) < 7 {
+				t.Errorf("unexpected methods of %v: %v", c.mapping, methods)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(methods, c.expected) {
+			t.Errorf("unexpected methods of %v: %v != %v", c.mapping, methods, c.expected)
+		}
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	cases := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/api/projects/1/members/2", "/api/projects/:pid([0-9]+)/members/?:mid"},
+		{"GET", "/api/projects/1/members", "/api/projects/:pid([0-9]+)/members/?:mid"},
+		{"GET", "/api/users/current", "/api/users/?:id"},
+		{"GET", "/api/users/1/password", "/api/users/:id([0-9]+)/password"},
+		{"GET", "/api/targets/1", unknownRoute},
+		{"DELETE", "/api/targets/abc", unknownRoute},
+		{"GET", "/static/resources/js/harbor.js", unknownRoute},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, c.path, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if r := matchRoute(req); r != c.expected {
+			t.Errorf("unexpected route of %s %s: %s != %s", c.method, c.path, r, c.expected)
+		}
+	}
+}
+
+func TestMetricsFilter(t *testing.T) {
+	req, err := http.NewRequest("DELETE", "/api/targets/3", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	ctx := context.NewContext()
+	ctx.Reset(httptest.NewRecorder(), req)
+
+	route := "/api/targets/:id([0-9]+)"
+	before := counterValue(t, httpRequests, "DELETE", route, "404")
+	MetricsFilter(ctx)
+	ctx.ResponseWriter.WriteHeader(http.StatusNotFound)
+	ctx.ResponseWriter.Write([]byte("not found"))
+	MetricsFinishFilter(ctx)
+
+	if v := counterValue(t, httpRequests, "DELETE", route, "404"); v != before+1 {
+		t.Errorf("unexpected count of requests: %v != %v", v, before+1)
+	}
+	if v := counterValue(t, httpRequests, "DELETE", route, "200"); v != 0 {
+		t.Errorf("the request is counted more than once")
+	}
+}
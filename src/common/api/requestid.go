@@ -0,0 +1,78 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/astaxie/beego/context"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// HeaderRequestID is the header carrying the ID of the request, the UI passes it to the job
+// service, so the logs of a request and of the jobs it creates can be correlated
+const HeaderRequestID = "X-Request-Id"
+
+// the IDs longer than this set by the clients are replaced, so the logs can't be flooded
+const requestIDMaxLen = 64
+
+// RequestIDFilter is the beego filter assigning an ID to every request, the ID set by
+// the client, e.g. the proxy or the UI calling the job service, is kept. The ID is
+// returned in the header of the response
+func RequestIDFilter(ctx *context.Context) {
+	id := ctx.Request.Header.Get(HeaderRequestID)
+	if len(id) == 0 || len(id) > requestIDMaxLen {
+		id = NewRequestID()
+	}
+	ctx.Request.Header.Set(HeaderRequestID, id)
+	ctx.Output.Header(HeaderRequestID, id)
+}
+
+// NewRequestID generates a random ID for the request or the operation not triggered
+// by a request, e.g. the scheduled replication
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Errorf("failed to generate request ID: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestID returns the ID of the request assigned by RequestIDFilter
+func RequestID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	return r.Header.Get(HeaderRequestID)
+}
+
+// RequestLogger returns the logger adding the ID of the request to the logs
+func RequestLogger(requestID string) *log.Logger {
+	return log.WithFields(log.Fields{"request_id": requestID})
+}
+
+// RequestID returns the ID of the request handled by the API
+func (b *BaseAPI) RequestID() string {
+	return RequestID(b.Ctx.Request)
+}
+
+// Logger returns the logger adding the ID of the request handled by the API to the logs
+func (b *BaseAPI) Logger() *log.Logger {
+	return RequestLogger(b.RequestID())
+}
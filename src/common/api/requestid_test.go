@@ -0,0 +1,63 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astaxie/beego/context"
+)
+
+func TestRequestIDFilter(t *testing.T) {
+	cases := []struct {
+		id   string
+		kept bool
+	}{
+		{"", false},
+		{"abc", true},
+		{strings.Repeat("a", requestIDMaxLen+1), false},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "/api/projects", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if len(c.id) != 0 {
+			req.Header.Set(HeaderRequestID, c.id)
+		}
+		rw := httptest.NewRecorder()
+		ctx := context.NewContext()
+		ctx.Reset(rw, req)
+
+		RequestIDFilter(ctx)
+
+		id := RequestID(req)
+		if len(id) == 0 || (id == c.id) != c.kept {
+			t.Errorf("unexpected request ID for %q: %q", c.id, id)
+		}
+		if rw.Header().Get(HeaderRequestID) != id {
+			t.Errorf("unexpected request ID in response: %q != %q", rw.Header().Get(HeaderRequestID), id)
+		}
+	}
+
+	if NewRequestID() == NewRequestID() {
+		t.Errorf("the request IDs generated are the same")
+	}
+}
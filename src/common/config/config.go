@@ -120,13 +120,14 @@ func (cp *commonParser) Parse(raw map[string]string, config map[string]interface
 	config["ext_endpoint"] = raw["EXT_ENDPOINT"]
 	config["token_endpoint"] = raw["TOKEN_ENDPOINT"]
 	config["log_level"] = raw["LOG_LEVEL"]
+	config["log_format"] = raw["LOG_FORMAT"]
 	return nil
 }
 
 var commonConfig *Config
 
 func init() {
-	commonKeys := []string{"DATABASE", "MYSQL_DATABASE", "MYSQL_USR", "MYSQL_PWD", "MYSQL_HOST", "MYSQL_PORT", "SQLITE_FILE", "VERIFY_REMOTE_CERT", "EXT_ENDPOINT", "TOKEN_ENDPOINT", "LOG_LEVEL"}
+	commonKeys := []string{"DATABASE", "MYSQL_DATABASE", "MYSQL_USR", "MYSQL_PWD", "MYSQL_HOST", "MYSQL_PORT", "SQLITE_FILE", "VERIFY_REMOTE_CERT", "EXT_ENDPOINT", "TOKEN_ENDPOINT", "LOG_LEVEL", "LOG_FORMAT"}
 	commonConfig = &Config{
 		Config: make(map[string]interface{}),
 		Loader: &EnvConfigLoader{Keys: commonKeys},
@@ -176,3 +177,8 @@ func TokenEndpoint() string {
 func LogLevel() string {
 	return commonConfig.Config["log_level"].(string)
 }
+
+// LogFormat returns the format of the logs, "text" or "json".
+func LogFormat() string {
+	return commonConfig.Config["log_format"].(string)
+}
@@ -52,6 +52,7 @@ func TestCommonConfig(t *testing.T) {
 	ext := "http://harbor"
 	token := "http://token"
 	loglevel := "info"
+	logformat := "json"
 
 	os.Setenv("DATABASE", "")
 	os.Setenv("MYSQL_DATABASE", mysql.Database)
@@ -64,6 +65,7 @@ func TestCommonConfig(t *testing.T) {
 	os.Setenv("EXT_ENDPOINT", ext)
 	os.Setenv("TOKEN_ENDPOINT", token)
 	os.Setenv("LOG_LEVEL", loglevel)
+	os.Setenv("LOG_FORMAT", logformat)
 
 	err := Reload()
 	if err != nil {
@@ -87,6 +89,9 @@ func TestCommonConfig(t *testing.T) {
 	if LogLevel() != loglevel {
 		t.Errorf("Expected LogLevel: %s, fact: %s", loglevel, LogLevel())
 	}
+	if LogFormat() != logformat {
+		t.Errorf("Expected LogFormat: %s, fact: %s", logformat, LogFormat())
+	}
 	os.Setenv("DATABASE", "sqlite")
 	err = Reload()
 	if err != nil {
@@ -107,5 +112,6 @@ func TestCommonConfig(t *testing.T) {
 	os.Unsetenv("EXT_ENDPOINT")
 	os.Unsetenv("TOKEN_ENDPOINT")
 	os.Unsetenv("LOG_LEVEL")
+	os.Unsetenv("LOG_FORMAT")
 
 }
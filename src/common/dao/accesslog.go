@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/astaxie/beego/orm"
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
 )
@@ -234,6 +235,25 @@ func GetAccessLogCreator(repoName string) (string, error) {
 	return u[0].Username, nil
 }
 
+// GetTagPusher returns the name of the user who pushed the tag of the repository last time,
+// an empty string is returned if no push of the tag is recorded
+func GetTagPusher(repoName, tag string) (string, error) {
+	o := GetOrmer()
+	sql := `select u.username from access_log a join user u on a.user_id = u.user_id 
+		where a.operation = 'push' and a.repo_name = ? and a.repo_tag = ? 
+		order by a.op_time desc, a.log_id desc limit 1`
+
+	var username string
+	if err := o.Raw(sql, repoName, tag).QueryRow(&username); err != nil {
+		if err == orm.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return username, nil
+}
+
 // CountPull ...
 func CountPull(repoName string) (int64, error) {
 	o := GetOrmer()
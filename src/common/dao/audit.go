@@ -0,0 +1,88 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// the max lengths of the columns of table audit_log
+const (
+	auditActorMaxLen      = 255
+	auditResourceIDMaxLen = 128
+	auditSummaryMaxLen    = 1024
+)
+
+// AddAuditLog inserts the audit log, the actor, resource ID and summaries longer than
+// the columns are truncated rather than failing the operation being audited
+func AddAuditLog(auditLog models.AuditLog) (int64, error) {
+	auditLog.Actor = truncate(auditLog.Actor, auditActorMaxLen)
+	auditLog.ResourceID = truncate(auditLog.ResourceID, auditResourceIDMaxLen)
+	auditLog.Before = truncate(auditLog.Before, auditSummaryMaxLen)
+	auditLog.After = truncate(auditLog.After, auditSummaryMaxLen)
+	return GetOrmer().Insert(&auditLog)
+}
+
+// FilterAuditLogs returns the audit logs matching the query, the latest first, and the
+// total number of them
+func FilterAuditLogs(query models.AuditLogQuery, limit, offset int64) ([]*models.AuditLog, int64, error) {
+	logs := []*models.AuditLog{}
+
+	qs := GetOrmer().QueryTable(new(models.AuditLog))
+
+	if len(query.Actor) != 0 {
+		qs = qs.Filter("Actor", query.Actor)
+	}
+	if len(query.ResourceType) != 0 {
+		qs = qs.Filter("ResourceType", query.ResourceType)
+	}
+	if len(query.ResourceID) != 0 {
+		qs = qs.Filter("ResourceID", query.ResourceID)
+	}
+	if len(query.Action) != 0 {
+		qs = qs.Filter("Action", query.Action)
+	}
+	if len(query.SourceIP) != 0 {
+		qs = qs.Filter("SourceIP", query.SourceIP)
+	}
+	if query.StartTime != nil {
+		qs = qs.Filter("OpTime__gte", query.StartTime)
+	}
+	if query.EndTime != nil {
+		qs = qs.Filter("OpTime__lte", query.EndTime)
+	}
+
+	total, err := qs.Count()
+	if err != nil {
+		return logs, 0, err
+	}
+
+	_, err = qs.OrderBy("-OpTime", "-ID").Limit(limit).Offset(offset).All(&logs)
+	if err != nil {
+		return logs, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// truncate cuts s to at most n characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestAuditLog(t *testing.T) {
+	defer func() {
+		if _, err := GetOrmer().Raw(`delete from audit_log`).Exec(); err != nil {
+			t.Fatalf("failed to clear audit logs: %v", err)
+		}
+	}()
+
+	if _, err := AddAuditLog(models.AuditLog{
+		ActorID:      1,
+		Actor:        "admin",
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   "2",
+		Action:       models.AuditActionSetAdminRole,
+		Before:       "has_admin_role=0",
+		After:        "has_admin_role=1",
+		SourceIP:     "10.0.0.1",
+	}); err != nil {
+		t.Fatalf("failed to add audit log: %v", err)
+	}
+	if _, err := AddAuditLog(models.AuditLog{
+		Actor:        "mallory",
+		ResourceType: models.AuditResourceUser,
+		Action:       models.AuditActionLoginFailed,
+		After:        strings.Repeat("a", auditSummaryMaxLen+1),
+		SourceIP:     "10.0.0.2",
+	}); err != nil {
+		t.Fatalf("failed to add audit log: %v", err)
+	}
+
+	logs, total, err := FilterAuditLogs(models.AuditLogQuery{}, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to filter audit logs: %v", err)
+	}
+	if total != 2 || len(logs) != 2 {
+		t.Fatalf("unexpected audit logs, total: %d, logs: %+v", total, logs)
+	}
+
+	logs, total, err = FilterAuditLogs(models.AuditLogQuery{
+		Action: models.AuditActionLoginFailed,
+	}, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to filter audit logs: %v", err)
+	}
+	if total != 1 || logs[0].Actor != "mallory" || len(logs[0].After) != auditSummaryMaxLen {
+		t.Errorf("unexpected audit logs, total: %d, logs: %+v", total, logs)
+	}
+
+	logs, total, err = FilterAuditLogs(models.AuditLogQuery{
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   "2",
+		SourceIP:     "10.0.0.1",
+	}, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to filter audit logs: %v", err)
+	}
+	if total != 1 || logs[0].Action != models.AuditActionSetAdminRole {
+		t.Errorf("unexpected audit logs, total: %d, logs: %+v", total, logs)
+	}
+
+	future := time.Now().Add(time.Hour)
+	_, total, err = FilterAuditLogs(models.AuditLogQuery{StartTime: &future}, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to filter audit logs: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("unexpected number of audit logs after %v: %d", future, total)
+	}
+
+	// paginated
+	logs, total, err = FilterAuditLogs(models.AuditLogQuery{}, 1, 1)
+	if err != nil {
+		t.Fatalf("failed to filter audit logs: %v", err)
+	}
+	if total != 2 || len(logs) != 1 {
+		t.Errorf("unexpected audit logs, total: %d, logs: %+v", total, logs)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddCVEAllowlistItem ...
+func AddCVEAllowlistItem(item models.CVEAllowlistItem) (int64, error) {
+	return GetOrmer().Insert(&item)
+}
+
+// GetCVEAllowlistItem ...
+func GetCVEAllowlistItem(id int64) (*models.CVEAllowlistItem, error) {
+	item := models.CVEAllowlistItem{ID: id}
+	if err := GetOrmer().Read(&item); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetCVEAllowlistItemByCVE returns the item of the project allowing the vulnerability
+func GetCVEAllowlistItemByCVE(projectID int64, cveID string) (*models.CVEAllowlistItem, error) {
+	item := models.CVEAllowlistItem{ProjectID: projectID, CVEID: cveID}
+	if err := GetOrmer().Read(&item, "ProjectID", "CVEID"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetCVEAllowlist returns the CVE allowlist of the project, including the expired items
+func GetCVEAllowlist(projectID int64) ([]*models.CVEAllowlistItem, error) {
+	items := []*models.CVEAllowlistItem{}
+	_, err := GetOrmer().QueryTable(new(models.CVEAllowlistItem)).
+		Filter("ProjectID", projectID).
+		OrderBy("ID").
+		All(&items)
+	return items, err
+}
+
+// GetAllowedCVEs returns the vulnerabilities allowed by the project at the time
+func GetAllowedCVEs(projectID int64, now time.Time) (map[string]bool, error) {
+	items := []*models.CVEAllowlistItem{}
+	if _, err := GetOrmer().QueryTable(new(models.CVEAllowlistItem)).
+		Filter("ProjectID", projectID).
+		Filter("ExpiresAt__gt", now).
+		All(&items); err != nil {
+		return nil, err
+	}
+
+	allowed := map[string]bool{}
+	for _, item := range items {
+		allowed[item.CVEID] = true
+	}
+	return allowed, nil
+}
+
+// DeleteCVEAllowlistItem ...
+func DeleteCVEAllowlistItem(id int64) error {
+	_, err := GetOrmer().Delete(&models.CVEAllowlistItem{ID: id})
+	return err
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestCVEAllowlist(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+
+	id, err := AddCVEAllowlistItem(models.CVEAllowlistItem{
+		ProjectID: projectID,
+		CVEID:     "CVE-2017-3735",
+		Reason:    "false positive",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatorID: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add CVE allowlist item: %v", err)
+	}
+	defer func() {
+		if err := DeleteCVEAllowlistItem(id); err != nil {
+			t.Fatalf("failed to delete CVE allowlist item %d: %v", id, err)
+		}
+	}()
+
+	expiredID, err := AddCVEAllowlistItem(models.CVEAllowlistItem{
+		ProjectID: projectID,
+		CVEID:     "CVE-2016-2177",
+		ExpiresAt: time.Now().Add(-time.Hour),
+		CreatorID: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add CVE allowlist item: %v", err)
+	}
+	defer func() {
+		if err := DeleteCVEAllowlistItem(expiredID); err != nil {
+			t.Fatalf("failed to delete CVE allowlist item %d: %v", expiredID, err)
+		}
+	}()
+
+	item, err := GetCVEAllowlistItemByCVE(projectID, "CVE-2017-3735")
+	if err != nil {
+		t.Fatalf("failed to get CVE allowlist item: %v", err)
+	}
+	if item == nil || item.ID != id {
+		t.Fatalf("unexpected CVE allowlist item: %+v", item)
+	}
+
+	items, err := GetCVEAllowlist(projectID)
+	if err != nil {
+		t.Fatalf("failed to get CVE allowlist: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("unexpected CVE allowlist: %+v", items)
+	}
+
+	allowed, err := GetAllowedCVEs(projectID, time.Now())
+	if err != nil {
+		t.Fatalf("failed to get allowed CVEs: %v", err)
+	}
+	if !allowed["CVE-2017-3735"] || allowed["CVE-2016-2177"] {
+		t.Errorf("unexpected allowed CVEs: %v", allowed)
+	}
+}
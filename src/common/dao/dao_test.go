@@ -648,6 +648,29 @@ func TestGetAccessLogCreator(t *testing.T) {
 	}
 }
 
+func TestGetTagPusher(t *testing.T) {
+	repository := currentProject.Name + "/tomcat"
+	if err := AccessLog(currentUser.Username, currentProject.Name, repository, "pusher", "push"); err != nil {
+		t.Fatalf("Error occurred in AccessLog: %v", err)
+	}
+
+	pusher, err := GetTagPusher(repository, "pusher")
+	if err != nil {
+		t.Fatalf("Error occurred in GetTagPusher: %v", err)
+	}
+	if pusher != currentUser.Username {
+		t.Errorf("unexpected pusher: %s != %s", pusher, currentUser.Username)
+	}
+
+	pusher, err = GetTagPusher(repository, "nonexist")
+	if err != nil {
+		t.Fatalf("Error occurred in GetTagPusher: %v", err)
+	}
+	if len(pusher) != 0 {
+		t.Errorf("unexpected pusher of nonexist tag: %s", pusher)
+	}
+}
+
 func TestCountPull(t *testing.T) {
 	var err error
 	err = AccessLog(currentUser.Username, currentProject.Name, currentProject.Name+"/tomcat", repoTag2, "pull")
@@ -1082,6 +1105,7 @@ func TestUpdateRepTarget(t *testing.T) {
 	target.URL = "http://new_url"
 	target.Username = "new_username"
 	target.Password = "new_password"
+	target.Type = models.RepTargetTypeDockerRegistry
 
 	if err = UpdateRepTarget(*target); err != nil {
 		t.Fatalf("failed to update target: %v", err)
@@ -1107,6 +1131,10 @@ func TestUpdateRepTarget(t *testing.T) {
 	if target.Password != "new_password" {
 		t.Errorf("unexpected password: %s, expected: %s", target.Password, "new_password")
 	}
+
+	if target.Type != models.RepTargetTypeDockerRegistry {
+		t.Errorf("unexpected type: %d, expected: %d", target.Type, models.RepTargetTypeDockerRegistry)
+	}
 }
 
 func TestFilterRepTargets(t *testing.T) {
@@ -1291,6 +1319,72 @@ func TestAddRepJob(t *testing.T) {
 	}
 }
 
+func TestGetRepPolicyLastRunTime(t *testing.T) {
+	lastRun, err := GetRepPolicyLastRunTime(policyID)
+	if err != nil {
+		t.Fatalf("failed to get last run time of policy %d: %v", policyID, err)
+	}
+	if lastRun != nil {
+		t.Errorf("unexpected last run time: %v, the job with tags should not be counted", lastRun)
+	}
+
+	id, err := AddRepJob(models.RepJob{
+		Repository: "library/ubuntu",
+		PolicyID:   policyID,
+		Operation:  models.RepOpTransfer,
+	})
+	if err != nil {
+		t.Fatalf("Error occurred in AddRepJob: %v", err)
+	}
+	defer func() {
+		if err := DeleteRepJob(id); err != nil {
+			t.Errorf("failed to delete job %d: %v", id, err)
+		}
+	}()
+
+	lastRun, err = GetRepPolicyLastRunTime(policyID)
+	if err != nil {
+		t.Fatalf("failed to get last run time of policy %d: %v", policyID, err)
+	}
+	if lastRun == nil {
+		t.Fatalf("unexpected nil last run time of policy %d", policyID)
+	}
+}
+
+func TestGetScheduledRepPolicies(t *testing.T) {
+	policy, err := GetRepPolicy(policyID)
+	if err != nil {
+		t.Fatalf("failed to get policy %d: %v", policyID, err)
+	}
+
+	policy.CronStr = "0 0 * * *"
+	if err = UpdateRepPolicy(policy); err != nil {
+		t.Fatalf("failed to update policy %d: %v", policyID, err)
+	}
+	defer func() {
+		policy.CronStr = ""
+		if err := UpdateRepPolicy(policy); err != nil {
+			t.Errorf("failed to update policy %d: %v", policyID, err)
+		}
+	}()
+
+	policies, err := GetScheduledRepPolicies()
+	if err != nil {
+		t.Fatalf("failed to get scheduled policies: %v", err)
+	}
+
+	found := false
+	for _, p := range policies {
+		if p.ID == policyID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("policy %d not found in scheduled policies", policyID)
+	}
+}
+
 func TestUpdateRepJobStatus(t *testing.T) {
 	err := UpdateRepJobStatus(jobID, models.JobFinished)
 	if err != nil {
@@ -1505,7 +1599,9 @@ func TestResetRepJobs(t *testing.T) {
 		Repository: "library/ubuntua",
 		PolicyID:   policyID,
 		Operation:  "transfer",
-		Status:     models.JobRunning,
+		Status:     models.JobPending,
+		// claimed before the jobs created by other tests
+		Priority: 2000,
 	}
 	job2 := models.RepJob{
 		Repository: "library/ubuntub",
@@ -1523,7 +1619,13 @@ func TestResetRepJobs(t *testing.T) {
 		t.Errorf("Failed to add job: %+v, error: %v", job2, err)
 		return
 	}
-	err = ResetRunningJobs()
+	claimed, err := ClaimRepJob("instance1", time.Now(), time.Minute)
+	if err != nil || claimed == nil || claimed.ID != id1 {
+		t.Errorf("Failed to claim job %d: %+v, error: %v", id1, claimed, err)
+		return
+	}
+	// the job claimed by other instance is untouched
+	err = ResetRunningJobs("instance2")
 	if err != nil {
 		t.Errorf("Failed to reset running jobs, error: %v", err)
 	}
@@ -1532,6 +1634,19 @@ func TestResetRepJobs(t *testing.T) {
 		t.Errorf("Failed to get rep job, id: %d, error: %v", id1, err)
 		return
 	}
+	if j1.Status != models.JobRunning || j1.Owner != "instance1" {
+		t.Errorf("The rep job: %d, status should be Running and owned by instance1, but infact: %s, %s", id1, j1.Status, j1.Owner)
+		return
+	}
+	err = ResetRunningJobs("instance1")
+	if err != nil {
+		t.Errorf("Failed to reset running jobs, error: %v", err)
+	}
+	j1, err = GetRepJob(id1)
+	if err != nil {
+		t.Errorf("Failed to get rep job, id: %d, error: %v", id1, err)
+		return
+	}
 	if j1.Status != models.JobPending {
 		t.Errorf("The rep job: %d, status should be Pending, but infact: %s", id1, j1.Status)
 		return
@@ -1632,6 +1747,90 @@ func TestIncreasePullCount(t *testing.T) {
 	}
 }
 
+func TestProjectQuota(t *testing.T) {
+	if err := UpdateRepositorySize(currentRepository.Name, 1024); err != nil {
+		t.Fatalf("Error occurred in UpdateRepositorySize: %v", err)
+	}
+
+	size, count, err := GetProjectUsage(currentProject.ProjectID)
+	if err != nil {
+		t.Fatalf("Error occurred in GetProjectUsage: %v", err)
+	}
+	if size != 1024 || count != 1 {
+		t.Errorf("unexpected usage of project %d, size: %d, count: %d", currentProject.ProjectID, size, count)
+	}
+
+	if err = UpdateProjectQuota(currentProject.ProjectID, 2048, 10); err != nil {
+		t.Fatalf("Error occurred in UpdateProjectQuota: %v", err)
+	}
+
+	project, err := GetProjectByID(currentProject.ProjectID)
+	if err != nil {
+		t.Fatalf("Error occurred in GetProjectByID: %v", err)
+	}
+	if project.StorageLimit != 2048 || project.RepoLimit != 10 {
+		t.Errorf("unexpected quotas of project %d, storage limit: %d, repo limit: %d",
+			project.ProjectID, project.StorageLimit, project.RepoLimit)
+	}
+
+	if err = UpdateProjectQuota(currentProject.ProjectID, 0, 0); err != nil {
+		t.Fatalf("Error occurred in UpdateProjectQuota: %v", err)
+	}
+}
+
+func TestProjectProxy(t *testing.T) {
+	if err := UpdateProjectProxy(currentProject.ProjectID, 1, 3600); err != nil {
+		t.Fatalf("Error occurred in UpdateProjectProxy: %v", err)
+	}
+
+	project, err := GetProjectByID(currentProject.ProjectID)
+	if err != nil {
+		t.Fatalf("Error occurred in GetProjectByID: %v", err)
+	}
+	if !project.IsProxy() || project.ProxyTargetID != 1 || project.ProxyTTL != 3600 {
+		t.Errorf("unexpected proxy settings of project %d, target: %d, ttl: %d",
+			project.ProjectID, project.ProxyTargetID, project.ProxyTTL)
+	}
+
+	projects, err := GetProjectsByProxyTarget(1)
+	if err != nil {
+		t.Fatalf("Error occurred in GetProjectsByProxyTarget: %v", err)
+	}
+	if len(projects) != 1 || projects[0].ProjectID != currentProject.ProjectID {
+		t.Errorf("unexpected proxy cache projects: %+v", projects)
+	}
+
+	repository := currentProject.Name + "/library/ubuntu"
+	job, err := GetLastProxyJob(repository)
+	if err != nil {
+		t.Fatalf("Error occurred in GetLastProxyJob: %v", err)
+	}
+	if job != nil {
+		t.Errorf("no proxy job is expected: %+v", job)
+	}
+
+	id, err := AddRepJob(models.RepJob{
+		Repository: repository,
+		Operation:  models.RepOpProxy,
+	})
+	if err != nil {
+		t.Fatalf("Error occurred in AddRepJob: %v", err)
+	}
+	defer DeleteRepJob(id)
+
+	job, err = GetLastProxyJob(repository)
+	if err != nil {
+		t.Fatalf("Error occurred in GetLastProxyJob: %v", err)
+	}
+	if job == nil || job.ID != id {
+		t.Errorf("unexpected proxy job: %+v", job)
+	}
+
+	if err = UpdateProjectProxy(currentProject.ProjectID, 0, 0); err != nil {
+		t.Fatalf("Error occurred in UpdateProjectProxy: %v", err)
+	}
+}
+
 func TestRepositoryExists(t *testing.T) {
 	var exists bool
 	exists = RepositoryExists(currentRepository.Name)
@@ -0,0 +1,125 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddGCJob adds the job with the status pending unless another job is pending or running,
+// 0 is returned in that case. The check and the insert are done in one statement, so only
+// one job is added when the requests are handled concurrently by the job services
+func AddGCJob(job models.GCJob) (int64, error) {
+	o := GetOrmer()
+	now := time.Now()
+	sql := `insert into gc_job (status, dry_run, creation_time, update_time)
+		select ?, ?, ?, ? from (select 1) t
+		where not exists (select 1 from gc_job where status in (?, ?))`
+	result, err := o.Raw(sql, models.JobPending, job.DryRun, now, now,
+		models.JobPending, models.JobRunning).Exec()
+	if ok, err := claimed(result, err); err != nil || !ok {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetGCJob ...
+func GetGCJob(id int64) (*models.GCJob, error) {
+	o := GetOrmer()
+	j := models.GCJob{ID: id}
+	err := o.Read(&j)
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	return &j, err
+}
+
+// GetLastGCJob returns the latest finished garbage collection job which is not
+// a dry run, nil is returned if there is no such job
+func GetLastGCJob() (*models.GCJob, error) {
+	o := GetOrmer()
+	jobs := []*models.GCJob{}
+	n, err := o.QueryTable("gc_job").
+		Filter("status", models.JobFinished).
+		Filter("dry_run", 0).
+		OrderBy("-update_time", "-id").
+		Limit(1).
+		All(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	return jobs[0], nil
+}
+
+// GetUnfinishedGCJob returns a garbage collection job which is pending or running,
+// nil is returned if there is no such job
+func GetUnfinishedGCJob() (*models.GCJob, error) {
+	o := GetOrmer()
+	jobs := []*models.GCJob{}
+	n, err := o.QueryTable("gc_job").
+		Filter("status__in", models.JobPending, models.JobRunning).
+		Limit(1).
+		All(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	return jobs[0], nil
+}
+
+// UpdateGCJobStatus ...
+func UpdateGCJobStatus(id int64, status string) error {
+	o := GetOrmer()
+	j := models.GCJob{
+		ID:         id,
+		Status:     status,
+		UpdateTime: time.Now(),
+	}
+	num, err := o.Update(&j, "Status", "UpdateTime")
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("failed to update gc job with id: %d", id)
+	}
+	return nil
+}
+
+// UpdateGCJobResult updates the number and the size of the blobs unlinked by the job
+func UpdateGCJobResult(id int64, blobs int, unlinkedBytes int64) error {
+	o := GetOrmer()
+	j := models.GCJob{
+		ID:            id,
+		Blobs:         blobs,
+		UnlinkedBytes: unlinkedBytes,
+		UpdateTime:    time.Now(),
+	}
+	_, err := o.Update(&j, "Blobs", "UnlinkedBytes", "UpdateTime")
+	return err
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestGCJob(t *testing.T) {
+	defer func() {
+		if _, err := GetOrmer().Raw(`delete from gc_job`).Exec(); err != nil {
+			t.Fatalf("failed to clear gc jobs: %v", err)
+		}
+	}()
+
+	dryRunID, err := AddGCJob(models.GCJob{
+		DryRun: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add gc job: %v", err)
+	}
+	if err = UpdateGCJobStatus(dryRunID, models.JobFinished); err != nil {
+		t.Fatalf("failed to update status of gc job: %v", err)
+	}
+
+	// a dry run is not counted as the last run
+	job, err := GetLastGCJob()
+	if err != nil {
+		t.Fatalf("failed to get last gc job: %v", err)
+	}
+	if job != nil {
+		t.Errorf("expected nil gc job, but got %+v", job)
+	}
+
+	id, err := AddGCJob(models.GCJob{})
+	if err != nil {
+		t.Fatalf("failed to add gc job: %v", err)
+	}
+	if id == 0 {
+		t.Fatal("the gc job is not added after the previous one finished")
+	}
+
+	// only one job can be pending or running
+	another, err := AddGCJob(models.GCJob{})
+	if err != nil {
+		t.Fatalf("failed to add gc job: %v", err)
+	}
+	if another != 0 {
+		t.Errorf("gc job %d is added while gc job %d is pending", another, id)
+	}
+
+	job, err = GetGCJob(id)
+	if err != nil {
+		t.Fatalf("failed to get gc job: %v", err)
+	}
+	if job.Status != models.JobPending {
+		t.Errorf("unexpected status: %s != %s", job.Status, models.JobPending)
+	}
+
+	if err = UpdateGCJobResult(id, 2, 1024); err != nil {
+		t.Fatalf("failed to update result of gc job: %v", err)
+	}
+	if err = UpdateGCJobStatus(id, models.JobFinished); err != nil {
+		t.Fatalf("failed to update status of gc job: %v", err)
+	}
+
+	job, err = GetLastGCJob()
+	if err != nil {
+		t.Fatalf("failed to get last gc job: %v", err)
+	}
+	if job == nil || job.ID != id {
+		t.Fatalf("unexpected last gc job: %+v", job)
+	}
+	if job.Blobs != 2 || job.UnlinkedBytes != 1024 {
+		t.Errorf("unexpected result: blobs %d, unlinked bytes %d", job.Blobs, job.UnlinkedBytes)
+	}
+}
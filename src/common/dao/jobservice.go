@@ -0,0 +1,133 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// HeartbeatJobServiceInstance records the heartbeat of the job service instance, the instance
+// is added if it doesn't exist
+func HeartbeatJobServiceInstance(id string, now time.Time) error {
+	o := GetOrmer()
+	instance := &models.JobServiceInstance{
+		ID:            id,
+		HeartbeatTime: now,
+	}
+	n, err := o.Update(instance, "HeartbeatTime")
+	if err != nil || n != 0 {
+		return err
+	}
+	_, err = o.Insert(instance)
+	return err
+}
+
+// GetJobServiceInstances returns all the job service instances, the latest heartbeat comes first
+func GetJobServiceInstances() ([]*models.JobServiceInstance, error) {
+	instances := []*models.JobServiceInstance{}
+	_, err := GetOrmer().QueryTable(new(models.JobServiceInstance)).
+		OrderBy("-HeartbeatTime", "ID").All(&instances)
+	return instances, err
+}
+
+// DeleteJobServiceInstances deletes the job service instances whose last heartbeats are
+// before the time, i.e. the ones removed or whose hostnames changed
+func DeleteJobServiceInstances(before time.Time) (int64, error) {
+	result, err := GetOrmer().Raw(`delete from job_service_instance where heartbeat_time < ?`,
+		before).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// the tables of the garbage collection, retention and scan jobs, which are queued in DB and
+// leased by the job service instances like the replication jobs
+const (
+	GCJobTable        = "gc_job"
+	RetentionJobTable = "retention_job"
+	ScanJobTable      = "scan_job"
+)
+
+// the condition of the jobs which can be claimed: the pending ones and the running ones whose
+// lease expires, i.e. the instance which claimed them is down, or which have no lease as they
+// were started before the leases were introduced
+const claimableJobCond = `(status = ? or (status = ? and (lease_expiration is null or lease_expiration < ?)))`
+
+func claimableJobParams(now time.Time) []interface{} {
+	return []interface{}{models.JobPending, models.JobRunning, now}
+}
+
+// ClaimJob claims the earliest queued job in the table of jobs for the job service instance owner,
+// the job is set to running and leased until now+lease. It returns 0 if there is no job to claim.
+// As the job is claimed by a conditional update, a job is never claimed by two instances.
+func ClaimJob(table, owner string, now time.Time, lease time.Duration) (int64, error) {
+	o := GetOrmer()
+	var ids []int64
+	sql := `select id from ` + table + ` where ` + claimableJobCond + ` order by id limit 10`
+	if _, err := o.Raw(sql, claimableJobParams(now)...).QueryRows(&ids); err != nil {
+		return 0, err
+	}
+
+	sql = `update ` + table + ` set status = ?, lease_expiration = ?, lease_owner = ?, update_time = ?
+		where id = ? and ` + claimableJobCond
+	for _, id := range ids {
+		params := []interface{}{models.JobRunning, now.Add(lease), owner, now, id}
+		params = append(params, claimableJobParams(now)...)
+		result, err := o.Raw(sql, params...).Exec()
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		// claimed by others in the meantime
+		if n == 0 {
+			continue
+		}
+		return id, nil
+	}
+
+	return 0, nil
+}
+
+// RenewJobLeases extends the leases of the running jobs in the table claimed by the job service
+// instance owner to the expiration, the jobs claimed by others in the meantime are untouched
+func RenewJobLeases(table, owner string, ids []int64, expiration time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	sql := `update ` + table + ` set lease_expiration = ? where status = ? and lease_owner = ? and id in (` +
+		inClausePlaceholders(len(ids)) + `)`
+	params := []interface{}{expiration, models.JobRunning, owner}
+	for _, id := range ids {
+		params = append(params, id)
+	}
+	_, err := GetOrmer().Raw(sql, params...).Exec()
+	return err
+}
+
+// ResetLeasedJobs updates the status of the running jobs in the table claimed by the job service
+// instance owner to pending, it's called when the instance starts, so the jobs interrupted by its
+// restart are queued again without waiting for their leases to expire
+func ResetLeasedJobs(table, owner string) error {
+	sql := `update ` + table + ` set status = ?, update_time = ? where status = ? and lease_owner = ?`
+	_, err := GetOrmer().Raw(sql, models.JobPending, time.Now(), models.JobRunning, owner).Exec()
+	return err
+}
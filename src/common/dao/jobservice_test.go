@@ -0,0 +1,92 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestJobLeases(t *testing.T) {
+	defer func() {
+		if _, err := GetOrmer().Raw(`delete from retention_job`).Exec(); err != nil {
+			t.Fatalf("failed to clear retention jobs: %v", err)
+		}
+	}()
+
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		id, err := AddRetentionJob(models.RetentionJob{PolicyID: 1})
+		if err != nil {
+			t.Fatalf("failed to add retention job: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	now := time.Now()
+	// the earliest jobs are claimed first
+	for i, owner := range []string{"instance-a", "instance-b"} {
+		id, err := ClaimJob(RetentionJobTable, owner, now, time.Minute)
+		if err != nil {
+			t.Fatalf("failed to claim retention job: %v", err)
+		}
+		if id != ids[i] {
+			t.Errorf("unexpected job claimed by %s: %d != %d", owner, id, ids[i])
+		}
+	}
+
+	id, err := ClaimJob(RetentionJobTable, "instance-c", now, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to claim retention job: %v", err)
+	}
+	if id != 0 {
+		t.Errorf("unexpected job claimed while all jobs are leased: %d", id)
+	}
+
+	// only the leases of the jobs claimed by the instance are renewed
+	if err = RenewJobLeases(RetentionJobTable, "instance-a", ids, now.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to renew leases of retention jobs: %v", err)
+	}
+
+	// the lease of the job claimed by instance-b expires
+	id, err = ClaimJob(RetentionJobTable, "instance-c", now.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to claim retention job: %v", err)
+	}
+	if id != ids[1] {
+		t.Errorf("unexpected job claimed after the lease expires: %d != %d", id, ids[1])
+	}
+
+	if err = ResetLeasedJobs(RetentionJobTable, "instance-a"); err != nil {
+		t.Fatalf("failed to reset leased retention jobs: %v", err)
+	}
+	job, err := GetRetentionJob(ids[0])
+	if err != nil {
+		t.Fatalf("failed to get retention job: %v", err)
+	}
+	if job.Status != models.JobPending {
+		t.Errorf("unexpected status of the reset job: %s != %s", job.Status, models.JobPending)
+	}
+	job, err = GetRetentionJob(ids[1])
+	if err != nil {
+		t.Fatalf("failed to get retention job: %v", err)
+	}
+	if job.Status != models.JobRunning {
+		t.Errorf("unexpected status of the job claimed by others: %s != %s", job.Status, models.JobRunning)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddProjectLDAPGroup ...
+func AddProjectLDAPGroup(group models.ProjectLDAPGroup) (int64, error) {
+	return GetOrmer().Insert(&group)
+}
+
+// GetProjectLDAPGroup ...
+func GetProjectLDAPGroup(id int64) (*models.ProjectLDAPGroup, error) {
+	group := models.ProjectLDAPGroup{ID: id}
+	if err := GetOrmer().Read(&group); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetProjectLDAPGroupByDN returns the LDAP group member of the project with the normalized DN
+func GetProjectLDAPGroupByDN(projectID int64, groupDN string) (*models.ProjectLDAPGroup, error) {
+	group := models.ProjectLDAPGroup{ProjectID: projectID, GroupDN: groupDN}
+	if err := GetOrmer().Read(&group, "ProjectID", "GroupDN"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetProjectLDAPGroups returns the LDAP group members of the project
+func GetProjectLDAPGroups(projectID int64) ([]*models.ProjectLDAPGroup, error) {
+	groups := []*models.ProjectLDAPGroup{}
+	_, err := GetOrmer().QueryTable(new(models.ProjectLDAPGroup)).
+		Filter("ProjectID", projectID).
+		OrderBy("ID").
+		All(&groups)
+	return groups, err
+}
+
+// GetProjectLDAPGroupsByDNs returns the LDAP group members of all projects with any of the
+// normalized DNs
+func GetProjectLDAPGroupsByDNs(groupDNs []string) ([]*models.ProjectLDAPGroup, error) {
+	groups := []*models.ProjectLDAPGroup{}
+	if len(groupDNs) == 0 {
+		return groups, nil
+	}
+	_, err := GetOrmer().QueryTable(new(models.ProjectLDAPGroup)).
+		Filter("GroupDN__in", groupDNs).
+		All(&groups)
+	return groups, err
+}
+
+// DeleteProjectLDAPGroup ...
+func DeleteProjectLDAPGroup(id int64) error {
+	_, err := GetOrmer().Delete(&models.ProjectLDAPGroup{ID: id})
+	return err
+}
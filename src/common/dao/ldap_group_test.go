@@ -0,0 +1,87 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestProjectLDAPGroup(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+	groupDN := "cn=developers,ou=groups,dc=mydomain,dc=com"
+
+	id, err := AddProjectLDAPGroup(models.ProjectLDAPGroup{
+		ProjectID: projectID,
+		GroupDN:   groupDN,
+		Role:      models.DEVELOPER,
+		CreatorID: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add LDAP group member: %v", err)
+	}
+	defer func() {
+		if err := DeleteProjectLDAPGroup(id); err != nil {
+			t.Fatalf("failed to delete LDAP group member %d: %v", id, err)
+		}
+	}()
+
+	group, err := GetProjectLDAPGroupByDN(projectID, groupDN)
+	if err != nil {
+		t.Fatalf("failed to get LDAP group member: %v", err)
+	}
+	if group == nil || group.ID != id {
+		t.Fatalf("unexpected LDAP group member: %+v", group)
+	}
+
+	groups, err := GetProjectLDAPGroups(projectID)
+	if err != nil {
+		t.Fatalf("failed to get LDAP group members: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Errorf("unexpected LDAP group members: %+v", groups)
+	}
+
+	groups, err = GetProjectLDAPGroupsByDNs([]string{groupDN, "cn=testers,ou=groups,dc=mydomain,dc=com"})
+	if err != nil {
+		t.Fatalf("failed to get LDAP group members: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Role != models.DEVELOPER {
+		t.Errorf("unexpected LDAP group members: %+v", groups)
+	}
+}
+
+func TestGroupMemberGrant(t *testing.T) {
+	// project "library" and user "admin"
+	var projectID int64 = 1
+	userID := 1
+
+	if err := AddGroupMemberGrant(projectID, userID); err != nil {
+		t.Fatalf("failed to add group member grant: %v", err)
+	}
+	projectIDs, err := GetGroupMemberGrants(userID)
+	if err != nil {
+		t.Fatalf("failed to get group member grants: %v", err)
+	}
+	if len(projectIDs) != 1 || projectIDs[0] != projectID {
+		t.Errorf("unexpected group member grants: %v", projectIDs)
+	}
+	if err = DeleteGroupMemberGrant(projectID, userID); err != nil {
+		t.Fatalf("failed to delete group member grant: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddOIDCUser ...
+func AddOIDCUser(user models.OIDCUser) (int64, error) {
+	return GetOrmer().Insert(&user)
+}
+
+// GetOIDCUserBySubject returns the OIDC user linked to the subject of the provider
+func GetOIDCUserBySubject(subject string) (*models.OIDCUser, error) {
+	user := models.OIDCUser{Subject: subject}
+	if err := GetOrmer().Read(&user, "Subject"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetOIDCUserByUserID returns the OIDC user linked to the user of Harbor
+func GetOIDCUserByUserID(userID int) (*models.OIDCUser, error) {
+	user := models.OIDCUser{UserID: userID}
+	if err := GetOrmer().Read(&user, "UserID"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateOIDCUserSecret updates the hashed CLI secret of the OIDC user
+func UpdateOIDCUserSecret(id int64, secret, salt string) error {
+	_, err := GetOrmer().Update(&models.OIDCUser{
+		ID:     id,
+		Secret: secret,
+		Salt:   salt,
+	}, "Secret", "Salt", "UpdateTime")
+	return err
+}
+
+// AddOIDCGroupRole ...
+func AddOIDCGroupRole(groupRole models.OIDCGroupRole) (int64, error) {
+	return GetOrmer().Insert(&groupRole)
+}
+
+// GetOIDCGroupRole ...
+func GetOIDCGroupRole(id int64) (*models.OIDCGroupRole, error) {
+	groupRole := models.OIDCGroupRole{ID: id}
+	if err := GetOrmer().Read(&groupRole); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &groupRole, nil
+}
+
+// GetOIDCGroupRoleByName returns the role the project grants to the group
+func GetOIDCGroupRoleByName(projectID int64, groupName string) (*models.OIDCGroupRole, error) {
+	groupRole := models.OIDCGroupRole{ProjectID: projectID, GroupName: groupName}
+	if err := GetOrmer().Read(&groupRole, "ProjectID", "GroupName"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &groupRole, nil
+}
+
+// GetOIDCGroupRoles returns the roles the project grants to groups
+func GetOIDCGroupRoles(projectID int64) ([]*models.OIDCGroupRole, error) {
+	groupRoles := []*models.OIDCGroupRole{}
+	_, err := GetOrmer().QueryTable(new(models.OIDCGroupRole)).
+		Filter("ProjectID", projectID).
+		OrderBy("ID").
+		All(&groupRoles)
+	return groupRoles, err
+}
+
+// GetOIDCGroupRolesByGroups returns the roles granted to any of the groups by all projects
+func GetOIDCGroupRolesByGroups(groups []string) ([]*models.OIDCGroupRole, error) {
+	groupRoles := []*models.OIDCGroupRole{}
+	if len(groups) == 0 {
+		return groupRoles, nil
+	}
+	_, err := GetOrmer().QueryTable(new(models.OIDCGroupRole)).
+		Filter("GroupName__in", groups).
+		All(&groupRoles)
+	return groupRoles, err
+}
+
+// DeleteOIDCGroupRole ...
+func DeleteOIDCGroupRole(id int64) error {
+	_, err := GetOrmer().Delete(&models.OIDCGroupRole{ID: id})
+	return err
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestOIDCUser(t *testing.T) {
+	// user "admin"
+	userID := 1
+
+	id, err := AddOIDCUser(models.OIDCUser{
+		UserID:  userID,
+		Subject: "https://oidc.mydomain.com#12345",
+	})
+	if err != nil {
+		t.Fatalf("failed to add OIDC user: %v", err)
+	}
+	defer func() {
+		if _, err := GetOrmer().Delete(&models.OIDCUser{ID: id}); err != nil {
+			t.Fatalf("failed to delete OIDC user %d: %v", id, err)
+		}
+	}()
+
+	user, err := GetOIDCUserBySubject("https://oidc.mydomain.com#12345")
+	if err != nil {
+		t.Fatalf("failed to get OIDC user: %v", err)
+	}
+	if user == nil || user.ID != id || user.UserID != userID {
+		t.Fatalf("unexpected OIDC user: %+v", user)
+	}
+
+	if err = UpdateOIDCUserSecret(id, "secret", "salt"); err != nil {
+		t.Fatalf("failed to update CLI secret: %v", err)
+	}
+
+	user, err = GetOIDCUserByUserID(userID)
+	if err != nil {
+		t.Fatalf("failed to get OIDC user: %v", err)
+	}
+	if user == nil || user.Secret != "secret" || user.Salt != "salt" {
+		t.Errorf("unexpected OIDC user: %+v", user)
+	}
+
+	user, err = GetOIDCUserBySubject("non-exist")
+	if err != nil {
+		t.Fatalf("failed to get OIDC user: %v", err)
+	}
+	if user != nil {
+		t.Errorf("unexpected OIDC user: %+v", user)
+	}
+}
+
+func TestOIDCGroupRole(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+
+	id, err := AddOIDCGroupRole(models.OIDCGroupRole{
+		ProjectID: projectID,
+		GroupName: "developers",
+		Role:      models.DEVELOPER,
+		CreatorID: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add OIDC group role: %v", err)
+	}
+	defer func() {
+		if err := DeleteOIDCGroupRole(id); err != nil {
+			t.Fatalf("failed to delete OIDC group role %d: %v", id, err)
+		}
+	}()
+
+	groupRole, err := GetOIDCGroupRoleByName(projectID, "developers")
+	if err != nil {
+		t.Fatalf("failed to get OIDC group role: %v", err)
+	}
+	if groupRole == nil || groupRole.ID != id {
+		t.Fatalf("unexpected OIDC group role: %+v", groupRole)
+	}
+
+	groupRoles, err := GetOIDCGroupRolesByGroups([]string{"developers", "testers"})
+	if err != nil {
+		t.Fatalf("failed to get OIDC group roles: %v", err)
+	}
+	if len(groupRoles) != 1 || groupRoles[0].Role != models.DEVELOPER {
+		t.Errorf("unexpected OIDC group roles: %+v", groupRoles)
+	}
+}
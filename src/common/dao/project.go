@@ -30,13 +30,14 @@ import (
 func AddProject(project models.Project) (int64, error) {
 
 	o := GetOrmer()
-	p, err := o.Raw("insert into project (owner_id, name, creation_time, update_time, deleted, public) values (?, ?, ?, ?, ?, ?)").Prepare()
+	p, err := o.Raw("insert into project (owner_id, name, creation_time, update_time, deleted, public, proxy_target_id, proxy_ttl) values (?, ?, ?, ?, ?, ?, ?, ?)").Prepare()
 	if err != nil {
 		return 0, err
 	}
 
 	now := time.Now()
-	r, err := p.Exec(project.OwnerID, project.Name, now, now, project.Deleted, project.Public)
+	r, err := p.Exec(project.OwnerID, project.Name, now, now, project.Deleted, project.Public,
+		project.ProxyTargetID, project.ProxyTTL)
 	if err != nil {
 		return 0, err
 	}
@@ -96,7 +97,9 @@ func ProjectExists(nameOrID interface{}) (bool, error) {
 func GetProjectByID(id int64) (*models.Project, error) {
 	o := GetOrmer()
 
-	sql := `select p.project_id, p.name, u.username as owner_name, p.owner_id, p.creation_time, p.update_time, p.public  
+	sql := `select p.project_id, p.name, u.username as owner_name, p.owner_id, p.creation_time, p.update_time, p.public,
+		p.storage_limit, p.repo_limit, p.proxy_target_id, p.proxy_ttl, p.require_signature, p.scan_on_push,
+		p.vulnerability_severity, p.block_unscanned
 		from project p left join user u on p.owner_id = u.user_id where p.deleted = 0 and p.project_id = ?`
 	queryParam := make([]interface{}, 1)
 	queryParam = append(queryParam, id)
@@ -162,6 +165,66 @@ func ToggleProjectPublicity(projectID int64, publicity int) error {
 	return err
 }
 
+// UpdateProjectQuota updates the quotas of the project, 0 means no limit
+func UpdateProjectQuota(projectID int64, storageLimit int64, repoLimit int) error {
+	o := GetOrmer()
+	sql := "update project set storage_limit = ?, repo_limit = ?, update_time = ? where project_id = ?"
+	_, err := o.Raw(sql, storageLimit, repoLimit, time.Now(), projectID).Exec()
+	return err
+}
+
+// UpdateProjectProxy updates the target and the TTL of the proxy cache project
+func UpdateProjectProxy(projectID, targetID int64, ttl int) error {
+	o := GetOrmer()
+	sql := "update project set proxy_target_id = ?, proxy_ttl = ?, update_time = ? where project_id = ?"
+	_, err := o.Raw(sql, targetID, ttl, time.Now(), projectID).Exec()
+	return err
+}
+
+// UpdateProjectRequireSignature sets whether only the signed images of the project can be pulled
+func UpdateProjectRequireSignature(projectID int64, requireSignature int) error {
+	o := GetOrmer()
+	sql := "update project set require_signature = ?, update_time = ? where project_id = ?"
+	_, err := o.Raw(sql, requireSignature, time.Now(), projectID).Exec()
+	return err
+}
+
+// UpdateProjectScanOnPush sets whether the images pushed to the project are scanned
+func UpdateProjectScanOnPush(projectID int64, scanOnPush int) error {
+	o := GetOrmer()
+	sql := "update project set scan_on_push = ?, update_time = ? where project_id = ?"
+	_, err := o.Raw(sql, scanOnPush, time.Now(), projectID).Exec()
+	return err
+}
+
+// UpdateProjectVulnerabilityPolicy sets the policy which prevents the vulnerable images
+// of the project from being pulled
+func UpdateProjectVulnerabilityPolicy(projectID int64, policy *models.ProjectVulnerabilityPolicy) error {
+	o := GetOrmer()
+	sql := "update project set vulnerability_severity = ?, block_unscanned = ?, update_time = ? where project_id = ?"
+	_, err := o.Raw(sql, policy.Severity, policy.BlockUnscanned, time.Now(), projectID).Exec()
+	return err
+}
+
+// GetProjectsByProxyTarget returns the proxy cache projects of the target
+func GetProjectsByProxyTarget(targetID int64) ([]*models.Project, error) {
+	o := GetOrmer()
+	var projects []*models.Project
+	if _, err := o.Raw(`select * from project where deleted = 0 and proxy_target_id = ?`,
+		targetID).QueryRows(&projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProjectUsage returns the total size and the number of the repositories under the project
+func GetProjectUsage(projectID int64) (size int64, count int64, err error) {
+	o := GetOrmer()
+	sql := "select coalesce(sum(size), 0), count(*) from repository where project_id = ?"
+	err = o.Raw(sql, projectID).QueryRow(&size, &count)
+	return
+}
+
 // SearchProjects returns a project list,
 // which satisfies the following conditions:
 // 1. the project is not deleted
@@ -54,6 +54,30 @@ func DeleteProjectMember(projectID int64, userID int) error {
 	return nil
 }
 
+// AddGroupMemberGrant records that the user became a member of the project because of
+// the groups of the user
+func AddGroupMemberGrant(projectID int64, userID int) error {
+	sql := "insert into group_member_grant (project_id, user_id) values (?, ?)"
+	_, err := GetOrmer().Raw(sql, projectID, userID).Exec()
+	return err
+}
+
+// GetGroupMemberGrants returns the IDs of the projects the user became a member of
+// because of the groups of the user
+func GetGroupMemberGrants(userID int) ([]int64, error) {
+	sql := "select project_id from group_member_grant where user_id = ?"
+	projectIDs := []int64{}
+	_, err := GetOrmer().Raw(sql, userID).QueryRows(&projectIDs)
+	return projectIDs, err
+}
+
+// DeleteGroupMemberGrant ...
+func DeleteGroupMemberGrant(projectID int64, userID int) error {
+	sql := "delete from group_member_grant where project_id = ? and user_id = ?"
+	_, err := GetOrmer().Raw(sql, projectID, userID).Exec()
+	return err
+}
+
 // GetUserByProject gets all members of the project.
 func GetUserByProject(projectID int64, queryUser models.User) ([]models.User, error) {
 	o := GetOrmer()
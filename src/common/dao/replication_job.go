@@ -16,6 +16,7 @@
 package dao
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -77,7 +78,7 @@ func DeleteRepTarget(id int64) error {
 func UpdateRepTarget(target models.RepTarget) error {
 	o := GetOrmer()
 	target.UpdateTime = time.Now()
-	_, err := o.Update(&target, "URL", "Name", "Username", "Password", "UpdateTime")
+	_, err := o.Update(&target, "URL", "Name", "Username", "Password", "Type", "UpdateTime")
 	return err
 }
 
@@ -106,12 +107,18 @@ func FilterRepTargets(name string) ([]*models.RepTarget, error) {
 // AddRepPolicy ...
 func AddRepPolicy(policy models.RepPolicy) (int64, error) {
 	o := GetOrmer()
-	sql := `insert into replication_policy (name, project_id, target_id, enabled, description, cron_str, start_time, creation_time, update_time ) values (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	sql := `insert into replication_policy (name, project_id, target_id, enabled, description, cron_str, start_time, direction, repositories, 
+		repo_filter, repo_exclude_filter, tag_filter, tag_exclude_filter, pushed_by, creation_time, update_time ) 
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	p, err := o.Raw(sql).Prepare()
 	if err != nil {
 		return 0, err
 	}
 
+	if len(policy.Direction) == 0 {
+		policy.Direction = models.RepDirectionPush
+	}
+
 	params := []interface{}{}
 	params = append(params, policy.Name, policy.ProjectID, policy.TargetID, policy.Enabled, policy.Description, policy.CronStr)
 	now := time.Now()
@@ -120,7 +127,8 @@ func AddRepPolicy(policy models.RepPolicy) (int64, error) {
 	} else {
 		params = append(params, nil)
 	}
-	params = append(params, now, now)
+	params = append(params, policy.Direction, strings.Join(policy.RepositoryList, ","),
+		policy.RepoFilter, policy.RepoExcludeFilter, policy.TagFilter, policy.TagExcludeFilter, policy.PushedBy, now, now)
 
 	r, err := p.Exec(params...)
 	if err != nil {
@@ -144,6 +152,7 @@ func GetRepPolicy(id int64) (*models.RepPolicy, error) {
 		return nil, err
 	}
 
+	genRepositoriesForPolicy(&policy)
 	return &policy, nil
 }
 
@@ -155,8 +164,9 @@ func FilterRepPolicies(name string, projectID int64) ([]*models.RepPolicy, error
 
 	sql := `select rp.id, rp.project_id, p.name as project_name, rp.target_id, 
 				rt.name as target_name, rp.name, rp.enabled, rp.description,
-				rp.cron_str, rp.start_time, rp.creation_time, rp.update_time, 
-				count(rj.status) as error_job_count 
+				rp.cron_str, rp.start_time, rp.direction, rp.repositories,
+				rp.repo_filter, rp.repo_exclude_filter, rp.tag_filter, rp.tag_exclude_filter, rp.pushed_by,
+				rp.last_scheduled_time, rp.creation_time, rp.update_time, count(rj.status) as error_job_count 
 			from replication_policy rp 
 			left join project p on rp.project_id=p.project_id 
 			left join replication_target rt on rp.target_id=rt.id 
@@ -182,6 +192,7 @@ func FilterRepPolicies(name string, projectID int64) ([]*models.RepPolicy, error
 	if _, err := o.Raw(sql, args).QueryRows(&policies); err != nil {
 		return nil, err
 	}
+	genRepositoriesForPolicy(policies...)
 	return policies, nil
 }
 
@@ -213,6 +224,7 @@ func GetRepPolicyByProject(projectID int64) ([]*models.RepPolicy, error) {
 		return nil, err
 	}
 
+	genRepositoriesForPolicy(policies...)
 	return policies, nil
 }
 
@@ -244,14 +256,75 @@ func GetRepPolicyByProjectAndTarget(projectID, targetID int64) ([]*models.RepPol
 	return policies, nil
 }
 
+// GetScheduledRepPolicies returns the enabled policies which have a cron string
+func GetScheduledRepPolicies() ([]*models.RepPolicy, error) {
+	o := GetOrmer()
+	sql := `select * from replication_policy 
+		where deleted = 0 and enabled = 1 and cron_str is not null and cron_str != ''`
+
+	var policies []*models.RepPolicy
+
+	if _, err := o.Raw(sql).QueryRows(&policies); err != nil {
+		return nil, err
+	}
+
+	genRepositoriesForPolicy(policies...)
+	return policies, nil
+}
+
+// GetRepPolicyLastRunTime returns the creation time of the latest job which replicates
+// a whole repository for the policy, jobs triggered by pushing a tag are not counted.
+// It returns nil if no such job exists.
+func GetRepPolicyLastRunTime(policyID int64) (*time.Time, error) {
+	o := GetOrmer()
+	sql := `select * from replication_job 
+		where policy_id = ? and operation = ? and (tags is null or tags = '') 
+		order by creation_time desc limit 1`
+
+	var jobs []*models.RepJob
+	n, err := o.Raw(sql, policyID, models.RepOpTransfer).QueryRows(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	return &jobs[0].CreationTime, nil
+}
+
+// ClaimRepPolicyScheduledRun claims the run of the policy scheduled at runTime, the time the
+// run is triggered, now, is recorded as the last scheduled time of the policy. It returns false
+// if the run has been claimed by another job service instance.
+func ClaimRepPolicyScheduledRun(policyID int64, runTime, now time.Time) (bool, error) {
+	// update_time is kept as it's the time the policy was modified by users
+	sql := `update replication_policy set last_scheduled_time = ?, update_time = update_time
+		where id = ? and (last_scheduled_time is null or last_scheduled_time < ?)`
+	return claimed(GetOrmer().Raw(sql, now, policyID, runTime).Exec())
+}
+
 // UpdateRepPolicy ...
 func UpdateRepPolicy(policy *models.RepPolicy) error {
 	o := GetOrmer()
 	policy.UpdateTime = time.Now()
-	_, err := o.Update(policy, "TargetID", "Name", "Enabled", "Description", "CronStr", "UpdateTime")
+	policy.Repositories = strings.Join(policy.RepositoryList, ",")
+	_, err := o.Update(policy, "TargetID", "Name", "Enabled", "Description", "CronStr",
+		"Direction", "Repositories", "RepoFilter", "RepoExcludeFilter", "TagFilter", "TagExcludeFilter",
+		"PushedBy", "UpdateTime")
 	return err
 }
 
+func genRepositoriesForPolicy(policies ...*models.RepPolicy) {
+	for _, p := range policies {
+		p.RepositoryList = []string{}
+		if len(p.Repositories) == 0 {
+			continue
+		}
+		p.RepositoryList = strings.Split(p.Repositories, ",")
+	}
+}
+
 // DeleteRepPolicy ...
 func DeleteRepPolicy(id int64) error {
 	o := GetOrmer()
@@ -303,6 +376,9 @@ func AddRepJob(job models.RepJob) (int64, error) {
 	if len(job.TagList) > 0 {
 		job.Tags = strings.Join(job.TagList, ",")
 	}
+	if job.ScheduleTime.IsZero() {
+		job.ScheduleTime = time.Now()
+	}
 	return o.Insert(&job)
 }
 
@@ -318,6 +394,28 @@ func GetRepJob(id int64) (*models.RepJob, error) {
 	return &j, nil
 }
 
+// GetLastProxyJob returns the latest job which caches the repository of a proxy cache project,
+// it returns nil if no such job exists.
+func GetLastProxyJob(repository string) (*models.RepJob, error) {
+	o := GetOrmer()
+	sql := `select * from replication_job 
+		where policy_id = 0 and operation = ? and repository = ? 
+		order by creation_time desc, id desc limit 1`
+
+	var jobs []*models.RepJob
+	n, err := o.Raw(sql, models.RepOpProxy, repository).QueryRows(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	genTagListForJob(jobs...)
+	return jobs[0], nil
+}
+
 // GetRepJobByPolicy ...
 func GetRepJobByPolicy(policyID int64) ([]*models.RepJob, error) {
 	var res []*models.RepJob
@@ -406,11 +504,13 @@ func UpdateRepJobStatus(id int64, status string) error {
 	return err
 }
 
-// ResetRunningJobs update all running jobs status to pending
-func ResetRunningJobs() error {
+// ResetRunningJobs update the status of the running jobs claimed by the job service instance to pending,
+// it's called when the instance starts, so the jobs interrupted by its restart are queued again without
+// waiting for their leases to expire. The jobs claimed by other instances are untouched.
+func ResetRunningJobs(owner string) error {
 	o := GetOrmer()
-	sql := fmt.Sprintf("update replication_job set status = '%s', update_time = ? where status = '%s'", models.JobPending, models.JobRunning)
-	_, err := o.Raw(sql, time.Now()).Exec()
+	sql := fmt.Sprintf("update replication_job set status = '%s', update_time = ? where status = '%s' and lease_owner = ?", models.JobPending, models.JobRunning)
+	_, err := o.Raw(sql, time.Now(), owner).Exec()
 	return err
 }
 
@@ -433,3 +533,176 @@ func genTagListForJob(jobs ...*models.RepJob) {
 		}
 	}
 }
+
+// the condition of the jobs which can be claimed: the pending ones, the retrying ones whose
+// schedule time arrives and the running ones whose lease expires, i.e. the instance which claimed
+// them is down, or which have no lease as they were started before the leases were introduced
+const claimableRepJobCond = `(status = ? or (status = ? and schedule_time <= ?) or
+	(status = ? and (lease_expiration is null or lease_expiration < ?)))`
+
+func claimableRepJobParams(now time.Time) []interface{} {
+	return []interface{}{models.JobPending, models.JobRetrying, now, models.JobRunning, now}
+}
+
+// ClaimRepJob claims the queued job with the highest priority for the job service instance owner,
+// the job is set to running and leased until now+lease. It returns nil if there is no job to claim.
+// As the job is claimed by a conditional update, a job is never claimed by two instances.
+func ClaimRepJob(owner string, now time.Time, lease time.Duration) (*models.RepJob, error) {
+	o := GetOrmer()
+	sql := `select * from replication_job where ` + claimableRepJobCond + `
+		order by priority desc, schedule_time, id limit 10`
+	var jobs []*models.RepJob
+	if _, err := o.Raw(sql, claimableRepJobParams(now)...).QueryRows(&jobs); err != nil {
+		return nil, err
+	}
+
+	sql = `update replication_job set status = ?, lease_expiration = ?, lease_owner = ?, update_time = ?
+		where id = ? and ` + claimableRepJobCond
+	for _, job := range jobs {
+		params := []interface{}{models.JobRunning, now.Add(lease), owner, now, job.ID}
+		params = append(params, claimableRepJobParams(now)...)
+		result, err := o.Raw(sql, params...).Exec()
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		// claimed by others in the meantime
+		if n == 0 {
+			continue
+		}
+		job.Status = models.JobRunning
+		job.Owner = owner
+		genTagListForJob(job)
+		return job, nil
+	}
+
+	return nil, nil
+}
+
+// RenewRepJobLeases extends the leases of the running jobs claimed by the job service instance
+// owner to the expiration, the jobs claimed by others in the meantime are untouched
+func RenewRepJobLeases(owner string, ids []int64, expiration time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	// the lease isn't mapped to the model as it's only used by the queue
+	sql := `update replication_job set lease_expiration = ? where status = ? and lease_owner = ? and id in (` +
+		inClausePlaceholders(len(ids)) + `)`
+	params := []interface{}{expiration, models.JobRunning, owner}
+	for _, id := range ids {
+		params = append(params, id)
+	}
+	_, err := GetOrmer().Raw(sql, params...).Exec()
+	return err
+}
+
+// RequestRepJobsStop records the requests to stop the running jobs, every job service instance
+// stops the ones it has claimed once it sees the requests
+func RequestRepJobsStop(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	sql := `update replication_job set stop_requested = 1 where status = ? and id in (` +
+		inClausePlaceholders(len(ids)) + `)`
+	params := []interface{}{models.JobRunning}
+	for _, id := range ids {
+		params = append(params, id)
+	}
+	_, err := GetOrmer().Raw(sql, params...).Exec()
+	return err
+}
+
+// GetStopRequestedRepJobs returns the running jobs claimed by the job service instance owner
+// which are requested to be stopped
+func GetStopRequestedRepJobs(owner string) ([]*models.RepJob, error) {
+	var jobs []*models.RepJob
+	_, err := GetOrmer().Raw(`select * from replication_job
+		where status = ? and lease_owner = ? and stop_requested = 1`,
+		models.JobRunning, owner).QueryRows(&jobs)
+	if err != nil {
+		return nil, err
+	}
+	genTagListForJob(jobs...)
+	return jobs, nil
+}
+
+// claimed returns whether the conditional update claiming something affects any row
+func claimed(result sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n != 0, nil
+}
+
+func inClausePlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// RetryRepJob puts the job back into the queue with the status retrying, it can't be claimed
+// before the schedule time
+func RetryRepJob(id int64, scheduleTime time.Time) error {
+	o := GetOrmer()
+	j := models.RepJob{
+		ID:           id,
+		Status:       models.JobRetrying,
+		ScheduleTime: scheduleTime,
+		UpdateTime:   time.Now(),
+	}
+	num, err := o.Update(&j, "Status", "ScheduleTime", "UpdateTime")
+	if err == nil && num == 0 {
+		err = fmt.Errorf("replication job %d not found", id)
+	}
+	return err
+}
+
+// UpdateRepJobUploadSessions saves the blob upload sessions of the job, they are cleared if
+// sessions is empty
+func UpdateRepJobUploadSessions(id int64, sessions string) error {
+	_, err := GetOrmer().Raw(`update replication_job set upload_sessions = ? where id = ?`,
+		sessions, id).Exec()
+	return err
+}
+
+// GetRepJobQueue returns the statistics of the queue of replication jobs
+func GetRepJobQueue() (*models.RepJobQueue, error) {
+	queue := &models.RepJobQueue{}
+	var err error
+	if queue.Pending, err = repJobQs().Filter("status", models.JobPending).Count(); err != nil {
+		return nil, err
+	}
+	if queue.Retrying, err = repJobQs().Filter("status", models.JobRetrying).Count(); err != nil {
+		return nil, err
+	}
+	if queue.Running, err = repJobQs().Filter("status", models.JobRunning).Count(); err != nil {
+		return nil, err
+	}
+	queue.Depth = queue.Pending + queue.Retrying
+	if queue.Instances, err = GetJobServiceInstances(); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// GetRepJobQueuePosition returns the position, starting from 1, of the pending or retrying
+// job in the queue, i.e. the number of the queued jobs to be handled before it plus 1.
+// It returns 0 if the job isn't queued.
+func GetRepJobQueuePosition(job *models.RepJob) (int64, error) {
+	if job.Status != models.JobPending && job.Status != models.JobRetrying {
+		return 0, nil
+	}
+	sql := `select count(*) from replication_job where status in (?, ?) and (priority > ?
+		or (priority = ? and (schedule_time < ? or (schedule_time = ? and id < ?))))`
+	var n int64
+	if err := GetOrmer().Raw(sql, models.JobPending, models.JobRetrying, job.Priority,
+		job.Priority, job.ScheduleTime, job.ScheduleTime, job.ID).QueryRow(&n); err != nil {
+		return 0, err
+	}
+	return n + 1, nil
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestRepJobQueue(t *testing.T) {
+	// the jobs are bound to a policy which doesn't exist and have the priorities higher
+	// than the ones of the jobs created by other tests, so they are claimed first
+	var queuePolicyID int64 = 9999
+	defer func() {
+		if _, err := GetOrmer().Raw(`delete from replication_job where policy_id = ?`,
+			queuePolicyID).Exec(); err != nil {
+			t.Fatalf("failed to clear replication jobs: %v", err)
+		}
+	}()
+
+	now := time.Now().Truncate(time.Second)
+	add := func(priority int, status string, scheduleTime time.Time) *models.RepJob {
+		id, err := AddRepJob(models.RepJob{
+			Repository:   "library/queue",
+			PolicyID:     queuePolicyID,
+			Operation:    models.RepOpTransfer,
+			Status:       status,
+			Priority:     priority,
+			ScheduleTime: scheduleTime,
+		})
+		if err != nil {
+			t.Fatalf("failed to add replication job: %v", err)
+		}
+		job, err := GetRepJob(id)
+		if err != nil {
+			t.Fatalf("failed to get replication job %d: %v", id, err)
+		}
+		return job
+	}
+	low := add(3000, models.JobPending, now)
+	high := add(3001, models.JobPending, now.Add(time.Second))
+	retrying := add(3002, models.JobRetrying, now.Add(time.Hour))
+
+	for i, job := range []*models.RepJob{retrying, high, low} {
+		position, err := GetRepJobQueuePosition(job)
+		if err != nil {
+			t.Fatalf("failed to get queue position of job %d: %v", job.ID, err)
+		}
+		if position != int64(i+1) {
+			t.Errorf("unexpected queue position of job %d: %d != %d", job.ID, position, i+1)
+		}
+	}
+
+	claim := func(now time.Time, expected int64) {
+		job, err := ClaimRepJob("instance1", now, time.Minute)
+		if err != nil {
+			t.Fatalf("failed to claim job: %v", err)
+		}
+		if job == nil || job.ID != expected || job.Status != models.JobRunning || job.Owner != "instance1" {
+			t.Fatalf("unexpected job claimed: %+v, expected: %d", job, expected)
+		}
+	}
+	// the retrying job can't be claimed before its schedule time
+	claim(now, high.ID)
+	claim(now, low.ID)
+
+	position, err := GetRepJobQueuePosition(&models.RepJob{ID: low.ID, Status: models.JobRunning})
+	if err != nil || position != 0 {
+		t.Errorf("unexpected queue position of running job: %d, %v", position, err)
+	}
+
+	// the lease of the high one is renewed, the one of the low one expires
+	if err = RenewRepJobLeases("instance1", []int64{high.ID}, now.Add(3*time.Hour)); err != nil {
+		t.Fatalf("failed to renew leases: %v", err)
+	}
+	claim(now.Add(2*time.Hour), retrying.ID)
+	claim(now.Add(2*time.Hour), low.ID)
+
+	// only the owner stops the job
+	if err = RequestRepJobsStop([]int64{high.ID, low.ID}); err != nil {
+		t.Fatalf("failed to request jobs to stop: %v", err)
+	}
+	jobs, err := GetStopRequestedRepJobs("instance2")
+	if err != nil || len(jobs) != 0 {
+		t.Errorf("unexpected jobs to stop for instance2: %+v, %v", jobs, err)
+	}
+	jobs, err = GetStopRequestedRepJobs("instance1")
+	if err != nil || len(jobs) != 2 {
+		t.Errorf("unexpected jobs to stop for instance1: %+v, %v", jobs, err)
+	}
+
+	if err = RetryRepJob(low.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to retry job %d: %v", low.ID, err)
+	}
+	job, err := GetRepJob(low.ID)
+	if err != nil {
+		t.Fatalf("failed to get replication job %d: %v", low.ID, err)
+	}
+	if job.Status != models.JobRetrying || !job.ScheduleTime.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected job to retry: %+v", job)
+	}
+
+	queue, err := GetRepJobQueue()
+	if err != nil {
+		t.Fatalf("failed to get the queue: %v", err)
+	}
+	if queue.Retrying < 1 || queue.Running < 2 || queue.Depth != queue.Pending+queue.Retrying {
+		t.Errorf("unexpected queue: %+v", queue)
+	}
+}
@@ -83,6 +83,16 @@ func IncreasePullCount(name string) (err error) {
 	return err
 }
 
+// UpdateRepositorySize updates the size of the repository
+func UpdateRepositorySize(name string, size int64) error {
+	o := GetOrmer()
+	_, err := o.QueryTable("repository").Filter("name", name).Update(
+		orm.Params{
+			"size": size,
+		})
+	return err
+}
+
 //RepositoryExists returns whether the repository exists according to its name.
 func RepositoryExists(name string) bool {
 	o := GetOrmer()
@@ -0,0 +1,215 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddRetentionPolicy ...
+func AddRetentionPolicy(policy models.RetentionPolicy) (int64, error) {
+	o := GetOrmer()
+	now := time.Now()
+	policy.CreationTime = now
+	policy.UpdateTime = now
+	return o.Insert(&policy)
+}
+
+// GetRetentionPolicy ...
+func GetRetentionPolicy(id int64) (*models.RetentionPolicy, error) {
+	o := GetOrmer()
+	p := models.RetentionPolicy{ID: id}
+	err := o.Read(&p)
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
+
+// GetRetentionPolicyByProject returns the retention policy of the project,
+// nil is returned if the project has no retention policy
+func GetRetentionPolicyByProject(projectID int64) (*models.RetentionPolicy, error) {
+	o := GetOrmer()
+	p := models.RetentionPolicy{ProjectID: projectID}
+	err := o.Read(&p, "ProjectID")
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
+
+// GetScheduledRetentionPolicies returns the enabled retention policies which have a cron string
+func GetScheduledRetentionPolicies() ([]*models.RetentionPolicy, error) {
+	o := GetOrmer()
+	sql := `select * from retention_policy 
+		where enabled = 1 and cron_str is not null and cron_str != ''`
+
+	var policies []*models.RetentionPolicy
+
+	if _, err := o.Raw(sql).QueryRows(&policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// UpdateRetentionPolicy ...
+func UpdateRetentionPolicy(policy *models.RetentionPolicy) error {
+	o := GetOrmer()
+	policy.UpdateTime = time.Now()
+	_, err := o.Update(policy, "KeepLatest", "KeepPattern", "MaxAgeDays", "Enabled", "CronStr", "UpdateTime")
+	return err
+}
+
+// DeleteRetentionPolicy deletes the policy and its jobs
+func DeleteRetentionPolicy(id int64) error {
+	o := GetOrmer()
+	if _, err := o.Raw(`delete from retention_job where policy_id = ?`, id).Exec(); err != nil {
+		return err
+	}
+	_, err := o.Delete(&models.RetentionPolicy{ID: id})
+	return err
+}
+
+// AddRetentionJob ...
+func AddRetentionJob(job models.RetentionJob) (int64, error) {
+	o := GetOrmer()
+	if len(job.Status) == 0 {
+		job.Status = models.JobPending
+	}
+	return o.Insert(&job)
+}
+
+// GetRetentionJob ...
+func GetRetentionJob(id int64) (*models.RetentionJob, error) {
+	o := GetOrmer()
+	j := models.RetentionJob{ID: id}
+	err := o.Read(&j)
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = genTagsForRetentionJob(&j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// GetRetentionJobs returns the jobs of the policy, the latest one comes first
+func GetRetentionJobs(policyID int64, limit, offset int64) ([]*models.RetentionJob, int64, error) {
+	jobs := []*models.RetentionJob{}
+	qs := GetOrmer().QueryTable(new(models.RetentionJob)).Filter("PolicyID", policyID)
+
+	total, err := qs.Count()
+	if err != nil {
+		return jobs, 0, err
+	}
+
+	if _, err = qs.OrderBy("-CreationTime").Limit(limit).Offset(offset).All(&jobs); err != nil {
+		return jobs, 0, err
+	}
+
+	if err = genTagsForRetentionJob(jobs...); err != nil {
+		return jobs, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+// GetRetentionPolicyLastRunTime returns the creation time of the latest job which
+// is not a dry run of the policy, nil is returned if no such job exists
+func GetRetentionPolicyLastRunTime(policyID int64) (*time.Time, error) {
+	o := GetOrmer()
+	sql := `select * from retention_job 
+		where policy_id = ? and dry_run = 0 
+		order by creation_time desc limit 1`
+
+	var jobs []*models.RetentionJob
+	n, err := o.Raw(sql, policyID).QueryRows(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	return &jobs[0].CreationTime, nil
+}
+
+// ClaimRetentionPolicyScheduledRun claims the run of the policy scheduled at runTime, the time
+// the run is triggered, now, is recorded as the last scheduled time of the policy. It returns
+// false if the run has been claimed by another job service instance.
+func ClaimRetentionPolicyScheduledRun(policyID int64, runTime, now time.Time) (bool, error) {
+	// update_time is kept as the schedule of the policy starts from it
+	sql := `update retention_policy set last_scheduled_time = ?, update_time = update_time
+		where id = ? and (last_scheduled_time is null or last_scheduled_time < ?)`
+	return claimed(GetOrmer().Raw(sql, now, policyID, runTime).Exec())
+}
+
+// UpdateRetentionJobStatus ...
+func UpdateRetentionJobStatus(id int64, status string) error {
+	o := GetOrmer()
+	j := models.RetentionJob{
+		ID:         id,
+		Status:     status,
+		UpdateTime: time.Now(),
+	}
+	num, err := o.Update(&j, "Status", "UpdateTime")
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("failed to update retention job with id: %d", id)
+	}
+	return nil
+}
+
+// UpdateRetentionJobResult updates the tags deleted by the job
+func UpdateRetentionJobResult(id int64, tags map[string][]string) error {
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	o := GetOrmer()
+	j := models.RetentionJob{
+		ID:         id,
+		Result:     string(b),
+		UpdateTime: time.Now(),
+	}
+	_, err = o.Update(&j, "Result", "UpdateTime")
+	return err
+}
+
+func genTagsForRetentionJob(jobs ...*models.RetentionJob) error {
+	for _, j := range jobs {
+		if len(j.Result) == 0 {
+			continue
+		}
+		if err := json.Unmarshal([]byte(j.Result), &j.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
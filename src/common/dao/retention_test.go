@@ -0,0 +1,142 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+
+	id, err := AddRetentionPolicy(models.RetentionPolicy{
+		ProjectID:   projectID,
+		KeepLatest:  10,
+		KeepPattern: "^release-",
+		Enabled:     1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add retention policy: %v", err)
+	}
+	defer func() {
+		if err := DeleteRetentionPolicy(id); err != nil {
+			t.Fatalf("failed to delete retention policy %d: %v", id, err)
+		}
+	}()
+
+	policy, err := GetRetentionPolicyByProject(projectID)
+	if err != nil {
+		t.Fatalf("failed to get retention policy of project %d: %v", projectID, err)
+	}
+	if policy == nil || policy.ID != id {
+		t.Fatalf("unexpected retention policy: %+v", policy)
+	}
+
+	policy.MaxAgeDays = 30
+	policy.CronStr = "@daily"
+	if err = UpdateRetentionPolicy(policy); err != nil {
+		t.Fatalf("failed to update retention policy: %v", err)
+	}
+
+	policies, err := GetScheduledRetentionPolicies()
+	if err != nil {
+		t.Fatalf("failed to get scheduled retention policies: %v", err)
+	}
+	found := false
+	for _, p := range policies {
+		if p.ID == id {
+			found = true
+			if p.MaxAgeDays != 30 {
+				t.Errorf("unexpected max age days: %d != %d", p.MaxAgeDays, 30)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("retention policy %d not found in scheduled policies", id)
+	}
+
+	lastRun, err := GetRetentionPolicyLastRunTime(id)
+	if err != nil {
+		t.Fatalf("failed to get last run time of retention policy: %v", err)
+	}
+	if lastRun != nil {
+		t.Errorf("expected nil last run time, but got %v", lastRun)
+	}
+
+	if _, err = AddRetentionJob(models.RetentionJob{
+		PolicyID: id,
+		DryRun:   1,
+	}); err != nil {
+		t.Fatalf("failed to add retention job: %v", err)
+	}
+
+	// a dry run is not counted as a run of the policy
+	lastRun, err = GetRetentionPolicyLastRunTime(id)
+	if err != nil {
+		t.Fatalf("failed to get last run time of retention policy: %v", err)
+	}
+	if lastRun != nil {
+		t.Errorf("expected nil last run time, but got %v", lastRun)
+	}
+
+	jobID, err := AddRetentionJob(models.RetentionJob{
+		PolicyID: id,
+	})
+	if err != nil {
+		t.Fatalf("failed to add retention job: %v", err)
+	}
+
+	if err = UpdateRetentionJobStatus(jobID, models.JobFinished); err != nil {
+		t.Fatalf("failed to update status of retention job: %v", err)
+	}
+
+	tags := map[string][]string{
+		"library/ubuntu": []string{"14.04", "15.10"},
+	}
+	if err = UpdateRetentionJobResult(jobID, tags); err != nil {
+		t.Fatalf("failed to update result of retention job: %v", err)
+	}
+
+	job, err := GetRetentionJob(jobID)
+	if err != nil {
+		t.Fatalf("failed to get retention job: %v", err)
+	}
+	if job.Status != models.JobFinished {
+		t.Errorf("unexpected status: %s != %s", job.Status, models.JobFinished)
+	}
+	if len(job.Tags["library/ubuntu"]) != 2 {
+		t.Errorf("unexpected tags: %v", job.Tags)
+	}
+
+	lastRun, err = GetRetentionPolicyLastRunTime(id)
+	if err != nil {
+		t.Fatalf("failed to get last run time of retention policy: %v", err)
+	}
+	if lastRun == nil {
+		t.Errorf("last run time should not be nil")
+	}
+
+	jobs, total, err := GetRetentionJobs(id, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to get retention jobs: %v", err)
+	}
+	if total != 2 || len(jobs) != 2 {
+		t.Errorf("unexpected total of retention jobs: %d, length: %d", total, len(jobs))
+	}
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"strings"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddRobot ...
+func AddRobot(robot models.Robot) (int64, error) {
+	robot.Actions = strings.Join(robot.ActionList, ",")
+	return GetOrmer().Insert(&robot)
+}
+
+// GetRobot ...
+func GetRobot(id int64) (*models.Robot, error) {
+	robot := models.Robot{ID: id}
+	if err := GetOrmer().Read(&robot); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	genActionsForRobot(&robot)
+	return &robot, nil
+}
+
+// GetRobotByName returns the robot account of the project with the name
+func GetRobotByName(projectID int64, name string) (*models.Robot, error) {
+	robot := models.Robot{ProjectID: projectID, Name: name}
+	if err := GetOrmer().Read(&robot, "ProjectID", "Name"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	genActionsForRobot(&robot)
+	return &robot, nil
+}
+
+// GetRobots returns the robot accounts of the project
+func GetRobots(projectID int64) ([]*models.Robot, error) {
+	robots := []*models.Robot{}
+	_, err := GetOrmer().QueryTable(new(models.Robot)).
+		Filter("ProjectID", projectID).
+		OrderBy("ID").
+		All(&robots)
+	if err != nil {
+		return robots, err
+	}
+	genActionsForRobot(robots...)
+	return robots, nil
+}
+
+// DeleteRobot ...
+func DeleteRobot(id int64) error {
+	_, err := GetOrmer().Delete(&models.Robot{ID: id})
+	return err
+}
+
+func genActionsForRobot(robots ...*models.Robot) {
+	for _, r := range robots {
+		r.ActionList = []string{}
+		if len(r.Actions) == 0 {
+			continue
+		}
+		r.ActionList = strings.Split(r.Actions, ",")
+	}
+}
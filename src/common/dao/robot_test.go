@@ -0,0 +1,73 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestRobot(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+
+	id, err := AddRobot(models.Robot{
+		Name:       "ci",
+		ProjectID:  projectID,
+		Secret:     "secret",
+		Salt:       "salt",
+		ActionList: []string{models.RobotActionPull, models.RobotActionPush},
+		ExpiresAt:  time.Now().Add(time.Hour),
+		CreatorID:  1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add robot: %v", err)
+	}
+	defer func() {
+		if err := DeleteRobot(id); err != nil {
+			t.Fatalf("failed to delete robot %d: %v", id, err)
+		}
+	}()
+
+	robot, err := GetRobotByName(projectID, "ci")
+	if err != nil {
+		t.Fatalf("failed to get robot ci: %v", err)
+	}
+	if robot == nil || robot.ID != id {
+		t.Fatalf("unexpected robot: %+v", robot)
+	}
+	if !robot.Allows(models.RobotActionPush) || robot.Allows(models.RobotActionDelete) {
+		t.Errorf("unexpected actions: %v", robot.ActionList)
+	}
+
+	robot, err = GetRobotByName(projectID, "not-exist")
+	if err != nil {
+		t.Fatalf("failed to get robot not-exist: %v", err)
+	}
+	if robot != nil {
+		t.Errorf("unexpected robot: %+v", robot)
+	}
+
+	robots, err := GetRobots(projectID)
+	if err != nil {
+		t.Fatalf("failed to get robots: %v", err)
+	}
+	if len(robots) != 1 || robots[0].ID != id {
+		t.Errorf("unexpected robots: %+v", robots)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddScanJob ...
+func AddScanJob(job models.ScanJob) (int64, error) {
+	o := GetOrmer()
+	if len(job.Status) == 0 {
+		job.Status = models.JobPending
+	}
+	return o.Insert(&job)
+}
+
+// GetScanJob ...
+func GetScanJob(id int64) (*models.ScanJob, error) {
+	o := GetOrmer()
+	j := models.ScanJob{ID: id}
+	err := o.Read(&j)
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	return &j, err
+}
+
+// GetLastScanJob returns the latest scan job of the tag, nil is returned if the
+// tag has never been scanned
+func GetLastScanJob(repository, tag string) (*models.ScanJob, error) {
+	o := GetOrmer()
+	jobs := []*models.ScanJob{}
+	n, err := o.QueryTable("scan_job").
+		Filter("repository", repository).
+		Filter("tag", tag).
+		OrderBy("-id").
+		Limit(1).
+		All(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	return jobs[0], nil
+}
+
+// UpdateScanJobStatus ...
+func UpdateScanJobStatus(id int64, status string) error {
+	o := GetOrmer()
+	j := models.ScanJob{
+		ID:         id,
+		Status:     status,
+		UpdateTime: time.Now(),
+	}
+	num, err := o.Update(&j, "Status", "UpdateTime")
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("failed to update scan job with id: %d", id)
+	}
+	return nil
+}
+
+// UpdateScanJobDigest records the digest of the manifest scanned by the job
+func UpdateScanJobDigest(id int64, digest string) error {
+	o := GetOrmer()
+	j := models.ScanJob{
+		ID:         id,
+		Digest:     digest,
+		UpdateTime: time.Now(),
+	}
+	_, err := o.Update(&j, "Digest", "UpdateTime")
+	return err
+}
+
+// SaveScanResult stores the result of scanning the manifest, the previous result
+// of the same manifest is replaced
+func SaveScanResult(result *models.ScanResult) error {
+	if result.Vulnerabilities == nil {
+		result.Vulnerabilities = []*models.Vulnerability{}
+	}
+	data, err := json.Marshal(result.Vulnerabilities)
+	if err != nil {
+		return err
+	}
+	result.Data = string(data)
+	result.Severity = models.HighestSeverity(result.Vulnerabilities)
+
+	existing, err := GetScanResult(result.Digest)
+	if err != nil {
+		return err
+	}
+
+	o := GetOrmer()
+	if existing == nil {
+		result.ID, err = o.Insert(result)
+		return err
+	}
+
+	result.ID = existing.ID
+	result.UpdateTime = time.Now()
+	_, err = o.Update(result, "JobID", "Scanner", "Severity", "Data", "UpdateTime")
+	return err
+}
+
+// GetScanResult returns the result of scanning the manifest, nil is returned if
+// it hasn't been scanned
+func GetScanResult(digest string) (*models.ScanResult, error) {
+	o := GetOrmer()
+	result := models.ScanResult{Digest: digest}
+	err := o.Read(&result, "Digest")
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(result.Data), &result.Vulnerabilities); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestScanJob(t *testing.T) {
+	defer func() {
+		if _, err := GetOrmer().Raw(`delete from scan_job`).Exec(); err != nil {
+			t.Fatalf("failed to clear scan jobs: %v", err)
+		}
+		if _, err := GetOrmer().Raw(`delete from scan_result`).Exec(); err != nil {
+			t.Fatalf("failed to clear scan results: %v", err)
+		}
+	}()
+
+	job, err := GetLastScanJob("library/ubuntu", "14.04")
+	if err != nil {
+		t.Fatalf("failed to get last scan job: %v", err)
+	}
+	if job != nil {
+		t.Errorf("expected nil scan job, but got %+v", job)
+	}
+
+	id, err := AddScanJob(models.ScanJob{
+		Repository: "library/ubuntu",
+		Tag:        "14.04",
+	})
+	if err != nil {
+		t.Fatalf("failed to add scan job: %v", err)
+	}
+	if err = UpdateScanJobDigest(id, "sha256:1"); err != nil {
+		t.Fatalf("failed to update digest of scan job: %v", err)
+	}
+	if err = UpdateScanJobStatus(id, models.JobFinished); err != nil {
+		t.Fatalf("failed to update status of scan job: %v", err)
+	}
+
+	job, err = GetLastScanJob("library/ubuntu", "14.04")
+	if err != nil {
+		t.Fatalf("failed to get last scan job: %v", err)
+	}
+	if job == nil || job.ID != id || job.Digest != "sha256:1" || job.Status != models.JobFinished {
+		t.Fatalf("unexpected last scan job: %+v", job)
+	}
+
+	if err = SaveScanResult(&models.ScanResult{
+		Digest:  "sha256:1",
+		JobID:   id,
+		Scanner: "local",
+		Vulnerabilities: []*models.Vulnerability{
+			{ID: "CVE-2017-3735", Package: "openssl", Severity: models.SeverityMedium},
+		},
+	}); err != nil {
+		t.Fatalf("failed to save scan result: %v", err)
+	}
+
+	// the result of the same digest is replaced
+	if err = SaveScanResult(&models.ScanResult{
+		Digest:  "sha256:1",
+		JobID:   id,
+		Scanner: "local",
+		Vulnerabilities: []*models.Vulnerability{
+			{ID: "CVE-2017-3735", Package: "openssl", Severity: models.SeverityMedium},
+			{ID: "CVE-2016-2177", Package: "openssl", Severity: models.SeverityHigh},
+		},
+	}); err != nil {
+		t.Fatalf("failed to save scan result: %v", err)
+	}
+
+	result, err := GetScanResult("sha256:1")
+	if err != nil {
+		t.Fatalf("failed to get scan result: %v", err)
+	}
+	if result == nil || len(result.Vulnerabilities) != 2 || result.Severity != models.SeverityHigh {
+		t.Errorf("unexpected scan result: %+v", result)
+	}
+
+	result, err = GetScanResult("sha256:2")
+	if err != nil {
+		t.Fatalf("failed to get scan result: %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected nil scan result, but got %+v", result)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddSigningKey ...
+func AddSigningKey(key models.SigningKey) (int64, error) {
+	return GetOrmer().Insert(&key)
+}
+
+// GetSigningKey ...
+func GetSigningKey(id int64) (*models.SigningKey, error) {
+	key := models.SigningKey{ID: id}
+	if err := GetOrmer().Read(&key); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetSigningKeyByName returns the signing key of the project with the name
+func GetSigningKeyByName(projectID int64, name string) (*models.SigningKey, error) {
+	key := models.SigningKey{ProjectID: projectID, Name: name}
+	if err := GetOrmer().Read(&key, "ProjectID", "Name"); err != nil {
+		if err == orm.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetSigningKeys returns the signing keys of the project
+func GetSigningKeys(projectID int64) ([]*models.SigningKey, error) {
+	keys := []*models.SigningKey{}
+	_, err := GetOrmer().QueryTable(new(models.SigningKey)).
+		Filter("ProjectID", projectID).
+		OrderBy("ID").
+		All(&keys)
+	return keys, err
+}
+
+// DeleteSigningKey deletes the signing key and the signatures verified with it
+func DeleteSigningKey(id int64) error {
+	o := GetOrmer()
+	if _, err := o.QueryTable(new(models.Signature)).Filter("KeyID", id).Delete(); err != nil {
+		return err
+	}
+	_, err := o.Delete(&models.SigningKey{ID: id})
+	return err
+}
+
+// AddSignature ...
+func AddSignature(signature models.Signature) (int64, error) {
+	return GetOrmer().Insert(&signature)
+}
+
+// GetSignatures returns the signatures of the project, only the signatures of
+// the digest are returned if it's set
+func GetSignatures(projectID int64, digest string) ([]*models.Signature, error) {
+	signatures := []*models.Signature{}
+	qs := GetOrmer().QueryTable(new(models.Signature)).Filter("ProjectID", projectID)
+	if len(digest) != 0 {
+		qs = qs.Filter("Digest", digest)
+	}
+	_, err := qs.OrderBy("ID").All(&signatures)
+	return signatures, err
+}
+
+// GetSignedDigests returns which of the digests have signatures in the project
+func GetSignedDigests(projectID int64, digests []string) (map[string]bool, error) {
+	signed := map[string]bool{}
+	if len(digests) == 0 {
+		return signed, nil
+	}
+
+	signatures := []*models.Signature{}
+	_, err := GetOrmer().QueryTable(new(models.Signature)).
+		Filter("ProjectID", projectID).
+		Filter("Digest__in", digests).
+		All(&signatures, "Digest")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range signatures {
+		signed[s.Digest] = true
+	}
+	return signed, nil
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestSignature(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+
+	keyID, err := AddSigningKey(models.SigningKey{
+		ProjectID: projectID,
+		Name:      "release",
+		PublicKey: "public key",
+		CreatorID: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add signing key: %v", err)
+	}
+	defer func() {
+		if err := DeleteSigningKey(keyID); err != nil {
+			t.Fatalf("failed to delete signing key %d: %v", keyID, err)
+		}
+		signatures, err := GetSignatures(projectID, "")
+		if err != nil {
+			t.Fatalf("failed to get signatures: %v", err)
+		}
+		if len(signatures) != 0 {
+			t.Errorf("the signatures should be deleted with the key: %+v", signatures)
+		}
+	}()
+
+	key, err := GetSigningKeyByName(projectID, "release")
+	if err != nil {
+		t.Fatalf("failed to get signing key release: %v", err)
+	}
+	if key == nil || key.ID != keyID {
+		t.Fatalf("unexpected signing key: %+v", key)
+	}
+
+	keys, err := GetSigningKeys(projectID)
+	if err != nil {
+		t.Fatalf("failed to get signing keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != keyID {
+		t.Errorf("unexpected signing keys: %+v", keys)
+	}
+
+	if _, err = AddSignature(models.Signature{
+		ProjectID: projectID,
+		Digest:    "sha256:1",
+		KeyID:     keyID,
+		Signature: "c2lnbmF0dXJl",
+		Creator:   "admin",
+	}); err != nil {
+		t.Fatalf("failed to add signature: %v", err)
+	}
+
+	signatures, err := GetSignatures(projectID, "sha256:1")
+	if err != nil {
+		t.Fatalf("failed to get signatures: %v", err)
+	}
+	if len(signatures) != 1 || signatures[0].KeyID != keyID {
+		t.Errorf("unexpected signatures: %+v", signatures)
+	}
+
+	signed, err := GetSignedDigests(projectID, []string{"sha256:1", "sha256:2"})
+	if err != nil {
+		t.Fatalf("failed to get signed digests: %v", err)
+	}
+	if !signed["sha256:1"] || signed["sha256:2"] {
+		t.Errorf("unexpected signed digests: %v", signed)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// AddWebhook ...
+func AddWebhook(webhook models.Webhook) (int64, error) {
+	o := GetOrmer()
+	webhook.EventTypes = strings.Join(webhook.Events, ",")
+	return o.Insert(&webhook)
+}
+
+// GetWebhook ...
+func GetWebhook(id int64) (*models.Webhook, error) {
+	o := GetOrmer()
+	w := models.Webhook{ID: id}
+	err := o.Read(&w)
+	if err == orm.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	genEventsForWebhook(&w)
+	return &w, nil
+}
+
+// GetWebhooks returns the webhooks of the project, only the enabled ones are
+// returned if enabledOnly is true
+func GetWebhooks(projectID int64, enabledOnly bool) ([]*models.Webhook, error) {
+	webhooks := []*models.Webhook{}
+	qs := GetOrmer().QueryTable(new(models.Webhook)).Filter("ProjectID", projectID)
+	if enabledOnly {
+		qs = qs.Filter("Enabled", 1)
+	}
+
+	if _, err := qs.OrderBy("ID").All(&webhooks); err != nil {
+		return webhooks, err
+	}
+
+	genEventsForWebhook(webhooks...)
+	return webhooks, nil
+}
+
+// UpdateWebhook updates the target URL, the events and the enablement of the webhook,
+// the secret is updated only when it is not empty
+func UpdateWebhook(webhook *models.Webhook) error {
+	o := GetOrmer()
+	webhook.EventTypes = strings.Join(webhook.Events, ",")
+	webhook.UpdateTime = time.Now()
+	cols := []string{"TargetURL", "EventTypes", "Enabled", "UpdateTime"}
+	if len(webhook.Secret) != 0 {
+		cols = append(cols, "Secret")
+	}
+	_, err := o.Update(webhook, cols...)
+	return err
+}
+
+// DeleteWebhook deletes the webhook and its deliveries
+func DeleteWebhook(id int64) error {
+	o := GetOrmer()
+	if _, err := o.Raw(`delete from webhook_delivery where webhook_id = ?`, id).Exec(); err != nil {
+		return err
+	}
+	_, err := o.Delete(&models.Webhook{ID: id})
+	return err
+}
+
+func genEventsForWebhook(webhooks ...*models.Webhook) {
+	for _, w := range webhooks {
+		w.Events = []string{}
+		if len(w.EventTypes) == 0 {
+			continue
+		}
+		w.Events = strings.Split(w.EventTypes, ",")
+	}
+}
+
+// AddWebhookDelivery ...
+func AddWebhookDelivery(delivery models.WebhookDelivery) (int64, error) {
+	o := GetOrmer()
+	if len(delivery.Status) == 0 {
+		delivery.Status = models.WebhookDeliveryPending
+	}
+	if delivery.NextAttemptTime.IsZero() {
+		delivery.NextAttemptTime = time.Now()
+	}
+	return o.Insert(&delivery)
+}
+
+// GetWebhookDeliveries returns the deliveries of the webhook, the latest one comes first
+func GetWebhookDeliveries(webhookID int64, limit, offset int64) ([]*models.WebhookDelivery, int64, error) {
+	deliveries := []*models.WebhookDelivery{}
+	qs := GetOrmer().QueryTable(new(models.WebhookDelivery)).Filter("WebhookID", webhookID)
+
+	total, err := qs.Count()
+	if err != nil {
+		return deliveries, 0, err
+	}
+
+	_, err = qs.OrderBy("-CreationTime", "-ID").Limit(limit).Offset(offset).All(&deliveries)
+	return deliveries, total, err
+}
+
+// GetDueWebhookDeliveries returns at most limit pending deliveries whose next attempt
+// time is not after now, the earliest one comes first
+func GetDueWebhookDeliveries(now time.Time, limit int64) ([]*models.WebhookDelivery, error) {
+	deliveries := []*models.WebhookDelivery{}
+	_, err := GetOrmer().QueryTable(new(models.WebhookDelivery)).
+		Filter("Status", models.WebhookDeliveryPending).
+		Filter("NextAttemptTime__lte", now).
+		OrderBy("NextAttemptTime", "ID").
+		Limit(limit).
+		All(&deliveries)
+	return deliveries, err
+}
+
+// ClaimWebhookDelivery claims the due delivery for an attempt by postponing its next attempt
+// time to now+lease, so it's sent by only one job service instance and it's due again once the
+// lease expires if the instance goes down before recording the result of the attempt. It returns
+// false if the delivery has been claimed by another instance.
+func ClaimWebhookDelivery(id int64, now time.Time, lease time.Duration) (bool, error) {
+	sql := `update webhook_delivery set next_attempt_time = ?
+		where id = ? and status = ? and next_attempt_time <= ?`
+	return claimed(GetOrmer().Raw(sql, now.Add(lease), id, models.WebhookDeliveryPending, now).Exec())
+}
+
+// UpdateWebhookDelivery updates the result of an attempt of the delivery
+func UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	o := GetOrmer()
+	delivery.UpdateTime = time.Now()
+	_, err := o.Update(delivery, "Status", "Attempts", "ResponseCode", "LastError",
+		"NextAttemptTime", "UpdateTime")
+	return err
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestWebhook(t *testing.T) {
+	// project "library"
+	var projectID int64 = 1
+
+	id, err := AddWebhook(models.Webhook{
+		ProjectID: projectID,
+		TargetURL: "http://ci.example.com/hook",
+		Secret:    "secret",
+		Events:    []string{models.WebhookEventPush, models.WebhookEventDelete},
+		Enabled:   1,
+	})
+	if err != nil {
+		t.Fatalf("failed to add webhook: %v", err)
+	}
+	defer func() {
+		if err := DeleteWebhook(id); err != nil {
+			t.Fatalf("failed to delete webhook %d: %v", id, err)
+		}
+	}()
+
+	webhook, err := GetWebhook(id)
+	if err != nil {
+		t.Fatalf("failed to get webhook %d: %v", id, err)
+	}
+	if webhook == nil || !webhook.Subscribes(models.WebhookEventPush) ||
+		webhook.Subscribes(models.WebhookEventPull) {
+		t.Fatalf("unexpected webhook: %+v", webhook)
+	}
+
+	// the secret is kept if it is not set
+	webhook.Secret = ""
+	webhook.Enabled = 0
+	if err = UpdateWebhook(webhook); err != nil {
+		t.Fatalf("failed to update webhook: %v", err)
+	}
+
+	webhooks, err := GetWebhooks(projectID, true)
+	if err != nil {
+		t.Fatalf("failed to get webhooks: %v", err)
+	}
+	for _, w := range webhooks {
+		if w.ID == id {
+			t.Errorf("disabled webhook %d should not be returned", id)
+		}
+	}
+
+	webhook, err = GetWebhook(id)
+	if err != nil {
+		t.Fatalf("failed to get webhook %d: %v", id, err)
+	}
+	if webhook.Secret != "secret" {
+		t.Errorf("unexpected secret: %s", webhook.Secret)
+	}
+
+	now := time.Now()
+	deliveryID, err := AddWebhookDelivery(models.WebhookDelivery{
+		WebhookID: id,
+		EventType: models.WebhookEventPush,
+		Payload:   "{}",
+	})
+	if err != nil {
+		t.Fatalf("failed to add webhook delivery: %v", err)
+	}
+
+	deliveries, err := GetDueWebhookDeliveries(now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("failed to get due webhook deliveries: %v", err)
+	}
+	var delivery *models.WebhookDelivery
+	for _, d := range deliveries {
+		if d.ID == deliveryID {
+			delivery = d
+		}
+	}
+	if delivery == nil {
+		t.Fatalf("webhook delivery %d should be due", deliveryID)
+	}
+
+	// an attempt is claimed by only one instance
+	for i, expected := range []bool{true, false} {
+		claimed, err := ClaimWebhookDelivery(deliveryID, now.Add(time.Minute), time.Minute)
+		if err != nil {
+			t.Fatalf("failed to claim webhook delivery: %v", err)
+		}
+		if claimed != expected {
+			t.Errorf("unexpected result of claim %d: %v != %v", i, claimed, expected)
+		}
+	}
+
+	delivery.Attempts = 1
+	delivery.NextAttemptTime = now.Add(time.Hour)
+	if err = UpdateWebhookDelivery(delivery); err != nil {
+		t.Fatalf("failed to update webhook delivery: %v", err)
+	}
+
+	deliveries, err = GetDueWebhookDeliveries(now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("failed to get due webhook deliveries: %v", err)
+	}
+	for _, d := range deliveries {
+		if d.ID == deliveryID {
+			t.Errorf("webhook delivery %d should not be due", deliveryID)
+		}
+	}
+
+	deliveries, total, err := GetWebhookDeliveries(id, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to get webhook deliveries: %v", err)
+	}
+	if total != 1 || len(deliveries) != 1 || deliveries[0].Attempts != 1 {
+		t.Errorf("unexpected webhook deliveries: %d %+v", total, deliveries)
+	}
+}
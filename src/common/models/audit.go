@@ -0,0 +1,80 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// the types of the resources recorded in audit log
+const (
+	AuditResourceUser          = "user"
+	AuditResourceProject       = "project"
+	AuditResourceProjectMember = "project_member"
+	AuditResourceTarget        = "target"
+	AuditResourcePolicy        = "policy"
+	// the images pushed, pulled and deleted by robot accounts, the ID is "repository:tag"
+	AuditResourceRepository = "repository"
+)
+
+// the actions recorded in audit log
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionLogin          = "login"
+	AuditActionLoginFailed    = "login_failed"
+	AuditActionChangePassword = "change_password"
+	AuditActionResetPassword  = "reset_password"
+	AuditActionSetAdminRole   = "set_admin_role"
+	AuditActionSetPublicity   = "set_publicity"
+	AuditActionEnable         = "enable"
+	AuditActionDisable        = "disable"
+	AuditActionPush           = "push"
+	AuditActionPull           = "pull"
+)
+
+// AuditLog records who did what to a resource other than the images and where the
+// request came from, Before and After summarize the state of the resource around the
+// operation and never contain credentials
+type AuditLog struct {
+	ID           int64     `orm:"pk;auto;column(id)" json:"id"`
+	ActorID      int       `orm:"column(actor_id)" json:"actor_id"`
+	Actor        string    `orm:"column(actor)" json:"actor"`
+	ResourceType string    `orm:"column(resource_type)" json:"resource_type"`
+	ResourceID   string    `orm:"column(resource_id)" json:"resource_id"`
+	Action       string    `orm:"column(action)" json:"action"`
+	Before       string    `orm:"column(before_summary)" json:"before"`
+	After        string    `orm:"column(after_summary)" json:"after"`
+	SourceIP     string    `orm:"column(source_ip)" json:"source_ip"`
+	OpTime       time.Time `orm:"column(op_time);auto_now_add" json:"op_time"`
+}
+
+// TableName is required by by beego orm to map AuditLog to table audit_log
+func (a *AuditLog) TableName() string {
+	return "audit_log"
+}
+
+// AuditLogQuery holds the conditions to filter audit logs, the empty ones are ignored
+type AuditLogQuery struct {
+	Actor        string
+	ResourceType string
+	ResourceID   string
+	Action       string
+	SourceIP     string
+	StartTime    *time.Time
+	EndTime      *time.Time
+}
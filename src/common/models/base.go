@@ -27,5 +27,21 @@ func init() {
 		new(Project),
 		new(Role),
 		new(AccessLog),
-		new(RepoRecord))
+		new(RepoRecord),
+		new(RetentionPolicy),
+		new(RetentionJob),
+		new(GCJob),
+		new(Webhook),
+		new(WebhookDelivery),
+		new(Robot),
+		new(SigningKey),
+		new(Signature),
+		new(ScanJob),
+		new(ScanResult),
+		new(CVEAllowlistItem),
+		new(OIDCUser),
+		new(OIDCGroupRole),
+		new(ProjectLDAPGroup),
+		new(AuditLog),
+		new(JobServiceInstance))
 }
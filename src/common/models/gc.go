@@ -0,0 +1,41 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// GCJob is the model for a garbage collection job, which unlinks the blobs
+// not referenced by any manifest from the repositories of registry
+type GCJob struct {
+	ID     int64  `orm:"column(id)" json:"id"`
+	Status string `orm:"column(status)" json:"status"`
+	// DryRun indicates that the job only reports the blobs to be unlinked
+	DryRun int `orm:"column(dry_run)" json:"dry_run"`
+	// Blobs is the number of blobs unlinked, or to be unlinked if the job is a dry run
+	Blobs int `orm:"column(blobs)" json:"blobs"`
+	// UnlinkedBytes is the size of the blobs unlinked, their data stays on the storage
+	// until the offline garbage collection of registry is run
+	UnlinkedBytes int64     `orm:"column(unlinked_bytes)" json:"unlinked_bytes"`
+	CreationTime  time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime    time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// TableName is required by by beego orm to map GCJob to table gc_job
+func (g *GCJob) TableName() string {
+	return "gc_job"
+}
@@ -0,0 +1,33 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// JobServiceInstance is the model for a running instance of job service, the instance
+// sends heartbeats periodically which also renew the leases of the jobs it has claimed
+type JobServiceInstance struct {
+	ID            string    `orm:"pk;column(id)" json:"id"`
+	HeartbeatTime time.Time `orm:"column(heartbeat_time)" json:"heartbeat_time"`
+	CreationTime  time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+}
+
+// TableName is required by by beego orm to map JobServiceInstance to table job_service_instance
+func (j *JobServiceInstance) TableName() string {
+	return "job_service_instance"
+}
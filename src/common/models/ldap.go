@@ -0,0 +1,39 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+// LDAPConf is the configuration of an LDAP server to be tested
+type LDAPConf struct {
+	URL       string `json:"ldap_url"`
+	SearchDn  string `json:"ldap_search_dn"`
+	SearchPwd string `json:"ldap_search_password"`
+	BaseDn    string `json:"ldap_base_dn"`
+	StartTLS  bool   `json:"ldap_starttls"`
+}
+
+// LDAPUser is a user found in LDAP
+type LDAPUser struct {
+	Username string `json:"username"`
+	Realname string `json:"realname"`
+	Email    string `json:"email"`
+	DN       string `json:"dn"`
+}
+
+// LDAPImportFailure is a user failed to be imported from LDAP
+type LDAPImportFailure struct {
+	UID   string `json:"uid"`
+	Error string `json:"error"`
+}
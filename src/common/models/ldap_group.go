@@ -0,0 +1,69 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+)
+
+// ProjectLDAPGroup grants the role of the project to the members of the LDAP group,
+// it's a member of the project like a user
+type ProjectLDAPGroup struct {
+	ID           int64     `orm:"column(id)" json:"id"`
+	ProjectID    int64     `orm:"column(project_id)" json:"project_id"`
+	GroupDN      string    `orm:"column(group_dn)" json:"group_dn"`
+	Role         int       `orm:"column(role)" json:"role_id"`
+	CreatorID    int       `orm:"column(creator_id)" json:"creator_id"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+}
+
+// Valid ...
+func (p *ProjectLDAPGroup) Valid(v *validation.Validation) {
+	p.GroupDN = NormalizeDN(p.GroupDN)
+	if len(p.GroupDN) == 0 {
+		v.SetError("group_dn", "can not be empty")
+	} else if len(p.GroupDN) > 255 {
+		v.SetError("group_dn", "max length is 255")
+	}
+
+	if p.Role != PROJECTADMIN && p.Role != DEVELOPER && p.Role != GUEST {
+		v.SetError("role_id", "invalid role")
+	}
+}
+
+// TableName is required by by beego orm to map ProjectLDAPGroup to table project_ldap_group
+func (p *ProjectLDAPGroup) TableName() string {
+	return "project_ldap_group"
+}
+
+// NormalizeDN returns the form of the DN stored in DB, so the DNs returned by LDAP server
+// can be matched regardless of the case and the spaces between RDNs, e.g.
+// "CN=Developers, OU=Groups,DC=mydomain,DC=com" is normalized to
+// "cn=developers,ou=groups,dc=mydomain,dc=com"
+func NormalizeDN(dn string) string {
+	rdns := strings.Split(strings.TrimSpace(dn), ",")
+	for i, rdn := range rdns {
+		// the comma escaped is part of the value
+		if i > 0 && strings.HasSuffix(rdns[i-1], `\`) {
+			continue
+		}
+		rdns[i] = strings.TrimSpace(rdn)
+	}
+	return strings.ToLower(strings.Join(rdns, ","))
+}
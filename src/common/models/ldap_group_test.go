@@ -0,0 +1,35 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+)
+
+func TestNormalizeDN(t *testing.T) {
+	cases := map[string]string{
+		"cn=developers,ou=groups,dc=mydomain,dc=com":     "cn=developers,ou=groups,dc=mydomain,dc=com",
+		" CN=Developers, OU=Groups,DC=mydomain, DC=com ": "cn=developers,ou=groups,dc=mydomain,dc=com",
+		`CN=Smith\, John, OU=People,DC=mydomain,DC=com`:  `cn=smith\, john,ou=people,dc=mydomain,dc=com`,
+		"": "",
+	}
+
+	for dn, expected := range cases {
+		if normalized := NormalizeDN(dn); normalized != expected {
+			t.Errorf("unexpected normalized DN of %q: %q", dn, normalized)
+		}
+	}
+}
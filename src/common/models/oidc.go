@@ -0,0 +1,70 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/astaxie/beego/validation"
+)
+
+// OIDCUser links a user of Harbor to the subject of an OpenID Connect provider,
+// the user is provisioned when the subject logs in for the first time
+type OIDCUser struct {
+	ID      int64  `orm:"column(id)" json:"id"`
+	UserID  int    `orm:"column(user_id)" json:"user_id"`
+	Subject string `orm:"column(subject)" json:"subject"`
+	// Secret is the CLI secret hashed with the salt, it's used in place of the password
+	// by docker CLI, the plain secret is only returned once when it is generated
+	Secret       string    `orm:"column(secret)" json:"-"`
+	Salt         string    `orm:"column(salt)" json:"-"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime   time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// TableName is required by by beego orm to map OIDCUser to table oidc_user
+func (o *OIDCUser) TableName() string {
+	return "oidc_user"
+}
+
+// OIDCGroupRole grants the role of the project to the users whose ID token
+// contains the group in the groups claim
+type OIDCGroupRole struct {
+	ID           int64     `orm:"column(id)" json:"id"`
+	ProjectID    int64     `orm:"column(project_id)" json:"project_id"`
+	GroupName    string    `orm:"column(group_name)" json:"group_name"`
+	Role         int       `orm:"column(role)" json:"role_id"`
+	CreatorID    int       `orm:"column(creator_id)" json:"creator_id"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+}
+
+// Valid ...
+func (o *OIDCGroupRole) Valid(v *validation.Validation) {
+	if len(o.GroupName) == 0 {
+		v.SetError("group_name", "can not be empty")
+	} else if len(o.GroupName) > 128 {
+		v.SetError("group_name", "max length is 128")
+	}
+
+	if o.Role != PROJECTADMIN && o.Role != DEVELOPER && o.Role != GUEST {
+		v.SetError("role_id", "invalid role")
+	}
+}
+
+// TableName is required by by beego orm to map OIDCGroupRole to table oidc_group_role
+func (o *OIDCGroupRole) TableName() string {
+	return "oidc_group_role"
+}
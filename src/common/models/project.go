@@ -16,9 +16,17 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/astaxie/beego/validation"
 )
 
+// DefaultProxyTTL is the default seconds after which the repositories cached by
+// a proxy cache project are revalidated against the target
+const DefaultProxyTTL = 24 * 60 * 60
+
 // Project holds the details of a project.
 type Project struct {
 	ProjectID       int64     `orm:"pk;column(project_id)" json:"project_id"`
@@ -36,6 +44,70 @@ type Project struct {
 	UpdateTime time.Time `orm:"update_time" json:"update_time"`
 	Role       int       `orm:"-" json:"current_user_role_id"`
 	RepoCount  int       `orm:"-" json:"repo_count"`
+	// StorageLimit and RepoLimit are the quotas of the project, 0 means no limit
+	StorageLimit int64 `orm:"column(storage_limit)" json:"storage_limit"`
+	RepoLimit    int   `orm:"column(repo_limit)" json:"repo_limit"`
+	// ProxyTargetID is the ID of the target whose images the project caches,
+	// 0 means the project is not a proxy cache
+	ProxyTargetID int64 `orm:"column(proxy_target_id)" json:"proxy_target_id"`
+	// ProxyTTL is the seconds after which the cached repositories are revalidated against the target
+	ProxyTTL int `orm:"column(proxy_ttl)" json:"proxy_ttl"`
+	// RequireSignature is 1 if only the images signed by the trusted keys of the project can be pulled
+	RequireSignature int `orm:"column(require_signature)" json:"require_signature"`
+	// ScanOnPush is 1 if the images pushed to the project are scanned for vulnerabilities
+	ScanOnPush int `orm:"column(scan_on_push)" json:"scan_on_push"`
+	// VulnerabilitySeverity is the lowest severity of the vulnerabilities which prevent the
+	// images from being pulled, empty means no limit
+	VulnerabilitySeverity string `orm:"column(vulnerability_severity)" json:"vulnerability_severity"`
+	// BlockUnscanned is 1 if the images which have never been scanned can not be pulled
+	BlockUnscanned int `orm:"column(block_unscanned)" json:"block_unscanned"`
+}
+
+// IsProxy returns whether the project is a proxy cache of a target
+func (p *Project) IsProxy() bool {
+	return p.ProxyTargetID > 0
+}
+
+// ProjectProxy holds the settings of a proxy cache project
+type ProjectProxy struct {
+	// ProxyTargetID is the ID of the target whose images are cached
+	ProxyTargetID int64 `json:"proxy_target_id"`
+	// ProxyTTL is the seconds after which the cached repositories are revalidated,
+	// DefaultProxyTTL is used if it's 0
+	ProxyTTL int `json:"proxy_ttl"`
+}
+
+// Valid ...
+func (p *ProjectProxy) Valid(v *validation.Validation) {
+	if p.ProxyTargetID <= 0 {
+		v.SetError("proxy_target_id", "invalid")
+	}
+	if p.ProxyTTL < 0 {
+		v.SetError("proxy_ttl", "can not be negative")
+	}
+	if p.ProxyTTL == 0 {
+		p.ProxyTTL = DefaultProxyTTL
+	}
+}
+
+// ProjectQuota holds the quotas and the usage of a project
+type ProjectQuota struct {
+	// StorageLimit is the max total size in bytes of the repositories, 0 means no limit
+	StorageLimit int64 `json:"storage_limit"`
+	// RepoLimit is the max number of repositories, 0 means no limit
+	RepoLimit    int   `json:"repo_limit"`
+	StorageUsage int64 `json:"storage_usage"`
+	RepoUsage    int64 `json:"repo_usage"`
+}
+
+// Valid ...
+func (p *ProjectQuota) Valid(v *validation.Validation) {
+	if p.StorageLimit < 0 {
+		v.SetError("storage_limit", "can not be negative")
+	}
+	if p.RepoLimit < 0 {
+		v.SetError("repo_limit", "can not be negative")
+	}
 }
 
 // ProjectSorter holds an array of projects
@@ -57,3 +129,29 @@ func (ps *ProjectSorter) Less(i, j int) bool {
 func (ps *ProjectSorter) Swap(i, j int) {
 	ps.Projects[i], ps.Projects[j] = ps.Projects[j], ps.Projects[i]
 }
+
+// ProjectVulnerabilityPolicy holds the settings which prevent the vulnerable images of
+// the project from being pulled
+type ProjectVulnerabilityPolicy struct {
+	// Severity is the lowest severity of the vulnerabilities which prevent the images
+	// from being pulled, empty means no limit
+	Severity string `json:"vulnerability_severity"`
+	// BlockUnscanned is 1 if the images which have never been scanned can not be pulled
+	BlockUnscanned int `json:"block_unscanned"`
+}
+
+// Valid ...
+func (p *ProjectVulnerabilityPolicy) Valid(v *validation.Validation) {
+	if len(p.Severity) != 0 {
+		level := SeverityLevel(p.Severity)
+		if level <= SeverityLevel(SeverityNone) {
+			v.SetError("vulnerability_severity", fmt.Sprintf("invalid severity %s, supported severities: %s",
+				p.Severity, strings.Join(Severities[1:], ",")))
+		} else {
+			p.Severity = Severities[level]
+		}
+	}
+	if p.BlockUnscanned != 0 && p.BlockUnscanned != 1 {
+		v.SetError("block_unscanned", "should be 0 or 1")
+	}
+}
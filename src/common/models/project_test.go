@@ -0,0 +1,71 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+
+	"github.com/astaxie/beego/validation"
+)
+
+func TestProjectProxyValid(t *testing.T) {
+	proxy := &ProjectProxy{ProxyTargetID: 1}
+	v := &validation.Validation{}
+	proxy.Valid(v)
+	if v.HasErrors() {
+		t.Errorf("unexpected errors: %v", v.Errors)
+	}
+	if proxy.ProxyTTL != DefaultProxyTTL {
+		t.Errorf("unexpected default TTL: %d != %d", proxy.ProxyTTL, DefaultProxyTTL)
+	}
+
+	for _, proxy := range []*ProjectProxy{{}, {ProxyTargetID: 1, ProxyTTL: -1}} {
+		v = &validation.Validation{}
+		proxy.Valid(v)
+		if !v.HasErrors() {
+			t.Errorf("errors are expected for %+v", proxy)
+		}
+	}
+
+	project := &Project{ProxyTargetID: 1}
+	if !project.IsProxy() {
+		t.Errorf("project with proxy target should be a proxy cache")
+	}
+}
+
+func TestProjectVulnerabilityPolicyValid(t *testing.T) {
+	policy := &ProjectVulnerabilityPolicy{Severity: "high", BlockUnscanned: 1}
+	v := &validation.Validation{}
+	policy.Valid(v)
+	if v.HasErrors() {
+		t.Errorf("unexpected errors: %v", v.Errors)
+	}
+	if policy.Severity != SeverityHigh {
+		t.Errorf("the severity should be normalized: %s", policy.Severity)
+	}
+
+	for _, policy := range []*ProjectVulnerabilityPolicy{
+		{Severity: "None"},
+		{Severity: "severe"},
+		{BlockUnscanned: 2},
+	} {
+		v = &validation.Validation{}
+		policy.Valid(v)
+		if !v.HasErrors() {
+			t.Errorf("errors are expected for %+v", policy)
+		}
+	}
+}
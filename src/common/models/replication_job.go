@@ -16,10 +16,15 @@
 package models
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/astaxie/beego/validation"
 	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/cron"
 )
 
 const (
@@ -43,8 +48,30 @@ const (
 	RepOpTransfer string = "transfer"
 	//RepOpDelete represents the operation of a job to remove repository from a remote registry/harbor instance.
 	RepOpDelete string = "delete"
+	//RepOpProxy represents the operation of a job to cache a repository of the target in a proxy cache project,
+	//such a job doesn't belong to any policy.
+	RepOpProxy string = "proxy"
 	//UISecretCookie is the cookie name to contain the UI secret
 	UISecretCookie string = "uisecret"
+	//RepTargetTypeHarbor represents a target which is a harbor instance.
+	RepTargetTypeHarbor int = 0
+	//RepTargetTypeDockerRegistry represents a target which is a plain docker registry, only the v2 API is used.
+	RepTargetTypeDockerRegistry int = 1
+	//RepDirectionPush represents a policy which replicates the images of the project to the target.
+	RepDirectionPush string = "push"
+	//RepDirectionPull represents a policy which replicates the images of the target into the project.
+	RepDirectionPull string = "pull"
+	//RepJobPriorityLow is the priority of the jobs created by syncing all the repositories of a policy on schedule.
+	RepJobPriorityLow int = 0
+	//RepJobPriorityNormal is the priority of the jobs created for a single repository, e.g. triggered by a push,
+	//and of the jobs created by syncing a policy manually, e.g. when the policy is enabled.
+	RepJobPriorityNormal int = 10
+	//RepJobPriorityHigh is the priority of the proxy jobs, for which a client is waiting.
+	RepJobPriorityHigh int = 20
+	//RepTriggerManual represents the replication triggered by a user, e.g. by creating or enabling a policy.
+	RepTriggerManual string = "manual"
+	//RepTriggerEvent represents the replication triggered by pushing or deleting an image.
+	RepTriggerEvent string = "event"
 )
 
 // RepPolicy is the model for a replication policy, which associate to a project and a target (destination)
@@ -56,14 +83,34 @@ type RepPolicy struct {
 	TargetName  string `json:"target_name,omitempty"`
 	Name        string `orm:"column(name)" json:"name"`
 	//	Target       RepTarget `orm:"-" json:"target"`
-	Enabled       int       `orm:"column(enabled)" json:"enabled"`
-	Description   string    `orm:"column(description)" json:"description"`
-	CronStr       string    `orm:"column(cron_str)" json:"cron_str"`
-	StartTime     time.Time `orm:"column(start_time)" json:"start_time"`
-	CreationTime  time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
-	UpdateTime    time.Time `orm:"column(update_time);auto_now" json:"update_time"`
-	ErrorJobCount int       `json:"error_job_count"`
-	Deleted       int       `orm:"column(deleted)" json:"deleted"`
+	Enabled     int       `orm:"column(enabled)" json:"enabled"`
+	Description string    `orm:"column(description)" json:"description"`
+	CronStr     string    `orm:"column(cron_str)" json:"cron_str"`
+	StartTime   time.Time `orm:"column(start_time)" json:"start_time"`
+	Direction   string    `orm:"column(direction)" json:"direction"`
+	// Repositories is the comma separated form of RepositoryList which is stored in DB
+	Repositories string `orm:"column(repositories)" json:"-"`
+	// RepositoryList contains the repositories, in the form of "repository[:tag]", which
+	// are pulled from the target in pull mode
+	RepositoryList []string `orm:"-" json:"repositories"`
+	// RepoFilter and RepoExcludeFilter are the globs which the names of repositories,
+	// without the project, must and must not match to be replicated in push mode
+	RepoFilter        string `orm:"column(repo_filter)" json:"repo_filter"`
+	RepoExcludeFilter string `orm:"column(repo_exclude_filter)" json:"repo_exclude_filter"`
+	// TagFilter and TagExcludeFilter are the regular expressions which the whole
+	// tags must and must not match to be replicated in push mode
+	TagFilter        string `orm:"column(tag_filter)" json:"tag_filter"`
+	TagExcludeFilter string `orm:"column(tag_exclude_filter)" json:"tag_exclude_filter"`
+	// PushedBy is the name of the user, if it's set, only the tags pushed by the user are replicated
+	PushedBy string `orm:"column(pushed_by)" json:"pushed_by"`
+	// LastScheduledTime is the time the last scheduled run was triggered
+	LastScheduledTime time.Time  `orm:"column(last_scheduled_time)" json:"-"`
+	CreationTime      time.Time  `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime        time.Time  `orm:"column(update_time);auto_now" json:"update_time"`
+	ErrorJobCount     int        `json:"error_job_count"`
+	Deleted           int        `orm:"column(deleted)" json:"deleted"`
+	LastRunTime       *time.Time `orm:"-" json:"last_run_time,omitempty"`
+	NextRunTime       *time.Time `orm:"-" json:"next_run_time,omitempty"`
 }
 
 // Valid ...
@@ -91,6 +138,138 @@ func (r *RepPolicy) Valid(v *validation.Validation) {
 	if len(r.CronStr) > 256 {
 		v.SetError("cron_str", "max length is 256")
 	}
+
+	if len(r.CronStr) != 0 {
+		if _, err := cron.Parse(r.CronStr); err != nil {
+			v.SetError("cron_str", err.Error())
+		}
+	}
+
+	if len(r.Direction) == 0 {
+		r.Direction = RepDirectionPush
+	}
+
+	switch r.Direction {
+	case RepDirectionPush:
+		if len(r.RepositoryList) != 0 {
+			v.SetError("repositories", "only supported in pull mode")
+		}
+		r.validFilters(v)
+	case RepDirectionPull:
+		if len(r.RepositoryList) == 0 {
+			v.SetError("repositories", "can not be empty in pull mode")
+		}
+		if len(r.RepoFilter) != 0 || len(r.RepoExcludeFilter) != 0 || r.HasTagFilter() {
+			v.SetError("filters", "only supported in push mode")
+		}
+		for _, reference := range r.RepositoryList {
+			repository, _ := utils.ParseRepositoryTag(reference)
+			if len(repository) == 0 || strings.ContainsAny(reference, ", ") {
+				v.SetError("repositories", fmt.Sprintf("invalid repository %s", reference))
+			}
+		}
+	default:
+		v.SetError("direction", "must be push or pull")
+	}
+}
+
+// IsPull returns whether the policy replicates the images of the target into the project
+func (r *RepPolicy) IsPull() bool {
+	return r.Direction == RepDirectionPull
+}
+
+func (r *RepPolicy) validFilters(v *validation.Validation) {
+	globs := map[string]string{
+		"repo_filter":         r.RepoFilter,
+		"repo_exclude_filter": r.RepoExcludeFilter,
+	}
+	for key, glob := range globs {
+		if len(glob) > 256 {
+			v.SetError(key, "max length is 256")
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			v.SetError(key, err.Error())
+		}
+	}
+
+	regexps := map[string]string{
+		"tag_filter":         r.TagFilter,
+		"tag_exclude_filter": r.TagExcludeFilter,
+	}
+	for key, exp := range regexps {
+		if len(exp) > 256 {
+			v.SetError(key, "max length is 256")
+		}
+		if _, err := compileTagFilter(exp); err != nil {
+			v.SetError(key, err.Error())
+		}
+	}
+
+	if len(r.PushedBy) > 255 {
+		v.SetError("pushed_by", "max length is 255")
+	}
+}
+
+// MatchRepository returns whether the repository, whose name contains the project,
+// passes the repository filters of the policy
+func (r *RepPolicy) MatchRepository(repository string) bool {
+	_, name := utils.ParseRepository(repository)
+	if len(r.RepoFilter) != 0 {
+		if matched, _ := path.Match(r.RepoFilter, name); !matched {
+			return false
+		}
+	}
+	if len(r.RepoExcludeFilter) != 0 {
+		if matched, _ := path.Match(r.RepoExcludeFilter, name); matched {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchTag returns whether the tag passes the tag filters of the policy, the user
+// who pushed the tag isn't checked here
+func (r *RepPolicy) MatchTag(tag string) bool {
+	if len(r.TagFilter) != 0 {
+		re, err := compileTagFilter(r.TagFilter)
+		if err != nil || !re.MatchString(tag) {
+			return false
+		}
+	}
+	if len(r.TagExcludeFilter) != 0 {
+		re, err := compileTagFilter(r.TagExcludeFilter)
+		if err != nil || re.MatchString(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasTagFilter returns whether the tags to be replicated are filtered by the policy
+func (r *RepPolicy) HasTagFilter() bool {
+	return len(r.TagFilter) != 0 || len(r.TagExcludeFilter) != 0 || len(r.PushedBy) != 0
+}
+
+// the regular expression must match the whole tag
+func compileTagFilter(exp string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + exp + ")$")
+}
+
+// ScheduledRunTime returns the time at which the policy should be triggered by its cron
+// string after lastRun. The schedule starts from StartTime, when the policy was enabled,
+// so the run time is calculated from the later one of StartTime and lastRun.
+func (r *RepPolicy) ScheduledRunTime(lastRun time.Time) (time.Time, error) {
+	schedule, err := cron.Parse(r.CronStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	from := r.StartTime
+	if lastRun.After(from) {
+		from = lastRun
+	}
+
+	return schedule.Next(from), nil
 }
 
 // RepJob is the model for a replication job, which is the execution unit on job service, currently it is used to transfer/remove
@@ -103,11 +282,37 @@ type RepJob struct {
 	Operation  string   `orm:"column(operation)" json:"operation"`
 	Tags       string   `orm:"column(tags)" json:"-"`
 	TagList    []string `orm:"-" json:"tags"`
+	// RequestID is the ID of the request which created the job, it's written into the job log
+	RequestID string `orm:"column(request_id)" json:"request_id"`
+	// Priority decides the order in which the queued jobs are handled, the higher the earlier
+	Priority int `orm:"column(priority)" json:"priority"`
+	// ScheduleTime is the time before which the job can't be handled, the queued jobs
+	// with the same priority are handled in the order of it
+	ScheduleTime time.Time `orm:"column(schedule_time)" json:"schedule_time"`
+	// Owner is the ID of the job service instance which claimed the job
+	Owner string `orm:"column(lease_owner)" json:"owner"`
+	// UploadSessions holds the blob upload sessions of the job in JSON, they are saved when the
+	// uploads fail and resumed when the job is retried
+	UploadSessions string `orm:"column(upload_sessions)" json:"-"`
+	// QueuePosition is the position of the pending or retrying job in the queue, starting from 1
+	QueuePosition int64 `orm:"-" json:"queue_position,omitempty"`
 	//	Policy       RepPolicy `orm:"-" json:"policy"`
 	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
 	UpdateTime   time.Time `orm:"column(update_time);auto_now" json:"update_time"`
 }
 
+// RepJobQueue holds the statistics of the queue of replication jobs
+type RepJobQueue struct {
+	// Depth is the number of the jobs waiting to be handled, i.e. the pending and retrying ones
+	Depth    int64 `json:"depth"`
+	Pending  int64 `json:"pending"`
+	Retrying int64 `json:"retrying"`
+	Running  int64 `json:"running"`
+	// Instances are the job service instances claiming the jobs, the ones which haven't sent
+	// heartbeats for a while are down and the jobs they claimed are claimed by the others
+	Instances []*JobServiceInstance `json:"instances"`
+}
+
 // RepTarget is the model for a replication targe, i.e. destination, which wraps the endpoint URL and username/password of a remote registry.
 type RepTarget struct {
 	ID           int64     `orm:"column(id)" json:"id"`
@@ -140,6 +345,10 @@ func (r *RepTarget) Valid(v *validation.Validation) {
 		v.SetError("endpoint", "max length is 64")
 	}
 
+	if r.Type != RepTargetTypeHarbor && r.Type != RepTargetTypeDockerRegistry {
+		v.SetError("type", "must be 0(harbor) or 1(docker-registry)")
+	}
+
 	// password is encoded using base64, the length of this field
 	// in DB is 64, so the max length in request is 48
 	if len(r.Password) > 48 {
@@ -0,0 +1,121 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+
+	"github.com/astaxie/beego/validation"
+)
+
+func TestRepPolicyValidDirection(t *testing.T) {
+	cases := []struct {
+		direction    string
+		repositories []string
+		valid        bool
+	}{
+		{"", nil, true},
+		{RepDirectionPush, nil, true},
+		{RepDirectionPush, []string{"library/ubuntu"}, false},
+		{RepDirectionPull, nil, false},
+		{RepDirectionPull, []string{"library/ubuntu:16.04", "library/nginx"}, true},
+		{RepDirectionPull, []string{":16.04"}, false},
+		{RepDirectionPull, []string{"library/ubuntu,library/nginx"}, false},
+		{"both", nil, false},
+	}
+
+	for _, c := range cases {
+		policy := &RepPolicy{
+			Name:           "policy",
+			ProjectID:      1,
+			TargetID:       1,
+			Direction:      c.direction,
+			RepositoryList: c.repositories,
+		}
+		v := &validation.Validation{}
+		policy.Valid(v)
+		if v.HasErrors() == c.valid {
+			t.Errorf("unexpected validation result for direction %q, repositories %v: %v", c.direction, c.repositories, v.Errors)
+		}
+	}
+
+	policy := &RepPolicy{}
+	policy.Valid(&validation.Validation{})
+	if policy.IsPull() {
+		t.Errorf("the direction should be push by default")
+	}
+}
+
+func TestRepPolicyValidFilters(t *testing.T) {
+	cases := []struct {
+		policy *RepPolicy
+		valid  bool
+	}{
+		{&RepPolicy{RepoFilter: "app-*", TagFilter: `release-.*`, PushedBy: "ci"}, true},
+		{&RepPolicy{RepoFilter: "[app"}, false},
+		{&RepPolicy{TagExcludeFilter: "release-("}, false},
+		{&RepPolicy{Direction: RepDirectionPull, RepositoryList: []string{"library/ubuntu"}, TagFilter: "latest"}, false},
+	}
+
+	for _, c := range cases {
+		c.policy.Name = "policy"
+		c.policy.ProjectID = 1
+		c.policy.TargetID = 1
+		v := &validation.Validation{}
+		c.policy.Valid(v)
+		if v.HasErrors() == c.valid {
+			t.Errorf("unexpected validation result for policy %+v: %v", c.policy, v.Errors)
+		}
+	}
+}
+
+func TestRepPolicyMatch(t *testing.T) {
+	policy := &RepPolicy{
+		RepoFilter:        "app-*",
+		RepoExcludeFilter: "app-test*",
+		TagFilter:         `release-.*`,
+		TagExcludeFilter:  `.*-rc`,
+	}
+
+	repositories := map[string]bool{
+		"library/app-web":      true,
+		"library/app-test-web": false,
+		"library/web":          false,
+		"library/app-web/sub":  false,
+	}
+	for repository, expected := range repositories {
+		if matched := policy.MatchRepository(repository); matched != expected {
+			t.Errorf("unexpected result of matching repository %s: %v != %v", repository, matched, expected)
+		}
+	}
+
+	tags := map[string]bool{
+		"release-1.0":    true,
+		"release-1.0-rc": false,
+		"latest":         false,
+		"my-release-1.0": false,
+	}
+	for tag, expected := range tags {
+		if matched := policy.MatchTag(tag); matched != expected {
+			t.Errorf("unexpected result of matching tag %s: %v != %v", tag, matched, expected)
+		}
+	}
+
+	policy = &RepPolicy{}
+	if !policy.MatchRepository("library/ubuntu") || !policy.MatchTag("latest") || policy.HasTagFilter() {
+		t.Errorf("everything should be matched by a policy without filters")
+	}
+}
@@ -19,15 +19,17 @@ import (
 
 // RepoRecord holds the record of an repository in DB, all the infors are from the registry notification event.
 type RepoRecord struct {
-	RepositoryID string    `orm:"column(repository_id);pk" json:"repository_id"`
-	Name         string    `orm:"column(name)" json:"name"`
-	OwnerName    string    `orm:"-"`
-	OwnerID      int64     `orm:"column(owner_id)"  json:"owner_id"`
-	ProjectName  string    `orm:"-"`
-	ProjectID    int64     `orm:"column(project_id)"  json:"project_id"`
-	Description  string    `orm:"column(description)" json:"description"`
-	PullCount    int64     `orm:"column(pull_count)" json:"pull_count"`
-	StarCount    int64     `orm:"column(star_count)" json:"star_count"`
+	RepositoryID string `orm:"column(repository_id);pk" json:"repository_id"`
+	Name         string `orm:"column(name)" json:"name"`
+	OwnerName    string `orm:"-"`
+	OwnerID      int64  `orm:"column(owner_id)"  json:"owner_id"`
+	ProjectName  string `orm:"-"`
+	ProjectID    int64  `orm:"column(project_id)"  json:"project_id"`
+	Description  string `orm:"column(description)" json:"description"`
+	PullCount    int64  `orm:"column(pull_count)" json:"pull_count"`
+	StarCount    int64  `orm:"column(star_count)" json:"star_count"`
+	// Size is the total size of the layers referenced by the tags of the repository
+	Size         int64     `orm:"column(size)" json:"size"`
 	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
 	UpdateTime   time.Time `orm:"column(update_time);auto_now" json:"update_time"`
 }
@@ -0,0 +1,111 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/vmware/harbor/src/common/utils/cron"
+)
+
+// RetentionPolicy is the model for a tag retention policy of a project, it decides which tags
+// of the repositories under the project are deleted by the retention job.
+// A tag is deleted only when it does not match KeepPattern, is not one of the newest KeepLatest
+// tags of its repository and is older than MaxAgeDays, the rules whose value is zero are ignored.
+type RetentionPolicy struct {
+	ID          int64  `orm:"column(id)" json:"id"`
+	ProjectID   int64  `orm:"column(project_id)" json:"project_id"`
+	KeepLatest  int    `orm:"column(keep_latest)" json:"keep_latest"`
+	KeepPattern string `orm:"column(keep_pattern)" json:"keep_pattern"`
+	MaxAgeDays  int    `orm:"column(max_age_days)" json:"max_age_days"`
+	Enabled     int    `orm:"column(enabled)" json:"enabled"`
+	// CronStr is the schedule on which the policy is enforced, the policy
+	// is only enforced manually if it is empty
+	CronStr string `orm:"column(cron_str)" json:"cron_str"`
+	// CreatorID is the ID of the user who created the policy, the tags deleted by
+	// the policy are recorded in access log as deleted by the user
+	CreatorID int `orm:"column(creator_id)" json:"creator_id"`
+	// LastScheduledTime is the time the last scheduled enforcement was triggered
+	LastScheduledTime time.Time `orm:"column(last_scheduled_time)" json:"-"`
+	CreationTime      time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime        time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// Valid ...
+func (r *RetentionPolicy) Valid(v *validation.Validation) {
+	if r.KeepLatest < 0 {
+		v.SetError("keep_latest", "can not be negative")
+	}
+
+	if r.MaxAgeDays < 0 {
+		v.SetError("max_age_days", "can not be negative")
+	}
+
+	if r.KeepLatest == 0 && r.MaxAgeDays == 0 {
+		v.SetError("keep_latest", "at least one of keep_latest and max_age_days should be set")
+	}
+
+	if len(r.KeepPattern) > 256 {
+		v.SetError("keep_pattern", "max length is 256")
+	}
+
+	if len(r.KeepPattern) != 0 {
+		if _, err := regexp.Compile(r.KeepPattern); err != nil {
+			v.SetError("keep_pattern", err.Error())
+		}
+	}
+
+	if r.Enabled != 0 && r.Enabled != 1 {
+		v.SetError("enabled", "must be 0 or 1")
+	}
+
+	if len(r.CronStr) > 256 {
+		v.SetError("cron_str", "max length is 256")
+	}
+
+	if len(r.CronStr) != 0 {
+		if _, err := cron.Parse(r.CronStr); err != nil {
+			v.SetError("cron_str", err.Error())
+		}
+	}
+}
+
+// TableName is required by by beego orm to map RetentionPolicy to table retention_policy
+func (r *RetentionPolicy) TableName() string {
+	return "retention_policy"
+}
+
+// RetentionJob is the model for an execution of retention policy
+type RetentionJob struct {
+	ID       int64  `orm:"column(id)" json:"id"`
+	PolicyID int64  `orm:"column(policy_id)" json:"policy_id"`
+	Status   string `orm:"column(status)" json:"status"`
+	// DryRun indicates that the job only reports the tags to be deleted
+	DryRun int `orm:"column(dry_run)" json:"dry_run"`
+	// Result is the JSON of a map whose key is the repository and value is the
+	// tags deleted, or to be deleted if the job is a dry run
+	Result       string              `orm:"column(result)" json:"-"`
+	Tags         map[string][]string `orm:"-" json:"tags"`
+	CreationTime time.Time           `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime   time.Time           `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// TableName is required by by beego orm to map RetentionJob to table retention_job
+func (r *RetentionJob) TableName() string {
+	return "retention_job"
+}
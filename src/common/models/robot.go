@@ -0,0 +1,142 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+)
+
+const (
+	// RobotPrefix is the prefix of the names robot accounts authenticate with, the
+	// full name of a robot account is "robot$<project>+<name>"
+	RobotPrefix = "robot$"
+
+	// RobotActionPull ...
+	RobotActionPull = "pull"
+	// RobotActionPush ...
+	RobotActionPush = "push"
+	// RobotActionDelete ...
+	RobotActionDelete = "delete"
+)
+
+// RobotActions are the actions a robot account can be allowed to perform
+var RobotActions = []string{RobotActionPull, RobotActionPush, RobotActionDelete}
+
+var robotNameRegexp = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
+
+// Robot is the model for a robot account of a project, which is used by
+// automation, e.g. CI pipelines, instead of the credentials of a real user
+type Robot struct {
+	ID          int64  `orm:"column(id)" json:"id"`
+	Name        string `orm:"column(name)" json:"name"`
+	ProjectID   int64  `orm:"column(project_id)" json:"project_id"`
+	Description string `orm:"column(description)" json:"description"`
+	// Secret is hashed with the salt, the plain secret is only returned once
+	// when the robot account is created
+	Secret string `orm:"column(secret)" json:"-"`
+	Salt   string `orm:"column(salt)" json:"-"`
+	// Actions is the comma separated form of ActionList which is stored in DB
+	Actions      string    `orm:"column(actions)" json:"-"`
+	ActionList   []string  `orm:"-" json:"actions"`
+	ExpiresAt    time.Time `orm:"column(expires_at)" json:"expires_at"`
+	CreatorID    int       `orm:"column(creator_id)" json:"creator_id"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime   time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// Valid ...
+func (r *Robot) Valid(v *validation.Validation) {
+	if len(r.Name) == 0 {
+		v.SetError("name", "can not be empty")
+	} else if len(r.Name) > 64 {
+		v.SetError("name", "max length is 64")
+	} else if !robotNameRegexp.MatchString(r.Name) {
+		v.SetError("name", "must be in lower case and consist of letters, digits and separators ._-")
+	}
+
+	if len(r.Description) > 1024 {
+		v.SetError("description", "max length is 1024")
+	}
+
+	if len(r.ActionList) == 0 {
+		v.SetError("actions", "can not be empty")
+	}
+	for _, action := range r.ActionList {
+		if !isRobotAction(action) {
+			v.SetError("actions", fmt.Sprintf("invalid action %s, supported actions: %s",
+				action, strings.Join(RobotActions, ",")))
+		}
+	}
+
+	if r.ExpiresAt.IsZero() {
+		v.SetError("expires_at", "can not be empty")
+	} else if !r.ExpiresAt.After(time.Now()) {
+		v.SetError("expires_at", "must be in the future")
+	}
+}
+
+func isRobotAction(action string) bool {
+	for _, a := range RobotActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows returns whether the robot account is allowed to perform the action
+func (r *Robot) Allows(action string) bool {
+	for _, a := range r.ActionList {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired returns whether the robot account is expired
+func (r *Robot) Expired() bool {
+	return !r.ExpiresAt.After(time.Now())
+}
+
+// TableName is required by by beego orm to map Robot to table robot
+func (r *Robot) TableName() string {
+	return "robot"
+}
+
+// RobotFullName returns the name a robot account authenticates with
+func RobotFullName(projectName, name string) string {
+	return RobotPrefix + projectName + "+" + name
+}
+
+// ParseRobotFullName splits the full name of a robot account into the project
+// name and the robot name, ok is false if it isn't the name of a robot account
+func ParseRobotFullName(fullName string) (projectName, name string, ok bool) {
+	if !strings.HasPrefix(fullName, RobotPrefix) {
+		return "", "", false
+	}
+	fullName = strings.TrimPrefix(fullName, RobotPrefix)
+	i := strings.LastIndex(fullName, "+")
+	if i <= 0 || i == len(fullName)-1 {
+		return "", "", false
+	}
+	return fullName[:i], fullName[i+1:], true
+}
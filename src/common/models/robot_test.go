@@ -0,0 +1,43 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+)
+
+func TestParseRobotFullName(t *testing.T) {
+	cases := []struct {
+		fullName string
+		project  string
+		name     string
+		ok       bool
+	}{
+		{RobotFullName("library", "ci"), "library", "ci", true},
+		{"robot$my.project+ci-1", "my.project", "ci-1", true},
+		{"robot$library", "", "", false},
+		{"robot$library+", "", "", false},
+		{"robot$+ci", "", "", false},
+		{"admin", "", "", false},
+	}
+
+	for _, c := range cases {
+		project, name, ok := ParseRobotFullName(c.fullName)
+		if project != c.project || name != c.name || ok != c.ok {
+			t.Errorf("unexpected result for %s: %s, %s, %v", c.fullName, project, name, ok)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+)
+
+const (
+	// SeverityNone means no vulnerability is found
+	SeverityNone = "None"
+	// SeverityUnknown ...
+	SeverityUnknown = "Unknown"
+	// SeverityLow ...
+	SeverityLow = "Low"
+	// SeverityMedium ...
+	SeverityMedium = "Medium"
+	// SeverityHigh ...
+	SeverityHigh = "High"
+	// SeverityCritical ...
+	SeverityCritical = "Critical"
+)
+
+// Severities are the severities of vulnerabilities from the lowest to the highest
+var Severities = []string{SeverityNone, SeverityUnknown, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// SeverityLevel returns the level of the severity, the higher the level, the more severe
+// the vulnerability. The severity is case insensitive, -1 is returned if it is invalid.
+func SeverityLevel(severity string) int {
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Vulnerability is a vulnerability found in a package of image
+type Vulnerability struct {
+	// ID is the identifier of the vulnerability, e.g. CVE-2017-3735
+	ID           string `json:"id"`
+	Package      string `json:"package"`
+	Version      string `json:"version"`
+	FixedVersion string `json:"fixed_version"`
+	Severity     string `json:"severity"`
+	Description  string `json:"description"`
+	Link         string `json:"link"`
+}
+
+// ScanJob is the model for a job scanning a tag of repository for vulnerabilities
+type ScanJob struct {
+	ID         int64  `orm:"column(id)" json:"id"`
+	Repository string `orm:"column(repository)" json:"repository"`
+	Tag        string `orm:"column(tag)" json:"tag"`
+	// Digest is the digest of the manifest the tag refers to when it is scanned
+	Digest       string    `orm:"column(digest)" json:"digest"`
+	Status       string    `orm:"column(status)" json:"status"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime   time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// TableName is required by by beego orm to map ScanJob to table scan_job
+func (s *ScanJob) TableName() string {
+	return "scan_job"
+}
+
+// ScanResult is the model for the vulnerabilities found in a manifest, it's shared by
+// the tags referring to the manifest
+type ScanResult struct {
+	ID     int64  `orm:"column(id)" json:"id"`
+	Digest string `orm:"column(digest)" json:"digest"`
+	// JobID is the ID of the scan job which produced the result
+	JobID   int64  `orm:"column(job_id)" json:"job_id"`
+	Scanner string `orm:"column(scanner)" json:"scanner"`
+	// Severity is the highest severity of the vulnerabilities
+	Severity string `orm:"column(severity)" json:"severity"`
+	// Data is the vulnerabilities encoded in JSON
+	Data            string           `orm:"column(vulnerabilities)" json:"-"`
+	Vulnerabilities []*Vulnerability `orm:"-" json:"vulnerabilities"`
+	CreationTime    time.Time        `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime      time.Time        `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// TableName is required by by beego orm to map ScanResult to table scan_result
+func (s *ScanResult) TableName() string {
+	return "scan_result"
+}
+
+// HighestSeverity returns the highest severity of the vulnerabilities, SeverityNone
+// is returned if there is no vulnerability
+func HighestSeverity(vulnerabilities []*Vulnerability) string {
+	highest := SeverityNone
+	for _, v := range vulnerabilities {
+		level := SeverityLevel(v.Severity)
+		if level < 0 {
+			level = SeverityLevel(SeverityUnknown)
+		}
+		if level > SeverityLevel(highest) {
+			highest = Severities[level]
+		}
+	}
+	return highest
+}
+
+// CVEAllowlistItem is a vulnerability ignored by the pull policy of the project until it
+// expires, e.g. a false positive acknowledged by the team
+type CVEAllowlistItem struct {
+	ID        int64  `orm:"column(id)" json:"id"`
+	ProjectID int64  `orm:"column(project_id)" json:"project_id"`
+	CVEID     string `orm:"column(cve_id)" json:"cve_id"`
+	// Reason is why the vulnerability is ignored
+	Reason       string    `orm:"column(reason)" json:"reason"`
+	ExpiresAt    time.Time `orm:"column(expires_at)" json:"expires_at"`
+	CreatorID    int       `orm:"column(creator_id)" json:"creator_id"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+}
+
+// Valid ...
+func (c *CVEAllowlistItem) Valid(v *validation.Validation) {
+	if len(c.CVEID) == 0 {
+		v.SetError("cve_id", "can not be empty")
+	} else if len(c.CVEID) > 64 {
+		v.SetError("cve_id", "max length is 64")
+	}
+
+	if len(c.Reason) > 1024 {
+		v.SetError("reason", "max length is 1024")
+	}
+
+	if c.ExpiresAt.IsZero() {
+		v.SetError("expires_at", "can not be empty")
+	} else if !c.ExpiresAt.After(time.Now()) {
+		v.SetError("expires_at", "must be in the future")
+	}
+}
+
+// TableName is required by by beego orm to map CVEAllowlistItem to table cve_allowlist
+func (c *CVEAllowlistItem) TableName() string {
+	return "cve_allowlist"
+}
@@ -0,0 +1,49 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+)
+
+func TestHighestSeverity(t *testing.T) {
+	cases := []struct {
+		severities []string
+		highest    string
+	}{
+		{[]string{}, SeverityNone},
+		{[]string{"Low", "high", "Medium"}, SeverityHigh},
+		{[]string{"Negligible"}, SeverityUnknown},
+		{[]string{"Critical", "Low"}, SeverityCritical},
+	}
+
+	for _, c := range cases {
+		vulnerabilities := []*Vulnerability{}
+		for _, s := range c.severities {
+			vulnerabilities = append(vulnerabilities, &Vulnerability{Severity: s})
+		}
+		if highest := HighestSeverity(vulnerabilities); highest != c.highest {
+			t.Errorf("unexpected highest severity of %v: %s != %s", c.severities, highest, c.highest)
+		}
+	}
+
+	if SeverityLevel("High") <= SeverityLevel("medium") {
+		t.Errorf("High should be more severe than Medium")
+	}
+	if SeverityLevel("invalid") != -1 {
+		t.Errorf("the level of invalid severity should be -1")
+	}
+}
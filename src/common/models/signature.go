@@ -0,0 +1,95 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/vmware/harbor/src/common/utils/signature"
+)
+
+// SigningKey is the model for a public key trusted by a project, the images signed
+// by it can be pulled from the project which only allows signed images to be pulled
+type SigningKey struct {
+	ID        int64  `orm:"column(id)" json:"id"`
+	ProjectID int64  `orm:"column(project_id)" json:"project_id"`
+	Name      string `orm:"column(name)" json:"name"`
+	// PublicKey is the PEM encoded RSA or ECDSA public key
+	PublicKey    string    `orm:"column(public_key)" json:"public_key"`
+	CreatorID    int       `orm:"column(creator_id)" json:"creator_id"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+}
+
+// Valid ...
+func (s *SigningKey) Valid(v *validation.Validation) {
+	if len(s.Name) == 0 {
+		v.SetError("name", "can not be empty")
+	} else if len(s.Name) > 64 {
+		v.SetError("name", "max length is 64")
+	}
+
+	if _, err := signature.ParsePublicKey(s.PublicKey); err != nil {
+		v.SetError("public_key", err.Error())
+	}
+}
+
+// TableName is required by by beego orm to map SigningKey to table signing_key
+func (s *SigningKey) TableName() string {
+	return "signing_key"
+}
+
+// Signature is the model for a detached signature of a manifest, it's only stored
+// after being verified with a signing key of the project
+type Signature struct {
+	ID        int64  `orm:"column(id)" json:"id"`
+	ProjectID int64  `orm:"column(project_id)" json:"project_id"`
+	Digest    string `orm:"column(digest)" json:"digest"`
+	KeyID     int64  `orm:"column(key_id)" json:"key_id"`
+	// Signature is the base64 encoded signature of the digest
+	Signature string `orm:"column(signature)" json:"signature"`
+	// Creator is the name of the user or robot account which attached the signature
+	Creator      string    `orm:"column(creator)" json:"creator"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+}
+
+// Valid ...
+func (s *Signature) Valid(v *validation.Validation) {
+	if len(s.Digest) == 0 {
+		v.SetError("digest", "can not be empty")
+	} else if len(s.Digest) > 128 {
+		v.SetError("digest", "max length is 128")
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(s.Signature); err != nil || len(s.Signature) == 0 {
+		v.SetError("signature", "must be base64 encoded")
+	}
+}
+
+// Verify verifies the signature with the signing key
+func (s *Signature) Verify(key *SigningKey) error {
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return err
+	}
+	return signature.Verify(key.PublicKey, s.Digest, sig)
+}
+
+// TableName is required by by beego orm to map Signature to table signature
+func (s *Signature) TableName() string {
+	return "signature"
+}
@@ -0,0 +1,157 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/vmware/harbor/src/common/utils"
+)
+
+const (
+	// WebhookEventPush is the type of event sent when an image is pushed
+	WebhookEventPush = "push"
+	// WebhookEventPull is the type of event sent when an image is pulled
+	WebhookEventPull = "pull"
+	// WebhookEventDelete is the type of event sent when a manifest is deleted
+	WebhookEventDelete = "delete"
+	// WebhookEventReplication is the type of event sent when the status of a replication job changes
+	WebhookEventReplication = "replication"
+
+	// WebhookDeliveryPending ...
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDelivered ...
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryFailed means the delivery is given up after all attempts failed
+	WebhookDeliveryFailed = "failed"
+)
+
+// WebhookEventTypes are the types of events a webhook can subscribe
+var WebhookEventTypes = []string{WebhookEventPush, WebhookEventPull, WebhookEventDelete, WebhookEventReplication}
+
+// Webhook is the model for a webhook subscription of a project, the events subscribed
+// are sent to the target URL as JSON
+type Webhook struct {
+	ID        int64  `orm:"column(id)" json:"id"`
+	ProjectID int64  `orm:"column(project_id)" json:"project_id"`
+	TargetURL string `orm:"column(target_url)" json:"target_url"`
+	// Secret is used to sign the payload with HMAC-SHA256, it is encrypted in DB
+	// and never returned by API
+	Secret string `orm:"column(secret)" json:"secret,omitempty"`
+	// EventTypes is the comma separated form of Events which is stored in DB
+	EventTypes   string    `orm:"column(event_types)" json:"-"`
+	Events       []string  `orm:"-" json:"event_types"`
+	Enabled      int       `orm:"column(enabled)" json:"enabled"`
+	CreationTime time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime   time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// Valid ...
+func (w *Webhook) Valid(v *validation.Validation) {
+	if len(w.TargetURL) == 0 {
+		v.SetError("target_url", "can not be empty")
+	} else if len(w.TargetURL) > 256 {
+		v.SetError("target_url", "max length is 256")
+	} else if u, err := url.Parse(w.TargetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		v.SetError("target_url", "must be an absolute http or https URL")
+	} else if isInternalHost(u.Host) {
+		// the host names resolved to internal addresses are refused by job service when sending
+		v.SetError("target_url", "must not be a loopback, link-local or private address")
+	}
+
+	if len(w.Secret) > 128 {
+		v.SetError("secret", "max length is 128")
+	}
+
+	if len(w.Events) == 0 {
+		v.SetError("event_types", "can not be empty")
+	}
+	for _, e := range w.Events {
+		if !w.isEventType(e) {
+			v.SetError("event_types", fmt.Sprintf("invalid event type %s, supported types: %s",
+				e, strings.Join(WebhookEventTypes, ",")))
+		}
+	}
+
+	if w.Enabled != 0 && w.Enabled != 1 {
+		v.SetError("enabled", "must be 0 or 1")
+	}
+}
+
+// isInternalHost returns whether the host, which may contain a port, is localhost or an
+// internal IP address
+func isInternalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.ToLower(host) == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && utils.IsInternalIP(ip)
+}
+
+func (w *Webhook) isEventType(e string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribes returns whether the webhook subscribes the type of event
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// TableName is required by by beego orm to map Webhook to table webhook
+func (w *Webhook) TableName() string {
+	return "webhook"
+}
+
+// WebhookDelivery is the model for a delivery of event to a webhook, the failed
+// deliveries are retried until the max attempts are reached
+type WebhookDelivery struct {
+	ID        int64  `orm:"column(id)" json:"id"`
+	WebhookID int64  `orm:"column(webhook_id)" json:"webhook_id"`
+	EventType string `orm:"column(event_type)" json:"event_type"`
+	Payload   string `orm:"column(payload)" json:"payload"`
+	Status    string `orm:"column(status)" json:"status"`
+	Attempts  int    `orm:"column(attempts)" json:"attempts"`
+	// ResponseCode is the status code of the response to the last attempt
+	ResponseCode    int       `orm:"column(response_code)" json:"response_code"`
+	LastError       string    `orm:"column(last_error)" json:"last_error"`
+	NextAttemptTime time.Time `orm:"column(next_attempt_time)" json:"next_attempt_time"`
+	CreationTime    time.Time `orm:"column(creation_time);auto_now_add" json:"creation_time"`
+	UpdateTime      time.Time `orm:"column(update_time);auto_now" json:"update_time"`
+}
+
+// TableName is required by by beego orm to map WebhookDelivery to table webhook_delivery
+func (w *WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
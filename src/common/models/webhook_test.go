@@ -0,0 +1,50 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+
+	"github.com/astaxie/beego/validation"
+)
+
+func TestWebhookValidTargetURL(t *testing.T) {
+	cases := map[string]bool{
+		"http://ci.example.com/hook":      true,
+		"https://8.8.8.8:8443/hook":       true,
+		"ftp://ci.example.com/hook":       false,
+		"/hook":                           false,
+		"http://localhost/hook":           false,
+		"http://127.0.0.1:8080/hook":      false,
+		"http://[::1]/hook":               false,
+		"http://169.254.169.254/metadata": false,
+		"http://10.0.0.1/hook":            false,
+		"https://192.168.1.10:8443/hook":  false,
+		"http://[fd12:3456::1]:8080/hook": false,
+	}
+
+	for targetURL, valid := range cases {
+		w := &Webhook{
+			TargetURL: targetURL,
+			Events:    []string{WebhookEventPush},
+		}
+		v := &validation.Validation{}
+		w.Valid(v)
+		if v.HasErrors() == valid {
+			t.Errorf("unexpected validation result of %s: %v", targetURL, v.Errors)
+		}
+	}
+}
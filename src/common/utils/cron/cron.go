@@ -0,0 +1,221 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type bounds struct {
+	min, max uint
+}
+
+var (
+	minutes = bounds{0, 59}
+	hours   = bounds{0, 23}
+	days    = bounds{1, 31}
+	months  = bounds{1, 12}
+	weeks   = bounds{0, 6}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule is a parsed cron expression, each field is a bit set
+// of the values it matches.
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	day    uint64
+	month  uint64
+	week   uint64
+
+	// dayStar and weekStar record whether the day-of-month and day-of-week
+	// fields are "*", as cron matches either of them when both are restricted
+	dayStar  bool
+	weekStar bool
+}
+
+// Parse parses a standard cron expression with five fields:
+// minute, hour, day of month, month and day of week. The descriptors
+// @yearly, @monthly, @weekly, @daily and @hourly are supported as well.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := descriptors[spec]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %d: %s", len(fields), spec)
+	}
+
+	s := &Schedule{
+		dayStar:  fields[2] == "*",
+		weekStar: fields[4] == "*",
+	}
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, err
+	}
+	if s.day, err = parseField(fields[2], days); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, err
+	}
+	if s.week, err = parseField(fields[4], weeks); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Next returns the first time after t which matches the schedule,
+// the result is truncated to minute. It returns zero time if no
+// time matches the schedule in the following five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dayMatch := s.day&(1<<uint(t.Day())) != 0
+	weekMatch := s.week&(1<<uint(t.Weekday())) != 0
+	if s.dayStar || s.weekStar {
+		return dayMatch && weekMatch
+	}
+	return dayMatch || weekMatch
+}
+
+// parseField parses a comma separated list of ranges, e.g. "1-5/2,10,*/15"
+func parseField(field string, r bounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		b, err := parseRange(expr, r)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+func parseRange(expr string, r bounds) (uint64, error) {
+	var start, end, step uint
+	rangeAndStep := strings.Split(expr, "/")
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+
+	if lowAndHigh[0] == "*" {
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("invalid range: %s", expr)
+		}
+		start, end = r.min, r.max
+	} else {
+		var err error
+		if start, err = parseInt(lowAndHigh[0]); err != nil {
+			return 0, err
+		}
+		switch len(lowAndHigh) {
+		case 1:
+			end = start
+		case 2:
+			if end, err = parseInt(lowAndHigh[1]); err != nil {
+				return 0, err
+			}
+		default:
+			return 0, fmt.Errorf("too many hyphens: %s", expr)
+		}
+	}
+
+	switch len(rangeAndStep) {
+	case 1:
+		step = 1
+	case 2:
+		var err error
+		if step, err = parseInt(rangeAndStep[1]); err != nil {
+			return 0, err
+		}
+		// "N/step" means from N to the max
+		if len(lowAndHigh) == 1 && lowAndHigh[0] != "*" {
+			end = r.max
+		}
+	default:
+		return 0, fmt.Errorf("too many slashes: %s", expr)
+	}
+
+	if start < r.min {
+		return 0, fmt.Errorf("beginning of range %d below minimum %d: %s", start, r.min, expr)
+	}
+	if end > r.max {
+		return 0, fmt.Errorf("end of range %d above maximum %d: %s", end, r.max, expr)
+	}
+	if start > end {
+		return 0, fmt.Errorf("beginning of range %d beyond end of range %d: %s", start, end, expr)
+	}
+	if step == 0 {
+		return 0, fmt.Errorf("step of range should be a positive number: %s", expr)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits, nil
+}
+
+func parseInt(s string) (uint, error) {
+	i, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", s, err)
+	}
+	return uint(i), nil
+}
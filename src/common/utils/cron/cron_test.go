@@ -0,0 +1,96 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"0 0 * * *",
+		"*/15 8-18 * * 1-5",
+		"0,30 1 1,15 * *",
+		"5/10 * * * *",
+		"@daily",
+		" @hourly ",
+	}
+	for _, spec := range valid {
+		if _, err := Parse(spec); err != nil {
+			t.Errorf("failed to parse %q: %v", spec, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every",
+	}
+	for _, spec := range invalid {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected error when parsing %q, but got nil", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	base := time.Date(2016, time.November, 30, 23, 59, 30, 0, time.UTC)
+
+	cases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2016, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2016, time.December, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// 2016-12-04 is a Sunday
+		{"0 12 * * 0", time.Date(2016, time.December, 4, 12, 0, 0, 0, time.UTC)},
+		// either day of month or day of week matches
+		{"0 0 15 * 0", time.Date(2016, time.December, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2016, time.December, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		s, err := Parse(c.spec)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", c.spec, err)
+		}
+		if next := s.Next(base); !next.Equal(c.next) {
+			t.Errorf("unexpected next time of %q: %v != %v", c.spec, next, c.next)
+		}
+	}
+
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if next := s.Next(base); !next.IsZero() {
+		t.Errorf("expected zero time, but got %v", next)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package ldap wraps the LDAP client with the configuration of the connections,
+// which supports LDAPS, StartTLS and timeouts of operations, and a bounded pool
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "gopkg.in/ldap.v2"
+)
+
+// the scopes of search
+const (
+	ScopeBaseObject   = goldap.ScopeBaseObject
+	ScopeSingleLevel  = goldap.ScopeSingleLevel
+	ScopeWholeSubtree = goldap.ScopeWholeSubtree
+)
+
+const (
+	defaultLDAPPort  = "389"
+	defaultLDAPSPort = "636"
+)
+
+// ErrEmptyPassword is returned when binding a DN with an empty password, which
+// is an unauthenticated bind that the servers may accept without any check
+var ErrEmptyPassword = errors.New("the password is empty")
+
+// IsInvalidCredentials returns whether the error is caused by the invalid credentials
+func IsInvalidCredentials(err error) bool {
+	return goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials)
+}
+
+// EscapeFilter escapes the special characters in the value of a filter according
+// to RFC 4515, so that it is matched literally
+func EscapeFilter(value string) string {
+	return goldap.EscapeFilter(value)
+}
+
+// Config is the configuration to connect to an LDAP server
+type Config struct {
+	// URL is the URL of the server, the scheme is ldap or ldaps
+	URL string
+	// StartTLS upgrades the connection to TLS after connecting, only used
+	// when the scheme is ldap
+	StartTLS bool
+	// CACert is the path of PEM encoded certificates of the CAs which issue
+	// the certificate of the server, the system CAs are used if it is empty
+	CACert string
+	// VerifyCert is whether to verify the certificate of the server
+	VerifyCert bool
+	// Timeout is the timeout of each operation, including dialing, zero
+	// means no timeout
+	Timeout time.Duration
+}
+
+// Entry is an entry returned by search
+type Entry struct {
+	*goldap.Entry
+}
+
+// SearchRequest is the request of search
+type SearchRequest struct {
+	BaseDN     string
+	Scope      int
+	Filter     string
+	Attributes []string
+	// SizeLimit is the max number of entries returned, zero means no limit
+	SizeLimit int
+}
+
+// Conn is a connection to an LDAP server, it is not safe for concurrent use
+type Conn struct {
+	conn    *goldap.Conn
+	timeout time.Duration
+	// the DN the connection is bound as, only valid when bound is true
+	boundDN  string
+	bound    bool
+	broken   bool
+	lastUsed time.Time
+}
+
+// Dial connects to the LDAP server according to the configuration
+func Dial(config *Config) (*Conn, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL %s: %v", config.URL, err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if len(u.Host) == 0 {
+		return nil, fmt.Errorf("invalid LDAP URL %s: empty host", config.URL)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host, port = u.Host, ""
+	}
+
+	var tlsConfig *tls.Config
+	if scheme == "ldaps" || config.StartTLS {
+		tlsConfig, err = newTLSConfig(config, host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout: config.Timeout,
+	}
+
+	var conn *goldap.Conn
+	switch scheme {
+	case "ldap":
+		if len(port) == 0 {
+			port = defaultLDAPPort
+		}
+		c, err := dialer.Dial("tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return nil, err
+		}
+		conn = goldap.NewConn(c, false)
+	case "ldaps":
+		if config.StartTLS {
+			return nil, errors.New("StartTLS can not be used with ldaps")
+		}
+		if len(port) == 0 {
+			port = defaultLDAPSPort
+		}
+		c, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		conn = goldap.NewConn(c, true)
+	default:
+		return nil, fmt.Errorf("unsupported scheme of LDAP URL: %s", u.Scheme)
+	}
+	conn.Start()
+	conn.SetTimeout(config.Timeout)
+
+	if scheme == "ldap" && config.StartTLS {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return &Conn{
+		conn:     conn,
+		timeout:  config.Timeout,
+		lastUsed: time.Now(),
+	}, nil
+}
+
+func newTLSConfig(config *Config, host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: !config.VerifyCert,
+	}
+	if len(config.CACert) == 0 {
+		return tlsConfig, nil
+	}
+
+	data, err := ioutil.ReadFile(config.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in %s", config.CACert)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// Bind binds the connection as the DN by the simple authentication, an anonymous
+// bind is performed if both the DN and password are empty
+func (c *Conn) Bind(dn, password string) error {
+	if len(dn) != 0 && len(password) == 0 {
+		return ErrEmptyPassword
+	}
+
+	c.lastUsed = time.Now()
+	c.bound = false
+	if err := c.check(c.conn.Bind(dn, password)); err != nil {
+		return err
+	}
+	c.bound = true
+	c.boundDN = dn
+	return nil
+}
+
+// Search searches the entries, the entries returned before the size limit is
+// exceeded are returned without error
+func (c *Conn) Search(req *SearchRequest) ([]*Entry, error) {
+	filter := req.Filter
+	if len(filter) == 0 {
+		filter = "(objectClass=*)"
+	}
+
+	c.lastUsed = time.Now()
+	result, err := c.conn.Search(goldap.NewSearchRequest(req.BaseDN, req.Scope,
+		goldap.NeverDerefAliases, req.SizeLimit, int(c.timeout/time.Second), false,
+		filter, req.Attributes, nil))
+	if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		err = nil
+	}
+	if err = c.check(err); err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+	for _, en := range result.Entries {
+		entries = append(entries, &Entry{en})
+	}
+	return entries, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	c.broken = true
+	c.conn.Close()
+	return nil
+}
+
+// check marks the connection broken if the error isn't a result returned by the server,
+// e.g. the connection is closed or the operation times out
+func (c *Conn) check(err error) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(*goldap.Error)
+	if !ok || e.ResultCode == goldap.ErrorNetwork || e.ResultCode == goldap.ErrorUnexpectedMessage ||
+		e.ResultCode == goldap.ErrorUnexpectedResponse {
+		c.broken = true
+	}
+	return err
+}
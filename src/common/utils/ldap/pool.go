@@ -0,0 +1,188 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ldap
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// IdleTimeout is how long an idle connection is kept in the pool, the servers
+// usually close the connections idle for a while
+var IdleTimeout = 5 * time.Minute
+
+// ErrPoolTimeout is returned when no connection is available in the pool before timeout
+var ErrPoolTimeout = errors.New("timeout waiting for an available LDAP connection")
+
+// ErrPoolClosed is returned when getting a connection from a closed pool
+var ErrPoolClosed = errors.New("the LDAP connection pool is closed")
+
+// Pool is a bounded pool of connections which are bound as the same DN when
+// they are got from the pool, it is safe for concurrent use
+type Pool struct {
+	config   *Config
+	bindDN   string
+	password string
+	idle     chan *Conn
+	// tokens limits the number of connections in use, as a connection is
+	// only created when no idle one is available, it limits the total number
+	// of connections as well
+	tokens chan struct{}
+	closed bool
+	lock   sync.Mutex
+	// dial connects to the server, it's replaced in tests
+	dial func(config *Config) (*Conn, error)
+}
+
+// NewPool returns a pool which holds at most size connections, the connections
+// are bound as bindDN, anonymous if it is empty
+func NewPool(config *Config, bindDN, password string, size int) *Pool {
+	if size <= 0 {
+		size = 1
+	}
+	return &Pool{
+		config:   config,
+		bindDN:   bindDN,
+		password: password,
+		idle:     make(chan *Conn, size),
+		tokens:   make(chan struct{}, size),
+		dial:     Dial,
+	}
+}
+
+// Get returns a connection bound as the DN of the pool, it waits for a connection
+// returned to the pool if all of them are in use. The connection must be put back
+// by Put
+func (p *Pool) Get() (*Conn, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+
+	conn, err := p.get()
+	if err != nil {
+		<-p.tokens
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *Pool) acquire() error {
+	if p.isClosed() {
+		return ErrPoolClosed
+	}
+
+	if p.config.Timeout <= 0 {
+		p.tokens <- struct{}{}
+		return nil
+	}
+
+	timer := time.NewTimer(p.config.Timeout)
+	defer timer.Stop()
+	select {
+	case p.tokens <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrPoolTimeout
+	}
+}
+
+func (p *Pool) get() (*Conn, error) {
+	for {
+		var conn *Conn
+		select {
+		case conn = <-p.idle:
+		default:
+			return p.connect()
+		}
+
+		if time.Since(conn.lastUsed) > IdleTimeout {
+			conn.Close()
+			continue
+		}
+		if conn.bound && conn.boundDN == p.bindDN {
+			return conn, nil
+		}
+
+		err := conn.Bind(p.bindDN, p.password)
+		if err == nil {
+			return conn, nil
+		}
+		broken := conn.broken
+		conn.Close()
+		// the server rejects the credential, otherwise it may have closed
+		// the idle connection and the next one is tried
+		if !broken {
+			return nil, err
+		}
+		log.Debugf("failed to rebind the idle LDAP connection: %v", err)
+	}
+}
+
+func (p *Pool) connect() (*Conn, error) {
+	conn, err := p.dial(p.config)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Bind(p.bindDN, p.password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Put puts the connection back to the pool, the broken ones are closed
+func (p *Pool) Put(conn *Conn) {
+	defer func() {
+		<-p.tokens
+	}()
+
+	if conn.broken || p.isClosed() {
+		conn.Close()
+		return
+	}
+
+	select {
+	case p.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// Close closes the idle connections, the connections in use are closed when
+// they are put back
+func (p *Pool) Close() {
+	p.lock.Lock()
+	p.closed = true
+	p.lock.Unlock()
+
+	for {
+		select {
+		case conn := <-p.idle:
+			conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (p *Pool) isClosed() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.closed
+}
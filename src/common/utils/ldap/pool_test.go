@@ -0,0 +1,229 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ldap
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	ber "gopkg.in/asn1-ber.v1"
+	goldap "gopkg.in/ldap.v2"
+)
+
+// the tag of the response of bind
+const bindResponse = 1
+
+// fakeServer serves the connections of the pool through pipes, it accepts the binds
+// with any password other than "wrong"
+type fakeServer struct {
+	lock  sync.Mutex
+	conns []net.Conn
+}
+
+func (f *fakeServer) dial(config *Config) (*Conn, error) {
+	client, server := net.Pipe()
+	f.lock.Lock()
+	f.conns = append(f.conns, server)
+	f.lock.Unlock()
+	go serveBinds(server)
+
+	conn := goldap.NewConn(client, false)
+	conn.Start()
+	conn.SetTimeout(config.Timeout)
+	return &Conn{
+		conn:     conn,
+		timeout:  config.Timeout,
+		lastUsed: time.Now(),
+	}, nil
+}
+
+// connections returns the number of the connections dialed
+func (f *fakeServer) connections() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.conns)
+}
+
+// closeConnections closes the connections dialed on the server side
+func (f *fakeServer) closeConnections() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+}
+
+func serveBinds(conn net.Conn) {
+	defer conn.Close()
+	for {
+		req, err := ber.ReadPacket(conn)
+		if err != nil || len(req.Children) < 2 || len(req.Children[1].Children) < 3 {
+			return
+		}
+
+		code := goldap.LDAPResultSuccess
+		if req.Children[1].Children[2].Data.String() == "wrong" {
+			code = goldap.LDAPResultInvalidCredentials
+		}
+
+		resp := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		resp.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger,
+			req.Children[0].Value, ""))
+		result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, bindResponse, nil, "")
+		result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated,
+			uint64(code), ""))
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		resp.AppendChild(result)
+		if _, err = conn.Write(resp.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func newTestPool(server *fakeServer, password string, size int, timeout time.Duration) *Pool {
+	pool := NewPool(&Config{
+		URL:     "ldap://ldap.example.com",
+		Timeout: timeout,
+	}, "cn=admin,dc=example,dc=com", password, size)
+	pool.dial = server.dial
+	return pool
+}
+
+func TestPoolReuse(t *testing.T) {
+	server := &fakeServer{}
+	pool := newTestPool(server, "admin-password", 2, 5*time.Second)
+	defer pool.Close()
+
+	for i := 0; i < 5; i++ {
+		conn, err := pool.Get()
+		if err != nil {
+			t.Fatalf("failed to get connection: %v", err)
+		}
+		// bind as the user, the connection is rebound when it's reused
+		if err = conn.Bind("uid=user01,ou=people,dc=example,dc=com", "password"); err != nil {
+			t.Errorf("failed to bind as the user: %v", err)
+		}
+		pool.Put(conn)
+	}
+
+	if n := server.connections(); n != 1 {
+		t.Errorf("expected the connection to be reused, connections: %d", n)
+	}
+}
+
+func TestPoolBounded(t *testing.T) {
+	server := &fakeServer{}
+	pool := newTestPool(server, "admin-password", 2, 200*time.Millisecond)
+	defer pool.Close()
+
+	c1, err := pool.Get()
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	c2, err := pool.Get()
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	if _, err = pool.Get(); err != ErrPoolTimeout {
+		t.Errorf("expected ErrPoolTimeout, in fact: %v", err)
+	}
+	pool.Put(c1)
+	pool.Put(c2)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := pool.Get()
+			if err != nil {
+				t.Errorf("failed to get connection: %v", err)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			pool.Put(conn)
+		}()
+	}
+	wg.Wait()
+
+	if n := server.connections(); n > 2 {
+		t.Errorf("expected at most 2 connections, in fact: %d", n)
+	}
+
+	pool.Close()
+	if _, err = pool.Get(); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, in fact: %v", err)
+	}
+}
+
+func TestPoolReconnect(t *testing.T) {
+	server := &fakeServer{}
+	pool := newTestPool(server, "admin-password", 1, 5*time.Second)
+	defer pool.Close()
+
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	if err = conn.Bind("uid=user01,ou=people,dc=example,dc=com", "password"); err != nil {
+		t.Fatalf("failed to bind as the user: %v", err)
+	}
+	pool.Put(conn)
+
+	// the idle connection closed by the server is replaced when rebinding
+	server.closeConnections()
+	conn, err = pool.Get()
+	if err != nil {
+		t.Fatalf("failed to get connection after the server closed it: %v", err)
+	}
+	pool.Put(conn)
+	if n := server.connections(); n != 2 {
+		t.Errorf("expected a new connection, connections: %d", n)
+	}
+
+	// the broken connection is not put back
+	conn, err = pool.Get()
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	conn.broken = true
+	pool.Put(conn)
+	if len(pool.idle) != 0 {
+		t.Errorf("the broken connection should not be put back")
+	}
+
+	// the invalid credential of the pool is reported
+	invalid := newTestPool(server, "wrong", 1, 5*time.Second)
+	defer invalid.Close()
+	if _, err = invalid.Get(); !IsInvalidCredentials(err) {
+		t.Errorf("expected invalid credentials, in fact: %v", err)
+	}
+	// the token is released on error
+	if _, err = invalid.Get(); !IsInvalidCredentials(err) {
+		t.Errorf("expected invalid credentials, in fact: %v", err)
+	}
+}
+
+func TestDialInvalidURL(t *testing.T) {
+	for _, u := range []string{"ldap://", "http://ldap.example.com", "://"} {
+		if _, err := Dial(&Config{URL: u}); err == nil {
+			t.Errorf("expected error for URL %q", u)
+		}
+	}
+}
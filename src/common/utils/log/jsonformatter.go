@@ -0,0 +1,80 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// the keys of the record in JSON, the fields with the same keys are kept with the prefix "fields."
+const (
+	jsonKeyTime  = "time"
+	jsonKeyLevel = "level"
+	jsonKeyLine  = "line"
+	jsonKeyMsg   = "msg"
+)
+
+// JSONFormatter represents a kind of formatter that formats the logs as JSON objects,
+// one per line, with the fields of the record as top level keys
+type JSONFormatter struct {
+	timeFormat string
+}
+
+// NewJSONFormatter returns a JSONFormatter, the format of time is time.RFC3339
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{
+		timeFormat: defaultTimeFormat,
+	}
+}
+
+// Format formats the logs as {"time":"...","level":"...","line":"...","msg":"...","key":value...}
+func (j *JSONFormatter) Format(r *Record) ([]byte, error) {
+	data := make(map[string]interface{}, len(r.Fields)+4)
+	for k, v := range r.Fields {
+		// errors usually have no exported fields, so they are marshaled as the messages
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		switch k {
+		case jsonKeyTime, jsonKeyLevel, jsonKeyLine, jsonKeyMsg:
+			k = "fields." + k
+		}
+		data[k] = v
+	}
+
+	data[jsonKeyTime] = r.Time.Format(j.timeFormat)
+	data[jsonKeyLevel] = r.Lvl.string()
+	if len(r.Line) != 0 {
+		// the line is in the form of "[file:line]:"
+		data[jsonKeyLine] = strings.TrimSuffix(strings.TrimPrefix(r.Line, "["), "]:")
+	}
+	data[jsonKeyMsg] = strings.TrimSuffix(r.Msg, "\n")
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// SetTimeFormat sets time format of JSONFormatter if the parameter fmt is not null
+func (j *JSONFormatter) SetTimeFormat(fmt string) {
+	if len(fmt) != 0 {
+		j.timeFormat = fmt
+	}
+}
@@ -0,0 +1,67 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONFormat(t *testing.T) {
+	now := time.Now()
+	record := NewRecord(now, "message", "[logger.go:10]:", ErrorLevel)
+	record.Fields = Fields{
+		"request_id": "abc",
+		"job_id":     1,
+		"error":      errors.New("failed"),
+		"msg":        "conflicting",
+	}
+
+	b, err := NewJSONFormatter().Format(record)
+	if err != nil {
+		t.Fatalf("failed to format: %v", err)
+	}
+	if !strings.HasSuffix(string(b), "}\n") {
+		t.Errorf("the log isn't ended with a newline: %q", string(b))
+	}
+
+	m := map[string]interface{}{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", string(b), err)
+	}
+
+	expected := map[string]interface{}{
+		"time":       now.Format(time.RFC3339),
+		"level":      "ERROR",
+		"line":       "logger.go:10",
+		"msg":        "message",
+		"request_id": "abc",
+		"job_id":     float64(1),
+		"error":      "failed",
+		"fields.msg": "conflicting",
+	}
+	if len(m) != len(expected) {
+		t.Errorf("unexpected log: %v", m)
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("unexpected %s: %v != %v", k, m[k], v)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +32,10 @@ var logger = New(os.Stdout, NewTextFormatter(), WarningLevel)
 func init() {
 	logger.callDepth = 4
 
+	if strings.ToLower(config.LogFormat()) == "json" {
+		logger.SetFormatter(NewJSONFormatter())
+	}
+
 	lvl := config.LogLevel()
 	if len(lvl) == 0 {
 		logger.SetLevel(InfoLevel)
@@ -54,7 +59,9 @@ type Logger struct {
 	lvl       Level
 	callDepth int
 	skipLine  bool
-	mu        sync.Mutex
+	fields    Fields
+	// the lock is shared with the loggers derived by WithFields as they write to the same output
+	mu *sync.Mutex
 }
 
 // New returns a customized Logger
@@ -64,6 +71,34 @@ func New(out io.Writer, fmtter Formatter, lvl Level) *Logger {
 		fmtter:    fmtter,
 		lvl:       lvl,
 		callDepth: 3,
+		mu:        &sync.Mutex{},
+	}
+}
+
+// WithFields returns a logger which adds the fields, besides the ones of l, to every
+// record it produces. It writes to the output of l with the formatter and level of l,
+// setting them on one of the loggers afterwards doesn't change the other
+func (l *Logger) WithFields(fields Fields) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &Logger{
+		out:    l.out,
+		fmtter: l.fmtter,
+		lvl:    l.lvl,
+		// the methods of the logger are called directly rather than by the package level functions
+		callDepth: 3,
+		skipLine:  l.skipLine,
+		fields:    merged,
+		mu:        l.mu,
 	}
 }
 
@@ -106,7 +141,13 @@ func SetLevel(lvl Level) {
 	logger.SetLevel(lvl)
 }
 
+// WithFields returns a logger which adds the fields to every record, based on the default Logger
+func WithFields(fields Fields) *Logger {
+	return logger.WithFields(fields)
+}
+
 func (l *Logger) output(record *Record) (err error) {
+	record.Fields = l.fields
 	b, err := l.fmtter.Format(record)
 	if err != nil {
 		return
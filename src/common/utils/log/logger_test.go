@@ -154,3 +154,23 @@ func enter() *bytes.Buffer {
 func exit() {
 	logger.SetOutput(os.Stdout)
 }
+
+func TestWithFields(t *testing.T) {
+	buf := enter()
+	defer exit()
+
+	l := WithFields(Fields{"request_id": "abc"}).WithFields(Fields{"job_id": 1, "repo": "library/hello world"})
+	l.Info(message)
+
+	str := buf.String()
+	if !strings.HasSuffix(str, `[INFO] message job_id=1 repo="library/hello world" request_id=abc`+"\n") {
+		t.Errorf("unexpected message: %s", str)
+	}
+
+	// the default logger isn't affected
+	buf.Reset()
+	Info(message)
+	if str = buf.String(); !strings.HasSuffix(str, "[INFO] message\n") {
+		t.Errorf("unexpected message: %s", str)
+	}
+}
@@ -19,12 +19,16 @@ import (
 	"time"
 )
 
+// Fields are the structured data attached to the logs, key: name of the field
+type Fields map[string]interface{}
+
 // Record holds information about log
 type Record struct {
-	Time time.Time // time when the log produced
-	Msg  string    // content of the log
-	Line string    // in which file and line that the log produced
-	Lvl  Level     // level of the log
+	Time   time.Time // time when the log produced
+	Msg    string    // content of the log
+	Line   string    // in which file and line that the log produced
+	Lvl    Level     // level of the log
+	Fields Fields    // the fields of the logger which produced the log
 }
 
 // NewRecord creates a record according to the arguments provided and returns it
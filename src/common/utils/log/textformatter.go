@@ -17,6 +17,9 @@ package log
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,7 +37,8 @@ func NewTextFormatter() *TextFormatter {
 	}
 }
 
-// Format formats the logs as "time [level] line message"
+// Format formats the logs as "time [level] line message key=value ...", the fields are
+// sorted by the keys
 func (t *TextFormatter) Format(r *Record) (b []byte, err error) {
 	s := fmt.Sprintf("%s [%s] ", r.Time.Format(t.timeFormat), r.Lvl.string())
 
@@ -46,6 +50,10 @@ func (t *TextFormatter) Format(r *Record) (b []byte, err error) {
 		s = s + r.Msg
 	}
 
+	if len(r.Fields) != 0 {
+		s = strings.TrimSuffix(s, "\n") + " " + formatFields(r.Fields)
+	}
+
 	b = []byte(s)
 
 	if len(b) == 0 || b[len(b)-1] != '\n' {
@@ -61,3 +69,23 @@ func (t *TextFormatter) SetTimeFormat(fmt string) {
 		t.timeFormat = fmt
 	}
 }
+
+// formatFields formats the fields as "key=value" separated by spaces, the values
+// containing spaces, quotes or "=" are quoted
+func formatFields(fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := fmt.Sprint(fields[k])
+		if strings.ContainsAny(v, " \"=") {
+			v = strconv.Quote(v)
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, " ")
+}
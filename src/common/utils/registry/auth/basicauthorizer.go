@@ -0,0 +1,45 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http"
+)
+
+// basicAuthorizer authorizes the requests to the registries which use basic
+// authentication, e.g. the Docker Distribution with htpasswd
+type basicAuthorizer struct {
+	credential Credential
+}
+
+// NewBasicAuthorizer returns an authorizer which adds the credential to the requests
+// when the registry challenges with the basic scheme
+func NewBasicAuthorizer(credential Credential) Authorizer {
+	return &basicAuthorizer{
+		credential: credential,
+	}
+}
+
+// Scheme returns the scheme the authorizer handles
+func (b *basicAuthorizer) Scheme() string {
+	return "basic"
+}
+
+// Authorize adds the credential to the request
+func (b *basicAuthorizer) Authorize(req *http.Request, params map[string]string) error {
+	b.credential.AddAuthorization(req)
+	return nil
+}
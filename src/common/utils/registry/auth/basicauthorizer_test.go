@@ -0,0 +1,49 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/docker/distribution/registry/client/auth"
+)
+
+func TestBasicAuthorizer(t *testing.T) {
+	authorizer := NewBasicAuthorizer(NewBasicAuthCredential("user", "password"))
+	as := &AuthorizerStore{
+		authorizers: []Authorizer{authorizer},
+		challenges: []auth.Challenge{
+			{
+				Scheme: "basic",
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	if err = as.Modify(req); err != nil {
+		t.Fatalf("failed to modify request: %v", err)
+	}
+
+	username, password, ok := req.BasicAuth()
+	if !ok || username != "user" || password != "password" {
+		t.Errorf("unexpected basic auth: %s, %s, %v", username, password, ok)
+	}
+}
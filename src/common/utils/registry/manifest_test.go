@@ -54,3 +54,61 @@ func TestUnMarshal(t *testing.T) {
 		t.Errorf("unexpected digest: %s != %s", refs[0].Digest.String(), digest)
 	}
 }
+
+func TestUnMarshalManifestList(t *testing.T) {
+	b := []byte(`{
+   "schemaVersion":2,
+   "mediaType":"application/vnd.docker.distribution.manifest.list.v2+json",
+   "manifests":[
+      {
+         "mediaType":"application/vnd.docker.distribution.manifest.v2+json",
+         "size":527,
+         "digest":"sha256:a2490cec4484ee6c1068ba3a05f89934010c85242f736280b35343483b2264b6",
+         "platform":{
+            "architecture":"amd64",
+            "os":"linux"
+         }
+      },
+      {
+         "mediaType":"application/vnd.docker.distribution.manifest.v2+json",
+         "size":527,
+         "digest":"sha256:0b43bd2f1a1f5d2b6a0c9e4b7e0ebf4f0c6d4a5e2f2d0f8c6b6c9e8a2b0d4c6e",
+         "platform":{
+            "architecture":"arm",
+            "os":"linux",
+            "variant":"v7"
+         }
+      }
+   ]
+}`)
+
+	manifest, descriptor, err := UnMarshal(MediaTypeManifestList, b)
+	if err != nil {
+		t.Fatalf("failed to parse manifest list: %v", err)
+	}
+
+	if descriptor.MediaType != MediaTypeManifestList {
+		t.Errorf("unexpected media type: %s != %s", descriptor.MediaType, MediaTypeManifestList)
+	}
+
+	list, ok := manifest.(*DeserializedManifestList)
+	if !ok {
+		t.Fatalf("unexpected type of manifest: %T", manifest)
+	}
+
+	if len(list.References()) != 2 {
+		t.Fatalf("unexpected length of reference: %d != %d", len(list.References()), 2)
+	}
+
+	if list.Manifests[1].Platform.Architecture != "arm" || list.Manifests[1].Platform.Variant != "v7" {
+		t.Errorf("unexpected platform: %+v", list.Manifests[1].Platform)
+	}
+
+	_, payload, err := list.Payload()
+	if err != nil {
+		t.Fatalf("failed to get payload of manifest list: %v", err)
+	}
+	if string(payload) != string(b) {
+		t.Errorf("the payload of manifest list should not be changed")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/docker/distribution"
+	distribution_digest "github.com/docker/distribution/digest"
+	distribution_manifest "github.com/docker/distribution/manifest"
+)
+
+// MediaTypeManifestList is the media type of manifest list, which references
+// the manifests of an image for different platforms
+const MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+func init() {
+	manifestListFunc := func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
+		m := new(DeserializedManifestList)
+		if err := m.UnmarshalJSON(b); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
+
+		return m, distribution.Descriptor{
+			Digest:    distribution_digest.FromBytes(b),
+			Size:      int64(len(b)),
+			MediaType: MediaTypeManifestList,
+		}, nil
+	}
+	if err := distribution.RegisterManifestSchema(MediaTypeManifestList, manifestListFunc); err != nil {
+		panic(fmt.Sprintf("Unable to register manifest list: %s", err))
+	}
+}
+
+// PlatformSpec specifies the platform a manifest in manifest list runs on
+type PlatformSpec struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+	Features     []string `json:"features,omitempty"`
+}
+
+// ManifestDescriptor describes a manifest referenced by manifest list
+type ManifestDescriptor struct {
+	distribution.Descriptor
+
+	Platform PlatformSpec `json:"platform"`
+}
+
+// ManifestList references manifests for different platforms
+type ManifestList struct {
+	distribution_manifest.Versioned
+
+	Manifests []ManifestDescriptor `json:"manifests"`
+}
+
+// References returns the descriptors of the manifests referenced by the list
+func (m ManifestList) References() []distribution.Descriptor {
+	dependencies := make([]distribution.Descriptor, len(m.Manifests))
+	for i := range m.Manifests {
+		dependencies[i] = m.Manifests[i].Descriptor
+	}
+	return dependencies
+}
+
+// DeserializedManifestList wraps ManifestList with the raw payload, so that
+// the list can be pushed without changing its digest
+type DeserializedManifestList struct {
+	ManifestList
+
+	canonical []byte
+}
+
+// UnmarshalJSON populates a new manifest list from JSON data
+func (m *DeserializedManifestList) UnmarshalJSON(b []byte) error {
+	m.canonical = make([]byte, len(b), len(b))
+	copy(m.canonical, b)
+
+	var list ManifestList
+	if err := json.Unmarshal(m.canonical, &list); err != nil {
+		return err
+	}
+
+	m.ManifestList = list
+	return nil
+}
+
+// MarshalJSON returns the raw payload if it exists
+func (m *DeserializedManifestList) MarshalJSON() ([]byte, error) {
+	if len(m.canonical) > 0 {
+		return m.canonical, nil
+	}
+
+	return nil, errors.New("JSON representation not initialized in DeserializedManifestList")
+}
+
+// Payload returns the raw payload of the manifest list
+func (m DeserializedManifestList) Payload() (string, []byte, error) {
+	return MediaTypeManifestList, m.canonical, nil
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package registry
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clientRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "harbor_registry_client_requests_total",
+		Help: "The count of the requests sent by the registry client by method.",
+	}, []string{"method"})
+	clientErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "harbor_registry_client_errors_total",
+		Help: "The count of the requests sent by the registry client which fail, by method and " +
+			"status code, the code is \"network\" if no response is received.",
+	}, []string{"method", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(clientRequests, clientErrors)
+}
+
+// countRequest counts the request sent and the error if the request fails, code
+// is 0 if no response is received
+func countRequest(method string, code int) {
+	clientRequests.WithLabelValues(method).Inc()
+	switch {
+	case code == 0:
+		clientErrors.WithLabelValues(method, "network").Inc()
+	case code >= 400:
+		clientErrors.WithLabelValues(method, strconv.Itoa(code)).Inc()
+	}
+}
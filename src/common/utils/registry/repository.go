@@ -128,6 +128,7 @@ func (r *Repository) ManifestExist(reference string) (digest string, exist bool,
 
 	req.Header.Add(http.CanonicalHeaderKey("Accept"), schema1.MediaTypeManifest)
 	req.Header.Add(http.CanonicalHeaderKey("Accept"), schema2.MediaTypeManifest)
+	req.Header.Add(http.CanonicalHeaderKey("Accept"), MediaTypeManifestList)
 
 	resp, err := r.client.Do(req)
 	if err != nil {
@@ -416,6 +417,274 @@ func (r *Repository) PushBlob(digest string, size int64, data io.Reader) error {
 	return r.monolithicBlobUpload(location, digest, size, data)
 }
 
+// MountBlob mounts the blob from repository "from" of the same registry. It returns
+// false if the registry refuses to mount it, e.g. the blob does not exist in "from",
+// in which case the blob should be pushed by the caller.
+func (r *Repository) MountBlob(digest, from string) (bool, error) {
+	req, err := http.NewRequest("POST", buildMountBlobURL(r.Endpoint.String(), r.Name, digest, from), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set(http.CanonicalHeaderKey("Content-Length"), "0")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false, parseError(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusCreated {
+		return true, nil
+	}
+
+	// the registry falls back to initiate an upload session when
+	// the mount can not be done, cancel the session as it is useless
+	if resp.StatusCode == http.StatusAccepted {
+		if location := resp.Header.Get(http.CanonicalHeaderKey("Location")); len(location) != 0 {
+			r.cancelBlobUpload(location)
+		}
+		return false, nil
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	return false, &registry_error.Error{
+		StatusCode: resp.StatusCode,
+		Detail:     string(b),
+	}
+}
+
+// cancelBlobUpload cancels the upload session, the error is ignored as
+// registry will purge the session anyway
+func (r *Repository) cancelBlobUpload(location string) {
+	req, err := http.NewRequest("DELETE", location, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// BlobUpload is the state of a blob upload session, it can be kept by
+// the caller to resume the upload after a failure
+type BlobUpload struct {
+	// Location is the URL the next request of the session should be sent to
+	Location string `json:"location"`
+	// UUID is the ID of the session returned in "Docker-Upload-UUID" header
+	UUID string `json:"uuid"`
+	// Offset is the number of bytes the registry has acknowledged
+	Offset int64 `json:"offset"`
+}
+
+// PushBlobChunked uploads the blob in chunks of chunkSize bytes with PATCH requests.
+// If upload.Location is set, the session is resumed from the offset acknowledged by
+// the registry and the bytes before the offset are skipped from data, otherwise a new
+// session is initiated. upload is updated after every chunk, so when an error occurs
+// it can be passed to another call to continue the upload. If acknowledged is not nil,
+// it's called with the state of the session once the session is initiated and after
+// every chunk acknowledged by the registry, so the caller can persist the state.
+func (r *Repository) PushBlobChunked(digest string, size int64, data io.Reader, chunkSize int64,
+	upload *BlobUpload, acknowledged func(upload BlobUpload)) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	if len(upload.Location) != 0 {
+		exist, err := r.getBlobUploadStatus(upload)
+		if err != nil {
+			return err
+		}
+		// the session has been expired or canceled on registry
+		if !exist {
+			upload.Location, upload.UUID, upload.Offset = "", "", 0
+		}
+	}
+
+	if len(upload.Location) == 0 {
+		location, uploadUUID, err := r.initiateBlobUpload(r.Name)
+		if err != nil {
+			return err
+		}
+		upload.Location, upload.UUID, upload.Offset = location, uploadUUID, 0
+		if acknowledged != nil {
+			acknowledged(*upload)
+		}
+	}
+
+	if upload.Offset > 0 {
+		if _, err := io.CopyN(ioutil.Discard, data, upload.Offset); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, chunkSize)
+	for upload.Offset < size {
+		n, err := io.ReadFull(data, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+		if err = r.pushBlobChunk(upload, buf[:n]); err != nil {
+			return err
+		}
+		if acknowledged != nil {
+			acknowledged(*upload)
+		}
+	}
+
+	return r.completeBlobUpload(upload, digest)
+}
+
+// getBlobUploadStatus updates the location and offset of the upload from registry,
+// it returns false if the session does not exist
+func (r *Repository) getBlobUploadStatus(upload *BlobUpload) (bool, error) {
+	req, err := http.NewRequest("GET", upload.Location, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false, parseError(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return true, updateBlobUpload(upload, resp)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	return false, &registry_error.Error{
+		StatusCode: resp.StatusCode,
+		Detail:     string(b),
+	}
+}
+
+func (r *Repository) pushBlobChunk(upload *BlobUpload, chunk []byte) error {
+	req, err := http.NewRequest("PATCH", upload.Location, bytes.NewReader(chunk))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(chunk))
+	req.Header.Set(http.CanonicalHeaderKey("Content-Type"), "application/octet-stream")
+	req.Header.Set(http.CanonicalHeaderKey("Content-Range"),
+		fmt.Sprintf("%d-%d", upload.Offset, upload.Offset+int64(len(chunk))-1))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return parseError(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusAccepted {
+		return updateBlobUpload(upload, resp)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return &registry_error.Error{
+		StatusCode: resp.StatusCode,
+		Detail:     string(b),
+	}
+}
+
+func (r *Repository) completeBlobUpload(upload *BlobUpload, digest string) error {
+	req, err := http.NewRequest("PUT", buildMonolithicBlobUploadURL(upload.Location, digest), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(http.CanonicalHeaderKey("Content-Length"), "0")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return parseError(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusCreated {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return &registry_error.Error{
+		StatusCode: resp.StatusCode,
+		Detail:     string(b),
+	}
+}
+
+// updateBlobUpload updates the upload according to the "Location", "Docker-Upload-UUID"
+// and "Range" headers of the response
+func updateBlobUpload(upload *BlobUpload, resp *http.Response) error {
+	if location := resp.Header.Get(http.CanonicalHeaderKey("Location")); len(location) != 0 {
+		upload.Location = location
+	}
+	if uploadUUID := resp.Header.Get(http.CanonicalHeaderKey("Docker-Upload-UUID")); len(uploadUUID) != 0 {
+		upload.UUID = uploadUUID
+	}
+
+	offset, err := parseUploadRange(resp.Header.Get(http.CanonicalHeaderKey("Range")))
+	if err != nil {
+		return err
+	}
+	upload.Offset = offset
+	return nil
+}
+
+// parseUploadRange returns the number of bytes received by registry from the
+// "Range" header, whose format is "0-<offset of the last byte>"
+func parseUploadRange(r string) (int64, error) {
+	if len(r) == 0 {
+		return 0, fmt.Errorf("no range header in response")
+	}
+
+	// the value may be prefixed with "bytes="
+	r = strings.TrimPrefix(r, "bytes=")
+	parts := strings.Split(r, "-")
+	if len(parts) != 2 || parts[0] != "0" {
+		return 0, fmt.Errorf("invalid range header: %s", r)
+	}
+
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range header: %s", r)
+	}
+
+	// registry returns "0-0" for an empty upload
+	if end == 0 {
+		return 0, nil
+	}
+
+	return end + 1, nil
+}
+
 // DeleteBlob ...
 func (r *Repository) DeleteBlob(digest string) error {
 	req, err := http.NewRequest("DELETE", buildBlobURL(r.Endpoint.String(), r.Name, digest), nil)
@@ -465,6 +734,13 @@ func buildInitiateBlobUploadURL(endpoint, repoName string) string {
 	return fmt.Sprintf("%s/v2/%s/blobs/uploads/", endpoint, repoName)
 }
 
+func buildMountBlobURL(endpoint, repoName, digest, from string) string {
+	query := url.Values{}
+	query.Set("mount", digest)
+	query.Set("from", from)
+	return fmt.Sprintf("%s/v2/%s/blobs/uploads/?%s", endpoint, repoName, query.Encode())
+}
+
 func buildMonolithicBlobUploadURL(location, digest string) string {
 	query := ""
 	if strings.ContainsRune(location, '?') {
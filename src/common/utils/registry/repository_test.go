@@ -184,6 +184,194 @@ func TestPushBlob(t *testing.T) {
 	}
 }
 
+func TestMountBlob(t *testing.T) {
+	from := "library/busybox"
+	canceled := false
+	location := ""
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			canceled = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if r.URL.Query().Get("mount") != digest {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if r.URL.Query().Get("from") == from {
+			w.Header().Add(http.CanonicalHeaderKey("Location"), fmt.Sprintf("/v2/%s/blobs/%s", repository, digest))
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		w.Header().Add(http.CanonicalHeaderKey("Location"), location)
+		w.Header().Add(http.CanonicalHeaderKey("Docker-Upload-UUID"), uuid)
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	server := test.NewServer(
+		&test.RequestHandlerMapping{
+			Pattern: fmt.Sprintf("/v2/%s/blobs/uploads/", repository),
+			Handler: handler,
+		})
+	defer server.Close()
+	location = fmt.Sprintf("%s/v2/%s/blobs/uploads/%s", server.URL, repository, uuid)
+
+	client, err := newRepository(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client for repository: %v", err)
+	}
+
+	mounted, err := client.MountBlob(digest, from)
+	if err != nil {
+		t.Fatalf("failed to mount blob: %v", err)
+	}
+	if !mounted {
+		t.Errorf("blob should be mounted from %s, but it is not", from)
+	}
+
+	mounted, err = client.MountBlob(digest, "library/invalid")
+	if err != nil {
+		t.Fatalf("failed to mount blob: %v", err)
+	}
+	if mounted {
+		t.Errorf("blob should not be mounted, but it is")
+	}
+	if !canceled {
+		t.Errorf("the upload session initiated by registry should be canceled")
+	}
+}
+
+func TestBuildMountBlobURL(t *testing.T) {
+	url := buildMountBlobURL("http://registry", "library/ubuntu", "sha256:abc", "library/a&b=c")
+	expected := "http://registry/v2/library/ubuntu/blobs/uploads/?from=library%2Fa%26b%3Dc&mount=sha256%3Aabc"
+	if url != expected {
+		t.Errorf("unexpected url: %s != %s", url, expected)
+	}
+}
+
+func TestPushBlobChunked(t *testing.T) {
+	data := []byte("0123456789")
+	received := []byte{}
+	patches := 0
+	completed := false
+	location := ""
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			w.Header().Add(http.CanonicalHeaderKey("Location"), location)
+			w.Header().Add(http.CanonicalHeaderKey("Range"), "0-0")
+			w.Header().Add(http.CanonicalHeaderKey("Docker-Upload-UUID"), uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case "GET":
+			w.Header().Add(http.CanonicalHeaderKey("Location"), location)
+			w.Header().Add(http.CanonicalHeaderKey("Range"), fmt.Sprintf("0-%d", len(received)-1))
+			w.Header().Add(http.CanonicalHeaderKey("Docker-Upload-UUID"), uuid)
+			w.WriteHeader(http.StatusNoContent)
+		case "PATCH":
+			patches++
+			// the second chunk fails
+			if patches == 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			expected := fmt.Sprintf("%d-%d", len(received), len(received)+int(r.ContentLength)-1)
+			if cr := r.Header.Get("Content-Range"); cr != expected {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			received = append(received, b...)
+			w.Header().Add(http.CanonicalHeaderKey("Location"), location)
+			w.Header().Add(http.CanonicalHeaderKey("Range"), fmt.Sprintf("0-%d", len(received)-1))
+			w.Header().Add(http.CanonicalHeaderKey("Docker-Upload-UUID"), uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case "PUT":
+			if r.URL.Query().Get("digest") != digest {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			completed = true
+			w.WriteHeader(http.StatusCreated)
+		}
+	}
+
+	server := test.NewServer(
+		&test.RequestHandlerMapping{
+			Pattern: fmt.Sprintf("/v2/%s/blobs/uploads/", repository),
+			Handler: handler,
+		})
+	defer server.Close()
+	location = fmt.Sprintf("%s/v2/%s/blobs/uploads/%s", server.URL, repository, uuid)
+
+	client, err := newRepository(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client for repository: %v", err)
+	}
+
+	upload := &BlobUpload{}
+	// the offsets acknowledged: the session is initiated and the first chunk is received
+	acknowledged := []int64{}
+	if err = client.PushBlobChunked(digest, int64(len(data)), bytes.NewReader(data), 4, upload,
+		func(u BlobUpload) {
+			acknowledged = append(acknowledged, u.Offset)
+		}); err == nil {
+		t.Fatalf("expected error when pushing the second chunk, but got nil")
+	}
+
+	if upload.UUID != uuid || upload.Offset != 4 {
+		t.Fatalf("unexpected state of upload: uuid %s, offset %d", upload.UUID, upload.Offset)
+	}
+
+	if len(acknowledged) != 2 || acknowledged[0] != 0 || acknowledged[1] != 4 {
+		t.Errorf("unexpected acknowledged offsets: %v", acknowledged)
+	}
+
+	if err = client.PushBlobChunked(digest, int64(len(data)), bytes.NewReader(data), 4, upload, nil); err != nil {
+		t.Fatalf("failed to resume the upload: %v", err)
+	}
+
+	if !bytes.Equal(received, data) {
+		t.Errorf("unexpected data received by registry: %s != %s", string(received), string(data))
+	}
+
+	if upload.Offset != int64(len(data)) {
+		t.Errorf("unexpected offset: %d != %d", upload.Offset, len(data))
+	}
+
+	if !completed {
+		t.Errorf("the upload is not completed")
+	}
+}
+
+func TestParseUploadRange(t *testing.T) {
+	cases := map[string]int64{
+		"0-0":        0,
+		"0-1023":     1024,
+		"bytes=0-99": 100,
+	}
+	for r, expected := range cases {
+		offset, err := parseUploadRange(r)
+		if err != nil {
+			t.Errorf("failed to parse range %s: %v", r, err)
+			continue
+		}
+		if offset != expected {
+			t.Errorf("unexpected offset of range %s: %d != %d", r, offset, expected)
+		}
+	}
+
+	for _, r := range []string{"", "1-10", "0-a"} {
+		if _, err := parseUploadRange(r); err == nil {
+			t.Errorf("expected error when parsing range %q, but got nil", r)
+		}
+	}
+}
+
 func TestDeleteBlob(t *testing.T) {
 	handler := test.Handler(&test.Response{
 		StatusCode: http.StatusAccepted,
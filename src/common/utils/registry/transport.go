@@ -45,8 +45,10 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	resp, err := t.transport.RoundTrip(req)
 	if err != nil {
+		countRequest(req.Method, 0)
 		return nil, err
 	}
+	countRequest(req.Method, resp.StatusCode)
 
 	log.Debugf("%d | %s %s", resp.StatusCode, req.Method, req.URL.String())
 
@@ -20,6 +20,8 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/vmware/harbor/src/common/utils/test"
 )
 
@@ -58,3 +60,31 @@ func TestRoundTrip(t *testing.T) {
 	}
 
 }
+
+func counterValue(t *testing.T, c *prometheus.CounterVec, labels ...string) float64 {
+	m := &dto.Metric{}
+	if err := c.WithLabelValues(labels...).Write(m); err != nil {
+		t.Fatalf("failed to read the counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestCountRequest(t *testing.T) {
+	requests := counterValue(t, clientRequests, "PUT")
+	notFound := counterValue(t, clientErrors, "PUT", "404")
+	network := counterValue(t, clientErrors, "PUT", "network")
+
+	countRequest("PUT", http.StatusCreated)
+	countRequest("PUT", http.StatusNotFound)
+	countRequest("PUT", 0)
+
+	if v := counterValue(t, clientRequests, "PUT"); v != requests+3 {
+		t.Errorf("unexpected count of requests: %v != %v", v, requests+3)
+	}
+	if v := counterValue(t, clientErrors, "PUT", "404"); v != notFound+1 {
+		t.Errorf("unexpected count of 404 errors: %v != %v", v, notFound+1)
+	}
+	if v := counterValue(t, clientErrors, "PUT", "network"); v != network+1 {
+		t.Errorf("unexpected count of network errors: %v != %v", v, network+1)
+	}
+}
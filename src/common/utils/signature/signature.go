@@ -0,0 +1,82 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package signature verifies the detached signatures of images, a signature is made
+// by signing the digest of the manifest, e.g. "sha256:...", with SHA-256 and the
+// RSA(PKCS #1 v1.5) or ECDSA private key, so it can be generated by openssl:
+//
+//	echo -n <digest> | openssl dgst -sha256 -sign <private key> | base64
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ParsePublicKey parses the PEM encoded RSA or ECDSA public key
+func ParsePublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported type of public key: %T", key)
+	}
+}
+
+// Verify verifies the signature of the digest with the PEM encoded public key
+func Verify(publicKey, digest string, signature []byte) error {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	hashed := sha256.Sum256([]byte(digest))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], signature)
+	case *ecdsa.PublicKey:
+		sig := struct {
+			R, S *big.Int
+		}{}
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil || len(rest) != 0 {
+			return errors.New("malformed ECDSA signature")
+		}
+		if !ecdsa.Verify(k, hashed[:], sig.R, sig.S) {
+			return errors.New("ECDSA verification failure")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported type of public key: %T", key)
+}
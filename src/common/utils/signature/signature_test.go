@@ -0,0 +1,92 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+func encodePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifyRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	hashed := sha256.Sum256([]byte(digest))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	publicKey := encodePublicKey(t, &key.PublicKey)
+	if err = Verify(publicKey, digest, sig); err != nil {
+		t.Errorf("failed to verify the signature: %v", err)
+	}
+	if err = Verify(publicKey, "sha256:1", sig); err == nil {
+		t.Errorf("the signature of another digest should not be verified")
+	}
+}
+
+func TestVerifyECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	hashed := sha256.Sum256([]byte(digest))
+	sig, err := key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	publicKey := encodePublicKey(t, &key.PublicKey)
+	if err = Verify(publicKey, digest, sig); err != nil {
+		t.Errorf("failed to verify the signature: %v", err)
+	}
+
+	another, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if err = Verify(encodePublicKey(t, &another.PublicKey), digest, sig); err == nil {
+		t.Errorf("the signature should not be verified by another key")
+	}
+	if err = Verify(publicKey, digest, []byte("invalid")); err == nil {
+		t.Errorf("the malformed signature should not be verified")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	if _, err := ParsePublicKey("invalid"); err == nil {
+		t.Errorf("an error is expected for invalid key")
+	}
+}
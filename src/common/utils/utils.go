@@ -17,11 +17,25 @@ package utils
 
 import (
 	"math/rand"
+	"net"
 	"net/url"
 	"strings"
 	"time"
 )
 
+// the private address blocks of IPv4 (RFC 1918) and IPv6 (RFC 4193)
+var privateIPNets []*net.IPNet
+
+func init() {
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		privateIPNets = append(privateIPNets, ipNet)
+	}
+}
+
 // FormatEndpoint formats endpoint
 func FormatEndpoint(endpoint string) string {
 	endpoint = strings.TrimSpace(endpoint)
@@ -59,6 +73,31 @@ func ParseRepository(repository string) (project, rest string) {
 	return
 }
 
+// ParseRepositoryTag splits a reference in the form of "repository[:tag]" into
+// the repository and tag, the tag is empty if it isn't specified
+func ParseRepositoryTag(reference string) (repository, tag string) {
+	i := strings.LastIndex(reference, ":")
+	if i < 0 || strings.Contains(reference[i+1:], "/") {
+		return reference, ""
+	}
+	return reference[:i], reference[i+1:]
+}
+
+// IsInternalIP returns whether the IP is a loopback, link-local, unspecified or private
+// address, which can't be reached from outside of the network Harbor is deployed in
+func IsInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, ipNet := range privateIPNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateRandomString generates a random string
 func GenerateRandomString() string {
 	length := 32
@@ -17,6 +17,7 @@ package utils
 
 import (
 	"encoding/base64"
+	"net"
 	"strings"
 	"testing"
 )
@@ -93,6 +94,26 @@ func TestParseRepository(t *testing.T) {
 	}
 }
 
+func TestParseRepositoryTag(t *testing.T) {
+	cases := []struct {
+		reference  string
+		repository string
+		tag        string
+	}{
+		{"library/ubuntu", "library/ubuntu", ""},
+		{"library/ubuntu:14.04", "library/ubuntu", "14.04"},
+		{"ubuntu:latest", "ubuntu", "latest"},
+		{"localhost:5000/ubuntu", "localhost:5000/ubuntu", ""},
+	}
+
+	for _, c := range cases {
+		repository, tag := ParseRepositoryTag(c.reference)
+		if repository != c.repository || tag != c.tag {
+			t.Errorf("unexpected result for %s: %s, %s", c.reference, repository, tag)
+		}
+	}
+}
+
 func TestEncrypt(t *testing.T) {
 	content := "content"
 	salt := "salt"
@@ -178,3 +199,26 @@ func TestParseLink(t *testing.T) {
 		t.Errorf("unexpected prev: %s != %s", links.Next(), next)
 	}
 }
+
+func TestIsInternalIP(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":       true,
+		"::1":             true,
+		"0.0.0.0":         true,
+		"169.254.169.254": true,
+		"fe80::1":         true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"172.31.255.255":  true,
+		"192.168.1.1":     true,
+		"fd00::1":         true,
+		"172.32.0.1":      false,
+		"8.8.8.8":         false,
+		"2001:db8::1":     false,
+	}
+	for ip, expected := range cases {
+		if internal := IsInternalIP(net.ParseIP(ip)); internal != expected {
+			t.Errorf("unexpected result of %s: %v != %v", ip, internal, expected)
+		}
+	}
+}
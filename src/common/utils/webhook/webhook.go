@@ -0,0 +1,185 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+const (
+	// HeaderEvent is the header which contains the type of event
+	HeaderEvent = "X-Harbor-Event"
+	// HeaderDelivery is the header which contains the ID of delivery
+	HeaderDelivery = "X-Harbor-Delivery"
+	// HeaderSignature is the header which contains the signature of payload,
+	// it is "sha256=" followed by the hex encoded HMAC-SHA256 of the payload
+	HeaderSignature = "X-Harbor-Signature"
+)
+
+// Event is the payload sent to webhooks
+type Event struct {
+	Type       string    `json:"type"`
+	OccurAt    time.Time `json:"occur_at"`
+	Operator   string    `json:"operator,omitempty"`
+	Project    string    `json:"project"`
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag,omitempty"`
+	Digest     string    `json:"digest,omitempty"`
+	// the fields below are only set for the events of replication job
+	JobID     int64  `json:"job_id,omitempty"`
+	PolicyID  int64  `json:"policy_id,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Status    string `json:"status,omitempty"`
+}
+
+// Publish queues the event for the enabled webhooks of the project which subscribe
+// the type of it, the deliveries are made by job service
+func Publish(projectID int64, event *Event) error {
+	webhooks, err := dao.GetWebhooks(projectID, true)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		id, err := dao.AddWebhookDelivery(models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventType: event.Type,
+			Payload:   string(payload),
+		})
+		if err != nil {
+			return err
+		}
+		log.Debugf("delivery %d of %s event queued for webhook %d", id, event.Type, webhook.ID)
+	}
+
+	return nil
+}
+
+// Sign returns the signature of the payload signed with the secret
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// errRedirect is returned when the target of webhook responds with a redirection
+var errRedirect = errors.New("redirection is not followed")
+
+// NewClient returns the client to send the deliveries. As the target URLs are set by project
+// admins, it refuses to connect to the loopback, link-local and private addresses, which are
+// checked after the host name is resolved, and doesn't follow redirections, so the requests
+// can't be sent to the services inside the network Harbor is deployed in.
+func NewClient(insecure bool, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Dial: func(network, addr string) (net.Conn, error) {
+				host, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				ips, err := net.LookupIP(host)
+				if err != nil {
+					return nil, err
+				}
+				if len(ips) == 0 {
+					return nil, fmt.Errorf("no address found for %s", host)
+				}
+				for _, ip := range ips {
+					if utils.IsInternalIP(ip) {
+						return nil, fmt.Errorf("%s is resolved to the internal address %s", host, ip)
+					}
+				}
+				// dial the address checked rather than resolving the host again
+				return dialer.Dial(network, net.JoinHostPort(ips[0].String(), port))
+			},
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: insecure,
+			},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return errRedirect
+		},
+		Timeout: timeout,
+	}
+}
+
+// Send posts the payload of the delivery to the URL, the payload is signed if the secret
+// is not empty. It returns the status code of the response, the response whose status
+// code is not 2xx is treated as an error. The body of the response is not returned as the
+// error is recorded in DB and read by project admins.
+func Send(client *http.Client, url, secret string, delivery *models.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set(http.CanonicalHeaderKey("Content-Type"), "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	if len(secret) != 0 {
+		req.Header.Set(HeaderSignature, Sign(secret, payload))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// the response of redirection is returned with the error
+		if resp != nil {
+			return resp.StatusCode, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// the connection is reused after the body is drained
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
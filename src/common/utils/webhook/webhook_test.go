@@ -0,0 +1,132 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+	signature := Sign("secret", []byte("{}"))
+	if signature != expected {
+		t.Errorf("unexpected signature: %s != %s", signature, expected)
+	}
+	if signature == Sign("another", []byte("{}")) {
+		t.Error("the signature should depend on the secret")
+	}
+}
+
+func TestSend(t *testing.T) {
+	var header http.Header
+	var body []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	delivery := &models.WebhookDelivery{
+		ID:        1,
+		EventType: models.WebhookEventPush,
+		Payload:   `{"type":"push"}`,
+	}
+
+	code, err := Send(http.DefaultClient, server.URL, "secret", delivery)
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if code != http.StatusOK {
+		t.Errorf("unexpected status code: %d", code)
+	}
+	if string(body) != delivery.Payload {
+		t.Errorf("unexpected body: %s", string(body))
+	}
+	if header.Get(HeaderEvent) != models.WebhookEventPush || header.Get(HeaderDelivery) != "1" {
+		t.Errorf("unexpected headers: %v", header)
+	}
+	if header.Get(HeaderSignature) != Sign("secret", body) {
+		t.Errorf("unexpected signature: %s", header.Get(HeaderSignature))
+	}
+
+	// no signature without secret
+	if _, err = Send(http.DefaultClient, server.URL, "", delivery); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if len(header.Get(HeaderSignature)) != 0 {
+		t.Errorf("unexpected signature: %s", header.Get(HeaderSignature))
+	}
+
+	status = http.StatusInternalServerError
+	code, err = Send(http.DefaultClient, server.URL, "secret", delivery)
+	if err == nil {
+		t.Error("expected error for status code 500")
+	}
+	if code != http.StatusInternalServerError {
+		t.Errorf("unexpected status code: %d", code)
+	}
+}
+
+func TestSendResponseBodyNotReturned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("internal details"))
+	}))
+	defer server.Close()
+
+	_, err := Send(http.DefaultClient, server.URL, "", &models.WebhookDelivery{})
+	if err == nil {
+		t.Fatal("expected error for status code 400")
+	}
+	if strings.Contains(err.Error(), "internal details") {
+		t.Errorf("the body of response should not be returned: %v", err)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(false, 10*time.Second)
+	delivery := &models.WebhookDelivery{}
+
+	// the test server listens on the loopback address
+	if _, err := Send(client, server.URL, "", delivery); err == nil {
+		t.Error("expected error for the loopback address")
+	}
+
+	// the redirection is not followed
+	client.Transport = http.DefaultTransport
+	code, err := Send(client, server.URL, "", delivery)
+	if err == nil {
+		t.Error("expected error for the redirection")
+	}
+	if code != http.StatusFound {
+		t.Errorf("unexpected status code: %d != %d", code, http.StatusFound)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/job"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// GCJob handles /api/jobs/gc /api/jobs/gc/:id/log
+type GCJob struct {
+	api.BaseAPI
+}
+
+// GCReq holds informations of request for /api/jobs/gc
+type GCReq struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// Prepare ...
+func (g *GCJob) Prepare() {
+	authenticate(&g.BaseAPI)
+}
+
+// Post creates a garbage collection job and runs it, only one job can be pending or
+// running at a time across the job service instances
+func (g *GCJob) Post() {
+	var data GCReq
+	g.DecodeJSONReq(&data)
+
+	j := models.GCJob{}
+	if data.DryRun {
+		j.DryRun = 1
+	}
+
+	id, err := dao.AddGCJob(j)
+	if err != nil {
+		log.Errorf("failed to add gc job: %v", err)
+		g.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if id == 0 {
+		msg := "another gc job is pending or running"
+		if unfinished, err := dao.GetUnfinishedGCJob(); err != nil {
+			log.Errorf("failed to get the unfinished gc job: %v", err)
+		} else if unfinished != nil {
+			msg = fmt.Sprintf("gc job %d is %s", unfinished.ID, unfinished.Status)
+		}
+		g.CustomAbort(http.StatusConflict, msg)
+	}
+
+	job.ScheduleGC(id)
+
+	g.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// GetLog gets logs of the garbage collection job
+func (g *GCJob) GetLog() {
+	id := g.GetIDFromURL()
+	g.Ctx.Output.Download(utils.GetGCJobLogPath(id))
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	uti "github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/job"
+)
+
+// ProxyJob handles /api/jobs/proxy
+type ProxyJob struct {
+	api.BaseAPI
+}
+
+// ProxyReq holds informations of request for /api/jobs/proxy
+type ProxyReq struct {
+	// Repository is the name of the repository in the proxy cache project
+	Repository string   `json:"repository"`
+	TagList    []string `json:"tags"`
+}
+
+// Prepare ...
+func (p *ProxyJob) Prepare() {
+	authenticate(&p.BaseAPI)
+}
+
+// Post creates a job which caches the repository of the target in the proxy cache
+// project, all tags of the repository are cached if no tag is specified
+func (p *ProxyJob) Post() {
+	var data ProxyReq
+	p.DecodeJSONReq(&data)
+
+	projectName, rest := uti.ParseRepository(data.Repository)
+	if len(projectName) == 0 || len(rest) == 0 {
+		p.CustomAbort(http.StatusBadRequest, fmt.Sprintf("invalid repository: %s", data.Repository))
+	}
+
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("failed to get project %s: %v", projectName, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if project == nil {
+		p.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %s not found", projectName))
+	}
+	if !project.IsProxy() {
+		p.CustomAbort(http.StatusBadRequest, fmt.Sprintf("project %s is not a proxy cache", projectName))
+	}
+
+	id, err := dao.AddRepJob(models.RepJob{
+		Repository: data.Repository,
+		Operation:  models.RepOpProxy,
+		TagList:    data.TagList,
+		RequestID:  p.RequestID(),
+		Priority:   models.RepJobPriorityHigh,
+	})
+	if err != nil {
+		log.Errorf("failed to add proxy job for %s: %v", data.Repository, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+	log.Debugf("Send job to scheduler, job id: %d", id)
+	job.Schedule(id)
+
+	p.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
@@ -16,11 +16,8 @@
 package api
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
 	"strconv"
 
 	"github.com/vmware/harbor/src/common/api"
@@ -29,7 +26,6 @@ import (
 	"github.com/vmware/harbor/src/jobservice/config"
 	"github.com/vmware/harbor/src/jobservice/utils"
 	"github.com/vmware/harbor/src/common/models"
-	u "github.com/vmware/harbor/src/common/utils"
 	"github.com/vmware/harbor/src/common/utils/log"
 )
 
@@ -45,6 +41,9 @@ type ReplicationReq struct {
 	Repo      string   `json:"repository"`
 	Operation string   `json:"operation"`
 	TagList   []string `json:"tags"`
+	// Trigger is what caused the replication, "manual" or "event", the jobs syncing all the
+	// repositories of a policy triggered manually are queued above the scheduled ones
+	Trigger string `json:"trigger"`
 }
 
 // Prepare ...
@@ -53,18 +52,23 @@ func (rj *ReplicationJob) Prepare() {
 }
 
 func (rj *ReplicationJob) authenticate() {
-	cookie, err := rj.Ctx.Request.Cookie(models.UISecretCookie)
+	authenticate(&rj.BaseAPI)
+}
+
+// authenticate checks the UI secret in cookie, as the APIs of job service are only called by UI
+func authenticate(b *api.BaseAPI) {
+	cookie, err := b.Ctx.Request.Cookie(models.UISecretCookie)
 	if err != nil && err != http.ErrNoCookie {
 		log.Errorf("failed to get cookie %s: %v", models.UISecretCookie, err)
-		rj.CustomAbort(http.StatusInternalServerError, "")
+		b.CustomAbort(http.StatusInternalServerError, "")
 	}
 
 	if err == http.ErrNoCookie {
-		rj.CustomAbort(http.StatusUnauthorized, "")
+		b.CustomAbort(http.StatusUnauthorized, "")
 	}
 
 	if cookie.Value != config.UISecret() {
-		rj.CustomAbort(http.StatusForbidden, "")
+		b.CustomAbort(http.StatusForbidden, "")
 	}
 }
 
@@ -85,21 +89,15 @@ func (rj *ReplicationJob) Post() {
 		return
 	}
 	if len(data.Repo) == 0 { // sync all repositories
-		repoList, err := getRepoList(p.ProjectID)
-		if err != nil {
-			log.Errorf("Failed to get repository list, project id: %d, error: %v", p.ProjectID, err)
+		priority := models.RepJobPriorityLow
+		if data.Trigger == models.RepTriggerManual {
+			priority = models.RepJobPriorityNormal
+		}
+		if err := job.SyncPolicy(p, priority, rj.RequestID()); err != nil {
+			log.Errorf("Failed to sync policy %d: %v", p.ID, err)
 			rj.RenderError(http.StatusInternalServerError, err.Error())
 			return
 		}
-		log.Debugf("repo list: %v", repoList)
-		for _, repo := range repoList {
-			err := rj.addJob(repo, data.PolicyID, models.RepOpTransfer)
-			if err != nil {
-				log.Errorf("Failed to insert job record, error: %v", err)
-				rj.RenderError(http.StatusInternalServerError, err.Error())
-				return
-			}
-		}
 	} else { // sync a single repository
 		var op string
 		if len(data.Operation) > 0 {
@@ -122,13 +120,15 @@ func (rj *ReplicationJob) addJob(repo string, policyID int64, operation string,
 		PolicyID:   policyID,
 		Operation:  operation,
 		TagList:    tags,
+		RequestID:  rj.RequestID(),
+		Priority:   models.RepJobPriorityNormal,
 	}
-	log.Debugf("Creating job for repo: %s, policy: %d", repo, policyID)
+	rj.Logger().Debugf("Creating job for repo: %s, policy: %d", repo, policyID)
 	id, err := dao.AddRepJob(j)
 	if err != nil {
 		return err
 	}
-	log.Debugf("Send job to scheduler, job id: %d", id)
+	rj.Logger().Debugf("Send job to scheduler, job id: %d", id)
 	job.Schedule(id)
 	return nil
 }
@@ -159,6 +159,12 @@ func (rj *ReplicationJob) HandleAction() {
 	for _, j := range jobs {
 		jobIDList = append(jobIDList, j.ID)
 	}
+	// the jobs may be running on other instances, which stop them on their next heartbeats
+	if err = dao.RequestRepJobsStop(jobIDList); err != nil {
+		log.Errorf("Failed to request jobs to stop, error: %v", err)
+		rj.RenderError(http.StatusInternalServerError, "Faild to request jobs to stop")
+		return
+	}
 	job.WorkerPool.StopJobs(jobIDList)
 }
 
@@ -174,49 +180,3 @@ func (rj *ReplicationJob) GetLog() {
 	logFile := utils.GetJobLogPath(jid)
 	rj.Ctx.Output.Download(logFile)
 }
-
-// calls the api from UI to get repo list
-func getRepoList(projectID int64) ([]string, error) {
-	repositories := []string{}
-
-	client := &http.Client{}
-	uiURL := config.LocalUIURL()
-	next := "/api/repositories?project_id=" + strconv.Itoa(int(projectID))
-	for len(next) != 0 {
-		req, err := http.NewRequest("GET", uiURL+next, nil)
-		if err != nil {
-			return repositories, err
-		}
-
-		req.AddCookie(&http.Cookie{Name: models.UISecretCookie, Value: config.UISecret()})
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return repositories, err
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			dump, _ := httputil.DumpResponse(resp, true)
-			log.Debugf("response: %q", dump)
-			return repositories, fmt.Errorf("Unexpected status code when getting repository list: %d", resp.StatusCode)
-		}
-
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return repositories, err
-		}
-
-		var list []string
-		if err = json.Unmarshal(body, &list); err != nil {
-			return repositories, err
-		}
-
-		repositories = append(repositories, list...)
-
-		links := u.ParseLink(resp.Header.Get(http.CanonicalHeaderKey("link")))
-		next = links.Next()
-	}
-
-	return repositories, nil
-}
@@ -0,0 +1,83 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/job"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// RetentionJob handles /api/jobs/retention /api/jobs/retention/:id/log
+type RetentionJob struct {
+	api.BaseAPI
+}
+
+// RetentionReq holds informations of request for /api/jobs/retention
+type RetentionReq struct {
+	PolicyID int64 `json:"policy_id"`
+	DryRun   bool  `json:"dry_run"`
+}
+
+// Prepare ...
+func (r *RetentionJob) Prepare() {
+	authenticate(&r.BaseAPI)
+}
+
+// Post creates a retention job for the policy and runs it
+func (r *RetentionJob) Post() {
+	var data RetentionReq
+	r.DecodeJSONReq(&data)
+
+	policy, err := dao.GetRetentionPolicy(data.PolicyID)
+	if err != nil {
+		log.Errorf("failed to get retention policy %d: %v", data.PolicyID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if policy == nil {
+		r.CustomAbort(http.StatusNotFound, fmt.Sprintf("retention policy %d not found", data.PolicyID))
+	}
+
+	j := models.RetentionJob{
+		PolicyID: data.PolicyID,
+	}
+	if data.DryRun {
+		j.DryRun = 1
+	}
+
+	id, err := dao.AddRetentionJob(j)
+	if err != nil {
+		log.Errorf("failed to add retention job for policy %d: %v", data.PolicyID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	job.ScheduleRetention(id)
+
+	r.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// GetLog gets logs of the retention job
+func (r *RetentionJob) GetLog() {
+	id := r.GetIDFromURL()
+	r.Ctx.Output.Download(utils.GetRetentionJobLogPath(id))
+}
@@ -0,0 +1,72 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/job"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// ScanJob handles /api/jobs/scan /api/jobs/scan/:id/log
+type ScanJob struct {
+	api.BaseAPI
+}
+
+// ScanReq holds informations of request for /api/jobs/scan
+type ScanReq struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
+
+// Prepare ...
+func (s *ScanJob) Prepare() {
+	authenticate(&s.BaseAPI)
+}
+
+// Post creates a job scanning the tag of repository for vulnerabilities and runs it
+func (s *ScanJob) Post() {
+	var data ScanReq
+	s.DecodeJSONReq(&data)
+	if len(data.Repository) == 0 || len(data.Tag) == 0 {
+		s.CustomAbort(http.StatusBadRequest, "repository and tag are required")
+	}
+
+	id, err := dao.AddScanJob(models.ScanJob{
+		Repository: data.Repository,
+		Tag:        data.Tag,
+	})
+	if err != nil {
+		log.Errorf("failed to add scan job for %s:%s: %v", data.Repository, data.Tag, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	job.ScheduleScan(id)
+
+	s.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// GetLog gets logs of the scan job
+func (s *ScanJob) GetLog() {
+	id := s.GetIDFromURL()
+	s.Ctx.Output.Download(utils.GetScanJobLogPath(id))
+}
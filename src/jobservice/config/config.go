@@ -26,6 +26,12 @@ import (
 
 const defaultMaxWorkers int = 10
 
+// the default number of blobs transferred concurrently in a replication job
+const defaultBlobTransferConcurrency int = 3
+
+// the default size of chunks used when uploading blobs to remote registry: 10M
+const defaultBlobUploadChunkSize int64 = 10 * 1024 * 1024
+
 var maxJobWorkers int
 var localUIURL string
 var localRegURL string
@@ -33,6 +39,15 @@ var logDir string
 var uiSecret string
 var secretKey string
 var verifyRemoteCert string
+var blobUploadChunkSize int64
+var blobTransferConcurrency int
+var registryStoragePath string
+var scanner string
+var scanFeedPath string
+var instanceID string
+
+// the max length of the instance ID, it's limited by the column in DB
+const instanceIDMaxLen = 64
 
 func init() {
 	maxWorkersEnv := os.Getenv("MAX_JOB_WORKERS")
@@ -81,6 +96,52 @@ func init() {
 		verifyRemoteCert = "on"
 	}
 
+	blobUploadChunkSize = defaultBlobUploadChunkSize
+	if chunkSize := os.Getenv("BLOB_UPLOAD_CHUNK_SIZE"); len(chunkSize) != 0 {
+		size, err := strconv.ParseInt(chunkSize, 10, 64)
+		if err != nil || size <= 0 {
+			log.Warningf("Invalid blob upload chunk size: %s, the default value: %d will be used", chunkSize, defaultBlobUploadChunkSize)
+		} else {
+			blobUploadChunkSize = size
+		}
+	}
+
+	blobTransferConcurrency = defaultBlobTransferConcurrency
+	if concurrency := os.Getenv("BLOB_TRANSFER_CONCURRENCY"); len(concurrency) != 0 {
+		c, err := strconv.Atoi(concurrency)
+		if err != nil || c <= 0 {
+			log.Warningf("Invalid blob transfer concurrency: %s, the default value: %d will be used", concurrency, defaultBlobTransferConcurrency)
+		} else {
+			blobTransferConcurrency = c
+		}
+	}
+
+	registryStoragePath = os.Getenv("REGISTRY_STORAGE_PATH")
+	if len(registryStoragePath) == 0 {
+		registryStoragePath = "/storage"
+	}
+
+	scanner = os.Getenv("SCANNER")
+	if len(scanner) == 0 {
+		scanner = "local"
+	}
+
+	scanFeedPath = os.Getenv("SCAN_FEED_PATH")
+	if len(scanFeedPath) == 0 {
+		scanFeedPath = "/cve/feed.json"
+	}
+
+	instanceID = os.Getenv("INSTANCE_ID")
+	if len(instanceID) == 0 {
+		instanceID, err = os.Hostname()
+		if err != nil {
+			panic(fmt.Sprintf("failed to get hostname as instance ID: %v", err))
+		}
+	}
+	if len(instanceID) > instanceIDMaxLen {
+		panic(fmt.Sprintf("the length of instance ID %s exceeds %d", instanceID, instanceIDMaxLen))
+	}
+
 	configPath := os.Getenv("CONFIG_PATH")
 	if len(configPath) != 0 {
 		log.Infof("Config path: %s", configPath)
@@ -96,7 +157,13 @@ func init() {
 	log.Debugf("config: localUIURL: %s", localUIURL)
 	log.Debugf("config: localRegURL: %s", localRegURL)
 	log.Debugf("config: verifyRemoteCert: %s", verifyRemoteCert)
+	log.Debugf("config: blobUploadChunkSize: %d", blobUploadChunkSize)
+	log.Debugf("config: blobTransferConcurrency: %d", blobTransferConcurrency)
+	log.Debugf("config: registryStoragePath: %s", registryStoragePath)
+	log.Debugf("config: scanner: %s", scanner)
+	log.Debugf("config: scanFeedPath: %s", scanFeedPath)
 	log.Debugf("config: logDir: %s", logDir)
+	log.Debugf("config: instanceID: %s", instanceID)
 	log.Debugf("config: uiSecret: ******")
 }
 
@@ -134,3 +201,35 @@ func SecretKey() string {
 func VerifyRemoteCert() bool {
 	return verifyRemoteCert != "off"
 }
+
+// BlobUploadChunkSize returns the size of chunks in bytes used when pushing blobs to remote registry
+func BlobUploadChunkSize() int64 {
+	return blobUploadChunkSize
+}
+
+// BlobTransferConcurrency returns the max number of blobs transferred concurrently in a replication job
+func BlobTransferConcurrency() int {
+	return blobTransferConcurrency
+}
+
+// RegistryStoragePath returns the root directory of the filesystem storage of the local registry,
+// job service reads it to find the blobs which can be deleted by garbage collection
+func RegistryStoragePath() string {
+	return registryStoragePath
+}
+
+// Scanner returns the name of the scanner used by the scan jobs
+func Scanner() string {
+	return scanner
+}
+
+// ScanFeedPath returns the path of the offline CVE feed read by the local scanner
+func ScanFeedPath() string {
+	return scanFeedPath
+}
+
+// InstanceID returns the ID of this job service instance, the jobs claimed by the instance
+// are leased to it. It's the hostname if not set.
+func InstanceID() string {
+	return instanceID
+}
@@ -0,0 +1,120 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package gc
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	"github.com/vmware/harbor/src/common/utils/registry/auth"
+	registry_error "github.com/vmware/harbor/src/common/utils/registry/error"
+)
+
+var mediaTypes = []string{schema1.MediaTypeManifest, schema2.MediaTypeManifest, registry.MediaTypeManifestList}
+
+// Reference marks the manifests and the blobs referenced by them as referenced, the
+// manifests referenced by a manifest list are handled recursively. A reference which does
+// not exist any more is ignored.
+func Reference(client *registry.Repository, references []string, referenced map[string]bool) error {
+	for _, reference := range references {
+		if err := referenceManifest(client, reference, mediaTypes, referenced); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func referenceManifest(client *registry.Repository, ref string, accept []string, referenced map[string]bool) error {
+	// the manifest and the blobs have been handled
+	if referenced[ref] {
+		return nil
+	}
+
+	digest, mediaType, payload, err := client.PullManifest(ref, accept)
+	if err != nil {
+		if regErr, ok := err.(*registry_error.Error); ok && regErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if strings.Contains(mediaType, "application/json") {
+		mediaType = schema1.MediaTypeManifest
+	}
+
+	manifest, _, err := registry.UnMarshal(mediaType, payload)
+	if err != nil {
+		return err
+	}
+
+	referenced[digest] = true
+
+	if list, ok := manifest.(*registry.DeserializedManifestList); ok {
+		for _, descriptor := range list.Manifests {
+			if err = referenceManifest(client, descriptor.Digest.String(),
+				[]string{descriptor.MediaType}, referenced); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, descriptor := range manifest.References() {
+		referenced[descriptor.Digest.String()] = true
+	}
+
+	// the config is not included in the references of manifest schema v2
+	if m, ok := manifest.(*schema2.DeserializedManifest); ok {
+		referenced[m.Target().Digest.String()] = true
+	}
+
+	return nil
+}
+
+// Unreferenced returns the blobs which are not referenced and are not modified after the deadline
+func Unreferenced(blobs []*Blob, referenced map[string]bool, deadline time.Time) []*Blob {
+	unreferenced := []*Blob{}
+	for _, blob := range blobs {
+		if referenced[blob.Digest] || blob.ModTime.After(deadline) {
+			continue
+		}
+		unreferenced = append(unreferenced, blob)
+	}
+	return unreferenced
+}
+
+// NewRegistryClient returns a client for the local registry, the UI secret is used to
+// get the token which grants the access to the catalog
+func NewRegistryClient(endpoint string, insecure bool, secret string) (*registry.Registry, error) {
+	credential := auth.NewCookieCredential(&http.Cookie{
+		Name:  models.UISecretCookie,
+		Value: secret,
+	})
+	authorizer := auth.NewStandardTokenAuthorizer(credential, insecure,
+		"registry", "catalog", "*")
+
+	store, err := auth.NewAuthorizerStore(endpoint, insecure, authorizer)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.NewRegistryWithModifiers(endpoint, insecure, store)
+}
@@ -0,0 +1,124 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package gc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	"github.com/vmware/harbor/src/common/utils/test"
+)
+
+const testRepository = "library/hello-world"
+
+type testManifest struct {
+	digest    string
+	mediaType string
+	payload   string
+}
+
+func testDigest(i int) string {
+	return fmt.Sprintf("sha256:%064d", i)
+}
+
+func TestReference(t *testing.T) {
+	image := fmt.Sprintf(`{
+		"schemaVersion": 2,
+		"mediaType": "%s",
+		"config": {"mediaType": "%s", "size": 1, "digest": "%s"},
+		"layers": [{"mediaType": "%s", "size": 1, "digest": "%s"}]
+	}`, schema2.MediaTypeManifest, schema2.MediaTypeConfig, testDigest(1),
+		schema2.MediaTypeLayer, testDigest(2))
+	list := fmt.Sprintf(`{
+		"schemaVersion": 2,
+		"mediaType": "%s",
+		"manifests": [{"mediaType": "%s", "size": 1, "digest": "%s",
+			"platform": {"architecture": "amd64", "os": "linux"}}]
+	}`, registry.MediaTypeManifestList, schema2.MediaTypeManifest, testDigest(3))
+
+	manifests := map[string]*testManifest{
+		"latest": &testManifest{
+			digest:    testDigest(4),
+			mediaType: registry.MediaTypeManifestList,
+			payload:   list,
+		},
+		testDigest(3): &testManifest{
+			digest:    testDigest(3),
+			mediaType: schema2.MediaTypeManifest,
+			payload:   image,
+		},
+	}
+
+	server := test.NewServer(
+		&test.RequestHandlerMapping{
+			Method:  "GET",
+			Pattern: fmt.Sprintf("/v2/%s/manifests/", testRepository),
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				reference := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+				manifest, ok := manifests[reference]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set(http.CanonicalHeaderKey("Docker-Content-Digest"), manifest.digest)
+				w.Header().Set(http.CanonicalHeaderKey("Content-Type"), manifest.mediaType)
+				w.Write([]byte(manifest.payload))
+			},
+		})
+	defer server.Close()
+
+	client, err := registry.NewRepository(testRepository, server.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("failed to create client for registry: %v", err)
+	}
+
+	referenced := map[string]bool{}
+	// the manifest "deleted" does not exist and is ignored
+	if err = Reference(client, []string{"latest", "deleted"}, referenced); err != nil {
+		t.Fatalf("failed to reference manifests: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if !referenced[testDigest(i)] {
+			t.Errorf("%s should be referenced", testDigest(i))
+		}
+	}
+	if len(referenced) != 4 {
+		t.Errorf("unexpected referenced blobs: %v", referenced)
+	}
+}
+
+func TestUnreferenced(t *testing.T) {
+	now := time.Now()
+	blobs := []*Blob{
+		&Blob{Digest: testDigest(1), ModTime: now.Add(-2 * time.Hour)},
+		&Blob{Digest: testDigest(2), ModTime: now.Add(-2 * time.Hour)},
+		&Blob{Digest: testDigest(3), ModTime: now},
+	}
+	referenced := map[string]bool{
+		testDigest(1): true,
+	}
+
+	unreferenced := Unreferenced(blobs, referenced, now.Add(-time.Hour))
+	if len(unreferenced) != 1 || unreferenced[0].Digest != testDigest(2) {
+		t.Errorf("unexpected unreferenced blobs: %v", unreferenced)
+	}
+}
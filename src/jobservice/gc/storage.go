@@ -0,0 +1,148 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package gc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Blob is a blob, or a link to a blob, in the storage of registry
+type Blob struct {
+	Digest  string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage reads the filesystem storage of registry, the layout is:
+//
+//	<root>/docker/registry/v2/blobs/<algorithm>/<first two hex>/<hex>/data
+//	<root>/docker/registry/v2/repositories/<name>/_layers/<algorithm>/<hex>/link
+//	<root>/docker/registry/v2/repositories/<name>/_manifests/revisions/<algorithm>/<hex>/link
+type Storage struct {
+	root string
+}
+
+// NewStorage returns an instance of Storage, path is the root directory of the filesystem
+// storage driver of registry
+func NewStorage(path string) *Storage {
+	return &Storage{
+		root: filepath.Join(path, "docker", "registry", "v2"),
+	}
+}
+
+// Blobs returns all blobs in the storage
+func (s *Storage) Blobs() ([]*Blob, error) {
+	blobs := []*Blob{}
+	dir := filepath.Join(s.root, "blobs")
+
+	algorithms, err := readDirNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, algorithm := range algorithms {
+		prefixes, err := readDirNames(filepath.Join(dir, algorithm))
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range prefixes {
+			hexes, err := readDirNames(filepath.Join(dir, algorithm, prefix))
+			if err != nil {
+				return nil, err
+			}
+			for _, hex := range hexes {
+				info, err := os.Stat(filepath.Join(dir, algorithm, prefix, hex, "data"))
+				if err != nil {
+					// the directory is left without data by an interrupted deletion
+					if os.IsNotExist(err) {
+						continue
+					}
+					return nil, err
+				}
+				blobs = append(blobs, &Blob{
+					Digest:  algorithm + ":" + hex,
+					Size:    info.Size(),
+					ModTime: info.ModTime(),
+				})
+			}
+		}
+	}
+
+	return blobs, nil
+}
+
+// Layers returns the links of blobs in the repository, the size of them is always 0
+func (s *Storage) Layers(repository string) ([]*Blob, error) {
+	return s.links(filepath.Join(s.root, "repositories", repository, "_layers"))
+}
+
+// Revisions returns the links of manifests in the repository, including the untagged ones,
+// the size of them is always 0
+func (s *Storage) Revisions(repository string) ([]*Blob, error) {
+	return s.links(filepath.Join(s.root, "repositories", repository, "_manifests", "revisions"))
+}
+
+func (s *Storage) links(dir string) ([]*Blob, error) {
+	links := []*Blob{}
+
+	algorithms, err := readDirNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, algorithm := range algorithms {
+		hexes, err := readDirNames(filepath.Join(dir, algorithm))
+		if err != nil {
+			return nil, err
+		}
+		for _, hex := range hexes {
+			info, err := os.Stat(filepath.Join(dir, algorithm, hex, "link"))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			links = append(links, &Blob{
+				Digest:  algorithm + ":" + hex,
+				ModTime: info.ModTime(),
+			})
+		}
+	}
+
+	return links, nil
+}
+
+// readDirNames returns the names of the sub directories, a directory which does not
+// exist is treated as an empty one
+func readDirNames(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		if info.IsDir() {
+			names = append(names, info.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package gc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory for %s: %v", path, err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	path, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	hex1 := "0000000000000000000000000000000000000000000000000000000000000001"
+	hex2 := "0000000000000000000000000000000000000000000000000000000000000002"
+	root := filepath.Join(path, "docker", "registry", "v2")
+	writeFile(t, filepath.Join(root, "blobs", "sha256", "00", hex1, "data"), "layer")
+	writeFile(t, filepath.Join(root, "blobs", "sha256", "00", hex2, "data"), "manifest")
+	repository := filepath.Join(root, "repositories", "library", "hello-world")
+	writeFile(t, filepath.Join(repository, "_layers", "sha256", hex1, "link"), "sha256:"+hex1)
+	writeFile(t, filepath.Join(repository, "_manifests", "revisions", "sha256", hex2, "link"), "sha256:"+hex2)
+
+	storage := NewStorage(path)
+
+	blobs, err := storage.Blobs()
+	if err != nil {
+		t.Fatalf("failed to list blobs: %v", err)
+	}
+	sizes := map[string]int64{}
+	for _, blob := range blobs {
+		sizes[blob.Digest] = blob.Size
+	}
+	if len(sizes) != 2 || sizes["sha256:"+hex1] != 5 || sizes["sha256:"+hex2] != 8 {
+		t.Errorf("unexpected blobs: %v", sizes)
+	}
+
+	layers, err := storage.Layers("library/hello-world")
+	if err != nil {
+		t.Fatalf("failed to list layers: %v", err)
+	}
+	if len(layers) != 1 || layers[0].Digest != "sha256:"+hex1 {
+		t.Errorf("unexpected layers: %v", layers)
+	}
+
+	revisions, err := storage.Revisions("library/hello-world")
+	if err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Digest != "sha256:"+hex2 {
+		t.Errorf("unexpected revisions: %v", revisions)
+	}
+
+	// a repository which does not exist has no layers
+	layers, err = storage.Layers("library/nonexist")
+	if err != nil {
+		t.Fatalf("failed to list layers: %v", err)
+	}
+	if len(layers) != 0 {
+		t.Errorf("unexpected layers: %v", layers)
+	}
+}
@@ -0,0 +1,210 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	registry_error "github.com/vmware/harbor/src/common/utils/registry/error"
+	"github.com/vmware/harbor/src/jobservice/config"
+	"github.com/vmware/harbor/src/jobservice/gc"
+	"github.com/vmware/harbor/src/jobservice/retention"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// the blobs modified within the period are not unlinked by garbage collection, as the
+// manifests referencing them may be being pushed
+const gcGracePeriod = time.Hour
+
+// gcQueue runs the garbage collection jobs one at a time, across all the job service instances
+// as the job services reject a job while another one is unfinished
+var gcQueue = newJobQueue(dao.GCJobTable, 1, runGCJob)
+
+// ScheduleGC tells the queue that the garbage collection job has been added to DB, the job is
+// run in background once it's claimed
+func ScheduleGC(jobID int64) {
+	gcQueue.schedule(jobID)
+}
+
+func runGCJob(jobID int64) {
+	logger := utils.NewGCLogger(jobID)
+
+	job, err := dao.GetGCJob(jobID)
+	if err != nil {
+		logger.Errorf("failed to get gc job %d: %v", jobID, err)
+		return
+	}
+	if job == nil {
+		logger.Errorf("gc job %d not found", jobID)
+		return
+	}
+
+	status := models.JobFinished
+	blobs, unlinked, err := collectGarbage(job.DryRun == 1, logger)
+	if err != nil {
+		logger.Errorf("an error occurred while collecting garbage: %v", err)
+		status = models.JobError
+	}
+
+	if err = dao.UpdateGCJobResult(jobID, blobs, unlinked); err != nil {
+		logger.Errorf("failed to update result of gc job %d: %v", jobID, err)
+	}
+
+	if err = dao.UpdateGCJobStatus(jobID, status); err != nil {
+		logger.Errorf("failed to update status of gc job %d: %v", jobID, err)
+	}
+	countCompletedJob(jobTypeGC, status)
+}
+
+// collectGarbage unlinks the blobs which are not referenced by any manifest of the local registry
+// from the repositories linking them, it returns the number and the size of the blobs unlinked, or
+// to be unlinked if it is a dry run. The referenced blobs are computed before unlinking anything,
+// so any error in this phase stops the job. The storage is only read, the blobs are unlinked through
+// the API of registry, which keeps its cache consistent, and their data is removed from the storage
+// by the offline garbage collection of registry, as are the blobs not linked by any repository.
+func collectGarbage(dryRun bool, logger *log.Logger) (int, int64, error) {
+	start := time.Now()
+	storage := gc.NewStorage(config.RegistryStoragePath())
+	endpoint := config.LocalRegURL()
+	insecure := !config.VerifyRemoteCert()
+
+	registryClient, err := gc.NewRegistryClient(endpoint, insecure, config.UISecret())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	repositories, err := registryClient.Catalog()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	logger.Infof("collecting garbage from %d repositories, dry run: %v", len(repositories), dryRun)
+
+	referenced := map[string]bool{}
+	clients := map[string]*registry.Repository{}
+	// key: digest of blob, value: repositories linking the blob
+	links := map[string][]string{}
+	// key: digest of blob, value: the latest time it is linked
+	linkTimes := map[string]time.Time{}
+	for _, repository := range repositories {
+		client, err := retention.NewRepositoryClient(endpoint, insecure, config.UISecret(), repository)
+		if err != nil {
+			return 0, 0, err
+		}
+		clients[repository] = client
+
+		tags, err := client.ListTag()
+		if err != nil {
+			// the repository has no tag
+			if regErr, ok := err.(*registry_error.Error); !ok || regErr.StatusCode != http.StatusNotFound {
+				return 0, 0, err
+			}
+		}
+
+		revisions, err := storage.Revisions(repository)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		references := tags
+		for _, revision := range revisions {
+			references = append(references, revision.Digest)
+		}
+
+		if err = gc.Reference(client, references, referenced); err != nil {
+			return 0, 0, err
+		}
+
+		layers, err := storage.Layers(repository)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, layer := range layers {
+			links[layer.Digest] = append(links[layer.Digest], repository)
+			if layer.ModTime.After(linkTimes[layer.Digest]) {
+				linkTimes[layer.Digest] = layer.ModTime
+			}
+		}
+
+		logger.Infof("%d tags and %d manifests of %s handled", len(tags), len(revisions), repository)
+	}
+
+	blobs, err := storage.Blobs()
+	if err != nil {
+		return 0, 0, err
+	}
+	// a blob newly linked into a repository is being pushed as well
+	for _, blob := range blobs {
+		if t := linkTimes[blob.Digest]; t.After(blob.ModTime) {
+			blob.ModTime = t
+		}
+	}
+
+	unreferenced := gc.Unreferenced(blobs, referenced, start.Add(-gcGracePeriod))
+	logger.Infof("%d blobs found, %d of them are referenced, %d of them are not referenced",
+		len(blobs), len(referenced), len(unreferenced))
+
+	count := 0
+	var unlinked int64
+	for _, blob := range unreferenced {
+		repositories := links[blob.Digest]
+		// nothing can be done through the API of registry
+		if len(repositories) == 0 {
+			logger.Infof("blob %s (%d bytes) is not linked by any repository, it is left to the offline garbage collection",
+				blob.Digest, blob.Size)
+			continue
+		}
+
+		if dryRun {
+			logger.Infof("blob %s (%d bytes) would be unlinked from %v", blob.Digest, blob.Size, repositories)
+			count++
+			unlinked += blob.Size
+			continue
+		}
+
+		if err = unlinkBlob(clients, blob.Digest, repositories); err != nil {
+			logger.Errorf("failed to unlink blob %s: %v", blob.Digest, err)
+			return count, unlinked, err
+		}
+		logger.Infof("blob %s (%d bytes) unlinked from %v", blob.Digest, blob.Size, repositories)
+		count++
+		unlinked += blob.Size
+	}
+
+	logger.Infof("%d blobs, %d bytes unlinked, dry run: %v, the space is reclaimed once the offline garbage collection of registry is run",
+		count, unlinked, dryRun)
+
+	return count, unlinked, nil
+}
+
+// unlinkBlob removes the links of the blob from the repositories through the API of registry,
+// the data of the blob stays on the storage
+func unlinkBlob(clients map[string]*registry.Repository, digest string, repositories []string) error {
+	for _, repository := range repositories {
+		if err := clients[repository].DeleteBlob(digest); err != nil {
+			if regErr, ok := err.(*registry_error.Error); !ok || regErr.StatusCode != http.StatusNotFound {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// the types of the jobs in the metrics
+const (
+	jobTypeReplication = "replication"
+	jobTypeGC          = "gc"
+	jobTypeRetention   = "retention"
+	jobTypeScan        = "scan"
+)
+
+var (
+	completedJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "harbor_jobservice_jobs_total",
+		Help: "The count of the jobs by type and final state.",
+	}, []string{"type", "state"})
+	totalWorkers = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "harbor_jobservice_workers",
+		Help: "The number of the workers in the worker pool.",
+	}, func() float64 {
+		if WorkerPool == nil {
+			return 0
+		}
+		return float64(len(WorkerPool.workerList))
+	})
+	busyWorkers = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "harbor_jobservice_busy_workers",
+		Help: "The number of the workers handling a replication job.",
+	}, func() float64 {
+		if WorkerPool == nil {
+			return 0
+		}
+		return float64(len(WorkerPool.workerList) - len(WorkerPool.workerChan))
+	})
+	queueDepth = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "harbor_jobservice_queue_depth",
+		Help: "The number of the replication jobs queued in DB, i.e. the pending and retrying ones.",
+	}, func() float64 {
+		queue, err := dao.GetRepJobQueue()
+		if err != nil {
+			log.Errorf("failed to get the queue of replication jobs: %v", err)
+			return 0
+		}
+		return float64(queue.Depth)
+	})
+)
+
+func init() {
+	prometheus.MustRegister(completedJobs, totalWorkers, busyWorkers, queueDepth)
+}
+
+// countCompletedJob counts the job entering the final state
+func countCompletedJob(jobType, state string) {
+	completedJobs.WithLabelValues(jobType, state).Inc()
+}
@@ -0,0 +1,164 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"time"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	uti "github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// the interval to reload policies from DB and check whether any of them should be triggered,
+// policies are reloaded every time so that the changes made by UI take effect without notification
+const policyCheckInterval = 30 * time.Second
+
+// StartPolicyScheduler starts a loop which checks the cron strings of enabled replication
+// and retention policies periodically.
+// As the last run time is stored in DB, the policies whose run time was missed when the
+// job service was down will be triggered once it's started, and every scheduled run is
+// claimed in DB, so it's triggered by only one instance when several job services are running.
+func StartPolicyScheduler() {
+	go func() {
+		for {
+			now := time.Now()
+			checkRepPolicies(now)
+			checkRetentionPolicies(now)
+			time.Sleep(policyCheckInterval)
+		}
+	}()
+}
+
+// checkRepPolicies triggers the replication of every scheduled policy whose run time has arrived
+func checkRepPolicies(now time.Time) {
+	policies, err := dao.GetScheduledRepPolicies()
+	if err != nil {
+		log.Errorf("failed to get scheduled policies: %v", err)
+		return
+	}
+
+	for _, policy := range policies {
+		lastRun, err := repPolicyLastRunTime(policy)
+		if err != nil {
+			log.Errorf("failed to get the last run time of policy %d: %v", policy.ID, err)
+			continue
+		}
+
+		next, err := policy.ScheduledRunTime(lastRun)
+		if err != nil {
+			log.Errorf("failed to calculate the next run time of policy %d, cron string: %s, error: %v", policy.ID, policy.CronStr, err)
+			continue
+		}
+
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		ok, err := dao.ClaimRepPolicyScheduledRun(policy.ID, next, now)
+		if err != nil {
+			log.Errorf("failed to claim the scheduled run of policy %d: %v", policy.ID, err)
+			continue
+		}
+		// triggered by another instance. The run is claimed before the jobs are created, so a
+		// run failing to be triggered is not retried until the next scheduled time.
+		if !ok {
+			continue
+		}
+
+		// the jobs of a scheduled run are correlated by an ID as if they were created by a request
+		requestID := api.NewRequestID()
+		api.RequestLogger(requestID).Infof("policy %d was scheduled to run at %v, triggering replication", policy.ID, next)
+		if err = SyncPolicy(policy, models.RepJobPriorityLow, requestID); err != nil {
+			log.Errorf("failed to trigger replication of policy %d: %v", policy.ID, err)
+		}
+	}
+}
+
+// repPolicyLastRunTime returns the last time the policy was triggered, it is the later one of
+// the last scheduled time and the creation time of the last job in DB, as the policy may be
+// triggered manually as well.
+func repPolicyLastRunTime(policy *models.RepPolicy) (time.Time, error) {
+	lastRun := policy.LastScheduledTime
+
+	t, err := dao.GetRepPolicyLastRunTime(policy.ID)
+	if err != nil {
+		return lastRun, err
+	}
+
+	if t != nil && t.After(lastRun) {
+		lastRun = *t
+	}
+
+	return lastRun, nil
+}
+
+// SyncPolicy creates a job for every repository of the project the policy is bound to
+// and passes the repository filters of the policy, and puts them into the job queue,
+// the tag filters are applied by the jobs. For a pull policy the jobs are created for
+// the repositories on the target listed in the policy. The jobs are queued with the priority
+// and tagged with the ID of the request which triggered the sync.
+func SyncPolicy(policy *models.RepPolicy, priority int, requestID string) error {
+	if policy.IsPull() {
+		for _, reference := range policy.RepositoryList {
+			repository, tag := uti.ParseRepositoryTag(reference)
+			var tags []string
+			if len(tag) != 0 {
+				tags = []string{tag}
+			}
+			if err := addTransferJob(policy.ID, repository, tags, priority, requestID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	repositories, err := utils.GetRepoList(policy.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, repository := range repositories {
+		if !policy.MatchRepository(repository) {
+			continue
+		}
+		if err := addTransferJob(policy.ID, repository, nil, priority, requestID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addTransferJob(policyID int64, repository string, tags []string, priority int, requestID string) error {
+	id, err := dao.AddRepJob(models.RepJob{
+		Repository: repository,
+		PolicyID:   policyID,
+		Operation:  models.RepOpTransfer,
+		TagList:    tags,
+		RequestID:  requestID,
+		Priority:   priority,
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("Send job to scheduler, job id: %d", id)
+	Schedule(id)
+	return nil
+}
@@ -0,0 +1,199 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func init() {
+	dao.InitDatabase()
+}
+
+// addScheduledPolicy adds an enabled pull policy triggered at minute 0 of every hour, whose
+// schedule starts from start
+func addScheduledPolicy(t *testing.T, name string, start time.Time) int64 {
+	id, err := dao.AddRepPolicy(models.RepPolicy{
+		ProjectID:      1,
+		TargetID:       1,
+		Name:           name,
+		Enabled:        1,
+		CronStr:        "0 * * * *",
+		Direction:      models.RepDirectionPull,
+		RepositoryList: []string{"library/a", "library/b:v1"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add policy: %v", err)
+	}
+	if _, err = dao.GetOrmer().Raw(`update replication_policy set start_time = ? where id = ?`,
+		start, id).Exec(); err != nil {
+		t.Fatalf("failed to update start time of policy %d: %v", id, err)
+	}
+	return id
+}
+
+func deletePolicyAndJobs(t *testing.T, policyID int64) {
+	if _, err := dao.GetOrmer().Raw(`delete from replication_job where policy_id = ?`,
+		policyID).Exec(); err != nil {
+		t.Errorf("failed to delete jobs of policy %d: %v", policyID, err)
+	}
+	if _, err := dao.GetOrmer().Raw(`delete from replication_policy where id = ?`,
+		policyID).Exec(); err != nil {
+		t.Errorf("failed to delete policy %d: %v", policyID, err)
+	}
+}
+
+func countJobs(t *testing.T, policyID int64) int {
+	jobs, err := dao.GetRepJobByPolicy(policyID)
+	if err != nil {
+		t.Fatalf("failed to get jobs of policy %d: %v", policyID, err)
+	}
+	return len(jobs)
+}
+
+func TestCheckRepPolicies(t *testing.T) {
+	now := time.Now()
+	policyID := addScheduledPolicy(t, "test_check_rep_policies", now.Add(-3*time.Hour))
+	defer deletePolicyAndJobs(t, policyID)
+
+	// the 3 runs missed since the schedule started are caught up by one run,
+	// which creates a job for each repository of the pull policy
+	checkRepPolicies(now)
+	if n := countJobs(t, policyID); n != 2 {
+		t.Fatalf("unexpected number of jobs after the first check: %d != %d", n, 2)
+	}
+
+	// the jobs of a scheduled run are queued below the ones triggered manually
+	jobs, err := dao.GetRepJobByPolicy(policyID)
+	if err != nil {
+		t.Fatalf("failed to get jobs of policy %d: %v", policyID, err)
+	}
+	for _, job := range jobs {
+		if job.Priority != models.RepJobPriorityLow {
+			t.Errorf("unexpected priority of the scheduled job %d: %d != %d", job.ID, job.Priority, models.RepJobPriorityLow)
+		}
+	}
+
+	policy, err := dao.GetRepPolicy(policyID)
+	if err != nil {
+		t.Fatalf("failed to get policy %d: %v", policyID, err)
+	}
+	if policy.LastScheduledTime.IsZero() {
+		t.Errorf("last scheduled time of policy %d is not recorded", policyID)
+	}
+
+	// the run has been triggered
+	checkRepPolicies(now)
+	if n := countJobs(t, policyID); n != 2 {
+		t.Errorf("unexpected number of jobs after the second check: %d != %d", n, 2)
+	}
+
+	// the run can't be claimed by another instance
+	next, err := policy.ScheduledRunTime(time.Time{})
+	if err != nil {
+		t.Fatalf("failed to get the scheduled run time of policy %d: %v", policyID, err)
+	}
+	claimed, err := dao.ClaimRepPolicyScheduledRun(policyID, next, now)
+	if err != nil {
+		t.Fatalf("failed to claim the scheduled run of policy %d: %v", policyID, err)
+	}
+	if claimed {
+		t.Errorf("the scheduled run at %v of policy %d is claimed twice", next, policyID)
+	}
+
+	// the last run time is recovered from the jobs in DB without the last scheduled time,
+	// e.g. the policy was triggered by a job service before upgrading
+	if _, err = dao.GetOrmer().Raw(`update replication_policy set last_scheduled_time = null where id = ?`,
+		policyID).Exec(); err != nil {
+		t.Fatalf("failed to clear the last scheduled time of policy %d: %v", policyID, err)
+	}
+	checkRepPolicies(now)
+	if n := countJobs(t, policyID); n != 2 {
+		t.Errorf("unexpected number of jobs after the last scheduled time is cleared: %d != %d", n, 2)
+	}
+
+	// the next run
+	checkRepPolicies(now.Add(time.Hour + time.Minute))
+	if n := countJobs(t, policyID); n != 4 {
+		t.Errorf("unexpected number of jobs an hour later: %d != %d", n, 4)
+	}
+
+	// the runs missed when the job service was down are caught up by one run
+	checkRepPolicies(now.Add(5 * time.Hour))
+	if n := countJobs(t, policyID); n != 6 {
+		t.Errorf("unexpected number of jobs 5 hours later: %d != %d", n, 6)
+	}
+}
+
+func TestCheckRepPoliciesBeforeStartTime(t *testing.T) {
+	now := time.Now()
+	policyID := addScheduledPolicy(t, "test_check_rep_policies_before_start_time", now.Add(time.Hour))
+	defer deletePolicyAndJobs(t, policyID)
+
+	checkRepPolicies(now)
+	if n := countJobs(t, policyID); n != 0 {
+		t.Errorf("unexpected number of jobs before the schedule starts: %d != %d", n, 0)
+	}
+}
+
+func TestSyncPolicy(t *testing.T) {
+	policyID := addScheduledPolicy(t, "test_sync_policy", time.Now())
+	defer deletePolicyAndJobs(t, policyID)
+
+	policy, err := dao.GetRepPolicy(policyID)
+	if err != nil {
+		t.Fatalf("failed to get policy %d: %v", policyID, err)
+	}
+
+	// a manual sync is queued above the scheduled ones
+	requestID := "test-sync-policy"
+	if err = SyncPolicy(policy, models.RepJobPriorityNormal, requestID); err != nil {
+		t.Fatalf("failed to sync policy %d: %v", policyID, err)
+	}
+
+	jobs, err := dao.GetRepJobByPolicy(policyID)
+	if err != nil {
+		t.Fatalf("failed to get jobs of policy %d: %v", policyID, err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("unexpected number of jobs: %d != %d", len(jobs), 2)
+	}
+
+	// key: repository, value: tags
+	expected := map[string]string{
+		"library/a": "",
+		"library/b": "v1",
+	}
+	for _, job := range jobs {
+		tags, ok := expected[job.Repository]
+		if !ok {
+			t.Errorf("unexpected job for repository %s", job.Repository)
+			continue
+		}
+		if job.Tags != tags {
+			t.Errorf("unexpected tags of job for %s: %s != %s", job.Repository, job.Tags, tags)
+		}
+		if job.Operation != models.RepOpTransfer || job.Status != models.JobPending ||
+			job.Priority != models.RepJobPriorityNormal || job.RequestID != requestID {
+			t.Errorf("unexpected job for %s: operation: %s, status: %s, priority: %d, request ID: %s",
+				job.Repository, job.Operation, job.Status, job.Priority, job.RequestID)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/cron"
+	"github.com/vmware/harbor/src/common/utils/log"
+	registry_error "github.com/vmware/harbor/src/common/utils/registry/error"
+	"github.com/vmware/harbor/src/jobservice/config"
+	"github.com/vmware/harbor/src/jobservice/retention"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// retentionQueue runs the retention jobs one at a time, as the jobs of different projects
+// share the registry
+var retentionQueue = newJobQueue(dao.RetentionJobTable, 1, runRetentionJob)
+
+// ScheduleRetention tells the queue that the retention job has been added to DB, the job is
+// run in background once it's claimed
+func ScheduleRetention(jobID int64) {
+	retentionQueue.schedule(jobID)
+}
+
+func runRetentionJob(jobID int64) {
+	logger := utils.NewRetentionLogger(jobID)
+
+	job, err := dao.GetRetentionJob(jobID)
+	if err != nil {
+		logger.Errorf("failed to get retention job %d: %v", jobID, err)
+		return
+	}
+	if job == nil {
+		logger.Errorf("retention job %d not found", jobID)
+		return
+	}
+
+	status := models.JobFinished
+	tags, err := enforceRetention(job, logger)
+	if err != nil {
+		logger.Errorf("an error occurred while enforcing retention policy %d: %v", job.PolicyID, err)
+		status = models.JobError
+	}
+
+	if err = dao.UpdateRetentionJobResult(jobID, tags); err != nil {
+		logger.Errorf("failed to update result of retention job %d: %v", jobID, err)
+	}
+
+	if err = dao.UpdateRetentionJobStatus(jobID, status); err != nil {
+		logger.Errorf("failed to update status of retention job %d: %v", jobID, err)
+	}
+	countCompletedJob(jobTypeRetention, status)
+}
+
+// enforceRetention deletes the tags selected by the policy from every repository of the project,
+// it returns the tags deleted, or to be deleted if the job is a dry run. The error of one repository
+// does not stop the job, the first one is returned after all repositories are handled.
+func enforceRetention(job *models.RetentionJob, logger *log.Logger) (map[string][]string, error) {
+	result := map[string][]string{}
+
+	policy, err := dao.GetRetentionPolicy(job.PolicyID)
+	if err != nil {
+		return result, err
+	}
+	if policy == nil {
+		return result, fmt.Errorf("retention policy %d not found", job.PolicyID)
+	}
+
+	project, err := dao.GetProjectByID(policy.ProjectID)
+	if err != nil {
+		return result, err
+	}
+	if project == nil {
+		return result, fmt.Errorf("project %d not found", policy.ProjectID)
+	}
+
+	// the deletions are recorded in access log as done by the creator of the policy, the
+	// tags are still deleted if the creator can't be found, e.g. the user has been removed
+	username, err := getRetentionPolicyCreator(policy)
+	if err != nil {
+		logger.Errorf("failed to get the creator of retention policy %d: %v", policy.ID, err)
+	}
+	if len(username) == 0 {
+		logger.Warningf("the creator %d of retention policy %d not found, the tags deleted will not be recorded in access log",
+			policy.CreatorID, policy.ID)
+	}
+
+	repositories, err := utils.GetRepoList(policy.ProjectID)
+	if err != nil {
+		return result, err
+	}
+
+	logger.Infof("enforcing retention policy %d on project %s, keep latest: %d, keep pattern: %s, max age days: %d, dry run: %v",
+		policy.ID, project.Name, policy.KeepLatest, policy.KeepPattern, policy.MaxAgeDays, job.DryRun == 1)
+
+	var firstErr error
+	for _, repository := range repositories {
+		deleted, err := enforceRetentionOnRepository(policy, repository, project.Name,
+			username, job.DryRun == 1, logger)
+		if len(deleted) != 0 {
+			result[repository] = deleted
+		}
+		if err != nil {
+			logger.Errorf("an error occurred while enforcing retention policy on %s: %v", repository, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return result, firstErr
+}
+
+// getRetentionPolicyCreator returns the name of the user who created the policy, it's empty
+// if the user has been removed
+func getRetentionPolicyCreator(policy *models.RetentionPolicy) (string, error) {
+	// the query returns any user if the ID is not set
+	if policy.CreatorID == 0 {
+		return "", nil
+	}
+	user, err := dao.GetUser(models.User{UserID: policy.CreatorID})
+	if err != nil || user == nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+func enforceRetentionOnRepository(policy *models.RetentionPolicy, repository, projectName,
+	username string, dryRun bool, logger *log.Logger) ([]string, error) {
+	client, err := retention.NewRepositoryClient(config.LocalRegURL(), !config.VerifyRemoteCert(),
+		config.UISecret(), repository)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := retention.ListTags(client)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := retention.Select(policy, tags, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		logger.Infof("tags of %s to be deleted: %v", repository, selected)
+		return selected, nil
+	}
+
+	deleted := []string{}
+	for _, tag := range selected {
+		// the tag may have been removed along with another tag sharing the same manifest
+		if err = client.DeleteTag(tag); err != nil {
+			if regErr, ok := err.(*registry_error.Error); !ok || regErr.StatusCode != http.StatusNotFound {
+				break
+			}
+			err = nil
+		}
+		logger.Infof("tag %s:%s deleted", repository, tag)
+		deleted = append(deleted, tag)
+
+		if len(username) != 0 {
+			if err := dao.AccessLog(username, projectName, repository, tag, "delete"); err != nil {
+				logger.Errorf("failed to add access log: %v", err)
+			}
+		}
+	}
+
+	if len(deleted) != 0 {
+		triggerReplicationByRepository(policy.ProjectID, repository, deleted, models.RepOpDelete, logger)
+	}
+
+	if err != nil {
+		return deleted, err
+	}
+
+	if len(deleted) == len(tags) {
+		if err = dao.DeleteRepository(repository); err != nil {
+			return deleted, err
+		}
+		logger.Infof("all tags of %s are deleted, the repository is removed", repository)
+	}
+
+	return deleted, nil
+}
+
+// triggerReplicationByRepository creates jobs for the enabled replication policies
+// of the project to replicate the operation on tags of the repository
+func triggerReplicationByRepository(projectID int64, repository string, tags []string,
+	operation string, logger *log.Logger) {
+	policies, err := dao.GetRepPolicyByProject(projectID)
+	if err != nil {
+		logger.Errorf("failed to get replication policies of project %d: %v", projectID, err)
+		return
+	}
+
+	for _, policy := range policies {
+		// the images of pull policies are replicated from the target
+		if policy.Enabled == 0 || policy.IsPull() || !policy.MatchRepository(repository) {
+			continue
+		}
+		matched := []string{}
+		for _, tag := range tags {
+			if policy.MatchTag(tag) {
+				matched = append(matched, tag)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		id, err := dao.AddRepJob(models.RepJob{
+			Repository: repository,
+			PolicyID:   policy.ID,
+			Operation:  operation,
+			TagList:    matched,
+			Priority:   models.RepJobPriorityNormal,
+		})
+		if err != nil {
+			logger.Errorf("failed to add replication job of policy %d for %s: %v", policy.ID, repository, err)
+			continue
+		}
+		logger.Infof("replication job %d of policy %d for %s triggered", id, policy.ID, repository)
+		Schedule(id)
+	}
+}
+
+// checkRetentionPolicies starts a retention job for every scheduled retention
+// policy whose run time has arrived
+func checkRetentionPolicies(now time.Time) {
+	policies, err := dao.GetScheduledRetentionPolicies()
+	if err != nil {
+		log.Errorf("failed to get scheduled retention policies: %v", err)
+		return
+	}
+
+	for _, policy := range policies {
+		lastRun := policy.UpdateTime
+		if policy.LastScheduledTime.After(lastRun) {
+			lastRun = policy.LastScheduledTime
+		}
+		t, err := dao.GetRetentionPolicyLastRunTime(policy.ID)
+		if err != nil {
+			log.Errorf("failed to get the last run time of retention policy %d: %v", policy.ID, err)
+			continue
+		}
+		if t != nil && t.After(lastRun) {
+			lastRun = *t
+		}
+
+		schedule, err := cron.Parse(policy.CronStr)
+		if err != nil {
+			log.Errorf("invalid cron string of retention policy %d: %s, error: %v", policy.ID, policy.CronStr, err)
+			continue
+		}
+
+		next := schedule.Next(lastRun)
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		ok, err := dao.ClaimRetentionPolicyScheduledRun(policy.ID, next, now)
+		if err != nil {
+			log.Errorf("failed to claim the scheduled run of retention policy %d: %v", policy.ID, err)
+			continue
+		}
+		// triggered by another instance
+		if !ok {
+			continue
+		}
+
+		id, err := dao.AddRetentionJob(models.RetentionJob{
+			PolicyID: policy.ID,
+		})
+		if err != nil {
+			log.Errorf("failed to add retention job for policy %d: %v", policy.ID, err)
+			continue
+		}
+		log.Infof("retention policy %d was scheduled to run at %v, job %d queued", policy.ID, next, id)
+		ScheduleRetention(id)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestGetRetentionPolicyCreator(t *testing.T) {
+	cases := []struct {
+		creatorID int
+		expected  string
+	}{
+		{1, "admin"},
+		// the policy created before the creator is recorded
+		{0, ""},
+		// the user has been removed
+		{10000, ""},
+	}
+
+	for _, c := range cases {
+		username, err := getRetentionPolicyCreator(&models.RetentionPolicy{CreatorID: c.creatorID})
+		if err != nil {
+			t.Errorf("failed to get the creator %d: %v", c.creatorID, err)
+			continue
+		}
+		if username != c.expected {
+			t.Errorf("unexpected creator of %d: %s != %s", c.creatorID, username, c.expected)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/config"
+	"github.com/vmware/harbor/src/jobservice/retention"
+	"github.com/vmware/harbor/src/jobservice/scan"
+	"github.com/vmware/harbor/src/jobservice/utils"
+)
+
+// scanQueue limits the number of scan jobs running concurrently, as a push of many images
+// to the projects scanning on push triggers a job for each of them
+var scanQueue = newJobQueue(dao.ScanJobTable, config.MaxJobWorkers(), runScanJob)
+
+// ScheduleScan tells the queue that the scan job has been added to DB, the job is run in
+// background once it's claimed
+func ScheduleScan(jobID int64) {
+	scanQueue.schedule(jobID)
+}
+
+func runScanJob(jobID int64) {
+	logger := utils.NewScanLogger(jobID)
+
+	job, err := dao.GetScanJob(jobID)
+	if err != nil {
+		logger.Errorf("failed to get scan job %d: %v", jobID, err)
+		return
+	}
+	if job == nil {
+		logger.Errorf("scan job %d not found", jobID)
+		return
+	}
+
+	status := models.JobFinished
+	if err = scanImage(job, logger); err != nil {
+		logger.Errorf("an error occurred while scanning %s:%s: %v", job.Repository, job.Tag, err)
+		status = models.JobError
+	}
+
+	if err = dao.UpdateScanJobStatus(jobID, status); err != nil {
+		logger.Errorf("failed to update status of scan job %d: %v", jobID, err)
+	}
+	countCompletedJob(jobTypeScan, status)
+}
+
+// scanImage scans the tag with the configured scanner and stores the result for
+// the digest of the manifest
+func scanImage(job *models.ScanJob, logger *log.Logger) error {
+	scanner, err := scan.NewScanner(config.Scanner(), config.ScanFeedPath())
+	if err != nil {
+		return err
+	}
+
+	client, err := retention.NewRepositoryClient(config.LocalRegURL(), !config.VerifyRemoteCert(),
+		config.UISecret(), job.Repository)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("scanning %s:%s with scanner %s", job.Repository, job.Tag, scanner.Name())
+
+	digest, vulnerabilities, err := scan.Image(client, job.Tag, scanner)
+	if err != nil {
+		return err
+	}
+
+	if err = dao.UpdateScanJobDigest(job.ID, digest); err != nil {
+		return err
+	}
+
+	result := &models.ScanResult{
+		Digest:          digest,
+		JobID:           job.ID,
+		Scanner:         scanner.Name(),
+		Vulnerabilities: vulnerabilities,
+	}
+	if err = dao.SaveScanResult(result); err != nil {
+		return err
+	}
+
+	logger.Infof("%d vulnerabilities found in %s:%s (%s), the highest severity: %s",
+		len(vulnerabilities), job.Repository, job.Tag, digest, result.Severity)
+	return nil
+}
@@ -16,21 +16,153 @@
 package job
 
 import (
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/jobservice/config"
 	"github.com/vmware/harbor/src/common/utils/log"
+	"sync"
 	"time"
 )
 
-var jobQueue = make(chan int64)
+const (
+	// the lease of a claimed job, it's renewed by the heartbeats of the instance while the
+	// job is running, so the job is claimed again only if the instance is down
+	jobLease = 2 * time.Minute
+	// the interval of the heartbeats, which renew the leases of the running jobs and stop
+	// the ones requested to be stopped
+	heartbeatInterval = 10 * time.Second
+	// the instances which haven't sent heartbeats for this period are removed from DB
+	instanceRetention = 24 * time.Hour
+	// the interval to check the queue when no job is scheduled, so the retrying jobs are
+	// claimed once their schedule time arrives
+	queueCheckInterval = 10 * time.Second
+	// the delay before a failed job is retried
+	retryDelay = 5 * time.Minute
+)
+
+// scheduled wakes up the dispatcher once a job is queued
+var scheduled = make(chan struct{}, 1)
 
-// Schedule put a job id into job queue.
+// Schedule tells the dispatcher that the job has been queued in DB, it never blocks as the
+// job is claimed by the dispatcher from DB when a worker is free.
 func Schedule(jobID int64) {
-	jobQueue <- jobID
+	log.Debugf("Job %d is queued", jobID)
+	select {
+	case scheduled <- struct{}{}:
+	default:
+	}
+}
+
+// claimJob blocks until a queued job is claimed, the jobs with higher priority are claimed first
+func claimJob() int64 {
+	for {
+		job, err := dao.ClaimRepJob(config.InstanceID(), time.Now(), jobLease)
+		if err != nil {
+			log.Errorf("Failed to claim job from queue, error: %v", err)
+		} else if job != nil {
+			log.Debugf("Job %d with priority %d is claimed", job.ID, job.Priority)
+			return job.ID
+		}
+		select {
+		case <-scheduled:
+		case <-time.After(queueCheckInterval):
+		}
+	}
+}
+
+// jobQueue runs the jobs of a kind other than replication, i.e. garbage collection, retention
+// and scan, which are queued in a table in DB. The jobs are claimed with leases like the
+// replication jobs, so a job is run by only one job service instance, and the jobs claimed by
+// an instance which is down are claimed by others once their leases expire.
+type jobQueue struct {
+	table string
+	run   func(jobID int64)
+	// limits the number of jobs running concurrently in this instance
+	slots chan struct{}
+	// wakes up the dispatcher once a job is queued
+	scheduled chan struct{}
+	// the jobs being run by this instance, whose leases are renewed by the heartbeats
+	running map[int64]bool
+	lock    sync.Mutex
+}
+
+func newJobQueue(table string, concurrency int, run func(jobID int64)) *jobQueue {
+	return &jobQueue{
+		table:     table,
+		run:       run,
+		slots:     make(chan struct{}, concurrency),
+		scheduled: make(chan struct{}, 1),
+		running:   make(map[int64]bool),
+	}
+}
+
+// jobQueues are started with the job service and renewed by the heartbeats
+var jobQueues = []*jobQueue{gcQueue, retentionQueue, scanQueue}
+
+// StartJobQueues resets the jobs of garbage collection, retention and scan claimed by this
+// instance before it restarted and starts the dispatchers of their queues
+func StartJobQueues() {
+	for _, q := range jobQueues {
+		if err := dao.ResetLeasedJobs(q.table, config.InstanceID()); err != nil {
+			log.Warningf("Failed to reset running jobs in %s of instance %s to pending, error: %v",
+				q.table, config.InstanceID(), err)
+		}
+		go q.dispatch()
+	}
+}
+
+// schedule tells the dispatcher that the job has been queued in DB, it never blocks
+func (q *jobQueue) schedule(jobID int64) {
+	log.Debugf("Job %d in %s is queued", jobID, q.table)
+	select {
+	case q.scheduled <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch waits for a free slot, claims a job from the queue in DB and runs it in background
+func (q *jobQueue) dispatch() {
+	for {
+		q.slots <- struct{}{}
+		jobID := q.claim()
+		q.lock.Lock()
+		q.running[jobID] = true
+		q.lock.Unlock()
+		go func() {
+			defer func() {
+				q.lock.Lock()
+				delete(q.running, jobID)
+				q.lock.Unlock()
+				<-q.slots
+			}()
+			q.run(jobID)
+		}()
+	}
+}
+
+// claim blocks until a queued job is claimed, the earliest jobs are claimed first
+func (q *jobQueue) claim() int64 {
+	for {
+		jobID, err := dao.ClaimJob(q.table, config.InstanceID(), time.Now(), jobLease)
+		if err != nil {
+			log.Errorf("Failed to claim job from %s, error: %v", q.table, err)
+		} else if jobID != 0 {
+			log.Debugf("Job %d in %s is claimed", jobID, q.table)
+			return jobID
+		}
+		select {
+		case <-q.scheduled:
+		case <-time.After(queueCheckInterval):
+		}
+	}
 }
 
-// Reschedule is called by statemachine to retry a job
-func Reschedule(jobID int64) {
-	log.Debugf("Job %d will be rescheduled in 5 minutes", jobID)
-	time.Sleep(5 * time.Minute)
-	log.Debugf("Rescheduling job %d", jobID)
-	Schedule(jobID)
+// runningJobs returns the IDs of the jobs being run by this instance
+func (q *jobQueue) runningJobs() []int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var jobs []int64
+	for id := range q.running {
+		jobs = append(jobs, id)
+	}
+	return jobs
 }
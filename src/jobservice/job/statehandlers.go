@@ -46,10 +46,13 @@ func (su StatusUpdater) Enter() (string, error) {
 	err := dao.UpdateRepJobStatus(su.JobID, su.State)
 	if err != nil {
 		log.Warningf("Failed to update state of job: %d, state: %s, error: %v", su.JobID, su.State, err)
+	} else {
+		go publishReplicationEvent(su.JobID, su.State)
 	}
 	var next = models.JobContinue
 	if su.State == models.JobStopped || su.State == models.JobError || su.State == models.JobFinished {
 		next = ""
+		countCompletedJob(jobTypeReplication, su.State)
 	}
 	return next, err
 }
@@ -59,19 +62,18 @@ func (su StatusUpdater) Exit() error {
 	return nil
 }
 
-// Retry handles a special "retrying" in which case it will update the status in DB and reschedule the job
-// via scheduler
+// Retry handles a special "retrying" in which case it will put the job back into the queue in DB,
+// the job will be claimed again after retryDelay
 type Retry struct {
 	JobID int64
 }
 
 // Enter ...
 func (jr Retry) Enter() (string, error) {
-	err := dao.UpdateRepJobStatus(jr.JobID, models.JobRetrying)
+	err := dao.RetryRepJob(jr.JobID, time.Now().Add(retryDelay))
 	if err != nil {
 		log.Errorf("Failed to update state of job :%d to Retrying, error: %v", jr.JobID, err)
 	}
-	go Reschedule(jr.JobID)
 	return "", err
 }
 
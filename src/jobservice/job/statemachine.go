@@ -34,11 +34,18 @@ type RepJobParm struct {
 	TargetURL      string
 	TargetUsername string
 	TargetPassword string
+	TargetDriver   replication.TargetDriver
 	Repository     string
 	Tags           []string
 	Enabled        int
-	Operation      string
-	Insecure       bool
+	// whether the images are pulled from the target, LocalRepository is the
+	// repository in the project of the policy they are pulled into
+	Pull            bool
+	LocalRepository string
+	// filters the tags of the repository when no tag is specified
+	TagFilter func(tag string) (bool, error)
+	Operation string
+	Insecure  bool
 }
 
 // SM is the state machine to handle job, it handles one job at a time.
@@ -54,6 +61,8 @@ type SM struct {
 	Logger       *log.Logger
 	Parms        *RepJobParm
 	lock         *sync.Mutex
+	// the blob upload sessions of the job, loaded from DB when the job is started
+	uploads *replication.UploadSessions
 }
 
 // EnterState transit the statemachine from the current state to the state in parameter.
@@ -122,6 +131,12 @@ func (sm *SM) Start(s string) {
 		log.Warningf("Job id: %d, the statemachin will enter error state due to error: %v", sm.JobID, err)
 		sm.EnterState(models.JobError)
 	}
+	// the upload sessions are kept only when the job will be rescheduled
+	if sm.CurrentState != models.JobRetrying {
+		if err := dao.UpdateRepJobUploadSessions(sm.JobID, ""); err != nil {
+			log.Errorf("Job id: %d, failed to clear the upload sessions: %v", sm.JobID, err)
+		}
+	}
 }
 
 // AddTransition add a transition to the transition table of state machine, the handler is the handler of target state "to"
@@ -191,7 +206,6 @@ func (sm *SM) Reset(jid int64) error {
 	sm.desiredState = ""
 	sm.lock.Unlock()
 
-	sm.Logger = utils.NewLogger(sm.JobID)
 	//init parms
 	job, err := dao.GetRepJob(sm.JobID)
 	if err != nil {
@@ -200,6 +214,53 @@ func (sm *SM) Reset(jid int64) error {
 	if job == nil {
 		return fmt.Errorf("The job doesn't exist in DB, job id: %d", sm.JobID)
 	}
+	sm.Logger = utils.NewLogger(sm.JobID, job.RequestID)
+	store := func(data string) error {
+		return dao.UpdateRepJobUploadSessions(jid, data)
+	}
+	sm.uploads, err = replication.LoadUploadSessions(job.UploadSessions, store)
+	if err != nil {
+		sm.Logger.Warningf("failed to load the upload sessions, the blobs will be uploaded from the beginning: %v", err)
+		sm.uploads = replication.NewUploadSessions(store)
+	}
+	if job.Operation == models.RepOpProxy {
+		err = sm.initProxyParms(job)
+	} else {
+		err = sm.initReplicationParms(job)
+	}
+	if err != nil {
+		return err
+	}
+	if sm.Parms.Enabled == 0 {
+		//worker will cancel this job
+		return nil
+	}
+
+	//init states handlers
+	sm.Handlers = make(map[string]StateHandler)
+	sm.Transitions = make(map[string]map[string]struct{})
+	sm.CurrentState = models.JobPending
+
+	sm.AddTransition(models.JobPending, models.JobRunning, StatusUpdater{sm.JobID, models.JobRunning})
+	sm.AddTransition(models.JobRetrying, models.JobRunning, StatusUpdater{sm.JobID, models.JobRunning})
+	sm.Handlers[models.JobError] = StatusUpdater{sm.JobID, models.JobError}
+	sm.Handlers[models.JobStopped] = StatusUpdater{sm.JobID, models.JobStopped}
+	sm.Handlers[models.JobRetrying] = Retry{sm.JobID}
+
+	switch sm.Parms.Operation {
+	case models.RepOpTransfer, models.RepOpProxy:
+		addImgTransferTransition(sm)
+	case models.RepOpDelete:
+		addImgDeleteTransition(sm)
+	default:
+		err = fmt.Errorf("unsupported operation: %s", sm.Parms.Operation)
+	}
+
+	return err
+}
+
+// initReplicationParms initializes the parms of a job according to its replication policy
+func (sm *SM) initReplicationParms(job *models.RepJob) error {
 	policy, err := dao.GetRepPolicy(job.PolicyID)
 	if err != nil {
 		return fmt.Errorf("Failed to get policy, error: %v", err)
@@ -216,50 +277,98 @@ func (sm *SM) Reset(jid int64) error {
 		Insecure:    !config.VerifyRemoteCert(),
 	}
 	if policy.Enabled == 0 {
-		//worker will cancel this job
 		return nil
 	}
-	target, err := dao.GetRepTarget(policy.TargetID)
+	target, pwd, err := getTarget(policy.TargetID)
 	if err != nil {
-		return fmt.Errorf("Failed to get target, error: %v", err)
-	}
-	if target == nil {
-		return fmt.Errorf("The target doesn't exist in DB, target id: %d", policy.TargetID)
+		return err
 	}
 	sm.Parms.TargetURL = target.URL
 	sm.Parms.TargetUsername = target.Username
-	pwd := target.Password
+	sm.Parms.TargetPassword = pwd
 
-	if len(pwd) != 0 {
-		pwd, err = uti.ReversibleDecrypt(pwd, config.SecretKey())
+	if policy.IsPull() {
+		if sm.Parms.Operation != models.RepOpTransfer {
+			return fmt.Errorf("unsupported operation of pull replication: %s", sm.Parms.Operation)
+		}
+		project, err := dao.GetProjectByID(policy.ProjectID)
 		if err != nil {
-			return fmt.Errorf("failed to decrypt password: %v", err)
+			return fmt.Errorf("Failed to get project, error: %v", err)
+		}
+		if project == nil {
+			return fmt.Errorf("The project doesn't exist in DB, project id: %d", policy.ProjectID)
+		}
+		_, rest := uti.ParseRepository(job.Repository)
+		sm.Parms.Pull = true
+		sm.Parms.LocalRepository = project.Name + "/" + rest
+	} else {
+		sm.Parms.TargetDriver, err = replication.NewTargetDriver(target.Type, target.URL,
+			target.Username, pwd, sm.Parms.Insecure)
+		if err != nil {
+			return err
+		}
+		if policy.HasTagFilter() {
+			sm.Parms.TagFilter = newTagFilter(policy, job.Repository)
 		}
 	}
 
-	sm.Parms.TargetPassword = pwd
+	return nil
+}
 
-	//init states handlers
-	sm.Handlers = make(map[string]StateHandler)
-	sm.Transitions = make(map[string]map[string]struct{})
-	sm.CurrentState = models.JobPending
+// initProxyParms initializes the parms of a job which caches the repository of a proxy
+// cache project, the images are pulled from the repository on the target of the project
+// whose name is the repository without the project.
+func (sm *SM) initProxyParms(job *models.RepJob) error {
+	projectName, rest := uti.ParseRepository(job.Repository)
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		return fmt.Errorf("Failed to get project, error: %v", err)
+	}
+	if project == nil {
+		return fmt.Errorf("The project doesn't exist in DB, project name: %s", projectName)
+	}
+	if !project.IsProxy() {
+		return fmt.Errorf("The project %s is not a proxy cache", projectName)
+	}
+	target, pwd, err := getTarget(project.ProxyTargetID)
+	if err != nil {
+		return err
+	}
 
-	sm.AddTransition(models.JobPending, models.JobRunning, StatusUpdater{sm.JobID, models.JobRunning})
-	sm.AddTransition(models.JobRetrying, models.JobRunning, StatusUpdater{sm.JobID, models.JobRunning})
-	sm.Handlers[models.JobError] = StatusUpdater{sm.JobID, models.JobError}
-	sm.Handlers[models.JobStopped] = StatusUpdater{sm.JobID, models.JobStopped}
-	sm.Handlers[models.JobRetrying] = Retry{sm.JobID}
+	sm.Parms = &RepJobParm{
+		LocalRegURL:     config.LocalRegURL(),
+		TargetURL:       target.URL,
+		TargetUsername:  target.Username,
+		TargetPassword:  pwd,
+		Repository:      rest,
+		Tags:            job.TagList,
+		Enabled:         1,
+		Operation:       job.Operation,
+		Insecure:        !config.VerifyRemoteCert(),
+		Pull:            true,
+		LocalRepository: job.Repository,
+	}
+	return nil
+}
 
-	switch sm.Parms.Operation {
-	case models.RepOpTransfer:
-		addImgTransferTransition(sm)
-	case models.RepOpDelete:
-		addImgDeleteTransition(sm)
-	default:
-		err = fmt.Errorf("unsupported operation: %s", sm.Parms.Operation)
+// getTarget returns the target and its decrypted password
+func getTarget(id int64) (*models.RepTarget, string, error) {
+	target, err := dao.GetRepTarget(id)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to get target, error: %v", err)
+	}
+	if target == nil {
+		return nil, "", fmt.Errorf("The target doesn't exist in DB, target id: %d", id)
 	}
 
-	return err
+	pwd := target.Password
+	if len(pwd) != 0 {
+		pwd, err = uti.ReversibleDecrypt(pwd, config.SecretKey())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decrypt password: %v", err)
+		}
+	}
+	return target, pwd, nil
 }
 
 //for testing onlly
@@ -269,9 +378,23 @@ func addTestTransition(sm *SM) error {
 }
 
 func addImgTransferTransition(sm *SM) {
-	base := replication.InitBaseHandler(sm.Parms.Repository, sm.Parms.LocalRegURL, config.UISecret(),
-		sm.Parms.TargetURL, sm.Parms.TargetUsername, sm.Parms.TargetPassword,
-		sm.Parms.Insecure, sm.Parms.Tags, sm.Logger)
+	var base *replication.BaseHandler
+	if sm.Parms.Pull {
+		base = replication.InitPullBaseHandler(sm.Parms.Repository, sm.Parms.LocalRepository,
+			sm.Parms.LocalRegURL, config.UISecret(),
+			sm.Parms.TargetURL, sm.Parms.TargetUsername, sm.Parms.TargetPassword,
+			sm.Parms.Insecure, sm.Parms.Tags, sm.uploads,
+			config.BlobUploadChunkSize(), config.BlobTransferConcurrency(), sm.Logger)
+	} else {
+		base = replication.InitBaseHandler(sm.Parms.Repository, sm.Parms.LocalRegURL, config.UISecret(),
+			sm.Parms.TargetURL, sm.Parms.TargetUsername, sm.Parms.TargetPassword, sm.Parms.TargetDriver,
+			sm.Parms.Insecure, sm.Parms.Tags, sm.uploads,
+			config.BlobUploadChunkSize(), config.BlobTransferConcurrency(), sm.Logger)
+	}
+
+	if sm.Parms.TagFilter != nil {
+		base.SetTagFilter(sm.Parms.TagFilter)
+	}
 
 	sm.AddTransition(models.JobRunning, replication.StateInitialize, &replication.Initializer{BaseHandler: base})
 	sm.AddTransition(replication.StateInitialize, replication.StateCheck, &replication.Checker{BaseHandler: base})
@@ -282,9 +405,27 @@ func addImgTransferTransition(sm *SM) {
 	sm.AddTransition(replication.StatePushManifest, replication.StatePullManifest, &replication.ManifestPuller{BaseHandler: base})
 }
 
+// newTagFilter returns a filter which checks whether the tag of the repository passes
+// the tag filters of the policy and is pushed by the user specified in the policy
+func newTagFilter(policy *models.RepPolicy, repository string) func(string) (bool, error) {
+	return func(tag string) (bool, error) {
+		if !policy.MatchTag(tag) {
+			return false, nil
+		}
+		if len(policy.PushedBy) == 0 {
+			return true, nil
+		}
+		pusher, err := dao.GetTagPusher(repository, tag)
+		if err != nil {
+			return false, err
+		}
+		return pusher == policy.PushedBy, nil
+	}
+}
+
 func addImgDeleteTransition(sm *SM) {
 	deleter := replication.NewDeleter(sm.Parms.Repository, sm.Parms.Tags, sm.Parms.TargetURL,
-		sm.Parms.TargetUsername, sm.Parms.TargetPassword, sm.Parms.Insecure, sm.Logger)
+		sm.Parms.TargetUsername, sm.Parms.TargetDriver, sm.Parms.Insecure, sm.Logger)
 
 	sm.AddTransition(models.JobRunning, replication.StateDelete, deleter)
 	sm.AddTransition(replication.StateDelete, models.JobFinished, &StatusUpdater{sm.JobID, models.JobFinished})
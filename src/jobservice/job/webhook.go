@@ -0,0 +1,223 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package job
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	uti "github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/webhook"
+	"github.com/vmware/harbor/src/jobservice/config"
+)
+
+const (
+	// the interval to check the webhook deliveries which are due
+	webhookCheckInterval = 10 * time.Second
+	// the max number of deliveries handled in one check
+	webhookBatchSize = 100
+	// the number of deliveries sent concurrently
+	webhookWorkers = 10
+	// the delivery is given up after the attempts fail
+	webhookMaxAttempts = 5
+	// the delay before the first retry, it doubles after every attempt
+	webhookRetryDelay = 30 * time.Second
+	// the max length of error message recorded in DB
+	webhookMaxErrorLen = 512
+	// the lease of a claimed delivery, it must be longer than the timeout of sending it, the
+	// delivery is claimed again once the lease expires if the result is not recorded
+	webhookLease = time.Minute
+)
+
+var webhookClient *http.Client
+
+// StartWebhookDispatcher starts a loop which sends the due webhook deliveries queued by
+// UI and job service. As the deliveries are stored in DB, the ones not sent when the job
+// service was down will be sent once it's started, and every attempt is claimed in DB, so
+// a delivery is sent by only one instance when several job services are running.
+func StartWebhookDispatcher() {
+	webhookClient = webhook.NewClient(!config.VerifyRemoteCert(), 10*time.Second)
+	go func() {
+		for {
+			dispatchWebhookDeliveries(time.Now())
+			time.Sleep(webhookCheckInterval)
+		}
+	}()
+}
+
+// dispatchWebhookDeliveries sends the due deliveries concurrently by a bounded number of
+// workers, so a webhook which is slow to respond doesn't hold up the deliveries to others,
+// and returns when all of them are handled.
+func dispatchWebhookDeliveries(now time.Time) {
+	deliveries, err := dao.GetDueWebhookDeliveries(now, webhookBatchSize)
+	if err != nil {
+		log.Errorf("failed to get due webhook deliveries: %v", err)
+		return
+	}
+
+	// key: webhook ID, the deliveries to the webhooks which fail to be got are left for next check
+	webhooks := map[int64]*models.Webhook{}
+	for _, delivery := range deliveries {
+		if _, ok := webhooks[delivery.WebhookID]; ok {
+			continue
+		}
+		hook, err := dao.GetWebhook(delivery.WebhookID)
+		if err != nil {
+			log.Errorf("failed to get webhook %d: %v", delivery.WebhookID, err)
+			continue
+		}
+		webhooks[delivery.WebhookID] = hook
+	}
+
+	queue := make(chan *models.WebhookDelivery)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < webhookWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range queue {
+				hook, ok := webhooks[delivery.WebhookID]
+				if !ok {
+					continue
+				}
+				attempt(hook, delivery)
+			}
+		}()
+	}
+
+	for _, delivery := range deliveries {
+		queue <- delivery
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// attempt claims the delivery, sends it and records the result in DB
+func attempt(hook *models.Webhook, delivery *models.WebhookDelivery) {
+	ok, err := dao.ClaimWebhookDelivery(delivery.ID, time.Now(), webhookLease)
+	if err != nil {
+		log.Errorf("failed to claim webhook delivery %d: %v", delivery.ID, err)
+		return
+	}
+	// claimed by another instance
+	if !ok {
+		return
+	}
+
+	deliver(hook, delivery)
+
+	if err = dao.UpdateWebhookDelivery(delivery); err != nil {
+		log.Errorf("failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// deliver sends the delivery to the webhook and records the result of the attempt in it
+func deliver(hook *models.Webhook, delivery *models.WebhookDelivery) {
+	if hook == nil {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "the webhook does not exist"
+		return
+	}
+
+	if hook.Enabled == 0 {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "the webhook is disabled"
+		return
+	}
+
+	secret := hook.Secret
+	if len(secret) != 0 {
+		var err error
+		if secret, err = uti.ReversibleDecrypt(secret, config.SecretKey()); err != nil {
+			log.Errorf("failed to decrypt secret of webhook %d: %v", hook.ID, err)
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.LastError = "failed to decrypt the secret"
+			return
+		}
+	}
+
+	delivery.Attempts++
+	code, err := webhook.Send(webhookClient, hook.TargetURL, secret, delivery)
+	delivery.ResponseCode = code
+	if err == nil {
+		log.Debugf("webhook delivery %d sent to %s", delivery.ID, hook.TargetURL)
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		return
+	}
+
+	log.Warningf("attempt %d of webhook delivery %d to %s failed: %v", delivery.Attempts,
+		delivery.ID, hook.TargetURL, err)
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > webhookMaxErrorLen {
+		delivery.LastError = delivery.LastError[:webhookMaxErrorLen]
+	}
+
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptTime = time.Now().Add(webhookRetryDelay << uint(delivery.Attempts-1))
+}
+
+// publishReplicationEvent queues the status change of the replication job
+// for the webhooks of the project the policy belongs to
+func publishReplicationEvent(jobID int64, status string) {
+	job, err := dao.GetRepJob(jobID)
+	if err != nil {
+		log.Errorf("failed to get job %d: %v", jobID, err)
+		return
+	}
+	if job == nil {
+		log.Errorf("job %d not found", jobID)
+		return
+	}
+
+	// the jobs of proxy cache projects don't belong to any policy
+	if job.Operation == models.RepOpProxy {
+		return
+	}
+
+	project, _ := uti.ParseRepository(job.Repository)
+
+	policy, err := dao.GetRepPolicy(job.PolicyID)
+	if err != nil {
+		log.Errorf("failed to get policy %d: %v", job.PolicyID, err)
+		return
+	}
+	if policy == nil {
+		log.Errorf("policy %d not found", job.PolicyID)
+		return
+	}
+
+	if err = webhook.Publish(policy.ProjectID, &webhook.Event{
+		Type:       models.WebhookEventReplication,
+		OccurAt:    time.Now(),
+		Project:    project,
+		Repository: job.Repository,
+		Tag:        job.Tags,
+		JobID:      job.ID,
+		PolicyID:   job.PolicyID,
+		Operation:  job.Operation,
+		Status:     status,
+	}); err != nil {
+		log.Errorf("failed to publish webhook event of job %d: %v", jobID, err)
+	}
+}
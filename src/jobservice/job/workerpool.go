@@ -16,6 +16,9 @@
 package job
 
 import (
+	"sync/atomic"
+	"time"
+
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/jobservice/config"
 	"github.com/vmware/harbor/src/common/models"
@@ -27,16 +30,68 @@ type workerPool struct {
 	workerList []*Worker
 }
 
+// runningJobs returns the IDs of the jobs being handled by the workers
+func (wp *workerPool) runningJobs() []int64 {
+	var jobs []int64
+	for _, w := range wp.workerList {
+		if id := atomic.LoadInt64(&w.jobID); id != 0 {
+			jobs = append(jobs, id)
+		}
+	}
+	return jobs
+}
+
+// heartbeat records the heartbeats of this instance periodically, every heartbeat renews the leases
+// of the running jobs, so they are not claimed by other instances, and stops the jobs requested to
+// be stopped through any instance
+func (wp *workerPool) heartbeat() {
+	for {
+		wp.beat(time.Now())
+		time.Sleep(heartbeatInterval)
+	}
+}
+
+func (wp *workerPool) beat(now time.Time) {
+	id := config.InstanceID()
+	if err := dao.HeartbeatJobServiceInstance(id, now); err != nil {
+		log.Errorf("Failed to record heartbeat of instance %s, error: %v", id, err)
+	}
+	if err := dao.RenewRepJobLeases(id, wp.runningJobs(), now.Add(jobLease)); err != nil {
+		log.Errorf("Failed to renew leases of running jobs, error: %v", err)
+	}
+	for _, q := range jobQueues {
+		if err := dao.RenewJobLeases(q.table, id, q.runningJobs(), now.Add(jobLease)); err != nil {
+			log.Errorf("Failed to renew leases of running jobs in %s, error: %v", q.table, err)
+		}
+	}
+
+	jobs, err := dao.GetStopRequestedRepJobs(id)
+	if err != nil {
+		log.Errorf("Failed to get jobs requested to be stopped, error: %v", err)
+	} else if len(jobs) != 0 {
+		var ids []int64
+		for _, j := range jobs {
+			ids = append(ids, j.ID)
+		}
+		wp.StopJobs(ids)
+	}
+
+	if _, err := dao.DeleteJobServiceInstances(now.Add(-instanceRetention)); err != nil {
+		log.Errorf("Failed to delete stale instances, error: %v", err)
+	}
+}
+
 // WorkerPool is a set of workers each worker is associate to a statemachine for handling jobs.
 // it consists of a channel for free workers and a list to all workers
 var WorkerPool *workerPool
 
-// StopJobs accepts a list of jobs and will try to stop them if any of them is being executed by the worker.
+// StopJobs accepts a list of jobs and will try to stop them if any of them is being executed by the worker,
+// i.e. only the jobs claimed by this instance are stopped.
 func (wp *workerPool) StopJobs(jobs []int64) {
 	log.Debugf("Works working on jobs: %v will be stopped", jobs)
 	for _, id := range jobs {
 		for _, w := range wp.workerList {
-			if w.SM.JobID == id {
+			if atomic.LoadInt64(&w.jobID) == id {
 				log.Debugf("found a worker whose job ID is %d, will try to stop it", id)
 				w.SM.Stop(id)
 			}
@@ -51,6 +106,7 @@ type Worker struct {
 	RepJobs chan int64
 	SM      *SM
 	quit    chan bool
+	jobID   int64 // the job being handled, 0 if the worker is free
 }
 
 // Start is a loop worker gets id from its channel and handle it.
@@ -80,6 +136,8 @@ func (w *Worker) Stop() {
 }
 
 func (w *Worker) handleRepJob(id int64) {
+	atomic.StoreInt64(&w.jobID, id)
+	defer atomic.StoreInt64(&w.jobID, 0)
 	err := w.SM.Reset(id)
 	if err != nil {
 		log.Errorf("Worker %d, failed to re-initialize statemachine for job: %d, error: %v", w.ID, id, err)
@@ -87,11 +145,13 @@ func (w *Worker) handleRepJob(id int64) {
 		if err2 != nil {
 			log.Errorf("Failed to update job status to ERROR, job: %d, error:%v", id, err2)
 		}
+		countCompletedJob(jobTypeReplication, models.JobError)
 		return
 	}
 	if w.SM.Parms.Enabled == 0 {
 		log.Debugf("The policy of job:%d is disabled, will cancel the job", id)
 		_ = dao.UpdateRepJobStatus(id, models.JobCanceled)
+		countCompletedJob(jobTypeReplication, models.JobCanceled)
 		w.SM.Logger.Info("The job has been canceled")
 	} else {
 		w.SM.Start(models.JobRunning)
@@ -122,18 +182,16 @@ func InitWorkerPool() {
 		worker.Start()
 		log.Debugf("worker %d started", worker.ID)
 	}
+	go WorkerPool.heartbeat()
 }
 
-// Dispatch will listen to the jobQueue of job service and try to pick a free worker from the worker pool and assign the job to it.
+// Dispatch waits for a free worker from the worker pool, claims a job from the queue in DB and
+// assigns the job to the worker.
 func Dispatch() {
 	for {
-		select {
-		case job := <-jobQueue:
-			go func(jobID int64) {
-				log.Debugf("Trying to dispatch job: %d", jobID)
-				worker := <-WorkerPool.workerChan
-				worker.RepJobs <- jobID
-			}(job)
-		}
+		worker := <-WorkerPool.workerChan
+		jobID := claimJob()
+		log.Debugf("Dispatching job %d to worker %d", jobID, worker.ID)
+		worker.RepJobs <- jobID
 	}
 }
@@ -18,8 +18,8 @@ package main
 import (
 	"github.com/astaxie/beego"
 	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/jobservice/config"
 	"github.com/vmware/harbor/src/jobservice/job"
-	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
 )
 
@@ -27,24 +27,28 @@ func main() {
 	dao.InitDatabase()
 	initRouters()
 	job.InitWorkerPool()
-	go job.Dispatch()
+	// the running jobs must be reset before the dispatcher starts claiming
 	resumeJobs()
+	go job.Dispatch()
+	job.StartJobQueues()
+	job.StartPolicyScheduler()
+	job.StartWebhookDispatcher()
 	beego.Run()
 }
 
 func resumeJobs() {
 	log.Debugf("Trying to resume halted jobs...")
-	err := dao.ResetRunningJobs()
+	// only the jobs claimed by this instance before it restarted are reset, the ones claimed by
+	// other instances which are down are claimed again once their leases expire
+	err := dao.ResetRunningJobs(config.InstanceID())
 	if err != nil {
-		log.Warningf("Failed to reset all running jobs to pending, error: %v", err)
+		log.Warningf("Failed to reset running jobs of instance %s to pending, error: %v", config.InstanceID(), err)
 	}
-	jobs, err := dao.GetRepJobByStatus(models.JobPending, models.JobRetrying)
-	if err == nil {
-		for _, j := range jobs {
-			log.Debugf("Resuming job: %d", j.ID)
-			job.Schedule(j.ID)
-		}
-	} else {
-		log.Warningf("Failed to jobs to resume, error: %v", err)
+	// the pending and retrying jobs are kept in the queue in DB and claimed by the dispatcher
+	queue, err := dao.GetRepJobQueue()
+	if err != nil {
+		log.Warningf("Failed to get the queue of jobs, error: %v", err)
+		return
 	}
+	log.Debugf("%d jobs to resume", queue.Depth)
 }
@@ -20,13 +20,6 @@ import (
 
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
-	//"github.com/vmware/harbor/src/common/utils/registry"
-	//"github.com/vmware/harbor/src/common/utils/registry/auth"
-	"crypto/tls"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strings"
 )
 
 const (
@@ -43,25 +36,24 @@ type Deleter struct {
 	repository string // prject_name/repo_name
 	tags       []string
 
-	dstURL string // url of target registry
-	dstUsr string // username ...
-	dstPwd string // username ...
+	dstURL    string       // url of target registry
+	dstUsr    string       // username ...
+	dstDriver TargetDriver // deletes the images according to the type of target
 
 	insecure bool
 
-	//dstClient *registry.Repository
-
 	logger *log.Logger
 }
 
 // NewDeleter returns a Deleter
-func NewDeleter(repository string, tags []string, dstURL, dstUsr, dstPwd string, insecure bool, logger *log.Logger) *Deleter {
+func NewDeleter(repository string, tags []string, dstURL, dstUsr string, dstDriver TargetDriver,
+	insecure bool, logger *log.Logger) *Deleter {
 	deleter := &Deleter{
 		repository: repository,
 		tags:       tags,
 		dstURL:     dstURL,
 		dstUsr:     dstUsr,
-		dstPwd:     dstPwd,
+		dstDriver:  dstDriver,
 		insecure:   insecure,
 		logger:     logger,
 	}
@@ -87,12 +79,9 @@ func (d *Deleter) Enter() (string, error) {
 }
 
 func (d *Deleter) enter() (string, error) {
-	url := strings.TrimRight(d.dstURL, "/") + "/api/repositories/"
-
 	// delete repository
 	if len(d.tags) == 0 {
-		u := url + "?repo_name=" + d.repository
-		if err := del(u, d.dstUsr, d.dstPwd, d.insecure); err != nil {
+		if err := d.dstDriver.DeleteRepository(d.repository); err != nil {
 			if err == errNotFound {
 				d.logger.Warningf("repository %s does not exist on %s", d.repository, d.dstURL)
 				return models.JobFinished, nil
@@ -110,10 +99,9 @@ func (d *Deleter) enter() (string, error) {
 
 	// delele tags
 	for _, tag := range d.tags {
-		u := url + "?repo_name=" + d.repository + "&tag=" + tag
-		if err := del(u, d.dstUsr, d.dstPwd, d.insecure); err != nil {
+		if err := d.dstDriver.DeleteTag(d.repository, tag); err != nil {
 			if err == errNotFound {
-				d.logger.Warningf("repository %s does not exist on %s", d.repository, d.dstURL)
+				d.logger.Warningf("repository %s:%s does not exist on %s", d.repository, tag, d.dstURL)
 				continue
 			}
 
@@ -123,79 +111,4 @@ func (d *Deleter) enter() (string, error) {
 		d.logger.Infof("repository %s:%s on %s has been deleted", d.repository, tag, d.dstURL)
 	}
 	return models.JobFinished, nil
-
-	/*
-		// the follow codes can be used for non-harbor repository deletion
-		dstCred := auth.NewBasicAuthCredential(d.dstUsr, d.dstPwd)
-		dstClient, err := newRepositoryClient(d.dstURL, d.insecure, dstCred,
-			d.repository, "repository", d.repository, "pull", "push", "*")
-		if err != nil {
-			d.logger.Errorf("an error occurred while creating destination repository client: %v", err)
-			return "", err
-		}
-
-		d.dstClient = dstClient
-
-		if len(d.tags) == 0 {
-			tags, err := d.dstClient.ListTag()
-			if err != nil {
-				d.logger.Errorf("an error occurred while listing tags of repository %s on %s with user %s: %v", d.repository, d.dstURL, d.dstUsr, err)
-				return "", err
-			}
-
-			d.tags = append(d.tags, tags...)
-		}
-
-		d.logger.Infof("tags %v will be deleted", d.tags)
-
-		for _, tag := range d.tags {
-
-			if err := d.dstClient.DeleteTag(tag); err != nil {
-				d.logger.Errorf("an error occurred while deleting repository %s:%s on %s with user %s: %v", d.repository, tag, d.dstURL, d.dstUsr, err)
-				return "", err
-			}
-
-			d.logger.Infof("repository %s:%s on %s has been deleted", d.repository, tag, d.dstURL)
-		}
-
-		return models.JobFinished, nil
-	*/
-}
-
-func del(url, username, password string, insecure bool) error {
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return err
-	}
-
-	req.SetBasicAuth(username, password)
-
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: insecure,
-			},
-		},
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return nil
-	}
-
-	if resp.StatusCode == http.StatusNotFound {
-		return errNotFound
-	}
-
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	return fmt.Errorf("%d %s", resp.StatusCode, string(b))
 }
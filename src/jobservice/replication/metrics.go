@@ -0,0 +1,48 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	replicatedBlobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "harbor_replication_blobs_total",
+		Help: "The count of the blobs transferred by the replication, by direction (push or pull).",
+	}, []string{"direction"})
+	replicatedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "harbor_replication_bytes_total",
+		Help: "The bytes of the blobs transferred by the replication, by direction (push or pull).",
+	}, []string{"direction"})
+)
+
+func init() {
+	prometheus.MustRegister(replicatedBlobs, replicatedBytes)
+}
+
+// countTransferredBlob counts the blob once its transfer completes, the blob whose
+// upload is resumed is counted only once
+func (b *BaseHandler) countTransferredBlob(size int64) {
+	direction := "push"
+	if b.pull {
+		direction = "pull"
+	}
+	replicatedBlobs.WithLabelValues(direction).Inc()
+	if size > 0 {
+		replicatedBytes.WithLabelValues(direction).Add(float64(size))
+	}
+}
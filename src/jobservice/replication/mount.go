@@ -0,0 +1,78 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"sync"
+)
+
+// the max number of blobs indexed for a project on a target, the index of
+// the project is reset when it is exceeded to limit the memory usage
+const maxIndexedBlobs = 10000
+
+// blobIndex records the repositories on targets which are known to have
+// the blobs, so that a blob can be mounted from one of them rather than
+// uploaded again when it is replicated to another repository of the same project.
+type blobIndex struct {
+	// key: target URL + project, value: map whose key is the digest of blob
+	// and value is the repository having it
+	repositories map[string]map[string]string
+	lock         sync.Mutex
+}
+
+var mountSources = &blobIndex{
+	repositories: make(map[string]map[string]string),
+}
+
+func indexKey(target, project string) string {
+	return target + "/" + project
+}
+
+// add records that the blob exists in the repository of the target
+func (b *blobIndex) add(target, project, digest, repository string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	key := indexKey(target, project)
+	blobs, ok := b.repositories[key]
+	if !ok || len(blobs) >= maxIndexedBlobs {
+		blobs = make(map[string]string)
+		b.repositories[key] = blobs
+	}
+	blobs[digest] = repository
+}
+
+// get returns a repository other than "exclude" which has the blob,
+// an empty string is returned if there is no such repository
+func (b *blobIndex) get(target, project, digest, exclude string) string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	repository := b.repositories[indexKey(target, project)][digest]
+	if repository == exclude {
+		return ""
+	}
+	return repository
+}
+
+// remove removes the blob from the index, it is called when the
+// blob can not be mounted from the recorded repository
+func (b *blobIndex) remove(target, project, digest string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.repositories[indexKey(target, project)], digest)
+}
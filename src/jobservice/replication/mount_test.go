@@ -0,0 +1,47 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"testing"
+)
+
+func TestBlobIndex(t *testing.T) {
+	index := &blobIndex{
+		repositories: make(map[string]map[string]string),
+	}
+	target := "https://harbor.example.com"
+	digest := "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+	index.add(target, "library", digest, "library/ubuntu")
+
+	if r := index.get(target, "library", digest, "library/nginx"); r != "library/ubuntu" {
+		t.Errorf("unexpected repository: %s != %s", r, "library/ubuntu")
+	}
+
+	if r := index.get(target, "library", digest, "library/ubuntu"); len(r) != 0 {
+		t.Errorf("the excluded repository should not be returned: %s", r)
+	}
+
+	if r := index.get(target, "other", digest, "other/nginx"); len(r) != 0 {
+		t.Errorf("the repository of another project should not be returned: %s", r)
+	}
+
+	index.remove(target, "library", digest)
+	if r := index.get(target, "library", digest, "library/nginx"); len(r) != 0 {
+		t.Errorf("the blob should be removed from index, but got: %s", r)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	"github.com/vmware/harbor/src/common/utils/registry/auth"
+	registry_error "github.com/vmware/harbor/src/common/utils/registry/error"
+)
+
+// TargetDriver performs the operations on the replication target which differ
+// between the types of target, the images are pushed through the v2 API of
+// registry for all types.
+type TargetDriver interface {
+	// CreateProject creates the project on the target before the images are pushed,
+	// ErrConflict is returned if the project exists already
+	CreateProject(project string, public int) error
+	// DeleteRepository deletes the repository on the target, errNotFound is returned
+	// if it doesn't exist
+	DeleteRepository(repository string) error
+	// DeleteTag deletes the tag of the repository on the target, errNotFound is returned
+	// if it doesn't exist
+	DeleteTag(repository, tag string) error
+}
+
+// NewTargetDriver returns the driver for the type of target
+func NewTargetDriver(targetType int, url, username, password string, insecure bool) (TargetDriver, error) {
+	switch targetType {
+	case models.RepTargetTypeHarbor:
+		return &harborDriver{
+			url:      strings.TrimRight(url, "/"),
+			username: username,
+			password: password,
+			insecure: insecure,
+		}, nil
+	case models.RepTargetTypeDockerRegistry:
+		return &registryDriver{
+			url:      url,
+			username: username,
+			password: password,
+			insecure: insecure,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported target type: %d", targetType)
+	}
+}
+
+// harborDriver manages the projects and deletes the repositories through the API of Harbor
+type harborDriver struct {
+	url      string
+	username string
+	password string
+	insecure bool
+}
+
+func (h *harborDriver) CreateProject(name string, public int) error {
+	project := struct {
+		ProjectName string `json:"project_name"`
+		Public      int    `json:"public"`
+	}{
+		ProjectName: name,
+		Public:      public,
+	}
+
+	data, err := json.Marshal(project)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", h.url+"/api/projects/", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// version 0.1.1's reponse code is 200
+	if resp.StatusCode == http.StatusCreated ||
+		resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+
+	message, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("failed to create project %s on %s with user %s: %d %s",
+		name, h.url, h.username, resp.StatusCode, string(message))
+}
+
+func (h *harborDriver) DeleteRepository(repository string) error {
+	return h.delete(h.url + "/api/repositories/?repo_name=" + repository)
+}
+
+func (h *harborDriver) DeleteTag(repository, tag string) error {
+	return h.delete(h.url + "/api/repositories/?repo_name=" + repository + "&tag=" + tag)
+}
+
+func (h *harborDriver) delete(url string) error {
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%d %s", resp.StatusCode, string(b))
+}
+
+func (h *harborDriver) do(req *http.Request) (*http.Response, error) {
+	req.SetBasicAuth(h.username, h.password)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: h.insecure,
+			},
+		},
+	}
+
+	return client.Do(req)
+}
+
+// registryDriver works with the plain Docker Distribution, which has no projects,
+// the images are deleted through its v2 API, so the deletion must be enabled in
+// the configuration of the registry.
+type registryDriver struct {
+	url      string
+	username string
+	password string
+	insecure bool
+}
+
+// CreateProject does nothing as the repositories are created when pushed
+func (r *registryDriver) CreateProject(name string, public int) error {
+	return nil
+}
+
+func (r *registryDriver) DeleteRepository(repository string) error {
+	client, err := r.newClient(repository)
+	if err != nil {
+		return err
+	}
+
+	tags, err := client.ListTag()
+	if err != nil {
+		return convertRegistryError(err)
+	}
+
+	// the repository itself can not be deleted through the v2 API, it's
+	// removed by the garbage collection of registry after all tags are deleted
+	for _, tag := range tags {
+		if err = convertRegistryError(client.DeleteTag(tag)); err != nil && err != errNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *registryDriver) DeleteTag(repository, tag string) error {
+	client, err := r.newClient(repository)
+	if err != nil {
+		return err
+	}
+
+	return convertRegistryError(client.DeleteTag(tag))
+}
+
+func (r *registryDriver) newClient(repository string) (*registry.Repository, error) {
+	credential := auth.NewBasicAuthCredential(r.username, r.password)
+	return newRepositoryClient(r.url, r.insecure, credential,
+		repository, "repository", repository, "pull", "push", "*")
+}
+
+// convertRegistryError converts the 404 error returned by registry to errNotFound
+func convertRegistryError(err error) error {
+	if regErr, ok := err.(*registry_error.Error); ok && regErr.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/test"
+)
+
+func TestNewTargetDriver(t *testing.T) {
+	if _, err := NewTargetDriver(models.RepTargetTypeHarbor, "http://harbor", "", "", false); err != nil {
+		t.Errorf("failed to create driver for harbor: %v", err)
+	}
+	if _, err := NewTargetDriver(models.RepTargetTypeDockerRegistry, "http://registry", "", "", false); err != nil {
+		t.Errorf("failed to create driver for docker registry: %v", err)
+	}
+	if _, err := NewTargetDriver(2, "http://unknown", "", "", false); err == nil {
+		t.Error("expected error for unsupported target type")
+	}
+}
+
+func TestHarborDriver(t *testing.T) {
+	server := test.NewServer(
+		&test.RequestHandlerMapping{
+			Method:  "POST",
+			Pattern: "/api/projects/",
+			Handler: test.Handler(&test.Response{
+				StatusCode: http.StatusConflict,
+			}),
+		},
+		&test.RequestHandlerMapping{
+			Method:  "DELETE",
+			Pattern: "/api/repositories/",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("tag") == "latest" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			},
+		})
+	defer server.Close()
+
+	driver, err := NewTargetDriver(models.RepTargetTypeHarbor, server.URL, "admin", "Harbor12345", false)
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+
+	if err = driver.CreateProject("library", 1); err != ErrConflict {
+		t.Errorf("unexpected error: %v, expected: %v", err, ErrConflict)
+	}
+	if err = driver.DeleteTag(testRepository, "latest"); err != nil {
+		t.Errorf("failed to delete tag: %v", err)
+	}
+	if err = driver.DeleteRepository(testRepository); err != errNotFound {
+		t.Errorf("unexpected error: %v, expected: %v", err, errNotFound)
+	}
+}
+
+func TestRegistryDriver(t *testing.T) {
+	username, password := "user", "password"
+	// key: tag, value: digest
+	manifests := map[string]string{
+		"1": fmt.Sprintf("sha256:%064d", 1),
+		"2": fmt.Sprintf("sha256:%064d", 2),
+	}
+	lock := &sync.Mutex{}
+
+	authenticated := func(w http.ResponseWriter, r *http.Request) bool {
+		if u, p, ok := r.BasicAuth(); ok && u == username && p == password {
+			return true
+		}
+		w.Header().Set("Www-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+
+	server := test.NewServer(
+		&test.RequestHandlerMapping{
+			Method:  "GET",
+			Pattern: "/v2/",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				authenticated(w, r)
+			},
+		},
+		&test.RequestHandlerMapping{
+			Method:  "GET",
+			Pattern: fmt.Sprintf("/v2/%s/tags/list", testRepository),
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				if !authenticated(w, r) {
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				tags := []string{}
+				for tag := range manifests {
+					tags = append(tags, `"`+tag+`"`)
+				}
+				fmt.Fprintf(w, `{"name":"%s","tags":[%s]}`, testRepository, strings.Join(tags, ","))
+			},
+		},
+		&test.RequestHandlerMapping{
+			Pattern: fmt.Sprintf("/v2/%s/manifests/", testRepository),
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				if !authenticated(w, r) {
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				reference := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+				switch r.Method {
+				case "HEAD":
+					digest, exist := manifests[reference]
+					if !exist {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Docker-Content-Digest", digest)
+				case "DELETE":
+					for tag, digest := range manifests {
+						if digest == reference {
+							delete(manifests, tag)
+							w.WriteHeader(http.StatusAccepted)
+							return
+						}
+					}
+					w.WriteHeader(http.StatusNotFound)
+				}
+			},
+		})
+	defer server.Close()
+
+	driver, err := NewTargetDriver(models.RepTargetTypeDockerRegistry, server.URL, username, password, false)
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+
+	if err = driver.CreateProject("library", 1); err != nil {
+		t.Errorf("failed to create project: %v", err)
+	}
+
+	if err = driver.DeleteTag(testRepository, "1"); err != nil {
+		t.Fatalf("failed to delete tag: %v", err)
+	}
+	if _, exist := manifests["1"]; exist {
+		t.Errorf("tag 1 should be deleted")
+	}
+
+	if err = driver.DeleteTag(testRepository, "3"); err != errNotFound {
+		t.Errorf("unexpected error: %v, expected: %v", err, errNotFound)
+	}
+
+	if err = driver.DeleteRepository(testRepository); err != nil {
+		t.Fatalf("failed to delete repository: %v", err)
+	}
+	if len(manifests) != 0 {
+		t.Errorf("all tags should be deleted: %v", manifests)
+	}
+}
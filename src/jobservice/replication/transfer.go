@@ -16,14 +16,10 @@
 package replication
 
 import (
-	"bytes"
-	"crypto/tls"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/manifest/schema1"
@@ -55,16 +51,25 @@ var (
 
 // BaseHandler holds informations shared by other state handlers
 type BaseHandler struct {
-	project    string // project_name
-	repository string // prject_name/repo_name
-	tags       []string
+	project       string // project_name of the destination repository
+	repository    string // prject_name/repo_name
+	dstRepository string // name of the repository on destination registry
+	tags          []string
 
-	srcURL    string // url of source registry
-	srcSecret string
+	// whether the images are pulled from the target into the local registry,
+	// i.e. the target is the source registry
+	pull bool
 
-	dstURL string // url of target registry
-	dstUsr string // username ...
-	dstPwd string // password ...
+	srcURL  string // url of source registry
+	srcCred auth.Credential
+
+	dstURL    string // url of target registry
+	dstUsr    string // username ...
+	dstCred   auth.Credential
+	dstDriver TargetDriver // performs the operations depending on the type of target
+
+	// filters the tags listed from the source registry when no tag is specified
+	tagFilter func(tag string) (bool, error)
 
 	insecure bool // whether skip secure check when using https
 
@@ -74,39 +79,98 @@ type BaseHandler struct {
 	manifest distribution.Manifest // manifest of tags[0]
 	digest   string                //digest of tags[0]'s manifest
 	blobs    []string              // blobs need to be transferred for tags[0]
+	children []*childManifest      // manifests referenced by tags[0] if it is a manifest list
+
+	blobsExistence     map[string]bool //key: digest of blob, value: existence
+	blobsExistenceLock sync.Mutex
 
-	blobsExistence map[string]bool //key: digest of blob, value: existence
+	uploads         *UploadSessions // upload sessions of blobs, saved in DB across retries of the job
+	chunkSize       int64           // size of chunks used when pushing blobs
+	blobConcurrency int             // the max number of blobs transferred concurrently
 
 	logger *log.Logger
 }
 
-// InitBaseHandler initializes a BaseHandler.
+// childManifest is a manifest referenced by manifest list
+type childManifest struct {
+	digest    string
+	mediaType string
+	payload   []byte
+}
+
+// InitBaseHandler initializes a BaseHandler which replicates the repository from the local
+// registry to the target.
 func InitBaseHandler(repository, srcURL, srcSecret,
-	dstURL, dstUsr, dstPwd string, insecure bool, tags []string, logger *log.Logger) *BaseHandler {
+	dstURL, dstUsr, dstPwd string, dstDriver TargetDriver, insecure bool, tags []string, uploads *UploadSessions,
+	chunkSize int64, blobConcurrency int, logger *log.Logger) *BaseHandler {
 
-	base := &BaseHandler{
-		repository:     repository,
-		tags:           tags,
-		srcURL:         srcURL,
-		srcSecret:      srcSecret,
-		dstURL:         dstURL,
-		dstUsr:         dstUsr,
-		dstPwd:         dstPwd,
-		insecure:       insecure,
-		blobsExistence: make(map[string]bool, 10),
-		logger:         logger,
-	}
+	base := newBaseHandler(repository, repository, tags, insecure, uploads, chunkSize, blobConcurrency, logger)
+	base.srcURL = srcURL
+	base.srcCred = auth.NewCookieCredential(&http.Cookie{Name: models.UISecretCookie, Value: srcSecret})
+	base.dstURL = dstURL
+	base.dstUsr = dstUsr
+	base.dstCred = auth.NewBasicAuthCredential(dstUsr, dstPwd)
+	base.dstDriver = dstDriver
+
+	return base
+}
+
+// InitPullBaseHandler initializes a BaseHandler which replicates the repository from the
+// target into the local registry, where it's named localRepository.
+func InitPullBaseHandler(repository, localRepository, localURL, localSecret,
+	srcURL, srcUsr, srcPwd string, insecure bool, tags []string, uploads *UploadSessions,
+	chunkSize int64, blobConcurrency int, logger *log.Logger) *BaseHandler {
 
-	base.project = getProjectName(base.repository)
+	base := newBaseHandler(repository, localRepository, tags, insecure, uploads, chunkSize, blobConcurrency, logger)
+	base.pull = true
+	base.srcURL = srcURL
+	base.srcCred = auth.NewBasicAuthCredential(srcUsr, srcPwd)
+	base.dstURL = localURL
+	base.dstCred = auth.NewCookieCredential(&http.Cookie{Name: models.UISecretCookie, Value: localSecret})
 
 	return base
 }
 
+// SetTagFilter sets the filter which the tags listed from the source registry must pass
+// to be replicated, it isn't applied to the tags specified when the handler is initialized.
+func (b *BaseHandler) SetTagFilter(filter func(tag string) (bool, error)) {
+	b.tagFilter = filter
+}
+
+func newBaseHandler(repository, dstRepository string, tags []string, insecure bool,
+	uploads *UploadSessions, chunkSize int64, blobConcurrency int, logger *log.Logger) *BaseHandler {
+	return &BaseHandler{
+		project:         getProjectName(dstRepository),
+		repository:      repository,
+		dstRepository:   dstRepository,
+		tags:            tags,
+		insecure:        insecure,
+		blobsExistence:  make(map[string]bool, 10),
+		uploads:         uploads,
+		chunkSize:       chunkSize,
+		blobConcurrency: blobConcurrency,
+		logger:          logger,
+	}
+}
+
 // Exit ...
 func (b *BaseHandler) Exit() error {
 	return nil
 }
 
+func (b *BaseHandler) getBlobExistence(digest string) (exist, ok bool) {
+	b.blobsExistenceLock.Lock()
+	defer b.blobsExistenceLock.Unlock()
+	exist, ok = b.blobsExistence[digest]
+	return
+}
+
+func (b *BaseHandler) setBlobExistence(digest string, exist bool) {
+	b.blobsExistenceLock.Lock()
+	defer b.blobsExistenceLock.Unlock()
+	b.blobsExistence[digest] = exist
+}
+
 func getProjectName(repository string) string {
 	repository = strings.TrimSpace(repository)
 	repository = strings.TrimRight(repository, "/")
@@ -134,19 +198,21 @@ func (i *Initializer) Enter() (string, error) {
 }
 
 func (i *Initializer) enter() (string, error) {
-	c := &http.Cookie{Name: models.UISecretCookie, Value: i.srcSecret}
-	srcCred := auth.NewCookieCredential(c)
-	srcClient, err := newRepositoryClient(i.srcURL, i.insecure, srcCred,
-		i.repository, "repository", i.repository, "pull", "push", "*")
+	srcActions := []string{"pull", "push", "*"}
+	if i.pull {
+		// only the pull permission is required on the target
+		srcActions = []string{"pull"}
+	}
+	srcClient, err := newRepositoryClient(i.srcURL, i.insecure, i.srcCred,
+		i.repository, "repository", i.repository, srcActions...)
 	if err != nil {
 		i.logger.Errorf("an error occurred while creating source repository client: %v", err)
 		return "", err
 	}
 	i.srcClient = srcClient
 
-	dstCred := auth.NewBasicAuthCredential(i.dstUsr, i.dstPwd)
-	dstClient, err := newRepositoryClient(i.dstURL, i.insecure, dstCred,
-		i.repository, "repository", i.repository, "pull", "push", "*")
+	dstClient, err := newRepositoryClient(i.dstURL, i.insecure, i.dstCred,
+		i.dstRepository, "repository", i.dstRepository, "pull", "push", "*")
 	if err != nil {
 		i.logger.Errorf("an error occurred while creating destination repository client: %v", err)
 		return "", err
@@ -159,15 +225,37 @@ func (i *Initializer) enter() (string, error) {
 			i.logger.Errorf("an error occurred while listing tags for source repository: %v", err)
 			return "", err
 		}
+		if i.tagFilter != nil {
+			if tags, err = i.filterTags(tags); err != nil {
+				i.logger.Errorf("an error occurred while filtering tags: %v", err)
+				return "", err
+			}
+		}
 		i.tags = tags
 	}
 
-	i.logger.Infof("initialization completed: project: %s, repository: %s, tags: %v, source URL: %s, destination URL: %s, insecure: %v, destination user: %s",
-		i.project, i.repository, i.tags, i.srcURL, i.dstURL, i.insecure, i.dstUsr)
+	i.logger.Infof("initialization completed: project: %s, repository: %s, destination repository: %s, tags: %v, source URL: %s, destination URL: %s, insecure: %v, destination user: %s",
+		i.project, i.repository, i.dstRepository, i.tags, i.srcURL, i.dstURL, i.insecure, i.dstUsr)
 
 	return StateCheck, nil
 }
 
+func (i *Initializer) filterTags(tags []string) ([]string, error) {
+	matched := []string{}
+	for _, tag := range tags {
+		ok, err := i.tagFilter(tag)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, tag)
+		} else {
+			i.logger.Infof("tag %s is filtered out", tag)
+		}
+	}
+	return matched, nil
+}
+
 // Checker checks the existence of project and the user's privlege to the project
 type Checker struct {
 	*BaseHandler
@@ -186,15 +274,20 @@ func (c *Checker) Enter() (string, error) {
 }
 
 func (c *Checker) enter() (string, error) {
+	// the images are pulled into the project which the policy belongs to
+	if c.pull {
+		return StatePullManifest, nil
+	}
+
 	project, err := dao.GetProjectByName(c.project)
 	if err != nil {
 		c.logger.Errorf("an error occurred while getting project %s in DB: %v", c.project, err)
 		return "", err
 	}
 
-	err = c.createProject(project.Public)
+	err = c.dstDriver.CreateProject(c.project, project.Public)
 	if err == nil {
-		c.logger.Infof("project %s is created on %s with user %s", c.project, c.dstURL, c.dstUsr)
+		c.logger.Infof("project %s is ready on %s with user %s", c.project, c.dstURL, c.dstUsr)
 		return StatePullManifest, nil
 	}
 
@@ -211,62 +304,6 @@ func (c *Checker) enter() (string, error) {
 	return "", err
 }
 
-func (c *Checker) createProject(public int) error {
-	project := struct {
-		ProjectName string `json:"project_name"`
-		Public      int    `json:"public"`
-	}{
-		ProjectName: c.project,
-		Public:      public,
-	}
-
-	data, err := json.Marshal(project)
-	if err != nil {
-		return err
-	}
-
-	url := strings.TrimRight(c.dstURL, "/") + "/api/projects/"
-	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
-
-	req.SetBasicAuth(c.dstUsr, c.dstPwd)
-
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: c.insecure,
-			},
-		},
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer resp.Body.Close()
-
-	// version 0.1.1's reponse code is 200
-	if resp.StatusCode == http.StatusCreated ||
-		resp.StatusCode == http.StatusOK {
-		return nil
-	}
-
-	if resp.StatusCode == http.StatusConflict {
-		return ErrConflict
-	}
-
-	message, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		c.logger.Errorf("an error occurred while reading message from response: %v", err)
-	}
-
-	return fmt.Errorf("failed to create project %s on %s with user %s: %d %s",
-		c.project, c.dstURL, c.dstUsr, resp.StatusCode, string(message))
-}
-
 // ManifestPuller pulls the manifest of a tag. And if no tag needs to be pulled,
 // the next state that state machine should enter is "finished".
 type ManifestPuller struct {
@@ -294,57 +331,75 @@ func (m *ManifestPuller) enter() (string, error) {
 	name := m.repository
 	tag := m.tags[0]
 
-	acceptMediaTypes := []string{schema1.MediaTypeManifest, schema2.MediaTypeManifest}
-	digest, mediaType, payload, err := m.srcClient.PullManifest(tag, acceptMediaTypes)
+	acceptMediaTypes := []string{schema1.MediaTypeManifest, schema2.MediaTypeManifest, registry.MediaTypeManifestList}
+	digest, manifest, _, err := m.pullManifest(tag, acceptMediaTypes)
 	if err != nil {
 		m.logger.Errorf("an error occurred while pulling manifest of %s:%s from %s: %v", name, tag, m.srcURL, err)
 		return "", err
 	}
 	m.digest = digest
+	m.manifest = manifest
 	m.logger.Infof("manifest of %s:%s pulled successfully from %s: %s", name, tag, m.srcURL, digest)
 
-	if strings.Contains(mediaType, "application/json") {
-		mediaType = schema1.MediaTypeManifest
-	}
-
-	manifest, _, err := registry.UnMarshal(mediaType, payload)
-	if err != nil {
-		m.logger.Errorf("an error occurred while parsing manifest of %s:%s from %s: %v", name, tag, m.srcURL, err)
-		return "", err
+	manifests := []distribution.Manifest{manifest}
+	// the manifests referenced by manifest list are pulled, their blobs
+	// are transferred and they are pushed before the list
+	if list, ok := manifest.(*registry.DeserializedManifestList); ok {
+		manifests = nil
+		for _, descriptor := range list.Manifests {
+			d := descriptor.Digest.String()
+			_, child, payload, err := m.pullManifest(d, []string{descriptor.MediaType})
+			if err != nil {
+				m.logger.Errorf("an error occurred while pulling manifest %s for %s/%s of %s:%s from %s: %v",
+					d, descriptor.Platform.OS, descriptor.Platform.Architecture, name, tag, m.srcURL, err)
+				return "", err
+			}
+			m.logger.Infof("manifest %s for %s/%s of %s:%s pulled successfully from %s",
+				d, descriptor.Platform.OS, descriptor.Platform.Architecture, name, tag, m.srcURL)
+
+			manifests = append(manifests, child)
+			m.children = append(m.children, &childManifest{
+				digest:    d,
+				mediaType: descriptor.MediaType,
+				payload:   payload,
+			})
+		}
 	}
 
-	m.manifest = manifest
-
-	// all blobs(layers and config)
+	// all blobs(layers and config), the manifests in a list may share blobs
 	var blobs []string
-
-	for _, discriptor := range manifest.References() {
-		blobs = append(blobs, discriptor.Digest.String())
-	}
-
-	// config is also need to be transferred if the schema of manifest is v2
-	manifest2, ok := manifest.(*schema2.DeserializedManifest)
-	if ok {
-		blobs = append(blobs, manifest2.Target().Digest.String())
+	set := map[string]struct{}{}
+	for _, mf := range manifests {
+		for _, blob := range blobsOf(mf) {
+			if _, ok := set[blob]; ok {
+				continue
+			}
+			set[blob] = struct{}{}
+			blobs = append(blobs, blob)
+		}
 	}
 
 	m.logger.Infof("all blobs of %s:%s from %s: %v", name, tag, m.srcURL, blobs)
 
 	for _, blob := range blobs {
-		exist, ok := m.blobsExistence[blob]
+		exist, ok := m.getBlobExistence(blob)
 		if !ok {
 			exist, err = m.dstClient.BlobExist(blob)
 			if err != nil {
 				m.logger.Errorf("an error occurred while checking existence of blob %s of %s:%s on %s: %v", blob, name, tag, m.dstURL, err)
 				return "", err
 			}
-			m.blobsExistence[blob] = exist
+			if !exist {
+				exist = m.mountBlob(blob)
+			}
+			m.setBlobExistence(blob, exist)
 		}
 
 		if !exist {
 			m.blobs = append(m.blobs, blob)
 		} else {
 			m.logger.Infof("blob %s of %s:%s already exists in %s", blob, name, tag, m.dstURL)
+			mountSources.add(m.dstURL, m.project, blob, m.dstRepository)
 		}
 	}
 	m.logger.Infof("blobs of %s:%s need to be transferred to %s: %v", name, tag, m.dstURL, m.blobs)
@@ -352,6 +407,65 @@ func (m *ManifestPuller) enter() (string, error) {
 	return StateTransferBlob, nil
 }
 
+// pullManifest pulls the manifest from source registry and parses it
+func (m *ManifestPuller) pullManifest(reference string, acceptMediaTypes []string) (string, distribution.Manifest, []byte, error) {
+	digest, mediaType, payload, err := m.srcClient.PullManifest(reference, acceptMediaTypes)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if strings.Contains(mediaType, "application/json") {
+		mediaType = schema1.MediaTypeManifest
+	}
+
+	manifest, _, err := registry.UnMarshal(mediaType, payload)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return digest, manifest, payload, nil
+}
+
+// blobsOf returns the digests of blobs referenced by the manifest
+func blobsOf(manifest distribution.Manifest) []string {
+	var blobs []string
+	for _, discriptor := range manifest.References() {
+		blobs = append(blobs, discriptor.Digest.String())
+	}
+
+	// config is also need to be transferred if the schema of manifest is v2
+	if manifest2, ok := manifest.(*schema2.DeserializedManifest); ok {
+		blobs = append(blobs, manifest2.Target().Digest.String())
+	}
+
+	return blobs
+}
+
+// mountBlob tries to mount the blob from another repository of the same project on
+// the destination registry which is known to have it. It returns false if there is
+// no such repository or the mount fails, and then the blob will be transferred.
+func (m *ManifestPuller) mountBlob(blob string) bool {
+	from := mountSources.get(m.dstURL, m.project, blob, m.dstRepository)
+	if len(from) == 0 {
+		return false
+	}
+
+	mounted, err := m.dstClient.MountBlob(blob, from)
+	if err != nil {
+		m.logger.Warningf("an error occurred while mounting blob %s from %s on %s, it will be transferred: %v", blob, from, m.dstURL, err)
+		return false
+	}
+
+	if !mounted {
+		m.logger.Infof("blob %s can not be mounted from %s on %s, it will be transferred", blob, from, m.dstURL)
+		mountSources.remove(m.dstURL, m.project, blob)
+		return false
+	}
+
+	m.logger.Infof("blob %s mounted from %s to %s on %s", blob, from, m.dstRepository, m.dstURL)
+	return true
+}
+
 // BlobTransfer transfers blobs of a tag
 type BlobTransfer struct {
 	*BaseHandler
@@ -370,28 +484,91 @@ func (b *BlobTransfer) Enter() (string, error) {
 }
 
 func (b *BlobTransfer) enter() (string, error) {
-	name := b.repository
-	tag := b.tags[0]
+	concurrency := b.blobConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(b.blobs) {
+		concurrency = len(b.blobs)
+	}
+
+	blobs := make(chan string)
+	errs := make(chan error, len(b.blobs))
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for blob := range blobs {
+				if err := b.transfer(blob); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
 	for _, blob := range b.blobs {
-		b.logger.Infof("transferring blob %s of %s:%s to %s ...", blob, name, tag, b.dstURL)
-		size, data, err := b.srcClient.PullBlob(blob)
-		if err != nil {
-			b.logger.Errorf("an error occurred while pulling blob %s of %s:%s from %s: %v", blob, name, tag, b.srcURL, err)
-			return "", err
-		}
-		if data != nil {
-			defer data.Close()
+		// stop dispatching blobs once an error occurs
+		if len(errs) != 0 {
+			break
 		}
-		if err = b.dstClient.PushBlob(blob, size, data); err != nil {
-			b.logger.Errorf("an error occurred while pushing blob %s of %s:%s to %s : %v", blob, name, tag, b.dstURL, err)
-			return "", err
+		blobs <- blob
+	}
+	close(blobs)
+	wg.Wait()
+	close(errs)
+
+	// return the error which makes the job retry if there is one
+	var err error
+	for e := range errs {
+		if err == nil || (!retry(err) && retry(e)) {
+			err = e
 		}
-		b.logger.Infof("blob %s of %s:%s transferred to %s completed", blob, name, tag, b.dstURL)
+	}
+	if err != nil {
+		return "", err
 	}
 
 	return StatePushManifest, nil
 }
 
+// transfer pulls the blob from source registry and pushes it to destination registry
+func (b *BlobTransfer) transfer(blob string) error {
+	name := b.repository
+	tag := b.tags[0]
+	b.logger.Infof("transferring blob %s of %s:%s to %s ...", blob, name, tag, b.dstURL)
+	size, data, err := b.srcClient.PullBlob(blob)
+	if err != nil {
+		b.logger.Errorf("an error occurred while pulling blob %s of %s:%s from %s: %v", blob, name, tag, b.srcURL, err)
+		return err
+	}
+	if data != nil {
+		defer data.Close()
+	}
+	// the session is pushed with a copy and written back under the lock of the sessions,
+	// which are saved after every chunk acknowledged, as other blobs are pushed concurrently
+	upload := b.uploads.get(blob)
+	if len(upload.Location) != 0 {
+		b.logger.Infof("resuming the upload %s of blob %s from offset %d", upload.UUID, blob, upload.Offset)
+	}
+	if err = b.dstClient.PushBlobChunked(blob, size, data, b.chunkSize, &upload,
+		func(session registry.BlobUpload) {
+			if e := b.uploads.update(blob, session); e != nil {
+				b.logger.Errorf("failed to save the upload sessions: %v", e)
+			}
+		}); err != nil {
+		b.logger.Errorf("an error occurred while pushing blob %s of %s:%s to %s, %d bytes acknowledged: %v", blob, name, tag, b.dstURL, upload.Offset, err)
+		return err
+	}
+	b.uploads.remove(blob)
+	b.setBlobExistence(blob, true)
+	b.countTransferredBlob(size)
+	mountSources.add(b.dstURL, b.project, blob, b.dstRepository)
+	b.logger.Infof("blob %s of %s:%s transferred to %s completed", blob, name, tag, b.dstURL)
+
+	return nil
+}
+
 // ManifestPusher pushs the manifest to destination registry
 type ManifestPusher struct {
 	*BaseHandler
@@ -432,10 +609,19 @@ func (m *ManifestPusher) enter() (string, error) {
 			m.manifest = nil
 			m.digest = ""
 			m.blobs = nil
+			m.children = nil
 
 			return StatePullManifest, nil
 		}
 
+		for _, child := range m.children {
+			if _, err = m.dstClient.PushManifest(child.digest, child.mediaType, child.payload); err != nil {
+				m.logger.Errorf("an error occurred while pushing manifest %s of %s:%s to %s : %v", child.digest, name, tag, m.dstURL, err)
+				return "", err
+			}
+			m.logger.Infof("manifest %s of %s:%s has been pushed to %s", child.digest, name, tag, m.dstURL)
+		}
+
 		mediaType, data, err := m.manifest.Payload()
 		if err != nil {
 			m.logger.Errorf("an error occurred while getting payload of manifest for %s:%s : %v", name, tag, err)
@@ -453,6 +639,7 @@ func (m *ManifestPusher) enter() (string, error) {
 	m.manifest = nil
 	m.digest = ""
 	m.blobs = nil
+	m.children = nil
 
 	return StatePullManifest, nil
 }
@@ -462,7 +649,8 @@ func newRepositoryClient(endpoint string, insecure bool, credential auth.Credent
 
 	authorizer := auth.NewStandardTokenAuthorizer(credential, insecure, scopeType, scopeName, scopeActions...)
 
-	store, err := auth.NewAuthorizerStore(endpoint, insecure, authorizer)
+	// the basic authorizer is used by the plain registries with htpasswd
+	store, err := auth.NewAuthorizerStore(endpoint, insecure, authorizer, auth.NewBasicAuthorizer(credential))
 	if err != nil {
 		return nil, err
 	}
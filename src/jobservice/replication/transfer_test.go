@@ -0,0 +1,153 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	"github.com/vmware/harbor/src/common/utils/test"
+)
+
+const testRepository = "library/hello-world"
+
+func TestBlobTransfer(t *testing.T) {
+	blobs := []string{}
+	for i := 0; i < 5; i++ {
+		blobs = append(blobs, fmt.Sprintf("sha256:%064d", i))
+	}
+
+	src := test.NewServer(
+		&test.RequestHandlerMapping{
+			Method:  "GET",
+			Pattern: fmt.Sprintf("/v2/%s/blobs/", testRepository),
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				digest := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+				w.Header().Add(http.CanonicalHeaderKey("Content-Length"), strconv.Itoa(len(digest)))
+				w.Write([]byte(digest))
+			},
+		})
+	defer src.Close()
+
+	// key: location of upload, value: data received
+	uploads := map[string][]byte{}
+	// key: digest of blob, value: data
+	pushed := map[string][]byte{}
+	lock := &sync.Mutex{}
+	count := 0
+	dst := test.NewServer(
+		&test.RequestHandlerMapping{
+			Pattern: fmt.Sprintf("/v2/%s/blobs/uploads/", testRepository),
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				lock.Lock()
+				defer lock.Unlock()
+				switch r.Method {
+				case "POST":
+					count++
+					w.Header().Add(http.CanonicalHeaderKey("Location"),
+						fmt.Sprintf("http://%s/v2/%s/blobs/uploads/%d", r.Host, testRepository, count))
+					w.Header().Add(http.CanonicalHeaderKey("Range"), "0-0")
+					w.WriteHeader(http.StatusAccepted)
+				case "PATCH":
+					b, _ := ioutil.ReadAll(r.Body)
+					uploads[r.URL.Path] = append(uploads[r.URL.Path], b...)
+					w.Header().Add(http.CanonicalHeaderKey("Range"), fmt.Sprintf("0-%d", len(uploads[r.URL.Path])-1))
+					w.WriteHeader(http.StatusAccepted)
+				case "PUT":
+					pushed[r.URL.Query().Get("digest")] = uploads[r.URL.Path]
+					w.WriteHeader(http.StatusCreated)
+				}
+			},
+		})
+
+	srcClient, err := registry.NewRepository(testRepository, src.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("failed to create client for source registry: %v", err)
+	}
+	dstClient, err := registry.NewRepository(testRepository, dst.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("failed to create client for destination registry: %v", err)
+	}
+
+	base := InitBaseHandler(testRepository, src.URL, "", dst.URL, "", "", nil, false,
+		[]string{"latest"}, NewUploadSessions(nil), 1024, 3,
+		log.New(os.Stdout, log.NewTextFormatter(), log.DebugLevel))
+	base.srcClient = srcClient
+	base.dstClient = dstClient
+	base.blobs = blobs
+
+	transfer := &BlobTransfer{BaseHandler: base}
+	state, err := transfer.Enter()
+	if err != nil {
+		t.Fatalf("failed to transfer blobs: %v", err)
+	}
+	if state != StatePushManifest {
+		t.Errorf("unexpected state: %s != %s", state, StatePushManifest)
+	}
+
+	for _, blob := range blobs {
+		if string(pushed[blob]) != blob {
+			t.Errorf("unexpected data of blob %s: %s", blob, string(pushed[blob]))
+		}
+		if exist, _ := base.getBlobExistence(blob); !exist {
+			t.Errorf("blob %s should be marked as existing", blob)
+		}
+	}
+
+	// network errors make the job retry
+	dst.Close()
+	state, err = transfer.Enter()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != models.JobRetrying {
+		t.Errorf("unexpected state: %s != %s", state, models.JobRetrying)
+	}
+}
+
+func TestInitializerFilterTags(t *testing.T) {
+	base := InitBaseHandler(testRepository, "", "", "", "", "", nil, false,
+		nil, NewUploadSessions(nil), 1024, 3,
+		log.New(os.Stdout, log.NewTextFormatter(), log.DebugLevel))
+	base.SetTagFilter(func(tag string) (bool, error) {
+		return strings.HasPrefix(tag, "release-"), nil
+	})
+
+	initializer := &Initializer{BaseHandler: base}
+	tags, err := initializer.filterTags([]string{"latest", "release-1.0", "release-1.1"})
+	if err != nil {
+		t.Fatalf("failed to filter tags: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "release-1.0" || tags[1] != "release-1.1" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+
+	base.SetTagFilter(func(tag string) (bool, error) {
+		return false, fmt.Errorf("failed to get the pusher of %s", tag)
+	})
+	if _, err = initializer.filterTags([]string{"latest"}); err == nil {
+		t.Errorf("an error is expected when the filter fails")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/vmware/harbor/src/common/utils/registry"
+)
+
+// UploadSessions holds the blob upload sessions of a job. The sessions are saved with
+// the job in DB every time a chunk is acknowledged by the registry, so the job rescheduled
+// for retrying, which may be claimed by another job service instance after this one
+// crashes, resumes the interrupted uploads.
+// The sessions are held by value and only updated under the lock, as the blobs of a job
+// are transferred concurrently.
+type UploadSessions struct {
+	sessions map[string]registry.BlobUpload // key: digest of blob
+	store    func(data string) error        // saves the sessions in JSON, can be nil
+	lock     sync.Mutex
+	// serializes the saves, so the sessions encoded later are never overwritten by the
+	// ones encoded earlier
+	saveLock sync.Mutex
+}
+
+// NewUploadSessions returns an empty UploadSessions, the sessions are saved by store
+func NewUploadSessions(store func(data string) error) *UploadSessions {
+	return &UploadSessions{
+		sessions: make(map[string]registry.BlobUpload),
+		store:    store,
+	}
+}
+
+// LoadUploadSessions returns the UploadSessions holding the sessions saved by store before,
+// data is the JSON saved and an empty one is returned if data is empty
+func LoadUploadSessions(data string, store func(data string) error) (*UploadSessions, error) {
+	u := NewUploadSessions(store)
+	if len(data) == 0 {
+		return u, nil
+	}
+	if err := json.Unmarshal([]byte(data), &u.sessions); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// get returns a copy of the session of the blob, it's empty if the session does not exist
+func (u *UploadSessions) get(digest string) registry.BlobUpload {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return u.sessions[digest]
+}
+
+// update records the state of the session of the blob and saves the sessions by the store,
+// it is called when the registry acknowledges the session or a chunk of the blob
+func (u *UploadSessions) update(digest string, session registry.BlobUpload) error {
+	u.lock.Lock()
+	u.sessions[digest] = session
+	u.lock.Unlock()
+
+	return u.save()
+}
+
+// remove removes the session of the blob, it is called when the upload completes
+func (u *UploadSessions) remove(digest string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	delete(u.sessions, digest)
+}
+
+// encode returns the sessions in JSON
+func (u *UploadSessions) encode() (string, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	data, err := json.Marshal(u.sessions)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// save saves the sessions by the store
+func (u *UploadSessions) save() error {
+	if u.store == nil {
+		return nil
+	}
+
+	u.saveLock.Lock()
+	defer u.saveLock.Unlock()
+
+	data, err := u.encode()
+	if err != nil {
+		return err
+	}
+	return u.store(data)
+}
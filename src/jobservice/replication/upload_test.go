@@ -0,0 +1,109 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replication
+
+import (
+	"testing"
+)
+
+func TestUploadSessions(t *testing.T) {
+	digest := "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+	sessions := NewUploadSessions(nil)
+
+	session := sessions.get(digest)
+	session.Location = "http://registry/v2/library/ubuntu/blobs/uploads/uuid"
+	session.Offset = 1024
+
+	// the copy returned doesn't change the session until it is updated
+	if s := sessions.get(digest); len(s.Location) != 0 || s.Offset != 0 {
+		t.Errorf("expected an empty session before updating, but got: %+v", s)
+	}
+
+	if err := sessions.update(digest, session); err != nil {
+		t.Fatalf("failed to update the session: %v", err)
+	}
+	if s := sessions.get(digest); s != session {
+		t.Errorf("unexpected session: %+v != %+v", s, session)
+	}
+
+	sessions.remove(digest)
+	if s := sessions.get(digest); len(s.Location) != 0 || s.Offset != 0 {
+		t.Errorf("expected an empty session, but got: %+v", s)
+	}
+}
+
+func TestLoadUploadSessions(t *testing.T) {
+	digest := "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+	sessions := NewUploadSessions(nil)
+	session := sessions.get(digest)
+	session.Location = "http://registry/v2/library/ubuntu/blobs/uploads/uuid"
+	session.UUID = "uuid"
+	session.Offset = 1024
+	if err := sessions.update(digest, session); err != nil {
+		t.Fatalf("failed to update the session: %v", err)
+	}
+
+	data, err := sessions.encode()
+	if err != nil {
+		t.Fatalf("failed to encode the sessions: %v", err)
+	}
+
+	loaded, err := LoadUploadSessions(data, nil)
+	if err != nil {
+		t.Fatalf("failed to load the sessions: %v", err)
+	}
+	if s := loaded.get(digest); s != session {
+		t.Errorf("unexpected session: %+v != %+v", s, session)
+	}
+
+	loaded, err = LoadUploadSessions("", nil)
+	if err != nil {
+		t.Fatalf("failed to load the sessions: %v", err)
+	}
+	if len(loaded.sessions) != 0 {
+		t.Errorf("expected no session, but got: %v", loaded.sessions)
+	}
+
+	if _, err = LoadUploadSessions("invalid", nil); err == nil {
+		t.Error("expected error for invalid data")
+	}
+}
+
+func TestSaveUploadSessions(t *testing.T) {
+	digest := "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+	saved := ""
+	sessions := NewUploadSessions(func(data string) error {
+		saved = data
+		return nil
+	})
+
+	session := sessions.get(digest)
+	session.Location = "http://registry/v2/library/ubuntu/blobs/uploads/uuid"
+	session.UUID = "uuid"
+	session.Offset = 1024
+	if err := sessions.update(digest, session); err != nil {
+		t.Fatalf("failed to update the session: %v", err)
+	}
+
+	// the session is saved once it is updated
+	loaded, err := LoadUploadSessions(saved, nil)
+	if err != nil {
+		t.Fatalf("failed to load the saved sessions: %v", err)
+	}
+	if s := loaded.get(digest); s != session {
+		t.Errorf("unexpected saved session: %+v != %+v", s, session)
+	}
+}
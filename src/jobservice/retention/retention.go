@@ -0,0 +1,204 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	"github.com/vmware/harbor/src/common/utils/registry/auth"
+)
+
+// Tag is a tag of repository evaluated by the retention policy
+type Tag struct {
+	Name   string
+	Digest string
+	// Created is the creation time of the image the tag refers to
+	Created time.Time
+}
+
+// Select returns the names of the tags which should be deleted according to the policy.
+// A tag is kept if it matches the pattern, or is one of the newest tags, or is not old
+// enough. The tags sharing the digest with a kept tag are kept as well, as deleting them
+// removes the manifest of the kept one.
+func Select(policy *models.RetentionPolicy, tags []*Tag, now time.Time) ([]string, error) {
+	var pattern *regexp.Regexp
+	if len(policy.KeepPattern) != 0 {
+		p, err := regexp.Compile(policy.KeepPattern)
+		if err != nil {
+			return nil, err
+		}
+		pattern = p
+	}
+
+	sorted := make([]*Tag, len(tags))
+	copy(sorted, tags)
+	sort.Sort(byCreated(sorted))
+
+	maxAge := time.Duration(policy.MaxAgeDays) * 24 * time.Hour
+	kept := map[string]struct{}{}
+	candidates := []*Tag{}
+	for i, tag := range sorted {
+		if (pattern != nil && pattern.MatchString(tag.Name)) ||
+			(policy.KeepLatest > 0 && i < policy.KeepLatest) ||
+			(policy.MaxAgeDays > 0 && now.Sub(tag.Created) <= maxAge) {
+			kept[tag.Digest] = struct{}{}
+			continue
+		}
+		candidates = append(candidates, tag)
+	}
+
+	deleted := []string{}
+	for _, tag := range candidates {
+		if _, ok := kept[tag.Digest]; ok {
+			continue
+		}
+		deleted = append(deleted, tag.Name)
+	}
+
+	return deleted, nil
+}
+
+// byCreated sorts the tags from the newest to the oldest
+type byCreated []*Tag
+
+func (b byCreated) Len() int      { return len(b) }
+func (b byCreated) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byCreated) Less(i, j int) bool {
+	if b[i].Created.Equal(b[j].Created) {
+		return b[i].Name > b[j].Name
+	}
+	return b[i].Created.After(b[j].Created)
+}
+
+// ListTags lists the tags of the repository with their digests and creation time
+func ListTags(client *registry.Repository) ([]*Tag, error) {
+	names, err := client.ListTag()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*Tag{}
+	for _, name := range names {
+		digest, created, err := getCreationTime(client, name,
+			[]string{schema1.MediaTypeManifest, schema2.MediaTypeManifest, registry.MediaTypeManifestList})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the creation time of %s:%s: %v", client.Name, name, err)
+		}
+		tags = append(tags, &Tag{
+			Name:    name,
+			Digest:  digest,
+			Created: created,
+		})
+	}
+
+	return tags, nil
+}
+
+// getCreationTime returns the digest of the manifest and the creation time of the image,
+// for manifest list the creation time is the latest one of the images it references
+func getCreationTime(client *registry.Repository, reference string, mediaTypes []string) (string, time.Time, error) {
+	digest, mediaType, payload, err := client.PullManifest(reference, mediaTypes)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if strings.Contains(mediaType, "application/json") {
+		mediaType = schema1.MediaTypeManifest
+	}
+
+	manifest, _, err := registry.UnMarshal(mediaType, payload)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var created time.Time
+	switch m := manifest.(type) {
+	case *registry.DeserializedManifestList:
+		for _, descriptor := range m.Manifests {
+			_, t, err := getCreationTime(client, descriptor.Digest.String(), []string{descriptor.MediaType})
+			if err != nil {
+				return "", time.Time{}, err
+			}
+			if t.After(created) {
+				created = t
+			}
+		}
+	case *schema2.DeserializedManifest:
+		_, data, err := client.PullBlob(m.Target().Digest.String())
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		defer data.Close()
+		b, err := ioutil.ReadAll(data)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if created, err = parseCreated(b); err != nil {
+			return "", time.Time{}, err
+		}
+	case *schema1.SignedManifest:
+		if len(m.History) == 0 {
+			return "", time.Time{}, fmt.Errorf("no history in manifest")
+		}
+		if created, err = parseCreated([]byte(m.History[0].V1Compatibility)); err != nil {
+			return "", time.Time{}, err
+		}
+	default:
+		return "", time.Time{}, fmt.Errorf("unsupported manifest type: %s", mediaType)
+	}
+
+	return digest, created, nil
+}
+
+// parseCreated parses the "created" field of image config or v1 compatibility information
+func parseCreated(b []byte) (time.Time, error) {
+	c := struct {
+		Created time.Time `json:"created"`
+	}{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return time.Time{}, err
+	}
+	return c.Created, nil
+}
+
+// NewRepositoryClient returns a client for the repository on the local registry,
+// the UI secret is used to get the token which grants all access of the repository
+func NewRepositoryClient(endpoint string, insecure bool, secret, repository string) (*registry.Repository, error) {
+	credential := auth.NewCookieCredential(&http.Cookie{
+		Name:  models.UISecretCookie,
+		Value: secret,
+	})
+	authorizer := auth.NewStandardTokenAuthorizer(credential, insecure,
+		"repository", repository, "pull", "push", "*")
+
+	store, err := auth.NewAuthorizerStore(endpoint, insecure, authorizer)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.NewRepositoryWithModifiers(repository, endpoint, insecure, store)
+}
@@ -0,0 +1,87 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package retention
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestSelect(t *testing.T) {
+	now := time.Date(2016, time.December, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tags := []*Tag{
+		{Name: "1", Digest: "d1", Created: now.Add(-40 * day)},
+		{Name: "release-1.0", Digest: "d2", Created: now.Add(-30 * day)},
+		{Name: "2", Digest: "d3", Created: now.Add(-20 * day)},
+		{Name: "3", Digest: "d4", Created: now.Add(-10 * day)},
+		{Name: "latest", Digest: "d5", Created: now.Add(-1 * day)},
+		{Name: "4", Digest: "d5", Created: now.Add(-1 * day)},
+	}
+
+	cases := []struct {
+		policy  *models.RetentionPolicy
+		deleted []string
+	}{
+		// the newest 2 tags: "latest" and "4", which share the digest
+		{&models.RetentionPolicy{KeepLatest: 2}, []string{"1", "2", "3", "release-1.0"}},
+		{&models.RetentionPolicy{KeepLatest: 2, KeepPattern: "^release-"}, []string{"1", "2", "3"}},
+		{&models.RetentionPolicy{MaxAgeDays: 15}, []string{"1", "2", "release-1.0"}},
+		{&models.RetentionPolicy{KeepLatest: 4, MaxAgeDays: 15}, []string{"1", "release-1.0"}},
+		{&models.RetentionPolicy{KeepLatest: 10}, []string{}},
+	}
+
+	for _, c := range cases {
+		deleted, err := Select(c.policy, tags, now)
+		if err != nil {
+			t.Fatalf("failed to select tags: %v", err)
+		}
+		sort.Strings(deleted)
+		if !reflect.DeepEqual(deleted, c.deleted) {
+			t.Errorf("unexpected tags to be deleted by policy %+v: %v != %v", c.policy, deleted, c.deleted)
+		}
+	}
+
+	// the tag sharing digest with a kept one is kept
+	deleted, err := Select(&models.RetentionPolicy{KeepLatest: 1}, tags, now)
+	if err != nil {
+		t.Fatalf("failed to select tags: %v", err)
+	}
+	for _, tag := range deleted {
+		if tag == "4" || tag == "latest" {
+			t.Errorf("tag %s should be kept as it shares the digest with a kept tag", tag)
+		}
+	}
+
+	if _, err = Select(&models.RetentionPolicy{KeepLatest: 1, KeepPattern: "("}, tags, now); err == nil {
+		t.Errorf("expected error for invalid pattern, but got nil")
+	}
+}
+
+func TestParseCreated(t *testing.T) {
+	created, err := parseCreated([]byte(`{"architecture":"amd64","created":"2016-11-30T08:00:00.123456789Z"}`))
+	if err != nil {
+		t.Fatalf("failed to parse created: %v", err)
+	}
+	expected := time.Date(2016, time.November, 30, 8, 0, 0, 123456789, time.UTC)
+	if !created.Equal(expected) {
+		t.Errorf("unexpected creation time: %v != %v", created, expected)
+	}
+}
@@ -16,13 +16,25 @@
 package main
 
 import (
+	commonapi "github.com/vmware/harbor/src/common/api"
 	api "github.com/vmware/harbor/src/jobservice/api"
 
 	"github.com/astaxie/beego"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func initRouters() {
-	beego.Router("/api/jobs/replication", &api.ReplicationJob{})
-	beego.Router("/api/jobs/replication/:id/log", &api.ReplicationJob{}, "get:GetLog")
-	beego.Router("/api/jobs/replication/actions", &api.ReplicationJob{}, "post:HandleAction")
+	commonapi.InsertMetricsFilters()
+	beego.InsertFilter("*", beego.BeforeRouter, commonapi.RequestIDFilter)
+	commonapi.Router("/api/jobs/replication", &api.ReplicationJob{})
+	commonapi.Router("/api/jobs/replication/:id/log", &api.ReplicationJob{}, "get:GetLog")
+	commonapi.Router("/api/jobs/replication/actions", &api.ReplicationJob{}, "post:HandleAction")
+	commonapi.Router("/api/jobs/retention", &api.RetentionJob{})
+	commonapi.Router("/api/jobs/retention/:id/log", &api.RetentionJob{}, "get:GetLog")
+	commonapi.Router("/api/jobs/gc", &api.GCJob{})
+	commonapi.Router("/api/jobs/gc/:id/log", &api.GCJob{}, "get:GetLog")
+	commonapi.Router("/api/jobs/proxy", &api.ProxyJob{})
+	commonapi.Router("/api/jobs/scan", &api.ScanJob{})
+	commonapi.Router("/api/jobs/scan/:id/log", &api.ScanJob{}, "get:GetLog")
+	commonapi.Handler("/metrics", promhttp.Handler())
 }
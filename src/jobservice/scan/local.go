@@ -0,0 +1,338 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package scan
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+const (
+	osDebian = "debian"
+	osAlpine = "alpine"
+
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// the package databases read by the local scanner, key: path in the image, value: OS
+var packageDBs = map[string]string{
+	"var/lib/dpkg/status":  osDebian,
+	"lib/apk/db/installed": osAlpine,
+}
+
+// feed is the offline CVE feed read by the local scanner, a file in JSON
+type feed struct {
+	Vulnerabilities []*feedEntry `json:"vulnerabilities"`
+}
+
+// feedEntry is a vulnerability of a package in the feed
+type feedEntry struct {
+	ID string `json:"id"`
+	// OS is the distribution the package belongs to, "debian" or "alpine", the
+	// entry applies to both if it's empty
+	OS string `json:"os"`
+	// Package is the name of the binary or source package
+	Package string `json:"package"`
+	// FixedVersion is the first version which fixes the vulnerability, all versions
+	// are affected if it's empty
+	FixedVersion string `json:"fixed_version"`
+	Severity     string `json:"severity"`
+	Description  string `json:"description"`
+	Link         string `json:"link"`
+}
+
+// pkg is a package installed in the image
+type pkg struct {
+	Name string
+	// Source is the name of the source package, it's empty if it's the same as the name
+	Source  string
+	Version string
+}
+
+// localScanner reads the dpkg and apk databases in the layers of image and matches
+// the installed packages against the feed, the feed is read in every scan so that
+// it can be updated without restarting job service
+type localScanner struct {
+	feed string
+}
+
+func (l *localScanner) Name() string {
+	return ScannerLocal
+}
+
+func (l *localScanner) Scan(layers []*Layer) ([]*models.Vulnerability, error) {
+	entries, err := loadFeed(l.feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feed %s: %v", l.feed, err)
+	}
+
+	// key: path of the package database, value: the packages in it
+	dbs := map[string][]*pkg{}
+	for _, layer := range layers {
+		if err = readLayer(layer, dbs); err != nil {
+			return nil, fmt.Errorf("failed to read layer %s: %v", layer.Digest, err)
+		}
+	}
+
+	return match(entries, dbs), nil
+}
+
+// loadFeed reads the feed and indexes the entries by package
+func loadFeed(file string) (map[string][]*feedEntry, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feed{}
+	if err = json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+
+	entries := map[string][]*feedEntry{}
+	for _, e := range f.Vulnerabilities {
+		entries[e.Package] = append(entries[e.Package], e)
+	}
+	return entries, nil
+}
+
+// readLayer updates the package databases with the ones added, replaced or deleted
+// in the layer
+func readLayer(layer *Layer, dbs map[string][]*pkg) error {
+	data, err := layer.Open()
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	reader := bufio.NewReader(data)
+	var content io.Reader = reader
+	// the layers are gzipped tar archives in most cases
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		content = gz
+	}
+
+	added := map[string][]*pkg{}
+	tr := tar.NewReader(content)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(name)
+		if base == whiteoutOpaque {
+			deleteDBs(dbs, strings.TrimSuffix(dir, "/"))
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			deleteDBs(dbs, dir+strings.TrimPrefix(base, whiteoutPrefix))
+			continue
+		}
+
+		distro, ok := packageDBs[name]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var pkgs []*pkg
+		switch distro {
+		case osDebian:
+			pkgs, err = parseDpkgStatus(tr)
+		case osAlpine:
+			pkgs, err = parseApkInstalled(tr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		added[name] = pkgs
+	}
+
+	// the whiteouts only delete the files of the lower layers
+	for name, pkgs := range added {
+		dbs[name] = pkgs
+	}
+	return nil
+}
+
+// deleteDBs deletes the package databases which are the file or under the directory
+func deleteDBs(dbs map[string][]*pkg, name string) {
+	for db := range dbs {
+		if len(name) == 0 || db == name || strings.HasPrefix(db, name+"/") {
+			delete(dbs, db)
+		}
+	}
+}
+
+// parseDpkgStatus parses the status file of dpkg, which consists of paragraphs
+// of fields for each package
+func parseDpkgStatus(r io.Reader) ([]*pkg, error) {
+	pkgs := []*pkg{}
+	var p *pkg
+	installed := true
+	flush := func() {
+		if p != nil && len(p.Name) != 0 && len(p.Version) != 0 && installed {
+			pkgs = append(pkgs, p)
+		}
+		p = nil
+		installed = true
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			flush()
+			continue
+		}
+		// continuation of the multiline field
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := line[:i], strings.TrimSpace(line[i+1:])
+		if p == nil {
+			p = &pkg{}
+		}
+		switch key {
+		case "Package":
+			p.Name = value
+		case "Version":
+			p.Version = value
+		case "Source":
+			// the format is "name (version)" if the version differs from the binary package
+			if fields := strings.Fields(value); len(fields) > 0 {
+				p.Source = fields[0]
+			}
+		case "Status":
+			fields := strings.Fields(value)
+			installed = len(fields) > 0 && fields[len(fields)-1] == "installed"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return pkgs, nil
+}
+
+// parseApkInstalled parses the installed database of apk, in which each line
+// is a field of package with a single letter key
+func parseApkInstalled(r io.Reader) ([]*pkg, error) {
+	pkgs := []*pkg{}
+	var p *pkg
+	flush := func() {
+		if p != nil && len(p.Name) != 0 && len(p.Version) != 0 {
+			pkgs = append(pkgs, p)
+		}
+		p = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+
+		if p == nil {
+			p = &pkg{}
+		}
+		switch line[0] {
+		case 'P':
+			p.Name = line[2:]
+		case 'V':
+			p.Version = line[2:]
+		case 'o':
+			p.Source = line[2:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return pkgs, nil
+}
+
+// match returns the vulnerabilities of the packages whose versions are lower than
+// the fixed versions in the feed
+func match(entries map[string][]*feedEntry, dbs map[string][]*pkg) []*models.Vulnerability {
+	vulnerabilities := []*models.Vulnerability{}
+	for db, pkgs := range dbs {
+		distro := packageDBs[db]
+		for _, p := range pkgs {
+			candidates := entries[p.Name]
+			if len(p.Source) != 0 && p.Source != p.Name {
+				candidates = append(candidates[:len(candidates):len(candidates)], entries[p.Source]...)
+			}
+
+			for _, e := range candidates {
+				if len(e.OS) != 0 && e.OS != distro {
+					continue
+				}
+				if len(e.FixedVersion) != 0 && compareVersions(p.Version, e.FixedVersion) >= 0 {
+					continue
+				}
+
+				severity := models.SeverityUnknown
+				if level := models.SeverityLevel(e.Severity); level >= 0 {
+					severity = models.Severities[level]
+				}
+				vulnerabilities = append(vulnerabilities, &models.Vulnerability{
+					ID:           e.ID,
+					Package:      p.Name,
+					Version:      p.Version,
+					FixedVersion: e.FixedVersion,
+					Severity:     severity,
+					Description:  e.Description,
+					Link:         e.Link,
+				})
+			}
+		}
+	}
+	return vulnerabilities
+}
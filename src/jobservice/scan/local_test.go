@@ -0,0 +1,178 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package scan
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+const dpkgStatus = `Package: openssl
+Status: install ok installed
+Version: 1.1.0f-3
+Description: Secure Sockets Layer toolkit
+ This package contains the openssl binary.
+
+Package: libssl1.1
+Status: install ok installed
+Source: openssl
+Version: 1.1.0f-3
+
+Package: zlib1g
+Status: deinstall ok config-files
+Version: 1:1.2.8.dfsg-5
+`
+
+const apkInstalled = `P:musl
+V:1.1.16-r14
+o:musl
+
+P:busybox
+V:1.26.2-r9
+o:busybox
+`
+
+const testFeed = `{"vulnerabilities": [
+	{"id": "CVE-2017-3735", "os": "debian", "package": "openssl", "fixed_version": "1.1.0f-4", "severity": "medium"},
+	{"id": "CVE-2016-0000", "package": "openssl", "fixed_version": "1.1.0a-1", "severity": "High"},
+	{"id": "CVE-2016-9843", "package": "zlib1g", "severity": "High"},
+	{"id": "CVE-2017-15650", "os": "alpine", "package": "musl", "fixed_version": "1.1.16-r15", "severity": "Critical"},
+	{"id": "CVE-2017-0001", "os": "debian", "package": "busybox", "severity": "Low"}
+]}`
+
+// newLayer builds a gzipped tar archive containing the files
+func newLayer(t *testing.T, digest string, files map[string]string) *Layer {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	data := buf.Bytes()
+	return &Layer{
+		Digest: digest,
+		Open: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+}
+
+func newLocalScanner(t *testing.T) (Scanner, func()) {
+	dir, err := ioutil.TempDir("", "scan")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	feed := filepath.Join(dir, "feed.json")
+	if err = ioutil.WriteFile(feed, []byte(testFeed), 0644); err != nil {
+		t.Fatalf("failed to write feed: %v", err)
+	}
+
+	scanner, err := NewScanner(ScannerLocal, feed)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	return scanner, func() { os.RemoveAll(dir) }
+}
+
+func TestLocalScanner(t *testing.T) {
+	scanner, clean := newLocalScanner(t)
+	defer clean()
+
+	layers := []*Layer{
+		newLayer(t, "sha256:1", map[string]string{
+			"var/lib/dpkg/status": "Package: openssl\nStatus: install ok installed\nVersion: 1.0.1t-1\n",
+		}),
+		// replaces the status file of the lower layer
+		newLayer(t, "sha256:2", map[string]string{
+			"./var/lib/dpkg/status": dpkgStatus,
+			"lib/apk/db/installed":  apkInstalled,
+		}),
+	}
+
+	vulnerabilities, err := scanner.Scan(layers)
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+
+	// key: ID/package
+	expected := map[string]string{
+		"CVE-2017-3735/openssl":   models.SeverityMedium,
+		"CVE-2017-3735/libssl1.1": models.SeverityMedium,
+		"CVE-2017-15650/musl":     models.SeverityCritical,
+	}
+	if len(vulnerabilities) != len(expected) {
+		t.Fatalf("unexpected vulnerabilities: %+v", vulnerabilities)
+	}
+	for _, v := range vulnerabilities {
+		severity, ok := expected[v.ID+"/"+v.Package]
+		if !ok || severity != v.Severity {
+			t.Errorf("unexpected vulnerability: %+v", v)
+		}
+	}
+}
+
+func TestLocalScannerWhiteout(t *testing.T) {
+	scanner, clean := newLocalScanner(t)
+	defer clean()
+
+	layers := []*Layer{
+		newLayer(t, "sha256:1", map[string]string{
+			"var/lib/dpkg/status": dpkgStatus,
+		}),
+		newLayer(t, "sha256:2", map[string]string{
+			"var/lib/dpkg/.wh.status": "",
+		}),
+	}
+
+	vulnerabilities, err := scanner.Scan(layers)
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	if len(vulnerabilities) != 0 {
+		t.Errorf("expected no vulnerability, but got %+v", vulnerabilities)
+	}
+}
+
+func TestNewScanner(t *testing.T) {
+	if _, err := NewScanner("unknown", ""); err == nil {
+		t.Errorf("expected error for unknown scanner")
+	}
+}
@@ -0,0 +1,177 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package scan
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/registry"
+)
+
+// ScannerLocal is the name of the scanner which matches the packages installed
+// in the image against an offline CVE feed
+const ScannerLocal = "local"
+
+var mediaTypes = []string{schema1.MediaTypeManifest, schema2.MediaTypeManifest, registry.MediaTypeManifestList}
+
+// Layer is a layer of the image being scanned
+type Layer struct {
+	Digest string
+	// Open returns the content of the layer, which is a tar archive and may be gzipped
+	Open func() (io.ReadCloser, error)
+}
+
+// Scanner finds the vulnerabilities of an image from its layers, it is implemented
+// by an adapter for each kind of scanner
+type Scanner interface {
+	// Name returns the name of the scanner, which is stored with the result
+	Name() string
+	// Scan scans the layers of an image, which are ordered from the base layer
+	Scan(layers []*Layer) ([]*models.Vulnerability, error)
+}
+
+// NewScanner returns the scanner with the name, the feed is the path of the
+// vulnerability database used by the local scanner
+func NewScanner(name, feed string) (Scanner, error) {
+	switch name {
+	case ScannerLocal:
+		return &localScanner{
+			feed: feed,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scanner: %s", name)
+	}
+}
+
+// Image scans the image which the reference of the repository refers to, it returns
+// the digest of the manifest and the vulnerabilities found. The images referenced by
+// a manifest list are all scanned.
+func Image(client *registry.Repository, reference string, scanner Scanner) (string, []*models.Vulnerability, error) {
+	digest, manifest, err := pullManifest(client, reference, mediaTypes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	manifests := []distribution.Manifest{manifest}
+	if list, ok := manifest.(*registry.DeserializedManifestList); ok {
+		manifests = []distribution.Manifest{}
+		for _, descriptor := range list.Manifests {
+			_, m, err := pullManifest(client, descriptor.Digest.String(), []string{descriptor.MediaType})
+			if err != nil {
+				return "", nil, err
+			}
+			manifests = append(manifests, m)
+		}
+	}
+
+	vulnerabilities := []*models.Vulnerability{}
+	found := map[string]bool{}
+	for _, m := range manifests {
+		layers, err := imageLayers(client, m)
+		if err != nil {
+			return "", nil, err
+		}
+
+		vs, err := scanner.Scan(layers)
+		if err != nil {
+			return "", nil, err
+		}
+
+		for _, v := range vs {
+			key := v.ID + "/" + v.Package + "/" + v.Version
+			if found[key] {
+				continue
+			}
+			found[key] = true
+			vulnerabilities = append(vulnerabilities, v)
+		}
+	}
+
+	sort.Sort(bySeverity(vulnerabilities))
+
+	return digest, vulnerabilities, nil
+}
+
+// bySeverity sorts the vulnerabilities from the most severe one
+type bySeverity []*models.Vulnerability
+
+func (b bySeverity) Len() int      { return len(b) }
+func (b bySeverity) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b bySeverity) Less(i, j int) bool {
+	li, lj := models.SeverityLevel(b[i].Severity), models.SeverityLevel(b[j].Severity)
+	if li != lj {
+		return li > lj
+	}
+	if b[i].ID != b[j].ID {
+		return b[i].ID < b[j].ID
+	}
+	return b[i].Package < b[j].Package
+}
+
+func pullManifest(client *registry.Repository, reference string, accept []string) (string, distribution.Manifest, error) {
+	digest, mediaType, payload, err := client.PullManifest(reference, accept)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if strings.Contains(mediaType, "application/json") {
+		mediaType = schema1.MediaTypeManifest
+	}
+
+	manifest, _, err := registry.UnMarshal(mediaType, payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return digest, manifest, nil
+}
+
+// imageLayers returns the layers of the image from the base layer, the layers
+// are pulled from the registry when they are opened
+func imageLayers(client *registry.Repository, manifest distribution.Manifest) ([]*Layer, error) {
+	var descriptors []distribution.Descriptor
+	switch m := manifest.(type) {
+	case *schema2.DeserializedManifest:
+		descriptors = m.References()
+	case *schema1.SignedManifest:
+		// the layers of manifest schema v1 are ordered from the top layer
+		references := m.References()
+		for i := len(references) - 1; i >= 0; i-- {
+			descriptors = append(descriptors, references[i])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported manifest type: %T", manifest)
+	}
+
+	layers := []*Layer{}
+	for _, descriptor := range descriptors {
+		digest := descriptor.Digest.String()
+		layers = append(layers, &Layer{
+			Digest: digest,
+			Open: func() (io.ReadCloser, error) {
+				_, data, err := client.PullBlob(digest)
+				return data, err
+			},
+		})
+	}
+	return layers, nil
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package scan
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares the versions of packages with the algorithm of dpkg,
+// which works for the versions of apk as well. It returns a negative number if
+// a is lower than b, 0 if they are equal and a positive number if a is higher.
+func compareVersions(a, b string) int {
+	epochA, upstreamA, revisionA := splitVersion(a)
+	epochB, upstreamB, revisionB := splitVersion(b)
+	if epochA != epochB {
+		return epochA - epochB
+	}
+	if c := compareFragments(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareFragments(revisionA, revisionB)
+}
+
+// splitVersion splits the version in format "[epoch:]upstream[-revision]"
+func splitVersion(version string) (int, string, string) {
+	epoch := 0
+	if i := strings.Index(version, ":"); i >= 0 {
+		epoch, _ = strconv.Atoi(version[:i])
+		version = version[i+1:]
+	}
+
+	revision := ""
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		revision = version[i+1:]
+		version = version[:i]
+	}
+
+	return epoch, version, revision
+}
+
+// compareFragments compares the non-digit parts lexically, where "~" sorts before
+// anything and letters sort before non-letters, and the digit parts numerically
+func compareFragments(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			oa, ob := order(a, i), order(b, j)
+			if oa != ob {
+				return oa - ob
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		diff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if diff == 0 {
+				diff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if diff != 0 {
+			return diff
+		}
+	}
+	return 0
+}
+
+func order(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	c := s[i]
+	switch {
+	case isDigit(c):
+		return 0
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package scan
+
+import (
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a      string
+		b      string
+		result int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0a", "1.0", 1},
+		{"1:1.0", "2.0", 1},
+		{"1.1.0f-3", "1.1.0f-3+deb9u1", -1},
+		{"1.0.2g-1ubuntu4.8", "1.0.2g-1ubuntu4.10", -1},
+		{"1.1.1k-r0", "1.1.1l-r0", -1},
+		{"2.26-r1", "2.26-r0", 1},
+		{"1.01", "1.1", 0},
+	}
+
+	for _, c := range cases {
+		result := compareVersions(c.a, c.b)
+		if (result < 0 && c.result >= 0) || (result > 0 && c.result <= 0) ||
+			(result == 0 && c.result != 0) {
+			t.Errorf("unexpected result of comparing %s with %s: %d", c.a, c.b, result)
+		}
+	}
+}
@@ -25,9 +25,32 @@ import (
 	"strconv"
 )
 
-// NewLogger create a logger for a speicified job
-func NewLogger(jobID int64) *log.Logger {
-	logFile := GetJobLogPath(jobID)
+// NewLogger create a logger for a speicified job, the ID of the request which created
+// the job is added to every log if it's set
+func NewLogger(jobID int64, requestID string) *log.Logger {
+	logger := newLogger(GetJobLogPath(jobID))
+	if len(requestID) == 0 {
+		return logger
+	}
+	return logger.WithFields(log.Fields{"request_id": requestID})
+}
+
+// NewRetentionLogger creates a logger for the retention job
+func NewRetentionLogger(jobID int64) *log.Logger {
+	return newLogger(GetRetentionJobLogPath(jobID))
+}
+
+// NewGCLogger creates a logger for the garbage collection job
+func NewGCLogger(jobID int64) *log.Logger {
+	return newLogger(GetGCJobLogPath(jobID))
+}
+
+// NewScanLogger creates a logger for the scan job
+func NewScanLogger(jobID int64) *log.Logger {
+	return newLogger(GetScanJobLogPath(jobID))
+}
+
+func newLogger(logFile string) *log.Logger {
 	d := filepath.Dir(logFile)
 	if _, err := os.Stat(d); os.IsNotExist(err) {
 		err := os.MkdirAll(d, 0660)
@@ -37,7 +60,7 @@ func NewLogger(jobID int64) *log.Logger {
 	}
 	f, err := os.OpenFile(logFile, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0660)
 	if err != nil {
-		log.Errorf("Failed to open log file %s, the log of job %d will be printed to standard output, the error: %v", logFile, jobID, err)
+		log.Errorf("Failed to open log file %s, the log will be printed to standard output, the error: %v", logFile, err)
 		f = os.Stdout
 	}
 	return log.New(f, log.NewTextFormatter(), log.InfoLevel)
@@ -45,7 +68,26 @@ func NewLogger(jobID int64) *log.Logger {
 
 // GetJobLogPath returns the absolute path in which the job log file is located.
 func GetJobLogPath(jobID int64) string {
-	f := fmt.Sprintf("job_%d.log", jobID)
+	return getLogPath("job", jobID)
+}
+
+// GetRetentionJobLogPath returns the absolute path in which the log file of retention job is located.
+func GetRetentionJobLogPath(jobID int64) string {
+	return getLogPath("retention_job", jobID)
+}
+
+// GetGCJobLogPath returns the absolute path in which the log file of garbage collection job is located.
+func GetGCJobLogPath(jobID int64) string {
+	return getLogPath("gc_job", jobID)
+}
+
+// GetScanJobLogPath returns the absolute path in which the log file of scan job is located.
+func GetScanJobLogPath(jobID int64) string {
+	return getLogPath("scan_job", jobID)
+}
+
+func getLogPath(prefix string, jobID int64) string {
+	f := fmt.Sprintf("%s_%d.log", prefix, jobID)
 	k := jobID / 1000
 	p := ""
 	var d string
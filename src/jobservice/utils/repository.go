@@ -0,0 +1,76 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/models"
+	u "github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/jobservice/config"
+)
+
+// GetRepoList calls the api from UI to get repo list
+func GetRepoList(projectID int64) ([]string, error) {
+	repositories := []string{}
+
+	client := &http.Client{}
+	uiURL := config.LocalUIURL()
+	next := "/api/repositories?project_id=" + strconv.Itoa(int(projectID))
+	for len(next) != 0 {
+		req, err := http.NewRequest("GET", uiURL+next, nil)
+		if err != nil {
+			return repositories, err
+		}
+
+		req.AddCookie(&http.Cookie{Name: models.UISecretCookie, Value: config.UISecret()})
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return repositories, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			dump, _ := httputil.DumpResponse(resp, true)
+			log.Debugf("response: %q", dump)
+			return repositories, fmt.Errorf("Unexpected status code when getting repository list: %d", resp.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return repositories, err
+		}
+
+		var list []string
+		if err = json.Unmarshal(body, &list); err != nil {
+			return repositories, err
+		}
+
+		repositories = append(repositories, list...)
+
+		links := u.ParseLink(resp.Header.Get(http.CanonicalHeaderKey("link")))
+		next = links.Next()
+	}
+
+	return repositories, nil
+}
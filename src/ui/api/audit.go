@@ -0,0 +1,87 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// AuditAPI handles requests to /api/audit
+type AuditAPI struct {
+	api.BaseAPI
+}
+
+// Prepare validates that the user is system admin
+func (a *AuditAPI) Prepare() {
+	userID := a.ValidateUser()
+	isAdmin, err := dao.IsAdminRole(userID)
+	if err != nil {
+		log.Errorf("failed to check the role of user %d: %v", userID, err)
+		a.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !isAdmin {
+		a.CustomAbort(http.StatusForbidden, "")
+	}
+}
+
+// List filters the audit logs by the actor, resource type, resource ID, action,
+// source IP and the time range in query parameters, the latest first
+func (a *AuditAPI) List() {
+	query := models.AuditLogQuery{
+		Actor:        a.GetString("actor"),
+		ResourceType: a.GetString("resource_type"),
+		ResourceID:   a.GetString("resource_id"),
+		Action:       a.GetString("action"),
+		SourceIP:     a.GetString("source_ip"),
+		StartTime:    a.parseTime("start_time"),
+		EndTime:      a.parseTime("end_time"),
+	}
+
+	page, pageSize := a.GetPaginationParams()
+
+	logs, total, err := dao.FilterAuditLogs(query, pageSize, pageSize*(page-1))
+	if err != nil {
+		log.Errorf("failed to filter audit logs %+v: %v", query, err)
+		a.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	a.SetPaginationHeader(total, page, pageSize)
+
+	a.Data["json"] = logs
+	a.ServeJSON()
+}
+
+// parseTime parses the unix timestamp in the query parameter, nil is returned if
+// it isn't set
+func (a *AuditAPI) parseTime(key string) *time.Time {
+	str := a.GetString(key)
+	if len(str) == 0 {
+		return nil
+	}
+	i, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		a.CustomAbort(http.StatusBadRequest, "invalid "+key)
+	}
+	t := time.Unix(i, 0)
+	return &t
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// CVEAllowlistAPI handles request to /api/projects/:pid/cve_allowlist and /api/projects/:pid/cve_allowlist/:id
+type CVEAllowlistAPI struct {
+	api.BaseAPI
+	userID  int
+	project *models.Project
+	itemID  int64
+}
+
+// Prepare validates the user and the project, only the project admin and
+// system admin can manage the CVE allowlist
+func (c *CVEAllowlistAPI) Prepare() {
+	c.userID = c.ValidateUser()
+	c.project = getProjectFromURL(&c.BaseAPI)
+
+	if !hasProjectAdminRole(c.userID, c.project.ProjectID) {
+		c.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if len(c.Ctx.Input.Param(":id")) != 0 {
+		c.itemID = c.GetIDFromURL()
+	}
+}
+
+// List lists the CVE allowlist of the project, including the expired items
+func (c *CVEAllowlistAPI) List() {
+	items, err := dao.GetCVEAllowlist(c.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get CVE allowlist of project %d: %v", c.project.ProjectID, err)
+		c.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	c.Data["json"] = items
+	c.ServeJSON()
+}
+
+// Post adds a vulnerability to the CVE allowlist of the project, it's ignored by
+// the vulnerability policy of the project until it expires
+func (c *CVEAllowlistAPI) Post() {
+	item := &models.CVEAllowlistItem{}
+	c.DecodeJSONReqAndValidate(item)
+
+	existing, err := dao.GetCVEAllowlistItemByCVE(c.project.ProjectID, item.CVEID)
+	if err != nil {
+		log.Errorf("failed to get CVE allowlist item %s of project %d: %v", item.CVEID, c.project.ProjectID, err)
+		c.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if existing != nil {
+		c.CustomAbort(http.StatusConflict, fmt.Sprintf("%s is already in the allowlist", item.CVEID))
+	}
+
+	item.ProjectID = c.project.ProjectID
+	item.CreatorID = c.userID
+	id, err := dao.AddCVEAllowlistItem(*item)
+	if err != nil {
+		log.Errorf("failed to add CVE allowlist item for project %d: %v", c.project.ProjectID, err)
+		c.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(c.userID, c.project, "add cve allowlist")
+
+	c.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// Delete removes the vulnerability from the CVE allowlist
+func (c *CVEAllowlistAPI) Delete() {
+	item, err := dao.GetCVEAllowlistItem(c.itemID)
+	if err != nil {
+		log.Errorf("failed to get CVE allowlist item %d: %v", c.itemID, err)
+		c.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if item == nil || item.ProjectID != c.project.ProjectID {
+		c.CustomAbort(http.StatusNotFound, fmt.Sprintf("CVE allowlist item %d not found", c.itemID))
+	}
+
+	if err = dao.DeleteCVEAllowlistItem(item.ID); err != nil {
+		log.Errorf("failed to delete CVE allowlist item %d: %v", item.ID, err)
+		c.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(c.userID, c.project, "delete cve allowlist")
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+	svc_utils "github.com/vmware/harbor/src/ui/service/utils"
+)
+
+// GCAPI handles request to /api/system/gc and /api/system/gc/:id
+type GCAPI struct {
+	api.BaseAPI
+}
+
+// Prepare validates that the user is system admin
+func (g *GCAPI) Prepare() {
+	userID := g.ValidateUser()
+	isAdmin, err := dao.IsAdminRole(userID)
+	if err != nil {
+		log.Errorf("failed to check the role of user %d: %v", userID, err)
+		g.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !isAdmin {
+		g.CustomAbort(http.StatusForbidden, "")
+	}
+}
+
+// Post starts a garbage collection job, the blobs are not deleted if "dry_run" is true.
+// Only one job can be pending or running at a time.
+func (g *GCAPI) Post() {
+	req := struct {
+		DryRun bool `json:"dry_run"`
+	}{}
+	g.DecodeJSONReq(&req)
+
+	// the job service rejects the job if another one is pending or running
+	id, err := svc_utils.CreateJob(buildGCURL(), req)
+	if err != nil {
+		if e, ok := err.(*svc_utils.JobServiceError); ok && e.StatusCode == http.StatusConflict {
+			g.CustomAbort(http.StatusConflict, e.Detail)
+		}
+		log.Errorf("failed to trigger gc job: %v", err)
+		g.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	g.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// Get returns the garbage collection job
+func (g *GCAPI) Get() {
+	g.Data["json"] = g.getJob()
+	g.ServeJSON()
+}
+
+// GetLog returns the log of the garbage collection job
+func (g *GCAPI) GetLog() {
+	job := g.getJob()
+	proxyJobLog(&g.BaseAPI, buildGCLogURL(job.ID))
+}
+
+func (g *GCAPI) getJob() *models.GCJob {
+	id := g.GetIDFromURL()
+	job, err := dao.GetGCJob(id)
+	if err != nil {
+		log.Errorf("failed to get gc job %d: %v", id, err)
+		g.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	if job == nil {
+		g.CustomAbort(http.StatusNotFound, fmt.Sprintf("gc job %d not found", id))
+	}
+
+	return job
+}
+
+func buildGCURL() string {
+	return fmt.Sprintf("%s/api/jobs/gc", config.InternalJobServiceURL())
+}
+
+func buildGCLogURL(jobID int64) string {
+	return fmt.Sprintf("%s/api/jobs/gc/%d/log", config.InternalJobServiceURL(), jobID)
+}
@@ -0,0 +1,134 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth/ldap"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// LDAPAPI handles requests to /api/ldap/ping, /api/ldap/users/search and /api/ldap/users/import
+type LDAPAPI struct {
+	api.BaseAPI
+	userID int
+}
+
+// Prepare validates that the user is system admin and the auth mode is ldap_auth
+func (l *LDAPAPI) Prepare() {
+	l.userID = l.ValidateUser()
+	isAdmin, err := dao.IsAdminRole(l.userID)
+	if err != nil {
+		log.Errorf("failed to check the role of user %d: %v", l.userID, err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !isAdmin {
+		l.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if config.AuthMode() != "ldap_auth" {
+		l.CustomAbort(http.StatusPreconditionFailed, "the auth mode is not ldap_auth")
+	}
+}
+
+// Ping tests the connection to the LDAP server with the settings in request body,
+// the current configuration is tested if the body is empty
+func (l *LDAPAPI) Ping() {
+	setting := config.LDAP()
+	conf := models.LDAPConf{
+		URL:       setting.URL,
+		SearchDn:  setting.SearchDn,
+		SearchPwd: setting.SearchPwd,
+		BaseDn:    setting.BaseDn,
+		StartTLS:  config.LDAPConn().StartTLS,
+	}
+	if len(l.Ctx.Input.CopyBody(1<<32)) != 0 {
+		req := models.LDAPConf{}
+		l.DecodeJSONReq(&req)
+		// the configured password is used if the search DN is not changed,
+		// so that the admin doesn't need to provide it again
+		if len(req.SearchPwd) == 0 && req.SearchDn == setting.SearchDn {
+			req.SearchPwd = setting.SearchPwd
+		}
+		conf = req
+	}
+	if len(conf.URL) == 0 {
+		l.CustomAbort(http.StatusBadRequest, "ldap_url is required")
+	}
+
+	if err := ldap.Ping(conf); err != nil {
+		log.Errorf("failed to ping LDAP server %s: %v", conf.URL, err)
+		l.CustomAbort(http.StatusBadRequest, "failed to connect to the LDAP server: "+err.Error())
+	}
+}
+
+// Search searches the users in LDAP whose uid attribute contains the query parameter username
+func (l *LDAPAPI) Search() {
+	users, err := ldap.SearchUsers(l.GetString("username"))
+	if err != nil {
+		log.Errorf("failed to search LDAP users: %v", err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	l.Data["json"] = users
+	l.ServeJSON()
+}
+
+// ImportUsers registers the LDAP users in request body, 404 is returned with the
+// users failed to be imported if there is any
+func (l *LDAPAPI) ImportUsers() {
+	req := struct {
+		UIDs []string `json:"ldap_uid_list"`
+	}{}
+	l.DecodeJSONReq(&req)
+	if len(req.UIDs) == 0 {
+		l.CustomAbort(http.StatusBadRequest, "ldap_uid_list is required")
+	}
+
+	failures, err := ldap.ImportUsers(req.UIDs)
+	if err != nil {
+		log.Errorf("failed to import LDAP users: %v", err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	failed := map[string]bool{}
+	for _, failure := range failures {
+		failed[failure.UID] = true
+	}
+	for _, uid := range req.UIDs {
+		if failed[uid] {
+			continue
+		}
+		l.Audit(models.AuditLog{
+			ActorID:      l.userID,
+			ResourceType: models.AuditResourceUser,
+			Action:       models.AuditActionCreate,
+			After:        fmt.Sprintf("username=%s, imported from LDAP", uid),
+		})
+	}
+
+	if len(failures) != 0 {
+		l.Ctx.Output.SetStatus(http.StatusNotFound)
+		l.Data["json"] = failures
+		l.ServeJSON()
+	}
+}
@@ -0,0 +1,111 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// LDAPGroupAPI handles request to /api/projects/:pid/ldap_groups and /api/projects/:pid/ldap_groups/:id
+type LDAPGroupAPI struct {
+	api.BaseAPI
+	userID  int
+	project *models.Project
+	groupID int64
+}
+
+// Prepare validates the user and the project, only the project admin and
+// system admin can manage the LDAP group members
+func (l *LDAPGroupAPI) Prepare() {
+	l.userID = l.ValidateUser()
+	l.project = getProjectFromURL(&l.BaseAPI)
+
+	if !hasProjectAdminRole(l.userID, l.project.ProjectID) {
+		l.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if len(l.Ctx.Input.Param(":id")) != 0 {
+		l.groupID = l.GetIDFromURL()
+	}
+}
+
+// List lists the LDAP group members of the project
+func (l *LDAPGroupAPI) List() {
+	groups, err := dao.GetProjectLDAPGroups(l.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get LDAP groups of project %d: %v", l.project.ProjectID, err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	l.Data["json"] = groups
+	l.ServeJSON()
+}
+
+// Post adds an LDAP group as a member of the project, the users in the group become
+// members of the project when they log in or the groups are synchronized periodically
+func (l *LDAPGroupAPI) Post() {
+	group := &models.ProjectLDAPGroup{}
+	l.DecodeJSONReqAndValidate(group)
+
+	existing, err := dao.GetProjectLDAPGroupByDN(l.project.ProjectID, group.GroupDN)
+	if err != nil {
+		log.Errorf("failed to get LDAP group %s of project %d: %v", group.GroupDN, l.project.ProjectID, err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if existing != nil {
+		l.CustomAbort(http.StatusConflict, fmt.Sprintf("group %s is already a member", group.GroupDN))
+	}
+
+	group.ProjectID = l.project.ProjectID
+	group.CreatorID = l.userID
+	id, err := dao.AddProjectLDAPGroup(*group)
+	if err != nil {
+		log.Errorf("failed to add LDAP group for project %d: %v", l.project.ProjectID, err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(l.userID, l.project, "add ldap group")
+
+	l.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// Delete removes the LDAP group from the members of the project, the memberships
+// granted to the users in the group are removed when they log in or the groups are
+// synchronized periodically
+func (l *LDAPGroupAPI) Delete() {
+	group, err := dao.GetProjectLDAPGroup(l.groupID)
+	if err != nil {
+		log.Errorf("failed to get LDAP group %d: %v", l.groupID, err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if group == nil || group.ProjectID != l.project.ProjectID {
+		l.CustomAbort(http.StatusNotFound, fmt.Sprintf("LDAP group %d not found", l.groupID))
+	}
+
+	if err = dao.DeleteProjectLDAPGroup(group.ID); err != nil {
+		log.Errorf("failed to delete LDAP group %d: %v", group.ID, err)
+		l.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(l.userID, l.project, "delete ldap group")
+}
@@ -166,6 +166,9 @@ func (pma *ProjectMemberAPI) Post() {
 		pma.RenderError(http.StatusInternalServerError, "Failed to update data in database")
 		return
 	}
+
+	pma.audit(userID, models.AuditActionCreate, "",
+		memberSummary(pma.project.Name, username, []int{rid}))
 }
 
 // Put ...
@@ -205,6 +208,11 @@ func (pma *ProjectMemberAPI) Put() {
 			return
 		}
 	}
+
+	username := pma.memberName()
+	pma.audit(mid, models.AuditActionUpdate,
+		memberSummary(pma.project.Name, username, roleIDs(roleList)),
+		memberSummary(pma.project.Name, username, req.Roles))
 }
 
 // Delete ...
@@ -219,10 +227,57 @@ func (pma *ProjectMemberAPI) Delete() {
 
 	mid := pma.memberID
 
-	err := dao.DeleteProjectMember(pid, mid)
+	roleList, err := dao.GetUserProjectRoles(mid, pid)
+	if err != nil {
+		log.Errorf("Error occurred in GetUserProjectRoles, error: %v", err)
+		pma.CustomAbort(http.StatusInternalServerError, "Internal error.")
+	}
+
+	err = dao.DeleteProjectMember(pid, mid)
 	if err != nil {
 		log.Errorf("Failed to delete project roles for user, user id: %d, project id: %d, error: %v", mid, pid, err)
 		pma.RenderError(http.StatusInternalServerError, "Failed to update data in DB")
 		return
 	}
+
+	pma.audit(mid, models.AuditActionDelete,
+		memberSummary(pma.project.Name, pma.memberName(), roleIDs(roleList)), "")
+}
+
+// memberName returns the name of the member the request is for
+func (pma *ProjectMemberAPI) memberName() string {
+	user, err := dao.GetUser(models.User{UserID: pma.memberID})
+	if err != nil {
+		log.Errorf("Error occurred in GetUser, error: %v", err)
+		return ""
+	}
+	if user == nil {
+		return ""
+	}
+	return user.Username
+}
+
+// audit records the change of the user's membership in the project
+func (pma *ProjectMemberAPI) audit(userID int, action, before, after string) {
+	pma.Audit(models.AuditLog{
+		ActorID:      pma.currentUserID,
+		ResourceType: models.AuditResourceProjectMember,
+		ResourceID:   fmt.Sprintf("%d/%d", pma.project.ProjectID, userID),
+		Action:       action,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// memberSummary describes the membership in audit log
+func memberSummary(project, username string, roles []int) string {
+	return fmt.Sprintf("project=%s, username=%s, roles=%v", project, username, roles)
+}
+
+func roleIDs(roles []models.Role) []int {
+	ids := []int{}
+	for _, role := range roles {
+		ids = append(ids, role.RoleID)
+	}
+	return ids
 }
@@ -0,0 +1,110 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// OIDCGroupAPI handles request to /api/projects/:pid/oidc_groups and /api/projects/:pid/oidc_groups/:id
+type OIDCGroupAPI struct {
+	api.BaseAPI
+	userID      int
+	project     *models.Project
+	groupRoleID int64
+}
+
+// Prepare validates the user and the project, only the project admin and
+// system admin can manage the roles granted to OIDC groups
+func (o *OIDCGroupAPI) Prepare() {
+	o.userID = o.ValidateUser()
+	o.project = getProjectFromURL(&o.BaseAPI)
+
+	if !hasProjectAdminRole(o.userID, o.project.ProjectID) {
+		o.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if len(o.Ctx.Input.Param(":id")) != 0 {
+		o.groupRoleID = o.GetIDFromURL()
+	}
+}
+
+// List lists the roles the project grants to OIDC groups
+func (o *OIDCGroupAPI) List() {
+	groupRoles, err := dao.GetOIDCGroupRoles(o.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get OIDC group roles of project %d: %v", o.project.ProjectID, err)
+		o.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	o.Data["json"] = groupRoles
+	o.ServeJSON()
+}
+
+// Post grants a role of the project to an OIDC group, the users in the group
+// become members of the project the next time they log in
+func (o *OIDCGroupAPI) Post() {
+	groupRole := &models.OIDCGroupRole{}
+	o.DecodeJSONReqAndValidate(groupRole)
+
+	existing, err := dao.GetOIDCGroupRoleByName(o.project.ProjectID, groupRole.GroupName)
+	if err != nil {
+		log.Errorf("failed to get OIDC group role %s of project %d: %v", groupRole.GroupName, o.project.ProjectID, err)
+		o.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if existing != nil {
+		o.CustomAbort(http.StatusConflict, fmt.Sprintf("a role is already granted to group %s", groupRole.GroupName))
+	}
+
+	groupRole.ProjectID = o.project.ProjectID
+	groupRole.CreatorID = o.userID
+	id, err := dao.AddOIDCGroupRole(*groupRole)
+	if err != nil {
+		log.Errorf("failed to add OIDC group role for project %d: %v", o.project.ProjectID, err)
+		o.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(o.userID, o.project, "add oidc group")
+
+	o.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// Delete revokes the role granted to the OIDC group, the memberships granted
+// to the users in the group are removed the next time they log in
+func (o *OIDCGroupAPI) Delete() {
+	groupRole, err := dao.GetOIDCGroupRole(o.groupRoleID)
+	if err != nil {
+		log.Errorf("failed to get OIDC group role %d: %v", o.groupRoleID, err)
+		o.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if groupRole == nil || groupRole.ProjectID != o.project.ProjectID {
+		o.CustomAbort(http.StatusNotFound, fmt.Sprintf("OIDC group role %d not found", o.groupRoleID))
+	}
+
+	if err = dao.DeleteOIDCGroupRole(groupRole.ID); err != nil {
+		log.Errorf("failed to delete OIDC group role %d: %v", groupRole.ID, err)
+		o.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(o.userID, o.project, "delete oidc group")
+}
@@ -36,11 +36,15 @@ type ProjectAPI struct {
 	userID      int
 	projectID   int64
 	projectName string
+	public      int
 }
 
 type projectReq struct {
 	ProjectName string `json:"project_name"`
 	Public      int    `json:"public"`
+	// the project is created as a proxy cache of the target if it's set
+	ProxyTargetID int64 `json:"proxy_target_id"`
+	ProxyTTL      int   `json:"proxy_ttl"`
 }
 
 const projectNameMaxLen int = 30
@@ -67,6 +71,7 @@ func (p *ProjectAPI) Prepare() {
 			p.CustomAbort(http.StatusNotFound, fmt.Sprintf("project does not exist, id: %v", p.projectID))
 		}
 		p.projectName = project.Name
+		p.public = project.Public
 	}
 }
 
@@ -101,6 +106,16 @@ func (p *ProjectAPI) Post() {
 		return
 	}
 	project := models.Project{OwnerID: p.userID, Name: projectName, CreationTime: time.Now(), Public: public}
+	if req.ProxyTargetID != 0 {
+		if !isSysAdmin {
+			p.RenderError(http.StatusForbidden, "Only system admin can create proxy cache project")
+			return
+		}
+		proxy := &models.ProjectProxy{ProxyTargetID: req.ProxyTargetID, ProxyTTL: req.ProxyTTL}
+		p.validateProxy(proxy)
+		project.ProxyTargetID = proxy.ProxyTargetID
+		project.ProxyTTL = proxy.ProxyTTL
+	}
 	projectID, err := dao.AddProject(project)
 	if err != nil {
 		log.Errorf("Failed to add project, error: %v", err)
@@ -346,6 +361,179 @@ func (p *ProjectAPI) ToggleProjectPublic() {
 	if err != nil {
 		log.Errorf("Error while updating project, project id: %d, error: %v", projectID, err)
 		p.RenderError(http.StatusInternalServerError, "Failed to update project")
+		return
+	}
+
+	p.Audit(models.AuditLog{
+		ActorID:      p.userID,
+		ResourceType: models.AuditResourceProject,
+		ResourceID:   strconv.FormatInt(p.projectID, 10),
+		Action:       models.AuditActionSetPublicity,
+		Before:       fmt.Sprintf("project=%s, public=%d", p.projectName, p.public),
+		After:        fmt.Sprintf("project=%s, public=%d", p.projectName, public),
+	})
+}
+
+// GetQuota handles GET to /api/projects/{}/quota, it returns the quotas and the usage of the project
+func (p *ProjectAPI) GetQuota() {
+	p.userID = p.ValidateUser()
+	if !checkProjectPermission(p.userID, p.projectID) {
+		p.CustomAbort(http.StatusForbidden, "")
+	}
+
+	project, err := dao.GetProjectByID(p.projectID)
+	if err != nil {
+		log.Errorf("failed to get project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	size, count, err := dao.GetProjectUsage(p.projectID)
+	if err != nil {
+		log.Errorf("failed to get usage of project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	p.Data["json"] = &models.ProjectQuota{
+		StorageLimit: project.StorageLimit,
+		RepoLimit:    project.RepoLimit,
+		StorageUsage: size,
+		RepoUsage:    count,
+	}
+	p.ServeJSON()
+}
+
+// UpdateQuota handles PUT to /api/projects/{}/quota, only system admin can set the quotas
+func (p *ProjectAPI) UpdateQuota() {
+	p.userID = p.ValidateUser()
+	isSysAdmin, err := dao.IsAdminRole(p.userID)
+	if err != nil {
+		log.Errorf("failed to check admin role: %v", err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !isSysAdmin {
+		p.CustomAbort(http.StatusForbidden, "only system admin can set the quotas of project")
+	}
+
+	var req models.ProjectQuota
+	p.DecodeJSONReqAndValidate(&req)
+
+	if err = dao.UpdateProjectQuota(p.projectID, req.StorageLimit, req.RepoLimit); err != nil {
+		log.Errorf("failed to update quotas of project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// UpdateProxy handles PUT to /api/projects/{}/proxy, only system admin can change the target
+// and the TTL of a proxy cache project
+func (p *ProjectAPI) UpdateProxy() {
+	p.userID = p.ValidateUser()
+	isSysAdmin, err := dao.IsAdminRole(p.userID)
+	if err != nil {
+		log.Errorf("failed to check admin role: %v", err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !isSysAdmin {
+		p.CustomAbort(http.StatusForbidden, "only system admin can set the proxy cache of project")
+	}
+
+	project, err := dao.GetProjectByID(p.projectID)
+	if err != nil {
+		log.Errorf("failed to get project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !project.IsProxy() {
+		p.CustomAbort(http.StatusBadRequest, "the project is not a proxy cache")
+	}
+
+	var req models.ProjectProxy
+	p.DecodeJSONReq(&req)
+	p.validateProxy(&req)
+
+	if err = dao.UpdateProjectProxy(p.projectID, req.ProxyTargetID, req.ProxyTTL); err != nil {
+		log.Errorf("failed to update proxy cache of project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// validateProxy validates the settings of proxy cache and checks the existence of the target
+func (p *ProjectAPI) validateProxy(proxy *models.ProjectProxy) {
+	p.Validate(proxy)
+
+	target, err := dao.GetRepTarget(proxy.ProxyTargetID)
+	if err != nil {
+		log.Errorf("failed to get target %d: %v", proxy.ProxyTargetID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if target == nil {
+		p.CustomAbort(http.StatusBadRequest, fmt.Sprintf("target %d does not exist", proxy.ProxyTargetID))
+	}
+}
+
+// UpdateSigning handles PUT to /api/projects/{}/signing, when the signature is required
+// only the images signed by the signing keys of the project can be pulled
+func (p *ProjectAPI) UpdateSigning() {
+	p.userID = p.ValidateUser()
+	if !hasProjectAdminRole(p.userID, p.projectID) {
+		log.Warningf("Current user, id: %d does not have project admin role for project, id: %d", p.userID, p.projectID)
+		p.RenderError(http.StatusForbidden, "")
+		return
+	}
+
+	var req struct {
+		RequireSignature int `json:"require_signature"`
+	}
+	p.DecodeJSONReq(&req)
+	if req.RequireSignature != 0 && req.RequireSignature != 1 {
+		p.CustomAbort(http.StatusBadRequest, "require_signature should be 0 or 1")
+	}
+
+	if err := dao.UpdateProjectRequireSignature(p.projectID, req.RequireSignature); err != nil {
+		log.Errorf("failed to update signing setting of project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// UpdateScanning handles PUT to /api/projects/{}/scanning, when scan on push is enabled
+// the images pushed to the project are scanned for vulnerabilities
+func (p *ProjectAPI) UpdateScanning() {
+	p.userID = p.ValidateUser()
+	if !hasProjectAdminRole(p.userID, p.projectID) {
+		log.Warningf("Current user, id: %d does not have project admin role for project, id: %d", p.userID, p.projectID)
+		p.RenderError(http.StatusForbidden, "")
+		return
+	}
+
+	var req struct {
+		ScanOnPush int `json:"scan_on_push"`
+	}
+	p.DecodeJSONReq(&req)
+	if req.ScanOnPush != 0 && req.ScanOnPush != 1 {
+		p.CustomAbort(http.StatusBadRequest, "scan_on_push should be 0 or 1")
+	}
+
+	if err := dao.UpdateProjectScanOnPush(p.projectID, req.ScanOnPush); err != nil {
+		log.Errorf("failed to update scanning setting of project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// UpdateVulnerabilityPolicy handles PUT to /api/projects/{}/vulnerability_policy, the images
+// with vulnerabilities of the severity or higher, or never scanned if block_unscanned is
+// set, can not be pulled
+func (p *ProjectAPI) UpdateVulnerabilityPolicy() {
+	p.userID = p.ValidateUser()
+	if !hasProjectAdminRole(p.userID, p.projectID) {
+		log.Warningf("Current user, id: %d does not have project admin role for project, id: %d", p.userID, p.projectID)
+		p.RenderError(http.StatusForbidden, "")
+		return
+	}
+
+	var req models.ProjectVulnerabilityPolicy
+	p.DecodeJSONReqAndValidate(&req)
+
+	if err := dao.UpdateProjectVulnerabilityPolicy(p.projectID, &req); err != nil {
+		log.Errorf("failed to update vulnerability policy of project %d: %v", p.projectID, err)
+		p.CustomAbort(http.StatusInternalServerError, "")
 	}
 }
 
@@ -0,0 +1,121 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	registry_error "github.com/vmware/harbor/src/common/utils/registry/error"
+	"github.com/vmware/harbor/src/ui/config"
+	"github.com/vmware/harbor/src/ui/service/cache"
+)
+
+// RefreshRepositorySize computes the size of the repository and saves it, it is
+// called when a manifest is pushed to or deleted from the repository
+func RefreshRepositorySize(repository string) error {
+	client, err := cache.NewRepositoryClient(config.InternalRegistryURL(), api.GetIsInsecure(),
+		"admin", repository, "repository", repository, "pull")
+	if err != nil {
+		return err
+	}
+
+	size, err := repositorySize(client)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("size of repository %s: %d", repository, size)
+	return dao.UpdateRepositorySize(repository, size)
+}
+
+// repositorySize returns the total size of the layers referenced by the tags of the
+// repository, a layer shared by several tags is counted only once. Manifest schema v1
+// contains no size of layers, so the images of schema v1 are not counted.
+func repositorySize(client *registry.Repository) (int64, error) {
+	tags, err := client.ListTag()
+	if err != nil {
+		// the repository has been deleted
+		if regErr, ok := err.(*registry_error.Error); ok && regErr.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	// key: digest of layer, value: size of layer
+	layers := map[string]int64{}
+	for _, tag := range tags {
+		if err = collectLayers(client, tag, []string{schema1.MediaTypeManifest,
+			schema2.MediaTypeManifest, registry.MediaTypeManifestList}, layers); err != nil {
+			return 0, err
+		}
+	}
+
+	var size int64
+	for _, s := range layers {
+		size += s
+	}
+	return size, nil
+}
+
+func collectLayers(client *registry.Repository, reference string, mediaTypes []string, layers map[string]int64) error {
+	_, mediaType, payload, err := client.PullManifest(reference, mediaTypes)
+	if err != nil {
+		// the tag has been deleted
+		if regErr, ok := err.(*registry_error.Error); ok && regErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if strings.Contains(mediaType, "application/json") {
+		mediaType = schema1.MediaTypeManifest
+	}
+
+	manifest, _, err := registry.UnMarshal(mediaType, payload)
+	if err != nil {
+		return err
+	}
+
+	var descriptors []distribution.Descriptor
+	switch m := manifest.(type) {
+	case *registry.DeserializedManifestList:
+		for _, descriptor := range m.Manifests {
+			if err = collectLayers(client, descriptor.Digest.String(),
+				[]string{descriptor.MediaType}, layers); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *schema2.DeserializedManifest:
+		descriptors = append(descriptors, m.References()...)
+		descriptors = append(descriptors, m.Target())
+	default:
+		descriptors = append(descriptors, manifest.References()...)
+	}
+
+	for _, descriptor := range descriptors {
+		layers[descriptor.Digest.String()] = descriptor.Size
+	}
+	return nil
+}
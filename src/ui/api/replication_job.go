@@ -110,12 +110,31 @@ func (ra *RepJobAPI) List() {
 		ra.CustomAbort(http.StatusInternalServerError, "")
 	}
 
+	for _, job := range jobs {
+		if job.QueuePosition, err = dao.GetRepJobQueuePosition(job); err != nil {
+			log.Errorf("failed to get queue position of job %d: %v", job.ID, err)
+			ra.CustomAbort(http.StatusInternalServerError, "")
+		}
+	}
+
 	ra.SetPaginationHeader(total, page, pageSize)
 
 	ra.Data["json"] = jobs
 	ra.ServeJSON()
 }
 
+// GetQueue returns the statistics of the queue of replication jobs
+func (ra *RepJobAPI) GetQueue() {
+	queue, err := dao.GetRepJobQueue()
+	if err != nil {
+		log.Errorf("failed to get the queue of replication jobs: %v", err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	ra.Data["json"] = queue
+	ra.ServeJSON()
+}
+
 // Delete ...
 func (ra *RepJobAPI) Delete() {
 	if ra.jobID == 0 {
@@ -20,6 +20,7 @@ import (
 
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
@@ -30,15 +31,16 @@ import (
 // RepPolicyAPI handles /api/replicationPolicies /api/replicationPolicies/:id/enablement
 type RepPolicyAPI struct {
 	api.BaseAPI
+	userID int
 }
 
 // Prepare validates whether the user has system admin role
 func (pa *RepPolicyAPI) Prepare() {
-	uid := pa.ValidateUser()
+	pa.userID = pa.ValidateUser()
 	var err error
-	isAdmin, err := dao.IsAdminRole(uid)
+	isAdmin, err := dao.IsAdminRole(pa.userID)
 	if err != nil {
-		log.Errorf("Failed to Check if the user is admin, error: %v, uid: %d", err, uid)
+		log.Errorf("Failed to Check if the user is admin, error: %v, uid: %d", err, pa.userID)
 	}
 	if !isAdmin {
 		pa.CustomAbort(http.StatusForbidden, "")
@@ -58,10 +60,66 @@ func (pa *RepPolicyAPI) Get() {
 		pa.CustomAbort(http.StatusNotFound, http.StatusText(http.StatusNotFound))
 	}
 
+	if err = populateRunTime(policy); err != nil {
+		log.Errorf("failed to get run time of policy %d: %v", id, err)
+		pa.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+
 	pa.Data["json"] = policy
 	pa.ServeJSON()
 }
 
+// populateRunTime sets the last and next time when the policy is triggered by its cron string,
+// the next run time is only available when the policy is enabled. The last run time is the later
+// one of the last scheduled time and the creation time of the last job, as a scheduled run of
+// a policy whose filters match no repository creates no job.
+func populateRunTime(policy *models.RepPolicy) error {
+	lastRun, err := dao.GetRepPolicyLastRunTime(policy.ID)
+	if err != nil {
+		return err
+	}
+	if !policy.LastScheduledTime.IsZero() && (lastRun == nil || policy.LastScheduledTime.After(*lastRun)) {
+		t := policy.LastScheduledTime
+		lastRun = &t
+	}
+	policy.LastRunTime = lastRun
+
+	if len(policy.CronStr) == 0 || policy.Enabled == 0 {
+		return nil
+	}
+
+	var t time.Time
+	if lastRun != nil {
+		t = *lastRun
+	}
+	next, err := policy.ScheduledRunTime(t)
+	if err != nil {
+		return err
+	}
+	if !next.IsZero() {
+		policy.NextRunTime = &next
+	}
+
+	return nil
+}
+
+// checkPushedBy aborts the request if the user in the pushed-by filter doesn't exist
+func (pa *RepPolicyAPI) checkPushedBy(policy *models.RepPolicy) {
+	if len(policy.PushedBy) == 0 {
+		return
+	}
+
+	user, err := dao.GetUser(models.User{Username: policy.PushedBy})
+	if err != nil {
+		log.Errorf("failed to get user %s: %v", policy.PushedBy, err)
+		pa.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if user == nil {
+		pa.CustomAbort(http.StatusBadRequest, fmt.Sprintf("user %s does not exist", policy.PushedBy))
+	}
+}
+
 // List filters policies by name and project_id, if name and project_id
 // are nil, List returns all policies
 func (pa *RepPolicyAPI) List() {
@@ -104,6 +162,8 @@ func (pa *RepPolicyAPI) Post() {
 		}
 	*/
 
+	pa.checkPushedBy(policy)
+
 	project, err := dao.GetProjectByID(policy.ProjectID)
 	if err != nil {
 		log.Errorf("failed to get project %d: %v", policy.ProjectID, err)
@@ -141,9 +201,13 @@ func (pa *RepPolicyAPI) Post() {
 		return
 	}
 
+	pa.audit(pid, models.AuditActionCreate, "", policySummary(policy))
+
 	if policy.Enabled == 1 {
+		// the request may be done when the goroutine runs
+		requestID := pa.RequestID()
 		go func() {
-			if err := TriggerReplication(pid, "", nil, models.RepOpTransfer); err != nil {
+			if err := TriggerReplication(pid, "", nil, models.RepOpTransfer, models.RepTriggerManual, requestID); err != nil {
 				log.Errorf("failed to trigger replication of %d: %v", pid, err)
 			} else {
 				log.Infof("replication of %d triggered", pid)
@@ -170,8 +234,18 @@ func (pa *RepPolicyAPI) Put() {
 	policy := &models.RepPolicy{}
 	pa.DecodeJSONReq(policy)
 	policy.ProjectID = originalPolicy.ProjectID
+	if len(policy.Direction) == 0 {
+		policy.Direction = originalPolicy.Direction
+	}
 	pa.Validate(policy)
 
+	pa.checkPushedBy(policy)
+
+	//direction of policy can not be modified when the policy is enabled
+	if policy.Direction != originalPolicy.Direction && originalPolicy.Enabled == 1 {
+		pa.CustomAbort(http.StatusBadRequest, "direction of policy can not be modified when the policy is enabled")
+	}
+
 	/*
 		// check duplicate name
 		if policy.Name != originalPolicy.Name {
@@ -277,7 +351,7 @@ func (pa *RepPolicyAPI) Put() {
 
 		if shouldTrigger {
 			go func() {
-				if err := TriggerReplication(id, "", nil, models.RepOpTransfer); err != nil {
+				if err := TriggerReplication(id, "", nil, models.RepOpTransfer, models.RepTriggerManual, requestID); err != nil {
 					log.Errorf("failed to trigger replication of %d: %v", id, err)
 				} else {
 					log.Infof("replication of %d triggered", id)
@@ -291,9 +365,12 @@ func (pa *RepPolicyAPI) Put() {
 		pa.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 	}
 
+	pa.audit(id, models.AuditActionUpdate, policySummary(originalPolicy), policySummary(policy))
+
 	if policy.Enabled != originalPolicy.Enabled && policy.Enabled == 1 {
+		requestID := pa.RequestID()
 		go func() {
-			if err := TriggerReplication(id, "", nil, models.RepOpTransfer); err != nil {
+			if err := TriggerReplication(id, "", nil, models.RepOpTransfer, models.RepTriggerManual, requestID); err != nil {
 				log.Errorf("failed to trigger replication of %d: %v", id, err)
 			} else {
 				log.Infof("replication of %d triggered", id)
@@ -336,9 +413,17 @@ func (pa *RepPolicyAPI) UpdateEnablement() {
 		return
 	}
 
+	action := models.AuditActionDisable
+	if e.Enabled == 1 {
+		action = models.AuditActionEnable
+	}
+	pa.audit(id, action, fmt.Sprintf("name=%s, enabled=%d", policy.Name, policy.Enabled),
+		fmt.Sprintf("name=%s, enabled=%d", policy.Name, e.Enabled))
+
 	if e.Enabled == 1 {
+		requestID := pa.RequestID()
 		go func() {
-			if err := TriggerReplication(id, "", nil, models.RepOpTransfer); err != nil {
+			if err := TriggerReplication(id, "", nil, models.RepOpTransfer, models.RepTriggerManual, requestID); err != nil {
 				log.Errorf("failed to trigger replication of %d: %v", id, err)
 			} else {
 				log.Infof("replication of %d triggered", id)
@@ -391,4 +476,24 @@ func (pa *RepPolicyAPI) Delete() {
 		log.Errorf("failed to delete policy %d: %v", id, err)
 		pa.CustomAbort(http.StatusInternalServerError, "")
 	}
+
+	pa.audit(id, models.AuditActionDelete, policySummary(policy), "")
+}
+
+// audit records the operation on the policy
+func (pa *RepPolicyAPI) audit(id int64, action, before, after string) {
+	pa.Audit(models.AuditLog{
+		ActorID:      pa.userID,
+		ResourceType: models.AuditResourcePolicy,
+		ResourceID:   strconv.FormatInt(id, 10),
+		Action:       action,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// policySummary describes the policy in audit log
+func policySummary(policy *models.RepPolicy) string {
+	return fmt.Sprintf("name=%s, project_id=%d, target_id=%d, direction=%s, enabled=%d",
+		policy.Name, policy.ProjectID, policy.TargetID, policy.Direction, policy.Enabled)
 }
@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"sort"
 
+	"github.com/docker/distribution"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/vmware/harbor/src/common/api"
@@ -63,18 +64,8 @@ func (ra *RepositoryAPI) Get() {
 		ra.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %d not found", projectID))
 	}
 
-	if project.Public == 0 {
-		var userID int
-
-		if svc_utils.VerifySecret(ra.Ctx.Request) {
-			userID = 1
-		} else {
-			userID = ra.ValidateUser()
-		}
-
-		if !checkProjectPermission(userID, projectID) {
-			ra.CustomAbort(http.StatusForbidden, "")
-		}
+	if project.Public == 0 && !svc_utils.VerifySecret(ra.Ctx.Request) {
+		ra.checkAccess(projectID, models.RobotActionPull)
 	}
 
 	repositories, err := getReposByProject(project.Name, ra.GetString("q"))
@@ -120,10 +111,7 @@ func (ra *RepositoryAPI) Delete() {
 	}
 
 	if project.Public == 0 {
-		userID := ra.ValidateUser()
-		if !hasProjectAdminRole(userID, project.ProjectID) {
-			ra.CustomAbort(http.StatusForbidden, "")
-		}
+		ra.checkAccess(project.ProjectID, models.RobotActionDelete)
 	}
 
 	rc, err := ra.initRepositoryClient(repoName)
@@ -162,6 +150,8 @@ func (ra *RepositoryAPI) Delete() {
 			log.Errorf("failed to get user: %v", err)
 		}
 	}
+	// the request context can't be read after the request is handled
+	sourceIP := api.SourceIP(ra.Ctx)
 
 	for _, t := range tags {
 		if err = rc.DeleteTag(t); err != nil {
@@ -175,13 +165,9 @@ func (ra *RepositoryAPI) Delete() {
 			ra.CustomAbort(http.StatusInternalServerError, "internal error")
 		}
 		log.Infof("delete tag: %s:%s", repoName, t)
-		go TriggerReplicationByRepository(repoName, []string{t}, models.RepOpDelete)
+		go TriggerReplicationByRepository(repoName, []string{t}, models.RepOpDelete, user, ra.RequestID())
 
-		go func(tag string) {
-			if err := dao.AccessLog(user, projectName, repoName, tag, "delete"); err != nil {
-				log.Errorf("failed to add access log: %v", err)
-			}
-		}(t)
+		go LogImageAccess(user, sourceIP, projectName, repoName, t, models.AuditActionDelete)
 	}
 
 	exist, err := repositoryExist(repoName, rc)
@@ -228,10 +214,7 @@ func (ra *RepositoryAPI) GetTags() {
 	}
 
 	if project.Public == 0 {
-		userID := ra.ValidateUser()
-		if !checkProjectPermission(userID, project.ProjectID) {
-			ra.CustomAbort(http.StatusForbidden, "")
-		}
+		ra.checkAccess(project.ProjectID, models.RobotActionPull)
 	}
 
 	rc, err := ra.initRepositoryClient(repoName)
@@ -296,10 +279,7 @@ func (ra *RepositoryAPI) GetManifests() {
 	}
 
 	if project.Public == 0 {
-		userID := ra.ValidateUser()
-		if !checkProjectPermission(userID, project.ProjectID) {
-			ra.CustomAbort(http.StatusForbidden, "")
-		}
+		ra.checkAccess(project.ProjectID, models.RobotActionPull)
 	}
 
 	rc, err := ra.initRepositoryClient(repoName)
@@ -309,8 +289,9 @@ func (ra *RepositoryAPI) GetManifests() {
 	}
 
 	result := struct {
-		Manifest interface{} `json:"manifest"`
-		Config   interface{} `json:"config,omitempty" `
+		Manifest  interface{}         `json:"manifest"`
+		Config    interface{}         `json:"config,omitempty" `
+		Platforms []*platformManifest `json:"platforms,omitempty"`
 	}{}
 
 	mediaTypes := []string{}
@@ -318,46 +299,101 @@ func (ra *RepositoryAPI) GetManifests() {
 	case "v1":
 		mediaTypes = append(mediaTypes, schema1.MediaTypeManifest)
 	case "v2":
-		mediaTypes = append(mediaTypes, schema2.MediaTypeManifest)
+		mediaTypes = append(mediaTypes, schema2.MediaTypeManifest, registry.MediaTypeManifestList)
 	}
 
-	_, mediaType, payload, err := rc.PullManifest(tag, mediaTypes)
+	manifest, config := ra.getManifest(rc, repoName, tag, mediaTypes)
+	result.Manifest = manifest
+	result.Config = config
+
+	// the manifest and config of every platform are returned for manifest list
+	if list, ok := manifest.(*registry.DeserializedManifestList); ok {
+		for _, descriptor := range list.Manifests {
+			m, c := ra.getManifest(rc, repoName, descriptor.Digest.String(), []string{descriptor.MediaType})
+			result.Platforms = append(result.Platforms, &platformManifest{
+				Digest:   descriptor.Digest.String(),
+				Platform: descriptor.Platform,
+				Manifest: m,
+				Config:   c,
+			})
+		}
+	}
+
+	ra.Data["json"] = result
+	ra.ServeJSON()
+}
+
+// platformManifest is the manifest for a platform referenced by manifest list
+type platformManifest struct {
+	Digest   string                `json:"digest"`
+	Platform registry.PlatformSpec `json:"platform"`
+	Manifest interface{}           `json:"manifest"`
+	Config   interface{}           `json:"config,omitempty"`
+}
+
+// getManifest pulls the manifest and returns it with its config if the schema of it is v2
+func (ra *RepositoryAPI) getManifest(rc *registry.Repository, repoName, reference string,
+	mediaTypes []string) (distribution.Manifest, interface{}) {
+	_, mediaType, payload, err := rc.PullManifest(reference, mediaTypes)
 	if err != nil {
 		if regErr, ok := err.(*registry_error.Error); ok {
 			ra.CustomAbort(regErr.StatusCode, regErr.Detail)
 		}
 
-		log.Errorf("error occurred while getting manifest of %s:%s: %v", repoName, tag, err)
+		log.Errorf("error occurred while getting manifest of %s:%s: %v", repoName, reference, err)
 		ra.CustomAbort(http.StatusInternalServerError, "internal error")
 	}
 
 	manifest, _, err := registry.UnMarshal(mediaType, payload)
 	if err != nil {
-		log.Errorf("an error occurred while parsing manifest of %s:%s: %v", repoName, tag, err)
+		log.Errorf("an error occurred while parsing manifest of %s:%s: %v", repoName, reference, err)
 		ra.CustomAbort(http.StatusInternalServerError, "")
 	}
 
-	result.Manifest = manifest
-
 	deserializedmanifest, ok := manifest.(*schema2.DeserializedManifest)
-	if ok {
-		_, data, err := rc.PullBlob(deserializedmanifest.Target().Digest.String())
-		if err != nil {
-			log.Errorf("failed to get config of manifest %s:%s: %v", repoName, tag, err)
-			ra.CustomAbort(http.StatusInternalServerError, "")
-		}
+	if !ok {
+		return manifest, nil
+	}
 
-		b, err := ioutil.ReadAll(data)
-		if err != nil {
-			log.Errorf("failed to read config of manifest %s:%s: %v", repoName, tag, err)
-			ra.CustomAbort(http.StatusInternalServerError, "")
-		}
+	_, data, err := rc.PullBlob(deserializedmanifest.Target().Digest.String())
+	if err != nil {
+		log.Errorf("failed to get config of manifest %s:%s: %v", repoName, reference, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+	defer data.Close()
 
-		result.Config = string(b)
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		log.Errorf("failed to read config of manifest %s:%s: %v", repoName, reference, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
 	}
 
-	ra.Data["json"] = result
-	ra.ServeJSON()
+	return manifest, string(b)
+}
+
+// checkAccess aborts the request if the robot account or user who sends the request
+// isn't allowed to perform the action, "pull", "push" or "delete", in the project
+func (ra *RepositoryAPI) checkAccess(projectID int64, action string) {
+	if robot := ra.GetRobot(); robot != nil {
+		if robot.ProjectID != projectID || !robot.Allows(action) {
+			ra.CustomAbort(http.StatusForbidden, "")
+		}
+		return
+	}
+
+	userID := ra.ValidateUser()
+	allowed := false
+	switch action {
+	case models.RobotActionDelete:
+		allowed = hasProjectAdminRole(userID, projectID)
+	case models.RobotActionPush:
+		allowed = hasProjectWriteRole(userID, projectID)
+	default:
+		allowed = checkProjectPermission(userID, projectID)
+	}
+	if !allowed {
+		ra.CustomAbort(http.StatusForbidden, "")
+	}
 }
 
 func (ra *RepositoryAPI) initRepositoryClient(repoName string) (r *registry.Repository, err error) {
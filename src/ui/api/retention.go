@@ -0,0 +1,214 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+	svc_utils "github.com/vmware/harbor/src/ui/service/utils"
+)
+
+// RetentionAPI handles request to /api/projects/:pid/retention and
+// /api/projects/:pid/retention/jobs/:id
+type RetentionAPI struct {
+	api.BaseAPI
+	userID  int
+	project *models.Project
+	jobID   int64
+}
+
+// Prepare validates the user and the project
+func (r *RetentionAPI) Prepare() {
+	r.userID = r.ValidateUser()
+
+	pid, err := strconv.ParseInt(r.Ctx.Input.Param(":pid"), 10, 64)
+	if err != nil || pid <= 0 {
+		r.CustomAbort(http.StatusBadRequest, "invalid project ID")
+	}
+
+	project, err := dao.GetProjectByID(pid)
+	if err != nil {
+		log.Errorf("failed to get project %d: %v", pid, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if project == nil {
+		r.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %d not found", pid))
+	}
+	r.project = project
+
+	if len(r.Ctx.Input.Param(":id")) != 0 {
+		r.jobID = r.GetIDFromURL()
+	}
+
+	if r.Ctx.Request.Method == http.MethodGet {
+		if !checkProjectPermission(r.userID, pid) {
+			r.CustomAbort(http.StatusForbidden, "")
+		}
+		return
+	}
+
+	if !hasProjectAdminRole(r.userID, pid) {
+		r.CustomAbort(http.StatusForbidden, "")
+	}
+}
+
+// Get returns the retention policy of the project
+func (r *RetentionAPI) Get() {
+	r.Data["json"] = r.getPolicy(true)
+	r.ServeJSON()
+}
+
+// Put creates or updates the retention policy of the project
+func (r *RetentionAPI) Put() {
+	req := &models.RetentionPolicy{}
+	r.DecodeJSONReqAndValidate(req)
+
+	policy := r.getPolicy(false)
+	if policy == nil {
+		req.ProjectID = r.project.ProjectID
+		req.CreatorID = r.userID
+		id, err := dao.AddRetentionPolicy(*req)
+		if err != nil {
+			log.Errorf("failed to add retention policy for project %d: %v", r.project.ProjectID, err)
+			r.CustomAbort(http.StatusInternalServerError, "")
+		}
+		log.Debugf("retention policy %d added for project %d", id, r.project.ProjectID)
+		return
+	}
+
+	req.ID = policy.ID
+	req.ProjectID = policy.ProjectID
+	if err := dao.UpdateRetentionPolicy(req); err != nil {
+		log.Errorf("failed to update retention policy %d: %v", policy.ID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// Delete removes the retention policy of the project
+func (r *RetentionAPI) Delete() {
+	policy := r.getPolicy(true)
+	if err := dao.DeleteRetentionPolicy(policy.ID); err != nil {
+		log.Errorf("failed to delete retention policy %d: %v", policy.ID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// ListJobs lists the jobs of the retention policy
+func (r *RetentionAPI) ListJobs() {
+	policy := r.getPolicy(true)
+	page, pageSize := r.GetPaginationParams()
+
+	jobs, total, err := dao.GetRetentionJobs(policy.ID, pageSize, pageSize*(page-1))
+	if err != nil {
+		log.Errorf("failed to get jobs of retention policy %d: %v", policy.ID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	r.SetPaginationHeader(total, page, pageSize)
+	r.Data["json"] = jobs
+	r.ServeJSON()
+}
+
+// GetJob returns the retention job, it contains the tags deleted, or to be
+// deleted if the job is a dry run
+func (r *RetentionAPI) GetJob() {
+	r.Data["json"] = r.getJob()
+	r.ServeJSON()
+}
+
+// PostJob starts a retention job, the tags are not deleted if "dry_run" is true
+func (r *RetentionAPI) PostJob() {
+	policy := r.getPolicy(true)
+
+	req := struct {
+		DryRun bool `json:"dry_run"`
+	}{}
+	r.DecodeJSONReq(&req)
+
+	id, err := triggerRetention(policy.ID, req.DryRun)
+	if err != nil {
+		log.Errorf("failed to trigger retention job of policy %d: %v", policy.ID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	r.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// GetJobLog returns the log of the retention job
+func (r *RetentionAPI) GetJobLog() {
+	job := r.getJob()
+
+	proxyJobLog(&r.BaseAPI, buildRetentionJobLogURL(job.ID))
+}
+
+// getPolicy returns the retention policy of the project, if it does not exist,
+// the request is aborted with 404 when abort is true, otherwise nil is returned
+func (r *RetentionAPI) getPolicy(abort bool) *models.RetentionPolicy {
+	policy, err := dao.GetRetentionPolicyByProject(r.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get retention policy of project %d: %v", r.project.ProjectID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	if policy == nil && abort {
+		r.CustomAbort(http.StatusNotFound, fmt.Sprintf("no retention policy for project %d", r.project.ProjectID))
+	}
+
+	return policy
+}
+
+func (r *RetentionAPI) getJob() *models.RetentionJob {
+	policy := r.getPolicy(true)
+
+	job, err := dao.GetRetentionJob(r.jobID)
+	if err != nil {
+		log.Errorf("failed to get retention job %d: %v", r.jobID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	if job == nil || job.PolicyID != policy.ID {
+		r.CustomAbort(http.StatusNotFound, fmt.Sprintf("retention job %d not found", r.jobID))
+	}
+
+	return job
+}
+
+// triggerRetention calls the API of job service to start a retention job
+// for the policy, it returns the ID of the job
+func triggerRetention(policyID int64, dryRun bool) (int64, error) {
+	return svc_utils.CreateJob(buildRetentionURL(), struct {
+		PolicyID int64 `json:"policy_id"`
+		DryRun   bool  `json:"dry_run"`
+	}{
+		PolicyID: policyID,
+		DryRun:   dryRun,
+	})
+}
+
+func buildRetentionURL() string {
+	return fmt.Sprintf("%s/api/jobs/retention", config.InternalJobServiceURL())
+}
+
+func buildRetentionJobLogURL(jobID int64) string {
+	return fmt.Sprintf("%s/api/jobs/retention/%d/log", config.InternalJobServiceURL(), jobID)
+}
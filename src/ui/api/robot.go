@@ -0,0 +1,225 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// RobotAPI handles request to /api/projects/:pid/robots and /api/projects/:pid/robots/:id
+type RobotAPI struct {
+	api.BaseAPI
+	userID  int
+	project *models.Project
+	robotID int64
+}
+
+// Prepare validates the user and the project, only the project admin and
+// system admin can manage the robot accounts
+func (r *RobotAPI) Prepare() {
+	r.userID = r.ValidateUser()
+
+	pid, err := strconv.ParseInt(r.Ctx.Input.Param(":pid"), 10, 64)
+	if err != nil || pid <= 0 {
+		r.CustomAbort(http.StatusBadRequest, "invalid project ID")
+	}
+
+	project, err := dao.GetProjectByID(pid)
+	if err != nil {
+		log.Errorf("failed to get project %d: %v", pid, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if project == nil {
+		r.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %d not found", pid))
+	}
+	r.project = project
+
+	if !hasProjectAdminRole(r.userID, pid) {
+		r.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if len(r.Ctx.Input.Param(":id")) != 0 {
+		r.robotID = r.GetIDFromURL()
+	}
+}
+
+// Get returns the robot account
+func (r *RobotAPI) Get() {
+	r.Data["json"] = r.getRobot()
+	r.ServeJSON()
+}
+
+// List lists the robot accounts of the project
+func (r *RobotAPI) List() {
+	robots, err := dao.GetRobots(r.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get robot accounts of project %d: %v", r.project.ProjectID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	r.addAccessLog(r.project.Name+"/", "list robots")
+
+	r.Data["json"] = robots
+	r.ServeJSON()
+}
+
+// Post creates a robot account for the project, the secret is generated and
+// only returned in the response
+func (r *RobotAPI) Post() {
+	robot := &models.Robot{}
+	r.DecodeJSONReqAndValidate(robot)
+
+	existing, err := dao.GetRobotByName(r.project.ProjectID, robot.Name)
+	if err != nil {
+		log.Errorf("failed to get robot account %s of project %d: %v", robot.Name, r.project.ProjectID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if existing != nil {
+		r.CustomAbort(http.StatusConflict, fmt.Sprintf("robot account %s already exists", robot.Name))
+	}
+
+	secret, err := generateRobotSecret()
+	if err != nil {
+		log.Errorf("failed to generate secret of robot account: %v", err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	robot.ProjectID = r.project.ProjectID
+	robot.CreatorID = r.userID
+	robot.Salt = utils.GenerateRandomString()
+	robot.Secret = utils.Encrypt(secret, robot.Salt)
+
+	id, err := dao.AddRobot(*robot)
+	if err != nil {
+		log.Errorf("failed to add robot account for project %d: %v", r.project.ProjectID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	fullName := models.RobotFullName(r.project.Name, robot.Name)
+	r.addAccessLog(fullName, "create robot")
+
+	r.Ctx.ResponseWriter.Header().Set("Location", r.Ctx.Request.RequestURI+"/"+strconv.FormatInt(id, 10))
+	r.Ctx.Output.SetStatus(http.StatusCreated)
+	r.Data["json"] = struct {
+		ID     int64  `json:"id"`
+		Name   string `json:"name"`
+		Secret string `json:"secret"`
+	}{
+		ID:     id,
+		Name:   fullName,
+		Secret: secret,
+	}
+	r.ServeJSON()
+}
+
+// Delete revokes the robot account
+func (r *RobotAPI) Delete() {
+	robot := r.getRobot()
+	if err := dao.DeleteRobot(robot.ID); err != nil {
+		log.Errorf("failed to delete robot account %d: %v", robot.ID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	r.addAccessLog(models.RobotFullName(r.project.Name, robot.Name), "delete robot")
+}
+
+func (r *RobotAPI) getRobot() *models.Robot {
+	robot, err := dao.GetRobot(r.robotID)
+	if err != nil {
+		log.Errorf("failed to get robot account %d: %v", r.robotID, err)
+		r.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if robot == nil || robot.ProjectID != r.project.ProjectID {
+		r.CustomAbort(http.StatusNotFound, fmt.Sprintf("robot account %d not found", r.robotID))
+	}
+	return robot
+}
+
+func (r *RobotAPI) addAccessLog(name, operation string) {
+	go func(userID int, projectID int64) {
+		if err := dao.AddAccessLog(models.AccessLog{
+			UserID:    userID,
+			ProjectID: projectID,
+			RepoName:  name,
+			RepoTag:   "N/A",
+			Operation: operation,
+		}); err != nil {
+			log.Errorf("failed to add access log: %v", err)
+		}
+	}(r.userID, r.project.ProjectID)
+}
+
+// LogImageAccess records the operation on the image, i.e. "push", "pull" or "delete", in the
+// access log of the project. The operations of robot accounts are recorded in the audit log
+// with the robot account as the actor instead, as the access log must refer to a user.
+func LogImageAccess(username, sourceIP, projectName, repository, tag, action string) {
+	if strings.HasPrefix(username, models.RobotPrefix) {
+		api.Audit(nil, models.AuditLog{
+			Actor:        username,
+			ResourceType: models.AuditResourceRepository,
+			ResourceID:   repository + ":" + tag,
+			Action:       action,
+			SourceIP:     sourceIP,
+		})
+		return
+	}
+
+	if err := dao.AccessLog(username, projectName, repository, tag, action); err != nil {
+		log.Errorf("failed to add access log: %v", err)
+	}
+}
+
+// RobotCreator returns the name of the user who created the robot account, it's empty if
+// the robot account or the user has been removed
+func RobotCreator(fullName string) (string, error) {
+	projectName, name, ok := models.ParseRobotFullName(fullName)
+	if !ok {
+		return "", fmt.Errorf("invalid name of robot account: %s", fullName)
+	}
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil || project == nil {
+		return "", err
+	}
+	robot, err := dao.GetRobotByName(project.ProjectID, name)
+	if err != nil || robot == nil {
+		return "", err
+	}
+	user, err := dao.GetUser(models.User{UserID: robot.CreatorID})
+	if err != nil || user == nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// generateRobotSecret generates a random secret with crypto/rand as it is a credential
+func generateRobotSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,199 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+	svc_utils "github.com/vmware/harbor/src/ui/service/utils"
+)
+
+// ScanOverview is the status of the latest scan of a tag and the vulnerabilities
+// found in the manifest the tag refers to
+type ScanOverview struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+	// JobID and Status are of the latest scan job of the tag, they are empty if
+	// the tag has never been scanned
+	JobID  int64  `json:"job_id"`
+	Status string `json:"status"`
+	// the fields below are empty if the manifest hasn't been scanned
+	Scanner         string                  `json:"scanner"`
+	Severity        string                  `json:"severity"`
+	Vulnerabilities []*models.Vulnerability `json:"vulnerabilities"`
+	ScanTime        *time.Time              `json:"scan_time,omitempty"`
+}
+
+// GetScan handles GET /api/repositories/scan, it returns the result of scanning the tag
+func (ra *RepositoryAPI) GetScan() {
+	repoName, tag := ra.getScanTarget(models.RobotActionPull)
+
+	rc, err := ra.initRepositoryClient(repoName)
+	if err != nil {
+		log.Errorf("error occurred while initializing repository client for %s: %v", repoName, err)
+		ra.CustomAbort(http.StatusInternalServerError, "internal error")
+	}
+
+	digest, exist, err := rc.ManifestExist(tag)
+	if err != nil {
+		log.Errorf("failed to check the existence of %s:%s: %v", repoName, tag, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if !exist {
+		ra.CustomAbort(http.StatusNotFound, fmt.Sprintf("%s:%s not found", repoName, tag))
+	}
+
+	overview := &ScanOverview{
+		Repository: repoName,
+		Tag:        tag,
+		Digest:     digest,
+	}
+
+	job, err := dao.GetLastScanJob(repoName, tag)
+	if err != nil {
+		log.Errorf("failed to get the last scan job of %s:%s: %v", repoName, tag, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if job != nil {
+		overview.JobID = job.ID
+		overview.Status = job.Status
+	}
+
+	result, err := dao.GetScanResult(digest)
+	if err != nil {
+		log.Errorf("failed to get the scan result of %s: %v", digest, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if result != nil {
+		overview.Scanner = result.Scanner
+		overview.Severity = result.Severity
+		overview.Vulnerabilities = result.Vulnerabilities
+		overview.ScanTime = &result.UpdateTime
+	}
+
+	ra.Data["json"] = overview
+	ra.ServeJSON()
+}
+
+// Scan handles POST /api/repositories/scan, it starts a job scanning the tag
+func (ra *RepositoryAPI) Scan() {
+	repoName, tag := ra.getScanTarget(models.RobotActionPush)
+
+	id, err := TriggerScan(repoName, tag)
+	if err != nil {
+		log.Errorf("failed to trigger scan job for %s:%s: %v", repoName, tag, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	ra.Ctx.Output.SetStatus(http.StatusCreated)
+	ra.Data["json"] = struct {
+		JobID int64 `json:"job_id"`
+	}{
+		JobID: id,
+	}
+	ra.ServeJSON()
+}
+
+// GetScanLog handles GET /api/repositories/scan/log, it returns the log of the latest
+// scan job of the tag
+func (ra *RepositoryAPI) GetScanLog() {
+	repoName, tag := ra.getScanTarget(models.RobotActionPull)
+
+	job, err := dao.GetLastScanJob(repoName, tag)
+	if err != nil {
+		log.Errorf("failed to get the last scan job of %s:%s: %v", repoName, tag, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if job == nil {
+		ra.CustomAbort(http.StatusNotFound, fmt.Sprintf("%s:%s has never been scanned", repoName, tag))
+	}
+
+	proxyJobLog(&ra.BaseAPI, buildScanLogURL(job.ID))
+}
+
+// getScanTarget returns the repository and tag in the query parameters after checking
+// that the user or robot account is allowed to perform the action in the project
+func (ra *RepositoryAPI) getScanTarget(action string) (string, string) {
+	repoName := ra.GetString("repo_name")
+	tag := ra.GetString("tag")
+	if len(repoName) == 0 || len(tag) == 0 {
+		ra.CustomAbort(http.StatusBadRequest, "repo_name or tag is nil")
+	}
+
+	projectName, _ := utils.ParseRepository(repoName)
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("failed to get project %s: %v", projectName, err)
+		ra.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	if project == nil {
+		ra.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %s not found", projectName))
+	}
+
+	if project.Public == 0 || action != models.RobotActionPull {
+		ra.checkAccess(project.ProjectID, action)
+	}
+
+	return repoName, tag
+}
+
+// TriggerScan calls the API of job service to scan the tag, it returns the ID of the job
+func TriggerScan(repository, tag string) (int64, error) {
+	return svc_utils.CreateJob(buildScanURL(), struct {
+		Repository string `json:"repository"`
+		Tag        string `json:"tag"`
+	}{
+		Repository: repository,
+		Tag:        tag,
+	})
+}
+
+// TriggerScanOnPush scans the tag if the project scans the images pushed to it
+func TriggerScanOnPush(repository, tag string) {
+	projectName, _ := utils.ParseRepository(repository)
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("failed to get project %s: %v", projectName, err)
+		return
+	}
+	if project == nil || project.ScanOnPush == 0 {
+		return
+	}
+
+	id, err := TriggerScan(repository, tag)
+	if err != nil {
+		log.Errorf("failed to trigger scan job for %s:%s: %v", repository, tag, err)
+		return
+	}
+	log.Debugf("scan job %d for %s:%s triggered", id, repository, tag)
+}
+
+func buildScanURL() string {
+	return fmt.Sprintf("%s/api/jobs/scan", config.InternalJobServiceURL())
+}
+
+func buildScanLogURL(jobID int64) string {
+	return fmt.Sprintf("%s/api/jobs/scan/%d/log", config.InternalJobServiceURL(), jobID)
+}
@@ -0,0 +1,255 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// SigningKeyAPI handles request to /api/projects/:pid/signing_keys and /api/projects/:pid/signing_keys/:id
+type SigningKeyAPI struct {
+	api.BaseAPI
+	userID  int
+	project *models.Project
+	keyID   int64
+}
+
+// Prepare validates the user and the project, only the project admin and
+// system admin can manage the signing keys
+func (s *SigningKeyAPI) Prepare() {
+	s.userID = s.ValidateUser()
+	s.project = getProjectFromURL(&s.BaseAPI)
+
+	if !hasProjectAdminRole(s.userID, s.project.ProjectID) {
+		s.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if len(s.Ctx.Input.Param(":id")) != 0 {
+		s.keyID = s.GetIDFromURL()
+	}
+}
+
+// List lists the signing keys of the project
+func (s *SigningKeyAPI) List() {
+	keys, err := dao.GetSigningKeys(s.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get signing keys of project %d: %v", s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	s.Data["json"] = keys
+	s.ServeJSON()
+}
+
+// Post adds a public key to the trusted signing keys of the project
+func (s *SigningKeyAPI) Post() {
+	key := &models.SigningKey{}
+	s.DecodeJSONReqAndValidate(key)
+
+	existing, err := dao.GetSigningKeyByName(s.project.ProjectID, key.Name)
+	if err != nil {
+		log.Errorf("failed to get signing key %s of project %d: %v", key.Name, s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if existing != nil {
+		s.CustomAbort(http.StatusConflict, fmt.Sprintf("signing key %s already exists", key.Name))
+	}
+
+	key.ProjectID = s.project.ProjectID
+	key.CreatorID = s.userID
+	id, err := dao.AddSigningKey(*key)
+	if err != nil {
+		log.Errorf("failed to add signing key for project %d: %v", s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(s.userID, s.project, "create signing key")
+
+	s.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// Delete removes the signing key and the signatures verified by it, the images
+// signed only by this key can't be pulled any more if signature is required
+func (s *SigningKeyAPI) Delete() {
+	key, err := dao.GetSigningKey(s.keyID)
+	if err != nil {
+		log.Errorf("failed to get signing key %d: %v", s.keyID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if key == nil || key.ProjectID != s.project.ProjectID {
+		s.CustomAbort(http.StatusNotFound, fmt.Sprintf("signing key %d not found", s.keyID))
+	}
+
+	if err = dao.DeleteSigningKey(key.ID); err != nil {
+		log.Errorf("failed to delete signing key %d: %v", key.ID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	addProjectAccessLog(s.userID, s.project, "delete signing key")
+}
+
+// SignatureAPI handles request to /api/projects/:pid/signatures, it can be
+// accessed by the robot accounts of the project, e.g. from the CI pipelines
+type SignatureAPI struct {
+	api.BaseAPI
+	project *models.Project
+}
+
+// Prepare validates the project
+func (s *SignatureAPI) Prepare() {
+	s.project = getProjectFromURL(&s.BaseAPI)
+}
+
+// List returns the signatures of the manifest specified by the query parameter "digest"
+func (s *SignatureAPI) List() {
+	if s.project.Public == 0 {
+		s.checkAccess(models.RobotActionPull)
+	}
+
+	digest := s.GetString("digest")
+	if len(digest) == 0 {
+		s.CustomAbort(http.StatusBadRequest, "digest is required")
+	}
+
+	signatures, err := dao.GetSignatures(s.project.ProjectID, digest)
+	if err != nil {
+		log.Errorf("failed to get signatures of %s in project %d: %v", digest, s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	s.Data["json"] = signatures
+	s.ServeJSON()
+}
+
+// Post attaches a detached signature to the manifest, the signature is only
+// stored if it can be verified by one of the signing keys of the project
+func (s *SignatureAPI) Post() {
+	creator := s.checkAccess(models.RobotActionPush)
+
+	sig := &models.Signature{}
+	s.DecodeJSONReqAndValidate(sig)
+
+	keys, err := dao.GetSigningKeys(s.project.ProjectID)
+	if err != nil {
+		log.Errorf("failed to get signing keys of project %d: %v", s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	var key *models.SigningKey
+	for _, k := range keys {
+		if err := sig.Verify(k); err == nil {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		s.CustomAbort(http.StatusBadRequest, "the signature can not be verified by any signing key of the project")
+	}
+
+	signatures, err := dao.GetSignatures(s.project.ProjectID, sig.Digest)
+	if err != nil {
+		log.Errorf("failed to get signatures of %s in project %d: %v", sig.Digest, s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+	for _, existing := range signatures {
+		if existing.KeyID == key.ID {
+			s.CustomAbort(http.StatusConflict, fmt.Sprintf("%s is already signed by key %s", sig.Digest, key.Name))
+		}
+	}
+
+	sig.ProjectID = s.project.ProjectID
+	sig.KeyID = key.ID
+	sig.Creator = creator
+	id, err := dao.AddSignature(*sig)
+	if err != nil {
+		log.Errorf("failed to add signature for project %d: %v", s.project.ProjectID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	s.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// checkAccess aborts the request if the robot account or user who sends the request
+// isn't allowed to perform the action, "pull" or "push", in the project. The name
+// of the robot account or user is returned.
+func (s *SignatureAPI) checkAccess(action string) string {
+	if robot := s.GetRobot(); robot != nil {
+		if robot.ProjectID != s.project.ProjectID || !robot.Allows(action) {
+			s.CustomAbort(http.StatusForbidden, "")
+		}
+		return models.RobotFullName(s.project.Name, robot.Name)
+	}
+
+	userID := s.ValidateUser()
+	allowed := false
+	if action == models.RobotActionPush {
+		allowed = hasProjectWriteRole(userID, s.project.ProjectID)
+	} else {
+		allowed = checkProjectPermission(userID, s.project.ProjectID)
+	}
+	if !allowed {
+		s.CustomAbort(http.StatusForbidden, "")
+	}
+
+	user, err := dao.GetUser(models.User{UserID: userID})
+	if err != nil {
+		log.Errorf("failed to get user %d: %v", userID, err)
+		s.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if user == nil {
+		s.CustomAbort(http.StatusUnauthorized, "")
+	}
+	return user.Username
+}
+
+// getProjectFromURL returns the project specified by the parameter ":pid" in URL
+func getProjectFromURL(b *api.BaseAPI) *models.Project {
+	pid, err := strconv.ParseInt(b.Ctx.Input.Param(":pid"), 10, 64)
+	if err != nil || pid <= 0 {
+		b.CustomAbort(http.StatusBadRequest, "invalid project ID")
+	}
+
+	project, err := dao.GetProjectByID(pid)
+	if err != nil {
+		log.Errorf("failed to get project %d: %v", pid, err)
+		b.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if project == nil {
+		b.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %d not found", pid))
+	}
+	return project
+}
+
+func addProjectAccessLog(userID int, project *models.Project, operation string) {
+	go func() {
+		if err := dao.AddAccessLog(models.AccessLog{
+			UserID:    userID,
+			ProjectID: project.ProjectID,
+			RepoName:  project.Name + "/",
+			RepoTag:   "N/A",
+			Operation: operation,
+		}); err != nil {
+			log.Errorf("failed to add access log: %v", err)
+		}
+	}()
+}
@@ -30,6 +30,9 @@ type SystemInfo struct {
 type Storage struct {
 	Total uint64 `json:"total"`
 	Free  uint64 `json:"free"`
+	// Unlinked is the size of the blobs unlinked by the last garbage collection, the
+	// space is not reclaimed until the offline garbage collection of registry is run
+	Unlinked int64 `json:"unlinked"`
 }
 
 // Prepare for validating user if an admin.
@@ -58,10 +61,22 @@ func (sia *SystemInfoAPI) GetVolumeInfo() {
 		return
 	}
 
+	gcJob, err := dao.GetLastGCJob()
+	if err != nil {
+		log.Errorf("Error occurred in GetLastGCJob: %v", err)
+		sia.CustomAbort(http.StatusInternalServerError, "Internal error.")
+		return
+	}
+	var unlinked int64
+	if gcJob != nil {
+		unlinked = gcJob.UnlinkedBytes
+	}
+
 	systemInfo := SystemInfo{
 		HarborStorage: Storage{
-			Total: stat.Blocks * uint64(stat.Bsize),
-			Free:  stat.Bavail * uint64(stat.Bsize),
+			Total:    stat.Blocks * uint64(stat.Bsize),
+			Free:     stat.Bavail * uint64(stat.Bsize),
+			Unlinked: unlinked,
 		},
 	}
 
@@ -37,14 +37,15 @@ import (
 type TargetAPI struct {
 	api.BaseAPI
 	secretKey string
+	userID    int
 }
 
 // Prepare validates the user
 func (t *TargetAPI) Prepare() {
 	t.secretKey = config.SecretKey()
 
-	userID := t.ValidateUser()
-	isSysAdmin, err := dao.IsAdminRole(userID)
+	t.userID = t.ValidateUser()
+	isSysAdmin, err := dao.IsAdminRole(t.userID)
 	if err != nil {
 		log.Errorf("error occurred in IsAdminRole: %v", err)
 		t.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
@@ -219,6 +220,8 @@ func (t *TargetAPI) Post() {
 		t.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 	}
 
+	t.audit(id, models.AuditActionCreate, "", targetSummary(target))
+
 	t.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
 }
 
@@ -295,6 +298,8 @@ func (t *TargetAPI) Put() {
 		log.Errorf("failed to update target %d: %v", id, err)
 		t.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 	}
+
+	t.audit(id, models.AuditActionUpdate, targetSummary(originalTarget), targetSummary(target))
 }
 
 // Delete ...
@@ -321,10 +326,39 @@ func (t *TargetAPI) Delete() {
 		t.CustomAbort(http.StatusBadRequest, "the target is used by policies, can not be deleted")
 	}
 
+	projects, err := dao.GetProjectsByProxyTarget(id)
+	if err != nil {
+		log.Errorf("failed to get proxy cache projects of target %d: %v", id, err)
+		t.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if len(projects) > 0 {
+		t.CustomAbort(http.StatusBadRequest, "the target is used by proxy cache projects, can not be deleted")
+	}
+
 	if err = dao.DeleteRepTarget(id); err != nil {
 		log.Errorf("failed to delete target %d: %v", id, err)
 		t.CustomAbort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 	}
+
+	t.audit(id, models.AuditActionDelete, targetSummary(target), "")
+}
+
+// audit records the operation on the target
+func (t *TargetAPI) audit(id int64, action, before, after string) {
+	t.Audit(models.AuditLog{
+		ActorID:      t.userID,
+		ResourceType: models.AuditResourceTarget,
+		ResourceID:   strconv.FormatInt(id, 10),
+		Action:       action,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// targetSummary describes the target in audit log, the password is left out
+func targetSummary(target *models.RepTarget) string {
+	return fmt.Sprintf("name=%s, endpoint=%s, username=%s", target.Name, target.URL, target.Username)
 }
 
 func newRegistryClient(endpoint string, insecure bool, username, password, scopeType, scopeName string,
@@ -332,7 +366,8 @@ func newRegistryClient(endpoint string, insecure bool, username, password, scope
 	credential := auth.NewBasicAuthCredential(username, password)
 	authorizer := auth.NewStandardTokenAuthorizer(credential, insecure, scopeType, scopeName, scopeActions...)
 
-	store, err := auth.NewAuthorizerStore(endpoint, insecure, authorizer)
+	// the basic authorizer is used by the plain registries with htpasswd
+	store, err := auth.NewAuthorizerStore(endpoint, insecure, authorizer, auth.NewBasicAuthorizer(credential))
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth/oidc"
 	"github.com/vmware/harbor/src/ui/config"
 )
 
@@ -223,6 +224,15 @@ func (ua *UserAPI) Post() {
 		ua.CustomAbort(http.StatusInternalServerError, "Internal error.")
 	}
 
+	ua.Audit(models.AuditLog{
+		ActorID:      ua.currentUserID,
+		Actor:        actorName(ua.currentUserID, user.Username),
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   strconv.FormatInt(userID, 10),
+		Action:       models.AuditActionCreate,
+		After:        userSummary(&user),
+	})
+
 	ua.Redirect(http.StatusCreated, strconv.FormatInt(userID, 10))
 }
 
@@ -242,13 +252,26 @@ func (ua *UserAPI) Delete() {
 		ua.CustomAbort(http.StatusForbidden, "can not delete yourself")
 	}
 
-	var err error
+	user, err := dao.GetUser(models.User{UserID: ua.userID})
+	if err != nil {
+		log.Errorf("Error occurred in GetUser, error: %v", err)
+		ua.CustomAbort(http.StatusInternalServerError, "Internal error.")
+	}
+
 	err = dao.DeleteUser(ua.userID)
 	if err != nil {
 		log.Errorf("Failed to delete data from database, error: %v", err)
 		ua.RenderError(http.StatusInternalServerError, "Failed to delete User")
 		return
 	}
+
+	ua.Audit(models.AuditLog{
+		ActorID:      ua.currentUserID,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   strconv.Itoa(ua.userID),
+		Action:       models.AuditActionDelete,
+		Before:       userSummary(user),
+	})
 }
 
 // ChangePassword handles PUT to /api/users/{}/password
@@ -293,6 +316,14 @@ func (ua *UserAPI) ChangePassword() {
 		log.Errorf("Error occurred in ChangeUserPassword: %v", err)
 		ua.CustomAbort(http.StatusInternalServerError, "Internal error.")
 	}
+
+	ua.Audit(models.AuditLog{
+		ActorID:      ua.currentUserID,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   strconv.Itoa(ua.userID),
+		Action:       models.AuditActionChangePassword,
+		After:        fmt.Sprintf("username=%s", user.Username),
+	})
 }
 
 // ToggleUserAdminRole handles PUT api/users/{}/sysadmin
@@ -304,10 +335,60 @@ func (ua *UserAPI) ToggleUserAdminRole() {
 	}
 	userQuery := models.User{UserID: ua.userID}
 	ua.DecodeJSONReq(&userQuery)
+	user, err := dao.GetUser(models.User{UserID: userQuery.UserID})
+	if err != nil {
+		log.Errorf("Error occurred in GetUser, error: %v", err)
+		ua.CustomAbort(http.StatusInternalServerError, "Internal error.")
+	}
+	if user == nil {
+		ua.CustomAbort(http.StatusNotFound, "")
+	}
 	if err := dao.ToggleUserAdminRole(userQuery.UserID, userQuery.HasAdminRole); err != nil {
 		log.Errorf("Error occurred in ToggleUserAdminRole: %v", err)
 		ua.CustomAbort(http.StatusInternalServerError, "Internal error.")
 	}
+
+	ua.Audit(models.AuditLog{
+		ActorID:      ua.currentUserID,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   strconv.Itoa(userQuery.UserID),
+		Action:       models.AuditActionSetAdminRole,
+		Before:       fmt.Sprintf("username=%s, has_admin_role=%d", user.Username, user.HasAdminRole),
+		After:        fmt.Sprintf("username=%s, has_admin_role=%d", user.Username, userQuery.HasAdminRole),
+	})
+}
+
+// GenerateCLISecret handles POST to /api/users/{}/cli_secret, the secret is used by the user
+// provisioned from OIDC provider in place of the password when logging in with docker CLI,
+// it's only returned in the response and the secret generated before is revoked
+func (ua *UserAPI) GenerateCLISecret() {
+	if ua.AuthMode != "oidc_auth" {
+		ua.CustomAbort(http.StatusPreconditionFailed, "CLI secret is only available in OIDC authentication mode")
+	}
+
+	// Prepare skips the validation of the user for POST requests without credentials
+	if ua.currentUserID == 0 {
+		ua.CustomAbort(http.StatusUnauthorized, "")
+	}
+	if ua.userID != ua.currentUserID {
+		ua.CustomAbort(http.StatusForbidden, "users can only generate CLI secret for themselves")
+	}
+
+	secret, err := oidc.GenerateCLISecret(ua.userID)
+	if err == oidc.ErrNotOIDCUser {
+		ua.CustomAbort(http.StatusPreconditionFailed, err.Error())
+	}
+	if err != nil {
+		log.Errorf("failed to generate CLI secret for user %d: %v", ua.userID, err)
+		ua.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	ua.Data["json"] = struct {
+		Secret string `json:"secret"`
+	}{
+		Secret: secret,
+	}
+	ua.ServeJSON()
 }
 
 // validate only validate when user register
@@ -371,3 +452,20 @@ func isContainIllegalChar(s string, illegalChar []string) bool {
 	}
 	return false
 }
+
+// userSummary describes the user in audit log
+func userSummary(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	return fmt.Sprintf("username=%s, email=%s, realname=%s", user.Username, user.Email, user.Realname)
+}
+
+// actorName returns the name recorded as the actor of the registration, it's the new user
+// when the registration is done without logging in
+func actorName(currentUserID int, username string) string {
+	if currentUserID == 0 {
+		return username
+	}
+	return ""
+}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -26,6 +27,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/vmware/harbor/src/common/api"
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils"
@@ -61,6 +63,23 @@ func hasProjectAdminRole(userID int, projectID int64) bool {
 	return false
 }
 
+// hasProjectWriteRole returns whether the user is project admin or developer of the project
+func hasProjectWriteRole(userID int, projectID int64) bool {
+	roles, err := listRoles(userID, projectID)
+	if err != nil {
+		log.Errorf("error occurred in getProjectPermission: %v", err)
+		return false
+	}
+
+	for _, role := range roles {
+		if role.RoleID == models.PROJECTADMIN || role.RoleID == models.DEVELOPER {
+			return true
+		}
+	}
+
+	return false
+}
+
 //sysadmin has all privileges to all projects
 func listRoles(userID int, projectID int64) ([]models.Role, error) {
 	roles := make([]models.Role, 0, 1)
@@ -100,19 +119,24 @@ func checkUserExists(name string) int {
 	return 0
 }
 
-// TriggerReplication triggers the replication according to the policy
+// TriggerReplication triggers the replication according to the policy, trigger is what caused
+// the replication, "manual" or "event", the jobs of a manual sync of all the repositories are
+// queued above the scheduled ones. requestID is the ID of the request causing the replication,
+// it's passed to the job service and written into the job logs
 func TriggerReplication(policyID int64, repository string,
-	tags []string, operation string) error {
+	tags []string, operation, trigger, requestID string) error {
 	data := struct {
 		PolicyID  int64    `json:"policy_id"`
 		Repo      string   `json:"repository"`
 		Operation string   `json:"operation"`
 		TagList   []string `json:"tags"`
+		Trigger   string   `json:"trigger"`
 	}{
 		PolicyID:  policyID,
 		Repo:      repository,
 		TagList:   tags,
 		Operation: operation,
+		Trigger:   trigger,
 	}
 
 	b, err := json.Marshal(&data)
@@ -127,6 +151,9 @@ func TriggerReplication(policyID int64, repository string,
 		return err
 	}
 	addAuthentication(req)
+	if len(requestID) != 0 {
+		req.Header.Set(api.HeaderRequestID, requestID)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -163,29 +190,58 @@ func GetPoliciesByRepository(repository string) ([]*models.RepPolicy, error) {
 		return nil, err
 	}
 
-	return policies, nil
+	matched := []*models.RepPolicy{}
+	for _, policy := range policies {
+		if policy.MatchRepository(repository) {
+			matched = append(matched, policy)
+		}
+	}
+
+	return matched, nil
 }
 
-// TriggerReplicationByRepository triggers the replication according to the repository
-func TriggerReplicationByRepository(repository string, tags []string, operation string) {
+// TriggerReplicationByRepository triggers the replication according to the repository,
+// username is the user who performed the operation, the tags of a transfer are replicated
+// by the policies whose pushed-by filter is set only if they're pushed by the user
+func TriggerReplicationByRepository(repository string, tags []string, operation, username, requestID string) {
+	logger := api.RequestLogger(requestID)
 	policies, err := GetPoliciesByRepository(repository)
 	if err != nil {
-		log.Errorf("failed to get policies for repository %s: %v", repository, err)
+		logger.Errorf("failed to get policies for repository %s: %v", repository, err)
 		return
 	}
 
 	for _, policy := range policies {
-		if policy.Enabled == 0 {
+		// the images of pull policies are replicated from the target
+		if policy.Enabled == 0 || policy.IsPull() {
+			continue
+		}
+		if operation == models.RepOpTransfer && len(policy.PushedBy) != 0 && policy.PushedBy != username {
+			continue
+		}
+		matched := filterTags(policy, tags)
+		if len(matched) == 0 {
 			continue
 		}
-		if err := TriggerReplication(policy.ID, repository, tags, operation); err != nil {
-			log.Errorf("failed to trigger replication of policy %d for %s: %v", policy.ID, repository, err)
+		if err := TriggerReplication(policy.ID, repository, matched, operation, models.RepTriggerEvent, requestID); err != nil {
+			logger.Errorf("failed to trigger replication of policy %d for %s: %v", policy.ID, repository, err)
 		} else {
-			log.Infof("replication of policy %d for %s triggered", policy.ID, repository)
+			logger.Infof("replication of policy %d for %s triggered", policy.ID, repository)
 		}
 	}
 }
 
+// filterTags returns the tags which pass the tag filters of the policy
+func filterTags(policy *models.RepPolicy, tags []string) []string {
+	matched := []string{}
+	for _, tag := range tags {
+		if policy.MatchTag(tag) {
+			matched = append(matched, tag)
+		}
+	}
+	return matched
+}
+
 func postReplicationAction(policyID int64, acton string) error {
 	data := struct {
 		PolicyID int64  `json:"policy_id"`
@@ -509,3 +565,39 @@ func repositoryExist(name string, client *registry.Repository) (bool, error) {
 	}
 	return len(tags) != 0, nil
 }
+
+// proxyJobLog writes the log of the job got from job service to the response
+func proxyJobLog(b *api.BaseAPI, url string) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		log.Errorf("failed to create a request: %v", err)
+		b.CustomAbort(http.StatusInternalServerError, "")
+	}
+	addAuthentication(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Errorf("failed to get log from %s: %v", url, err)
+		b.CustomAbort(http.StatusInternalServerError, "")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		b.Ctx.ResponseWriter.Header().Set(http.CanonicalHeaderKey("Content-Length"), resp.Header.Get(http.CanonicalHeaderKey("Content-Length")))
+		b.Ctx.ResponseWriter.Header().Set(http.CanonicalHeaderKey("Content-Type"), "text/plain")
+
+		if _, err = io.Copy(b.Ctx.ResponseWriter, resp.Body); err != nil {
+			log.Errorf("failed to write log to response; %v", err)
+			b.CustomAbort(http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("failed to read reponse body: %v", err)
+		b.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	b.CustomAbort(resp.StatusCode, string(data))
+}
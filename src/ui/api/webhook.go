@@ -0,0 +1,171 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// WebhookAPI handles request to /api/projects/:pid/webhooks and
+// /api/projects/:pid/webhooks/:id
+type WebhookAPI struct {
+	api.BaseAPI
+	project   *models.Project
+	webhookID int64
+}
+
+// Prepare validates the user and the project, only the project admin and
+// system admin can manage the webhooks
+func (w *WebhookAPI) Prepare() {
+	userID := w.ValidateUser()
+
+	pid, err := strconv.ParseInt(w.Ctx.Input.Param(":pid"), 10, 64)
+	if err != nil || pid <= 0 {
+		w.CustomAbort(http.StatusBadRequest, "invalid project ID")
+	}
+
+	project, err := dao.GetProjectByID(pid)
+	if err != nil {
+		log.Errorf("failed to get project %d: %v", pid, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if project == nil {
+		w.CustomAbort(http.StatusNotFound, fmt.Sprintf("project %d not found", pid))
+	}
+	w.project = project
+
+	if !hasProjectAdminRole(userID, pid) {
+		w.CustomAbort(http.StatusForbidden, "")
+	}
+
+	if len(w.Ctx.Input.Param(":id")) != 0 {
+		w.webhookID = w.GetIDFromURL()
+	}
+}
+
+// Get returns the webhook
+func (w *WebhookAPI) Get() {
+	webhook := w.getWebhook()
+	webhook.Secret = ""
+	w.Data["json"] = webhook
+	w.ServeJSON()
+}
+
+// List lists the webhooks of the project
+func (w *WebhookAPI) List() {
+	webhooks, err := dao.GetWebhooks(w.project.ProjectID, false)
+	if err != nil {
+		log.Errorf("failed to get webhooks of project %d: %v", w.project.ProjectID, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	w.Data["json"] = webhooks
+	w.ServeJSON()
+}
+
+// Post creates a webhook for the project
+func (w *WebhookAPI) Post() {
+	webhook := &models.Webhook{}
+	w.DecodeJSONReqAndValidate(webhook)
+	webhook.ProjectID = w.project.ProjectID
+	w.encryptSecret(webhook)
+
+	id, err := dao.AddWebhook(*webhook)
+	if err != nil {
+		log.Errorf("failed to add webhook for project %d: %v", w.project.ProjectID, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	w.Redirect(http.StatusCreated, strconv.FormatInt(id, 10))
+}
+
+// Put updates the webhook, the secret keeps unchanged if it is not provided
+func (w *WebhookAPI) Put() {
+	webhook := w.getWebhook()
+
+	req := &models.Webhook{}
+	w.DecodeJSONReqAndValidate(req)
+	req.ID = webhook.ID
+	req.ProjectID = webhook.ProjectID
+	w.encryptSecret(req)
+
+	if err := dao.UpdateWebhook(req); err != nil {
+		log.Errorf("failed to update webhook %d: %v", webhook.ID, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// Delete deletes the webhook and its delivery history
+func (w *WebhookAPI) Delete() {
+	webhook := w.getWebhook()
+	if err := dao.DeleteWebhook(webhook.ID); err != nil {
+		log.Errorf("failed to delete webhook %d: %v", webhook.ID, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+}
+
+// ListDeliveries lists the delivery history of the webhook
+func (w *WebhookAPI) ListDeliveries() {
+	webhook := w.getWebhook()
+	page, pageSize := w.GetPaginationParams()
+
+	deliveries, total, err := dao.GetWebhookDeliveries(webhook.ID, pageSize, pageSize*(page-1))
+	if err != nil {
+		log.Errorf("failed to get deliveries of webhook %d: %v", webhook.ID, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	w.SetPaginationHeader(total, page, pageSize)
+	w.Data["json"] = deliveries
+	w.ServeJSON()
+}
+
+func (w *WebhookAPI) getWebhook() *models.Webhook {
+	webhook, err := dao.GetWebhook(w.webhookID)
+	if err != nil {
+		log.Errorf("failed to get webhook %d: %v", w.webhookID, err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+	if webhook == nil || webhook.ProjectID != w.project.ProjectID {
+		w.CustomAbort(http.StatusNotFound, fmt.Sprintf("webhook %d not found", w.webhookID))
+	}
+	return webhook
+}
+
+func (w *WebhookAPI) encryptSecret(webhook *models.Webhook) {
+	if len(webhook.Secret) == 0 {
+		return
+	}
+
+	secret, err := utils.ReversibleEncrypt(webhook.Secret, config.SecretKey())
+	if err != nil {
+		log.Errorf("failed to encrypt secret of webhook: %v", err)
+		w.CustomAbort(http.StatusInternalServerError, "")
+	}
+	webhook.Secret = secret
+}
@@ -17,6 +17,8 @@ package auth
 import (
 	"testing"
 	"time"
+
+	"github.com/vmware/harbor/src/common/models"
 )
 
 var l = NewUserLock(2 * time.Second)
@@ -38,3 +40,15 @@ func TestLock(t *testing.T) {
 		t.Errorf("daniel has never been locked, he should not be locked")
 	}
 }
+
+func TestGroupRoles(t *testing.T) {
+	roles := GroupRoles{}
+	roles.Add(1, models.DEVELOPER)
+	roles.Add(1, models.GUEST)
+	roles.Add(2, models.GUEST)
+	roles.Add(2, models.PROJECTADMIN)
+
+	if len(roles) != 2 || roles[1] != models.DEVELOPER || roles[2] != models.PROJECTADMIN {
+		t.Errorf("unexpected roles: %v", roles)
+	}
+}
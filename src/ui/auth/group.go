@@ -0,0 +1,87 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package auth
+
+import (
+	"github.com/vmware/harbor/src/common/dao"
+)
+
+// GroupRoles are the roles granted to the groups of a user by projects, keyed by project ID
+type GroupRoles map[int64]int
+
+// Add adds the role granted by the project, the most privileged role is kept if the
+// user is in several groups of the project
+func (g GroupRoles) Add(projectID int64, role int) {
+	// the smaller the role ID is, the more privileged the role is
+	if r, ok := g[projectID]; !ok || role < r {
+		g[projectID] = role
+	}
+}
+
+// SyncGroupRoles makes the user a member of the projects which grant roles to the groups
+// of the user, and removes the memberships granted before if the user is no longer in
+// the groups. Memberships added by project admins are left untouched.
+func SyncGroupRoles(userID int, roles GroupRoles) error {
+	grants, err := dao.GetGroupMemberGrants(userID)
+	if err != nil {
+		return err
+	}
+	granted := map[int64]bool{}
+	for _, projectID := range grants {
+		granted[projectID] = true
+	}
+
+	for projectID, role := range roles {
+		current, err := dao.GetUserProjectRoles(userID, projectID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(current) == 0:
+			if err = dao.AddProjectMember(projectID, userID, role); err != nil {
+				return err
+			}
+		case !granted[projectID]:
+			// the member is added by project admin
+			continue
+		case current[0].RoleID != role:
+			if err = dao.UpdateProjectMember(projectID, userID, role); err != nil {
+				return err
+			}
+		}
+
+		if !granted[projectID] {
+			if err = dao.AddGroupMemberGrant(projectID, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for projectID := range granted {
+		if _, ok := roles[projectID]; ok {
+			continue
+		}
+		if err = dao.DeleteProjectMember(projectID, userID); err != nil {
+			return err
+		}
+		if err = dao.DeleteGroupMemberGrant(projectID, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ldap
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	ldapUtils "github.com/vmware/harbor/src/common/utils/ldap"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// searchGroups returns the normalized DNs of the groups the user is a member of,
+// nil is returned if the groups are not configured
+func searchGroups(conn *ldapUtils.Conn, username, userDN string) ([]string, error) {
+	setting := config.LDAPGroup()
+	if len(setting.BaseDn) == 0 {
+		return nil, nil
+	}
+
+	filter := groupFilter(setting, username, userDN)
+	log.Debug("group filter", filter)
+	entries, err := conn.Search(&ldapUtils.SearchRequest{
+		BaseDN:     setting.BaseDn,
+		Scope:      ldapUtils.ScopeWholeSubtree,
+		Filter:     filter,
+		Attributes: []string{"cn"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []string{}
+	for _, en := range entries {
+		groups = append(groups, models.NormalizeDN(en.DN))
+	}
+	return groups, nil
+}
+
+// groupFilter returns the filter matching the groups which list the user as a member
+func groupFilter(setting config.LDAPGroupSetting, username, userDN string) string {
+	member := userDN
+	// posixGroup lists the user names rather than the DNs of its members
+	if strings.EqualFold(setting.MemberAttr, "memberUid") {
+		member = username
+	}
+	return "(&" + setting.Filter + "(" + setting.MemberAttr + "=" + ldapUtils.EscapeFilter(member) + "))"
+}
+
+// syncGroupRoles synchronizes the memberships granted to the groups of the user
+func syncGroupRoles(userID int, groups []string) error {
+	projectGroups, err := dao.GetProjectLDAPGroupsByDNs(groups)
+	if err != nil {
+		return err
+	}
+
+	roles := auth.GroupRoles{}
+	for _, g := range projectGroups {
+		roles.Add(g.ProjectID, g.Role)
+	}
+	return auth.SyncGroupRoles(userID, roles)
+}
+
+// SyncGroups synchronizes the memberships granted to LDAP groups for all users, the
+// memberships of the users not found in LDAP anymore are removed. The admin is skipped
+// as it's a local user.
+func SyncGroups() error {
+	users, err := dao.ListUsers(models.User{})
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if strings.ContainsAny(u.Username, metaChars) {
+			continue
+		}
+
+		groups, err := lookupGroups(u.Username)
+		if err != nil {
+			log.Errorf("failed to search the groups of user %s: %v", u.Username, err)
+			continue
+		}
+
+		if err = syncGroupRoles(u.UserID, groups); err != nil {
+			log.Errorf("failed to synchronize the group roles of user %s: %v", u.Username, err)
+		}
+	}
+	return nil
+}
+
+// lookupGroups returns the groups of the user, nil is returned if the user is not found
+func lookupGroups(username string) ([]string, error) {
+	conn, err := getPool().Get()
+	if err != nil {
+		return nil, err
+	}
+	defer getPool().Put(conn)
+
+	en, err := searchUser(conn, username)
+	if err != nil || en == nil {
+		return nil, err
+	}
+	return searchGroups(conn, username, en.DN)
+}
+
+// StartGroupSync starts a loop which synchronizes the memberships granted to LDAP
+// groups every LDAP_GROUP_SYNC_INTERVAL minutes, so they are added and removed as
+// the groups change in LDAP even if the users don't log in.
+func StartGroupSync() {
+	interval := config.LDAPGroup().SyncInterval
+	if interval <= 0 {
+		log.Info("the periodic synchronization of LDAP groups is disabled")
+		return
+	}
+
+	go func() {
+		for {
+			time.Sleep(time.Duration(interval) * time.Minute)
+			log.Debug("synchronizing the memberships granted to LDAP groups")
+			if err := SyncGroups(); err != nil {
+				log.Errorf("failed to synchronize LDAP groups: %v", err)
+			}
+		}
+	}()
+}
@@ -19,15 +19,16 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/vmware/harbor/src/common/utils/log"
 
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
+	ldapUtils "github.com/vmware/harbor/src/common/utils/ldap"
 	"github.com/vmware/harbor/src/ui/auth"
 	"github.com/vmware/harbor/src/ui/config"
-
-	"github.com/mqu/openldap"
 )
 
 // Auth implements Authenticator interface to authenticate against LDAP
@@ -35,9 +36,38 @@ type Auth struct{}
 
 const metaChars = "&|!=~*<>()"
 
+var (
+	pool     *ldapUtils.Pool
+	poolOnce sync.Once
+)
+
+// getPool returns the pool of connections bound as the search DN, it's created
+// on first use as the settings are only available in ldap_auth mode
+func getPool() *ldapUtils.Pool {
+	poolOnce.Do(func() {
+		setting := config.LDAP()
+		pool = ldapUtils.NewPool(newConfig(setting.URL, config.LDAPConn().StartTLS),
+			setting.SearchDn, setting.SearchPwd, config.LDAPConn().PoolSize)
+	})
+	return pool
+}
+
+// newConfig returns the configuration to connect to the LDAP server at url
+func newConfig(url string, startTLS bool) *ldapUtils.Config {
+	conn := config.LDAPConn()
+	return &ldapUtils.Config{
+		URL:        url,
+		StartTLS:   startTLS,
+		CACert:     conn.CACert,
+		VerifyCert: conn.VerifyCert,
+		Timeout:    time.Duration(conn.Timeout) * time.Second,
+	}
+}
+
 // Authenticate checks user's credential against LDAP based on basedn template and LDAP URL,
 // if the check is successful a dummy record will be inserted into DB, such that this user can
-// be associated to other entities in the system.
+// be associated to other entities in the system. The memberships of the projects granted to
+// the groups of the user are synchronized as well.
 func (l *Auth) Authenticate(m models.AuthModel) (*models.User, error) {
 
 	p := m.Principal
@@ -46,89 +76,52 @@ func (l *Auth) Authenticate(m models.AuthModel) (*models.User, error) {
 			return nil, fmt.Errorf("the principal contains meta char: %q", c)
 		}
 	}
-	ldapURL := config.LDAP().URL
-	if ldapURL == "" {
-		return nil, errors.New("can not get any available LDAP_URL")
-	}
-	log.Debug("ldapURL:", ldapURL)
-	ldap, err := openldap.Initialize(ldapURL)
+
+	conn, err := getPool().Get()
 	if err != nil {
 		return nil, err
 	}
-	ldap.SetOption(openldap.LDAP_OPT_PROTOCOL_VERSION, openldap.LDAP_VERSION3)
+	defer getPool().Put(conn)
 
-	ldapBaseDn := config.LDAP().BaseDn
-	if ldapBaseDn == "" {
-		return nil, errors.New("can not get any available LDAP_BASE_DN")
+	en, err := searchUser(conn, m.Principal)
+	if err != nil || en == nil {
+		return nil, err
 	}
-	log.Debug("baseDn:", ldapBaseDn)
+	log.Debug("found entry:", en.DN)
 
-	ldapSearchDn := config.LDAP().SearchDn
-	if ldapSearchDn != "" {
-		log.Debug("Search DN: ", ldapSearchDn)
-		ldapSearchPwd := config.LDAP().SearchPwd
-		err = ldap.Bind(ldapSearchDn, ldapSearchPwd)
-		if err != nil {
-			log.Debug("Bind search dn error", err)
-			return nil, err
-		}
-	}
+	// the groups are searched before binding as the user, who may not be allowed to search them
+	groups, groupErr := searchGroups(conn, m.Principal, en.DN)
 
-	attrName := config.LDAP().UID
-	filter := config.LDAP().Filter
-	if filter != "" {
-		filter = "(&" + filter + "(" + attrName + "=" + m.Principal + "))"
-	} else {
-		filter = "(" + attrName + "=" + m.Principal + ")"
-	}
-	log.Debug("one or more filter", filter)
-
-	ldapScope := config.LDAP().Scope
-	var scope int
-	if ldapScope == "1" {
-		scope = openldap.LDAP_SCOPE_BASE
-	} else if ldapScope == "2" {
-		scope = openldap.LDAP_SCOPE_ONELEVEL
-	} else {
-		scope = openldap.LDAP_SCOPE_SUBTREE
-	}
-	attributes := []string{"uid", "cn", "mail", "email"}
-	result, err := ldap.SearchAll(ldapBaseDn, scope, filter, attributes)
+	err = conn.Bind(en.DN, m.Password)
 	if err != nil {
+		log.Debug("Bind user error", err)
+		if err == ldapUtils.ErrEmptyPassword || ldapUtils.IsInvalidCredentials(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
-	if len(result.Entries()) == 0 {
-		log.Warningf("Not found an entry.")
-		return nil, nil
-	} else if len(result.Entries()) != 1 {
-		log.Warningf("Found more than one entry.")
-		return nil, nil
-	}
-	en := result.Entries()[0]
-	bindDN := en.Dn()
-	log.Debug("found entry:", en)
-	err = ldap.Bind(bindDN, m.Password)
+
+	u, err := provision(m.Principal, en)
 	if err != nil {
-		log.Debug("Bind user error", err)
 		return nil, err
 	}
-	defer ldap.Close()
-
-	u := models.User{}
-	for _, attr := range en.Attributes() {
-		val := attr.Values()[0]
-		switch attr.Name() {
-		case "uid":
-			u.Realname = val
-		case "cn":
-			u.Realname = val
-		case "mail":
-			u.Email = val
-		case "email":
-			u.Email = val
-		}
+
+	// the user can still log in with the memberships synchronized last time
+	if groupErr != nil {
+		log.Errorf("failed to search the groups of user %s: %v", u.Username, groupErr)
+	} else if err = syncGroupRoles(u.UserID, groups); err != nil {
+		log.Errorf("failed to synchronize the group roles of user %s: %v", u.Username, err)
+	}
+
+	return u, nil
+}
+
+// provision returns the user in DB, a dummy record is inserted if the user does not exist
+func provision(username string, en *ldapUtils.Entry) (*models.User, error) {
+	u := models.User{
+		Username: username,
+		Email:    email(en),
 	}
-	u.Username = m.Principal
 	log.Debug("username:", u.Username, ",email:", u.Email)
 	exist, err := dao.UserExists(u, "username")
 	if err != nil {
@@ -142,21 +135,99 @@ func (l *Auth) Authenticate(m models.AuthModel) (*models.User, error) {
 		}
 		u.UserID = currentUser.UserID
 	} else {
-		u.Realname = m.Principal
-		u.Password = "12345678AbC"
-		u.Comment = "registered from LDAP."
-		if u.Email == "" {
-			u.Email = u.Username + "@placeholder.com"
-		}
-		userID, err := dao.Register(u)
-		if err != nil {
+		if err = register(&u); err != nil {
 			return nil, err
 		}
-		u.UserID = int(userID)
 	}
 	return &u, nil
 }
 
+// register inserts the dummy record of the LDAP user into DB
+func register(u *models.User) error {
+	u.Realname = u.Username
+	u.Password = "12345678AbC"
+	u.Comment = "registered from LDAP."
+	if u.Email == "" {
+		u.Email = u.Username + "@placeholder.com"
+	}
+	userID, err := dao.Register(*u)
+	if err != nil {
+		return err
+	}
+	u.UserID = int(userID)
+	return nil
+}
+
+func email(en *ldapUtils.Entry) string {
+	if mail := en.GetAttributeValue("mail"); len(mail) > 0 {
+		return mail
+	}
+	return en.GetAttributeValue("email")
+}
+
+func realname(en *ldapUtils.Entry) string {
+	if cn := en.GetAttributeValue("cn"); len(cn) > 0 {
+		return cn
+	}
+	return en.GetAttributeValue("uid")
+}
+
+// scope converts the scope in setting to the one of search request
+func scope(s string) int {
+	switch s {
+	case "1":
+		return ldapUtils.ScopeBaseObject
+	case "2":
+		return ldapUtils.ScopeSingleLevel
+	default:
+		return ldapUtils.ScopeWholeSubtree
+	}
+}
+
+// userFilter returns the filter matching the users whose uid attribute is value,
+// value is not escaped so that it can contain wildcards
+func userFilter(value string) string {
+	setting := config.LDAP()
+	filter := "(" + setting.UID + "=" + value + ")"
+	if setting.Filter != "" {
+		filter = "(&" + setting.Filter + filter + ")"
+	}
+	return filter
+}
+
+// searchUser looks up the entry of the user, nil is returned if no entry or more
+// than one entries are found
+func searchUser(conn *ldapUtils.Conn, username string) (*ldapUtils.Entry, error) {
+	ldapBaseDn := config.LDAP().BaseDn
+	if ldapBaseDn == "" {
+		return nil, errors.New("can not get any available LDAP_BASE_DN")
+	}
+	log.Debug("baseDn:", ldapBaseDn)
+
+	filter := userFilter(ldapUtils.EscapeFilter(username))
+	log.Debug("one or more filter", filter)
+
+	entries, err := conn.Search(&ldapUtils.SearchRequest{
+		BaseDN:     ldapBaseDn,
+		Scope:      scope(config.LDAP().Scope),
+		Filter:     filter,
+		Attributes: []string{"uid", "cn", "mail", "email"},
+		// two entries are enough to tell the username is ambiguous
+		SizeLimit: 2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		log.Warningf("Not found an entry.")
+		return nil, nil
+	} else if len(entries) != 1 {
+		log.Warningf("Found more than one entry.")
+		return nil, nil
+	}
+	return entries[0], nil
+}
+
 func init() {
 	auth.Register("ldap_auth", &Auth{})
 }
@@ -2,8 +2,32 @@ package ldap
 
 import (
 	"testing"
+
+	"github.com/vmware/harbor/src/ui/config"
 )
 
 func TestMain(t *testing.T) {
 }
 
+func TestGroupFilter(t *testing.T) {
+	cases := []struct {
+		setting  config.LDAPGroupSetting
+		expected string
+	}{
+		{
+			config.LDAPGroupSetting{Filter: "(objectClass=groupOfNames)", MemberAttr: "member"},
+			`(&(objectClass=groupOfNames)(member=uid=jack\28dev\29,ou=people,dc=mydomain,dc=com))`,
+		},
+		{
+			config.LDAPGroupSetting{Filter: "(objectClass=posixGroup)", MemberAttr: "memberUid"},
+			`(&(objectClass=posixGroup)(memberUid=jack))`,
+		},
+	}
+
+	for _, c := range cases {
+		filter := groupFilter(c.setting, "jack", "uid=jack(dev),ou=people,dc=mydomain,dc=com")
+		if filter != c.expected {
+			t.Errorf("unexpected filter: %s, expected: %s", filter, c.expected)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ldap
+
+import (
+	"strings"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	ldapUtils "github.com/vmware/harbor/src/common/utils/ldap"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// MaxSearchResults is the max number of users returned by SearchUsers
+const MaxSearchResults = 100
+
+// Ping connects to the LDAP server, binds the search DN and looks up the base DN
+// to test the configuration, the certificate and timeout settings of the current
+// configuration are used
+func Ping(conf models.LDAPConf) error {
+	conn, err := ldapUtils.Dial(newConfig(conf.URL, conf.StartTLS))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err = conn.Bind(conf.SearchDn, conf.SearchPwd); err != nil {
+		return err
+	}
+
+	if len(conf.BaseDn) == 0 {
+		return nil
+	}
+	_, err = conn.Search(&ldapUtils.SearchRequest{
+		BaseDN:    conf.BaseDn,
+		Scope:     ldapUtils.ScopeBaseObject,
+		SizeLimit: 1,
+	})
+	return err
+}
+
+// SearchUsers returns the users in LDAP whose uid attribute contains the username,
+// all users are matched if it's empty. At most MaxSearchResults users are returned
+func SearchUsers(username string) ([]*models.LDAPUser, error) {
+	conn, err := getPool().Get()
+	if err != nil {
+		return nil, err
+	}
+	defer getPool().Put(conn)
+
+	value := "*"
+	if len(username) > 0 {
+		value = "*" + ldapUtils.EscapeFilter(username) + "*"
+	}
+	uid := config.LDAP().UID
+	entries, err := conn.Search(&ldapUtils.SearchRequest{
+		BaseDN:     config.LDAP().BaseDn,
+		Scope:      scope(config.LDAP().Scope),
+		Filter:     userFilter(value),
+		Attributes: []string{uid, "uid", "cn", "mail", "email"},
+		SizeLimit:  MaxSearchResults,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*models.LDAPUser{}
+	for _, en := range entries {
+		users = append(users, &models.LDAPUser{
+			Username: en.GetAttributeValue(uid),
+			Realname: realname(en),
+			Email:    email(en),
+			DN:       en.DN,
+		})
+	}
+	return users, nil
+}
+
+// ImportUsers registers the users in LDAP identified by the values of their uid
+// attributes, so that they can be added as members of projects before logging in.
+// The users failed to be imported are returned with the reasons
+func ImportUsers(uids []string) ([]*models.LDAPImportFailure, error) {
+	conn, err := getPool().Get()
+	if err != nil {
+		return nil, err
+	}
+	defer getPool().Put(conn)
+
+	failures := []*models.LDAPImportFailure{}
+	for _, uid := range uids {
+		reason, err := importUser(conn, uid)
+		if err != nil {
+			return nil, err
+		}
+		if len(reason) > 0 {
+			failures = append(failures, &models.LDAPImportFailure{
+				UID:   uid,
+				Error: reason,
+			})
+		}
+	}
+	return failures, nil
+}
+
+// importUser registers the user, the reason is returned if it can not be imported
+func importUser(conn *ldapUtils.Conn, uid string) (string, error) {
+	if strings.ContainsAny(uid, metaChars) {
+		return "the uid contains meta chars", nil
+	}
+
+	en, err := searchUser(conn, uid)
+	if err != nil {
+		log.Errorf("failed to search user %s: %v", uid, err)
+		return "failed to search the user in LDAP", nil
+	}
+	if en == nil {
+		return "the user is not found or not unique in LDAP", nil
+	}
+
+	u := &models.User{
+		Username: uid,
+		Email:    email(en),
+	}
+	exist, err := dao.UserExists(*u, "username")
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		return "the user already exists", nil
+	}
+	if err = register(u); err != nil {
+		log.Errorf("failed to register user %s: %v", uid, err)
+		return "failed to register the user", nil
+	}
+	return "", nil
+}
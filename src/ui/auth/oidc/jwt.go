@@ -0,0 +1,216 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	// register the hash functions used by the signing algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var errUnknownKey = errors.New("the ID token is signed by an unknown key")
+
+// the signing algorithms of ID token supported
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// jwk is a JSON web key published by the provider
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// keySet holds the RSA signing keys of the provider indexed by key ID
+type keySet struct {
+	keys map[string]*rsa.PublicKey
+}
+
+func newKeySet(set *jwks) (*keySet, error) {
+	ks := &keySet{
+		keys: map[string]*rsa.PublicKey{},
+	}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (len(k.Use) > 0 && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %s: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %s: %v", k.Kid, err)
+		}
+		ks.keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(ks.keys) == 0 {
+		return nil, errors.New("no RSA signing key is published by OIDC provider")
+	}
+	return ks, nil
+}
+
+// verify verifies the signature of the compact serialized JWT and returns its payload
+func (k *keySet) verify(raw string) ([]byte, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed header of ID token: %v", err)
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err = json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("malformed header of ID token: %v", err)
+	}
+
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing algorithm of ID token: %s", header.Alg)
+	}
+
+	key, ok := k.keys[header.Kid]
+	if !ok && len(header.Kid) == 0 && len(k.keys) == 1 {
+		// the key ID is optional when the provider publishes only one key
+		for _, only := range k.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, errUnknownKey
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature of ID token: %v", err)
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), signature); err != nil {
+		return nil, fmt.Errorf("invalid signature of ID token: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed payload of ID token: %v", err)
+	}
+	return payload, nil
+}
+
+// Claims are the claims in ID token Harbor relies on
+type Claims struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	Expiry   time.Time
+	Nonce    string
+	// Username is the "preferred_username" claim
+	Username string
+	Name     string
+	Email    string
+	Groups   []string
+}
+
+func (c *Claims) hasAudience(clientID string) bool {
+	for _, aud := range c.Audience {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClaims parses the payload of ID token, the groups are read from the claim
+// configured as it differs between providers
+func parseClaims(payload []byte, groupsClaim string) (*Claims, error) {
+	raw := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("malformed payload of ID token: %v", err)
+	}
+
+	claims := &Claims{
+		Issuer:   stringClaim(raw, "iss"),
+		Subject:  stringClaim(raw, "sub"),
+		Audience: stringsClaim(raw, "aud"),
+		Nonce:    stringClaim(raw, "nonce"),
+		Username: stringClaim(raw, "preferred_username"),
+		Name:     stringClaim(raw, "name"),
+		Email:    stringClaim(raw, "email"),
+		Groups:   stringsClaim(raw, groupsClaim),
+	}
+
+	exp, ok := raw["exp"].(json.Number)
+	if !ok {
+		return nil, errors.New("no expiry in ID token")
+	}
+	sec, err := exp.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiry of ID token: %v", err)
+	}
+	claims.Expiry = time.Unix(int64(sec), 0)
+
+	return claims, nil
+}
+
+func stringClaim(raw map[string]interface{}, name string) string {
+	s, _ := raw[name].(string)
+	return s
+}
+
+// stringsClaim reads the claim which can be either a string or an array of strings
+func stringsClaim(raw map[string]interface{}, name string) []string {
+	switch v := raw[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := []string{}
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// ErrNotOIDCUser is returned when the user isn't provisioned from the OIDC provider
+var ErrNotOIDCUser = errors.New("the user is not provisioned from OIDC provider")
+
+// Auth implements Authenticator interface to authenticate the users provisioned from
+// the OIDC provider with their CLI secrets, as the authorization code flow can not be
+// gone through by docker CLI. The users log in to UI via the flow handled by controllers.
+type Auth struct{}
+
+// Authenticate checks the principal and the CLI secret of the user
+func (a *Auth) Authenticate(m models.AuthModel) (*models.User, error) {
+	user, err := dao.GetUser(models.User{Username: m.Principal})
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	oidcUser, err := dao.GetOIDCUserByUserID(user.UserID)
+	if err != nil || oidcUser == nil {
+		return nil, err
+	}
+
+	if len(oidcUser.Secret) == 0 {
+		log.Debugf("no CLI secret is generated for user %s", m.Principal)
+		return nil, nil
+	}
+	hashed := utils.Encrypt(m.Password, oidcUser.Salt)
+	if subtle.ConstantTimeCompare([]byte(hashed), []byte(oidcUser.Secret)) != 1 {
+		log.Debugf("invalid CLI secret of user %s", m.Principal)
+		return nil, nil
+	}
+
+	return user, nil
+}
+
+var (
+	provider     *Provider
+	providerOnce sync.Once
+)
+
+// DefaultProvider returns the provider configured by OIDC_* settings
+func DefaultProvider() *Provider {
+	providerOnce.Do(func() {
+		provider = NewProvider(config.OIDC())
+	})
+	return provider
+}
+
+// Provision returns the user linked to the subject of the ID token, a user is
+// registered on the fly if the subject logs in for the first time.
+func Provision(claims *Claims) (*models.User, error) {
+	oidcUser, err := dao.GetOIDCUserBySubject(claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if oidcUser != nil {
+		user, err := dao.GetUser(models.User{UserID: oidcUser.UserID})
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("the user %d linked to subject %s has been deleted", oidcUser.UserID, claims.Subject)
+		}
+		return user, nil
+	}
+
+	u := models.User{
+		Username: claims.Username,
+		Email:    claims.Email,
+		Realname: claims.Name,
+		Comment:  "registered from OIDC.",
+	}
+	if len(u.Username) == 0 {
+		u.Username = claims.Email
+	}
+	if len(u.Username) == 0 {
+		return nil, fmt.Errorf("neither preferred_username nor email is in the ID token of subject %s", claims.Subject)
+	}
+	// the limits of the columns in table user
+	if len(u.Username) > 20 {
+		return nil, fmt.Errorf("the username %s of subject %s is longer than 20 characters", u.Username, claims.Subject)
+	}
+	if len(u.Realname) == 0 || len(u.Realname) > 20 {
+		u.Realname = u.Username
+	}
+
+	exist, err := dao.UserExists(u, "username")
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, fmt.Errorf("user %s already exists and isn't linked to subject %s", u.Username, claims.Subject)
+	}
+
+	if len(u.Email) > 0 {
+		exist, err = dao.UserExists(u, "email")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(u.Email) == 0 || exist {
+		u.Email = u.Username + "@placeholder.com"
+	}
+
+	// the password is never used as the user logs in via the provider
+	u.Password, err = generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := dao.Register(u)
+	if err != nil {
+		return nil, err
+	}
+	u.UserID = int(userID)
+
+	if _, err = dao.AddOIDCUser(models.OIDCUser{
+		UserID:  u.UserID,
+		Subject: claims.Subject,
+	}); err != nil {
+		return nil, err
+	}
+	log.Infof("user %s is registered for subject %s of OIDC provider", u.Username, claims.Subject)
+
+	return &u, nil
+}
+
+// SyncGroupRoles synchronizes the memberships granted to the groups in the ID token of the user
+func SyncGroupRoles(userID int, groups []string) error {
+	groupRoles, err := dao.GetOIDCGroupRolesByGroups(groups)
+	if err != nil {
+		return err
+	}
+	return auth.SyncGroupRoles(userID, resolveRoles(groupRoles))
+}
+
+// resolveRoles returns the role of each project granted to the groups
+func resolveRoles(groupRoles []*models.OIDCGroupRole) auth.GroupRoles {
+	roles := auth.GroupRoles{}
+	for _, gr := range groupRoles {
+		roles.Add(gr.ProjectID, gr.Role)
+	}
+	return roles
+}
+
+// GenerateCLISecret generates a new CLI secret for the user, the secret generated
+// before is revoked
+func GenerateCLISecret(userID int) (string, error) {
+	oidcUser, err := dao.GetOIDCUserByUserID(userID)
+	if err != nil {
+		return "", err
+	}
+	if oidcUser == nil {
+		return "", ErrNotOIDCUser
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+	salt := utils.GenerateRandomString()
+	if err = dao.UpdateOIDCUserSecret(oidcUser.ID, utils.Encrypt(secret, salt), salt); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// GenerateState generates a random value used as the state or the nonce of the
+// authorization code flow
+func GenerateState() (string, error) {
+	return generateSecret()
+}
+
+// generateSecret generates a random secret with crypto/rand as it is a credential
+func generateSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func init() {
+	auth.Register("oidc_auth", &Auth{})
+}
@@ -0,0 +1,35 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestResolveRoles(t *testing.T) {
+	roles := resolveRoles([]*models.OIDCGroupRole{
+		{ProjectID: 1, GroupName: "developers", Role: models.DEVELOPER},
+		{ProjectID: 1, GroupName: "testers", Role: models.GUEST},
+		{ProjectID: 2, GroupName: "testers", Role: models.GUEST},
+		{ProjectID: 2, GroupName: "admins", Role: models.PROJECTADMIN},
+	})
+
+	if len(roles) != 2 || roles[1] != models.DEVELOPER || roles[2] != models.PROJECTADMIN {
+		t.Errorf("unexpected roles: %v", roles)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/harbor/src/common/utils/registry"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// metadata is the part of the discovery document of the provider Harbor relies on
+type metadata struct {
+	Issuer   string `json:"issuer"`
+	AuthURL  string `json:"authorization_endpoint"`
+	TokenURL string `json:"token_endpoint"`
+	JWKSURL  string `json:"jwks_uri"`
+}
+
+// Provider handles the authorization code flow against an OpenID Connect provider
+type Provider struct {
+	setting config.OIDCSetting
+	client  *http.Client
+
+	sync.Mutex
+	meta *metadata
+	keys *keySet
+}
+
+// NewProvider returns an instance of Provider, the discovery document of the provider
+// is read the first time it is needed
+func NewProvider(setting config.OIDCSetting) *Provider {
+	return &Provider{
+		setting: setting,
+		client: &http.Client{
+			Transport: registry.GetHTTPTransport(!setting.VerifyCert),
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+// AuthCodeURL returns the URL of the provider the user is redirected to for login
+func (p *Provider) AuthCodeURL(redirectURL, state, nonce string) (string, error) {
+	meta, err := p.metadata()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(meta.AuthURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.setting.ClientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(p.setting.Scope, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange exchanges the authorization code for tokens and returns the raw ID token
+func (p *Provider) Exchange(code, redirectURL string) (string, error) {
+	meta, err := p.metadata()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	req, err := http.NewRequest(http.MethodPost, meta.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.setting.ClientID), url.QueryEscape(p.setting.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code from token endpoint: %d %s", resp.StatusCode, string(data))
+	}
+
+	token := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	if err = json.Unmarshal(data, &token); err != nil {
+		return "", err
+	}
+	if len(token.IDToken) == 0 {
+		return "", errors.New("no id_token in the response of token endpoint")
+	}
+	return token.IDToken, nil
+}
+
+// Verify verifies the signature and the claims of the ID token issued to Harbor
+// for the login identified by the nonce
+func (p *Provider) Verify(rawIDToken, nonce string) (*Claims, error) {
+	meta, err := p.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := p.verifySignature(meta, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := parseClaims(payload, p.setting.GroupsClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Issuer != meta.Issuer {
+		return nil, fmt.Errorf("unexpected issuer of ID token: %s", claims.Issuer)
+	}
+	if !claims.hasAudience(p.setting.ClientID) {
+		return nil, fmt.Errorf("ID token is not issued to client %s", p.setting.ClientID)
+	}
+	if !claims.Expiry.After(time.Now()) {
+		return nil, fmt.Errorf("ID token expired at %v", claims.Expiry)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("unexpected nonce of ID token")
+	}
+	if len(claims.Subject) == 0 {
+		return nil, errors.New("no subject in ID token")
+	}
+	return claims, nil
+}
+
+func (p *Provider) verifySignature(meta *metadata, rawIDToken string) ([]byte, error) {
+	p.Lock()
+	keys := p.keys
+	p.Unlock()
+
+	if keys != nil {
+		payload, err := keys.verify(rawIDToken)
+		if err != errUnknownKey {
+			return payload, err
+		}
+	}
+
+	// the keys are rotated by the provider, refresh them
+	keys, err := p.fetchKeys(meta.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	p.Lock()
+	p.keys = keys
+	p.Unlock()
+	return keys.verify(rawIDToken)
+}
+
+func (p *Provider) metadata() (*metadata, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	if len(p.setting.Endpoint) == 0 {
+		return nil, errors.New("the endpoint of OIDC provider is not configured")
+	}
+
+	meta := &metadata{}
+	if err := p.getJSON(p.setting.Endpoint+"/.well-known/openid-configuration", meta); err != nil {
+		return nil, err
+	}
+	if strings.TrimRight(meta.Issuer, "/") != p.setting.Endpoint {
+		return nil, fmt.Errorf("the issuer %s in discovery document doesn't match the endpoint %s", meta.Issuer, p.setting.Endpoint)
+	}
+	if len(meta.AuthURL) == 0 || len(meta.TokenURL) == 0 || len(meta.JWKSURL) == 0 {
+		return nil, errors.New("incomplete discovery document of OIDC provider")
+	}
+	p.meta = meta
+	return meta, nil
+}
+
+func (p *Provider) fetchKeys(jwksURL string) (*keySet, error) {
+	set := &jwks{}
+	if err := p.getJSON(jwksURL, set); err != nil {
+		return nil, err
+	}
+	return newKeySet(set)
+}
+
+func (p *Provider) getJSON(url string, v interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from %s: %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
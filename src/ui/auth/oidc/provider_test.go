@@ -0,0 +1,224 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+const (
+	clientID     = "harbor"
+	clientSecret = "secret"
+	redirectURL  = "https://harbor.mydomain.com/oidc/callback"
+	code         = "code"
+	nonce        = "nonce"
+	kid          = "key-1"
+)
+
+type fakeProvider struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	f := &fakeProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&metadata{
+			Issuer:   f.URL,
+			AuthURL:  f.URL + "/auth",
+			TokenURL: f.URL + "/token",
+			JWKSURL:  f.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&jwks{
+			Keys: []jwk{
+				{
+					Kty: "RSA",
+					Kid: kid,
+					Use: "sig",
+					N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != clientID || secret != clientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("code") != code || r.FormValue("redirect_uri") != redirectURL {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-token",
+			"id_token":     f.idToken,
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+func (f *fakeProvider) sign(t *testing.T, keyID string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"kid": keyID,
+	})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	h := crypto.SHA256.New()
+	h.Write([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, h.Sum(nil))
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (f *fakeProvider) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                f.URL,
+		"sub":                "0123456789",
+		"aud":                clientID,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"nonce":              nonce,
+		"preferred_username": "jack",
+		"email":              "jack@mydomain.com",
+		"groups":             []string{"developers", "testers"},
+	}
+}
+
+func newProvider(f *fakeProvider) *Provider {
+	return NewProvider(config.OIDCSetting{
+		Endpoint:     f.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scope:        []string{"openid", "email", "groups"},
+		GroupsClaim:  "groups",
+	})
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	f := newFakeProvider(t)
+	defer f.Close()
+
+	u, err := newProvider(f).AuthCodeURL(redirectURL, "state", nonce)
+	if err != nil {
+		t.Fatalf("failed to get auth code URL: %v", err)
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatalf("failed to parse auth code URL %s: %v", u, err)
+	}
+	if !strings.HasPrefix(u, f.URL+"/auth?") {
+		t.Errorf("unexpected auth code URL: %s", u)
+	}
+	q := parsed.Query()
+	if q.Get("client_id") != clientID || q.Get("redirect_uri") != redirectURL ||
+		q.Get("state") != "state" || q.Get("nonce") != nonce ||
+		q.Get("scope") != "openid email groups" || q.Get("response_type") != "code" {
+		t.Errorf("unexpected query of auth code URL: %v", q)
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	f := newFakeProvider(t)
+	defer f.Close()
+	f.idToken = f.sign(t, kid, f.claims())
+	p := newProvider(f)
+
+	if _, err := p.Exchange("invalid", redirectURL); err == nil {
+		t.Errorf("expected error for invalid code")
+	}
+
+	rawIDToken, err := p.Exchange(code, redirectURL)
+	if err != nil {
+		t.Fatalf("failed to exchange code: %v", err)
+	}
+
+	claims, err := p.Verify(rawIDToken, nonce)
+	if err != nil {
+		t.Fatalf("failed to verify ID token: %v", err)
+	}
+	if claims.Subject != "0123456789" || claims.Username != "jack" || claims.Email != "jack@mydomain.com" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if len(claims.Groups) != 2 || claims.Groups[0] != "developers" {
+		t.Errorf("unexpected groups: %v", claims.Groups)
+	}
+
+	if _, err = p.Verify(rawIDToken, "another-nonce"); err == nil {
+		t.Errorf("expected error for unexpected nonce")
+	}
+}
+
+func TestVerifyInvalidToken(t *testing.T) {
+	f := newFakeProvider(t)
+	defer f.Close()
+	p := newProvider(f)
+
+	expired := f.claims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	otherAudience := f.claims()
+	otherAudience["aud"] = []string{"another-client"}
+	otherIssuer := f.claims()
+	otherIssuer["iss"] = "https://another.mydomain.com"
+
+	tampered := f.sign(t, kid, f.claims())
+	parts := strings.Split(tampered, ".")
+	payload, _ := json.Marshal(otherIssuer)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+
+	cases := map[string]string{
+		"expired":        f.sign(t, kid, expired),
+		"other audience": f.sign(t, kid, otherAudience),
+		"other issuer":   f.sign(t, kid, otherIssuer),
+		"unknown key":    f.sign(t, "key-2", f.claims()),
+		"tampered":       strings.Join(parts, "."),
+		"malformed":      "a.b",
+	}
+	for name, token := range cases {
+		if _, err := p.Verify(token, nonce); err == nil {
+			t.Errorf("expected error for %s ID token", name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/subtle"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// LoginRobot authenticates the robot account whose full name is "robot$<project>+<name>",
+// it returns nil if the credentials are invalid or the robot account is expired. Unlike
+// Login, the account isn't locked on failure as the secret is generated by Harbor.
+func LoginRobot(fullName, secret string) (*models.Robot, error) {
+	robot, err := GetRobot(fullName)
+	if err != nil || robot == nil {
+		return nil, err
+	}
+
+	hashed := utils.Encrypt(secret, robot.Salt)
+	if subtle.ConstantTimeCompare([]byte(hashed), []byte(robot.Secret)) != 1 {
+		log.Debugf("invalid secret of robot account %s", fullName)
+		return nil, nil
+	}
+
+	return robot, nil
+}
+
+// GetRobot returns the robot account by its full name, nil is returned if it
+// doesn't exist or is expired
+func GetRobot(fullName string) (*models.Robot, error) {
+	projectName, name, ok := models.ParseRobotFullName(fullName)
+	if !ok {
+		return nil, nil
+	}
+
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	robot, err := dao.GetRobotByName(project.ProjectID, name)
+	if err != nil || robot == nil {
+		return nil, err
+	}
+
+	if robot.Expired() {
+		log.Debugf("robot account %s expired at %v", fullName, robot.ExpiresAt)
+		return nil, nil
+	}
+
+	return robot, nil
+}
@@ -35,8 +35,64 @@ type LDAPSetting struct {
 	Scope     string
 }
 
+// LDAPGroupSetting wraps the setting to look up the groups of LDAP users
+type LDAPGroupSetting struct {
+	// BaseDn is where the groups are searched, the groups are ignored if it's empty
+	BaseDn string
+	Filter string
+	// MemberAttr is the attribute of a group entry listing its members, the DN of
+	// the user is matched unless it's "memberUid" whose values are user names
+	MemberAttr string
+	// SyncInterval is the interval in minutes between the synchronizations of
+	// group memberships, 0 disables the periodic synchronization
+	SyncInterval int
+}
+
+// LDAPConnSetting wraps the setting of the connections to an LDAP server
+type LDAPConnSetting struct {
+	// StartTLS upgrades the connections to TLS, only used when the scheme of
+	// LDAP_URL is ldap
+	StartTLS bool
+	// CACert is the path of the CA certificate trusted to verify the server,
+	// the system CAs are used if it's empty
+	CACert     string
+	VerifyCert bool
+	// Timeout is the timeout in seconds of each operation
+	Timeout int
+	// PoolSize is the max number of connections kept to the server
+	PoolSize int
+}
+
+// OIDCSetting wraps the setting of an OpenID Connect provider
+type OIDCSetting struct {
+	// Endpoint is the issuer URL of the provider, the discovery document is
+	// read from <Endpoint>/.well-known/openid-configuration
+	Endpoint     string
+	ClientID     string
+	ClientSecret string
+	Scope        []string
+	// GroupsClaim is the name of the claim in ID token which contains the
+	// groups of the user
+	GroupsClaim string
+	VerifyCert  bool
+}
+
 type uiParser struct{}
 
+// parseInt parses the value of key as an integer, the default value is returned
+// if the value is empty, invalid or less than min
+func parseInt(raw map[string]string, key string, def, min int) int {
+	if len(raw[key]) == 0 {
+		return def
+	}
+	i, err := strconv.Atoi(raw[key])
+	if err != nil || i < min {
+		log.Warningf("invalid value of %s: %s, using default value: %d", key, raw[key], def)
+		return def
+	}
+	return i
+}
+
 // Parse parses the auth settings url settings and other configuration consumed by code under src/ui
 func (up *uiParser) Parse(raw map[string]string, config map[string]interface{}) error {
 	mode := raw["AUTH_MODE"]
@@ -51,6 +107,52 @@ func (up *uiParser) Parse(raw map[string]string, config map[string]interface{})
 			Scope:     raw["LDAP_SCOPE"],
 		}
 		config["ldap"] = setting
+
+		group := LDAPGroupSetting{
+			BaseDn:       raw["LDAP_GROUP_BASE_DN"],
+			Filter:       raw["LDAP_GROUP_FILTER"],
+			MemberAttr:   raw["LDAP_GROUP_MEMBER_ATTR"],
+			SyncInterval: 60,
+		}
+		if len(group.Filter) == 0 {
+			group.Filter = "(objectClass=groupOfNames)"
+		}
+		if len(group.MemberAttr) == 0 {
+			group.MemberAttr = "member"
+		}
+		group.SyncInterval = parseInt(raw, "LDAP_GROUP_SYNC_INTERVAL", group.SyncInterval, 0)
+		config["ldap_group"] = group
+
+		config["ldap_conn"] = LDAPConnSetting{
+			StartTLS:   raw["LDAP_STARTTLS"] == "on",
+			CACert:     raw["LDAP_CA_CERT"],
+			VerifyCert: raw["LDAP_VERIFY_CERT"] != "off",
+			Timeout:    parseInt(raw, "LDAP_TIMEOUT", 5, 1),
+			PoolSize:   parseInt(raw, "LDAP_POOL_SIZE", 10, 1),
+		}
+	}
+	if mode == "oidc_auth" {
+		scope := []string{}
+		for _, s := range strings.Split(raw["OIDC_SCOPE"], ",") {
+			if s = strings.TrimSpace(s); len(s) > 0 {
+				scope = append(scope, s)
+			}
+		}
+		if len(scope) == 0 {
+			scope = []string{"openid", "profile", "email"}
+		}
+		groupsClaim := raw["OIDC_GROUPS_CLAIM"]
+		if len(groupsClaim) == 0 {
+			groupsClaim = "groups"
+		}
+		config["oidc"] = OIDCSetting{
+			Endpoint:     strings.TrimRight(raw["OIDC_ENDPOINT"], "/"),
+			ClientID:     raw["OIDC_CLIENT_ID"],
+			ClientSecret: raw["OIDC_CLIENT_SECRET"],
+			Scope:        scope,
+			GroupsClaim:  groupsClaim,
+			VerifyCert:   raw["OIDC_VERIFY_CERT"] != "off",
+		}
 	}
 	config["auth_mode"] = mode
 	var tokenExpiration = 30 //minutes
@@ -83,7 +185,7 @@ func (up *uiParser) Parse(raw map[string]string, config map[string]interface{})
 var uiConfig *commonConfig.Config
 
 func init() {
-	uiKeys := []string{"AUTH_MODE", "LDAP_URL", "LDAP_BASE_DN", "LDAP_SEARCH_DN", "LDAP_SEARCH_PWD", "LDAP_UID", "LDAP_FILTER", "LDAP_SCOPE", "TOKEN_EXPIRATION", "HARBOR_ADMIN_PASSWORD", "EXT_REG_URL", "UI_SECRET", "SECRET_KEY", "SELF_REGISTRATION", "PROJECT_CREATION_RESTRICTION", "REGISTRY_URL", "JOB_SERVICE_URL"}
+	uiKeys := []string{"AUTH_MODE", "LDAP_URL", "LDAP_BASE_DN", "LDAP_SEARCH_DN", "LDAP_SEARCH_PWD", "LDAP_UID", "LDAP_FILTER", "LDAP_SCOPE", "LDAP_GROUP_BASE_DN", "LDAP_GROUP_FILTER", "LDAP_GROUP_MEMBER_ATTR", "LDAP_GROUP_SYNC_INTERVAL", "LDAP_STARTTLS", "LDAP_CA_CERT", "LDAP_VERIFY_CERT", "LDAP_TIMEOUT", "LDAP_POOL_SIZE", "OIDC_ENDPOINT", "OIDC_CLIENT_ID", "OIDC_CLIENT_SECRET", "OIDC_SCOPE", "OIDC_GROUPS_CLAIM", "OIDC_VERIFY_CERT", "TOKEN_EXPIRATION", "HARBOR_ADMIN_PASSWORD", "EXT_REG_URL", "UI_SECRET", "SECRET_KEY", "SELF_REGISTRATION", "PROJECT_CREATION_RESTRICTION", "REGISTRY_URL", "JOB_SERVICE_URL"}
 	uiConfig = &commonConfig.Config{
 		Config: make(map[string]interface{}),
 		Loader: &commonConfig.EnvConfigLoader{Keys: uiKeys},
@@ -109,6 +211,21 @@ func LDAP() LDAPSetting {
 	return uiConfig.Config["ldap"].(LDAPSetting)
 }
 
+// LDAPConn returns the setting of the connections to the LDAP server
+func LDAPConn() LDAPConnSetting {
+	return uiConfig.Config["ldap_conn"].(LDAPConnSetting)
+}
+
+// LDAPGroup returns the setting to look up the groups of LDAP users
+func LDAPGroup() LDAPGroupSetting {
+	return uiConfig.Config["ldap_group"].(LDAPGroupSetting)
+}
+
+// OIDC returns the setting of OpenID Connect provider
+func OIDC() OIDCSetting {
+	return uiConfig.Config["oidc"].(OIDCSetting)
+}
+
 // TokenExpiration returns the token expiration time (in minute)
 func TokenExpiration() int {
 	return uiConfig.Config["token_exp"].(int)
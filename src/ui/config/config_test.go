@@ -30,6 +30,19 @@ var (
 		"uid",
 		"2",
 	}
+	ldapGroup = LDAPGroupSetting{
+		"ou=groups,dc=whatever,dc=org",
+		"(objectClass=posixGroup)",
+		"memberUid",
+		30,
+	}
+	ldapConn = LDAPConnSetting{
+		true,
+		"/etc/ui/ldap_ca.crt",
+		true,
+		10,
+		5,
+	}
 	tokenExp                   = "3"
 	tokenExpRes                = 3
 	adminPassword              = "password"
@@ -52,6 +65,14 @@ func TestMain(m *testing.M) {
 	os.Setenv("LDAP_UID", ldap.UID)
 	os.Setenv("LDAP_SCOPE", ldap.Scope)
 	os.Setenv("LDAP_FILTER", ldap.Filter)
+	os.Setenv("LDAP_GROUP_BASE_DN", ldapGroup.BaseDn)
+	os.Setenv("LDAP_GROUP_FILTER", ldapGroup.Filter)
+	os.Setenv("LDAP_GROUP_MEMBER_ATTR", ldapGroup.MemberAttr)
+	os.Setenv("LDAP_GROUP_SYNC_INTERVAL", "30")
+	os.Setenv("LDAP_STARTTLS", "on")
+	os.Setenv("LDAP_CA_CERT", ldapConn.CACert)
+	os.Setenv("LDAP_TIMEOUT", "10")
+	os.Setenv("LDAP_POOL_SIZE", "5")
 	os.Setenv("TOKEN_EXPIRATION", tokenExp)
 	os.Setenv("HARBOR_ADMIN_PASSWORD", adminPassword)
 	os.Setenv("EXT_REG_URL", externalRegURL)
@@ -76,6 +97,14 @@ func TestMain(m *testing.M) {
 	os.Unsetenv("LDAP_UID")
 	os.Unsetenv("LDAP_SCOPE")
 	os.Unsetenv("LDAP_FILTER")
+	os.Unsetenv("LDAP_GROUP_BASE_DN")
+	os.Unsetenv("LDAP_GROUP_FILTER")
+	os.Unsetenv("LDAP_GROUP_MEMBER_ATTR")
+	os.Unsetenv("LDAP_GROUP_SYNC_INTERVAL")
+	os.Unsetenv("LDAP_STARTTLS")
+	os.Unsetenv("LDAP_CA_CERT")
+	os.Unsetenv("LDAP_TIMEOUT")
+	os.Unsetenv("LDAP_POOL_SIZE")
 	os.Unsetenv("TOKEN_EXPIRATION")
 	os.Unsetenv("HARBOR_ADMIN_PASSWORD")
 	os.Unsetenv("EXT_REG_URL")
@@ -96,6 +125,12 @@ func TestAuth(t *testing.T) {
 	if LDAP() != ldap {
 		t.Errorf("Expected ldap setting: %+v, in fact: %+v", ldap, LDAP())
 	}
+	if LDAPGroup() != ldapGroup {
+		t.Errorf("Expected ldap group setting: %+v, in fact: %+v", ldapGroup, LDAPGroup())
+	}
+	if LDAPConn() != ldapConn {
+		t.Errorf("Expected ldap connection setting: %+v, in fact: %+v", ldapConn, LDAPConn())
+	}
 }
 
 func TestTokenExpiration(t *testing.T) {
@@ -142,3 +177,42 @@ func TestInitAdminPassword(t *testing.T) {
 		t.Errorf("Expected adminPassword: %s, in fact: %s", adminPassword, InitialAdminPassword())
 	}
 }
+
+func TestOIDC(t *testing.T) {
+	os.Setenv("AUTH_MODE", "oidc_auth")
+	os.Setenv("OIDC_ENDPOINT", "https://oidc.mydomain.com/")
+	os.Setenv("OIDC_CLIENT_ID", "harbor")
+	os.Setenv("OIDC_CLIENT_SECRET", "secret")
+	os.Setenv("OIDC_SCOPE", "openid, email,groups")
+	defer func() {
+		os.Setenv("AUTH_MODE", auth)
+		os.Unsetenv("OIDC_ENDPOINT")
+		os.Unsetenv("OIDC_CLIENT_ID")
+		os.Unsetenv("OIDC_CLIENT_SECRET")
+		os.Unsetenv("OIDC_SCOPE")
+		if err := Reload(); err != nil {
+			t.Fatalf("failed to reload configurations: %v", err)
+		}
+	}()
+
+	if err := Reload(); err != nil {
+		t.Fatalf("failed to reload configurations: %v", err)
+	}
+
+	setting := OIDC()
+	if setting.Endpoint != "https://oidc.mydomain.com" {
+		t.Errorf("unexpected endpoint: %s", setting.Endpoint)
+	}
+	if setting.ClientID != "harbor" || setting.ClientSecret != "secret" {
+		t.Errorf("unexpected client: %s/%s", setting.ClientID, setting.ClientSecret)
+	}
+	if len(setting.Scope) != 3 || setting.Scope[1] != "email" {
+		t.Errorf("unexpected scope: %v", setting.Scope)
+	}
+	if setting.GroupsClaim != "groups" {
+		t.Errorf("unexpected groups claim: %s", setting.GroupsClaim)
+	}
+	if !setting.VerifyCert {
+		t.Errorf("the certificate of provider should be verified by default")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/astaxie/beego"
 	"github.com/beego/i18n"
+	"github.com/vmware/harbor/src/common/api"
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
@@ -184,13 +185,16 @@ func (cc *CommonController) Login() {
 	})
 	if err != nil {
 		log.Errorf("Error occurred in UserLogin: %v", err)
+		api.AuditLogin(cc.Ctx, nil, principal)
 		cc.CustomAbort(http.StatusUnauthorized, "")
 	}
 
 	if user == nil {
+		api.AuditLogin(cc.Ctx, nil, principal)
 		cc.CustomAbort(http.StatusUnauthorized, "")
 	}
 
+	api.AuditLogin(cc.Ctx, user, principal)
 	cc.SetSession("userId", user.UserID)
 	cc.SetSession("username", user.Username)
 }
@@ -0,0 +1,114 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/vmware/harbor/src/common/api"
+	"github.com/vmware/harbor/src/common/config"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth/oidc"
+)
+
+const (
+	oidcStateKey = "oidc_state"
+	oidcNonceKey = "oidc_nonce"
+)
+
+// OIDCLogin redirects the user to the OIDC provider to start the authorization code flow
+func (cc *CommonController) OIDCLogin() {
+	if cc.AuthMode != "oidc_auth" {
+		cc.CustomAbort(http.StatusPreconditionFailed, "the auth mode is not oidc_auth")
+	}
+
+	state, err := oidc.GenerateState()
+	if err != nil {
+		log.Errorf("failed to generate state: %v", err)
+		cc.CustomAbort(http.StatusInternalServerError, "")
+	}
+	nonce, err := oidc.GenerateState()
+	if err != nil {
+		log.Errorf("failed to generate nonce: %v", err)
+		cc.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	url, err := oidc.DefaultProvider().AuthCodeURL(oidcRedirectURL(), state, nonce)
+	if err != nil {
+		log.Errorf("failed to get the auth code URL of OIDC provider: %v", err)
+		cc.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	cc.SetSession(oidcStateKey, state)
+	cc.SetSession(oidcNonceKey, nonce)
+	cc.Redirect(url, http.StatusFound)
+}
+
+// OIDCCallback handles the redirection from the OIDC provider, the user is provisioned
+// and the memberships granted to the groups of the user are synchronized
+func (cc *CommonController) OIDCCallback() {
+	if cc.AuthMode != "oidc_auth" {
+		cc.CustomAbort(http.StatusPreconditionFailed, "the auth mode is not oidc_auth")
+	}
+
+	state, _ := cc.GetSession(oidcStateKey).(string)
+	nonce, _ := cc.GetSession(oidcNonceKey).(string)
+	cc.DelSession(oidcStateKey)
+	cc.DelSession(oidcNonceKey)
+	if len(state) == 0 || cc.GetString("state") != state {
+		cc.CustomAbort(http.StatusBadRequest, "invalid state")
+	}
+
+	if e := cc.GetString("error"); len(e) > 0 {
+		log.Warningf("OIDC provider rejected the login: %s %s", e, cc.GetString("error_description"))
+		cc.CustomAbort(http.StatusUnauthorized, "")
+	}
+
+	provider := oidc.DefaultProvider()
+	rawIDToken, err := provider.Exchange(cc.GetString("code"), oidcRedirectURL())
+	if err != nil {
+		log.Errorf("failed to exchange the code with OIDC provider: %v", err)
+		cc.CustomAbort(http.StatusUnauthorized, "")
+	}
+
+	claims, err := provider.Verify(rawIDToken, nonce)
+	if err != nil {
+		log.Errorf("failed to verify ID token: %v", err)
+		cc.CustomAbort(http.StatusUnauthorized, "")
+	}
+
+	user, err := oidc.Provision(claims)
+	if err != nil {
+		log.Errorf("failed to provision user for subject %s: %v", claims.Subject, err)
+		cc.CustomAbort(http.StatusInternalServerError, "")
+	}
+
+	// the user can still log in with the memberships synchronized last time
+	if err = oidc.SyncGroupRoles(user.UserID, claims.Groups); err != nil {
+		log.Errorf("failed to synchronize the group roles of user %s: %v", user.Username, err)
+	}
+
+	api.AuditLogin(cc.Ctx, user, claims.Subject)
+	cc.SetSession("userId", user.UserID)
+	cc.SetSession("username", user.Username)
+	cc.Redirect("/dashboard", http.StatusFound)
+}
+
+// oidcRedirectURL returns the URL the OIDC provider redirects the user to, which must
+// be registered as a redirect URI of the client in the provider
+func oidcRedirectURL() string {
+	return config.ExtEndpoint() + "/oidc/callback"
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"net/http"
 	"regexp"
+	"strconv"
 	"text/template"
 
 	"github.com/astaxie/beego"
+	"github.com/vmware/harbor/src/common/api"
 	"github.com/vmware/harbor/src/common/config"
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
@@ -159,6 +161,13 @@ func (cc *CommonController) ResetPassword() {
 			log.Errorf("Error occurred in ResetUserPassword: %v", err)
 			cc.CustomAbort(http.StatusInternalServerError, "Internal error.")
 		}
+		api.Audit(cc.Ctx, models.AuditLog{
+			ActorID:      user.UserID,
+			Actor:        user.Username,
+			ResourceType: models.AuditResourceUser,
+			ResourceID:   strconv.Itoa(user.UserID),
+			Action:       models.AuditActionResetPassword,
+		})
 	} else {
 		cc.CustomAbort(http.StatusBadRequest, "password_is_required")
 	}
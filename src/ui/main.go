@@ -29,7 +29,8 @@ import (
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/ui/api"
 	_ "github.com/vmware/harbor/src/ui/auth/db"
-	_ "github.com/vmware/harbor/src/ui/auth/ldap"
+	"github.com/vmware/harbor/src/ui/auth/ldap"
+	_ "github.com/vmware/harbor/src/ui/auth/oidc"
 	"github.com/vmware/harbor/src/ui/config"
 )
 
@@ -84,5 +85,8 @@ func main() {
 	if err := api.SyncRegistry(); err != nil {
 		log.Error(err)
 	}
+	if config.AuthMode() == "ldap_auth" {
+		ldap.StartGroupSync()
+	}
 	beego.Run()
 }
@@ -16,78 +16,121 @@
 package main
 
 import (
+	commonapi "github.com/vmware/harbor/src/common/api"
 	"github.com/vmware/harbor/src/ui/api"
 	"github.com/vmware/harbor/src/ui/controllers"
 	"github.com/vmware/harbor/src/ui/service"
 	"github.com/vmware/harbor/src/ui/service/token"
 
 	"github.com/astaxie/beego"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func initRouters() {
 
+	commonapi.InsertMetricsFilters()
+	beego.InsertFilter("*", beego.BeforeRouter, commonapi.RequestIDFilter)
+
 	beego.SetStaticPath("static/resources", "static/resources")
 	beego.SetStaticPath("static/vendors", "static/vendors")
 
 	//Page Controllers:
-	beego.Router("/", &controllers.IndexController{})
-	beego.Router("/dashboard", &controllers.DashboardController{})
-	beego.Router("/project", &controllers.ProjectController{})
-	beego.Router("/repository", &controllers.RepositoryController{})
-	beego.Router("/sign_up", &controllers.SignUpController{})
-	beego.Router("/add_new", &controllers.AddNewController{})
-	beego.Router("/account_setting", &controllers.AccountSettingController{})
-	beego.Router("/change_password", &controllers.ChangePasswordController{})
-	beego.Router("/admin_option", &controllers.AdminOptionController{})
-	beego.Router("/forgot_password", &controllers.ForgotPasswordController{})
-	beego.Router("/reset_password", &controllers.ResetPasswordController{})
-	beego.Router("/search", &controllers.SearchController{})
+	commonapi.Router("/", &controllers.IndexController{})
+	commonapi.Router("/dashboard", &controllers.DashboardController{})
+	commonapi.Router("/project", &controllers.ProjectController{})
+	commonapi.Router("/repository", &controllers.RepositoryController{})
+	commonapi.Router("/sign_up", &controllers.SignUpController{})
+	commonapi.Router("/add_new", &controllers.AddNewController{})
+	commonapi.Router("/account_setting", &controllers.AccountSettingController{})
+	commonapi.Router("/change_password", &controllers.ChangePasswordController{})
+	commonapi.Router("/admin_option", &controllers.AdminOptionController{})
+	commonapi.Router("/forgot_password", &controllers.ForgotPasswordController{})
+	commonapi.Router("/reset_password", &controllers.ResetPasswordController{})
+	commonapi.Router("/search", &controllers.SearchController{})
 
-	beego.Router("/login", &controllers.CommonController{}, "post:Login")
-	beego.Router("/log_out", &controllers.CommonController{}, "get:LogOut")
-	beego.Router("/reset", &controllers.CommonController{}, "post:ResetPassword")
-	beego.Router("/userExists", &controllers.CommonController{}, "post:UserExists")
-	beego.Router("/sendEmail", &controllers.CommonController{}, "get:SendEmail")
-	beego.Router("/language", &controllers.CommonController{}, "get:SwitchLanguage")
+	commonapi.Router("/login", &controllers.CommonController{}, "post:Login")
+	commonapi.Router("/log_out", &controllers.CommonController{}, "get:LogOut")
+	commonapi.Router("/reset", &controllers.CommonController{}, "post:ResetPassword")
+	commonapi.Router("/userExists", &controllers.CommonController{}, "post:UserExists")
+	commonapi.Router("/sendEmail", &controllers.CommonController{}, "get:SendEmail")
+	commonapi.Router("/language", &controllers.CommonController{}, "get:SwitchLanguage")
+	commonapi.Router("/oidc/login", &controllers.CommonController{}, "get:OIDCLogin")
+	commonapi.Router("/oidc/callback", &controllers.CommonController{}, "get:OIDCCallback")
 
-	beego.Router("/optional_menu", &controllers.OptionalMenuController{})
-	beego.Router("/navigation_header", &controllers.NavigationHeaderController{})
-	beego.Router("/navigation_detail", &controllers.NavigationDetailController{})
-	beego.Router("/sign_in", &controllers.SignInController{})
+	commonapi.Router("/optional_menu", &controllers.OptionalMenuController{})
+	commonapi.Router("/navigation_header", &controllers.NavigationHeaderController{})
+	commonapi.Router("/navigation_detail", &controllers.NavigationDetailController{})
+	commonapi.Router("/sign_in", &controllers.SignInController{})
 
 	//API:
-	beego.Router("/api/search", &api.SearchAPI{})
-	beego.Router("/api/projects/:pid([0-9]+)/members/?:mid", &api.ProjectMemberAPI{})
-	beego.Router("/api/projects/", &api.ProjectAPI{}, "get:List;post:Post")
-	beego.Router("/api/projects/:id", &api.ProjectAPI{})
-	beego.Router("/api/projects/:id/publicity", &api.ProjectAPI{}, "put:ToggleProjectPublic")
-	beego.Router("/api/statistics", &api.StatisticAPI{})
-	beego.Router("/api/projects/:id([0-9]+)/logs/filter", &api.ProjectAPI{}, "post:FilterAccessLog")
-	beego.Router("/api/users/?:id", &api.UserAPI{})
-	beego.Router("/api/users/:id([0-9]+)/password", &api.UserAPI{}, "put:ChangePassword")
-	beego.Router("/api/internal/syncregistry", &api.InternalAPI{}, "post:SyncRegistry")
-	beego.Router("/api/repositories", &api.RepositoryAPI{})
-	beego.Router("/api/repositories/tags", &api.RepositoryAPI{}, "get:GetTags")
-	beego.Router("/api/repositories/manifests", &api.RepositoryAPI{}, "get:GetManifests")
-	beego.Router("/api/jobs/replication/", &api.RepJobAPI{}, "get:List")
-	beego.Router("/api/jobs/replication/:id([0-9]+)", &api.RepJobAPI{})
-	beego.Router("/api/jobs/replication/:id([0-9]+)/log", &api.RepJobAPI{}, "get:GetLog")
-	beego.Router("/api/policies/replication/:id([0-9]+)", &api.RepPolicyAPI{})
-	beego.Router("/api/policies/replication", &api.RepPolicyAPI{}, "get:List")
-	beego.Router("/api/policies/replication", &api.RepPolicyAPI{}, "post:Post")
-	beego.Router("/api/policies/replication/:id([0-9]+)/enablement", &api.RepPolicyAPI{}, "put:UpdateEnablement")
-	beego.Router("/api/targets/", &api.TargetAPI{}, "get:List")
-	beego.Router("/api/targets/", &api.TargetAPI{}, "post:Post")
-	beego.Router("/api/targets/:id([0-9]+)", &api.TargetAPI{})
-	beego.Router("/api/targets/:id([0-9]+)/policies/", &api.TargetAPI{}, "get:ListPolicies")
-	beego.Router("/api/targets/ping", &api.TargetAPI{}, "post:Ping")
-	beego.Router("/api/users/:id/sysadmin", &api.UserAPI{}, "put:ToggleUserAdminRole")
-	beego.Router("/api/repositories/top", &api.RepositoryAPI{}, "get:GetTopRepos")
-	beego.Router("/api/logs", &api.LogAPI{})
+	commonapi.Router("/api/search", &api.SearchAPI{})
+	commonapi.Router("/api/projects/:pid([0-9]+)/members/?:mid", &api.ProjectMemberAPI{})
+	commonapi.Router("/api/projects/", &api.ProjectAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:id", &api.ProjectAPI{})
+	commonapi.Router("/api/projects/:id/publicity", &api.ProjectAPI{}, "put:ToggleProjectPublic")
+	commonapi.Router("/api/projects/:id([0-9]+)/quota", &api.ProjectAPI{}, "get:GetQuota;put:UpdateQuota")
+	commonapi.Router("/api/projects/:id([0-9]+)/proxy", &api.ProjectAPI{}, "put:UpdateProxy")
+	commonapi.Router("/api/projects/:id([0-9]+)/signing", &api.ProjectAPI{}, "put:UpdateSigning")
+	commonapi.Router("/api/projects/:id([0-9]+)/scanning", &api.ProjectAPI{}, "put:UpdateScanning")
+	commonapi.Router("/api/projects/:id([0-9]+)/vulnerability_policy", &api.ProjectAPI{}, "put:UpdateVulnerabilityPolicy")
+	commonapi.Router("/api/statistics", &api.StatisticAPI{})
+	commonapi.Router("/api/projects/:id([0-9]+)/logs/filter", &api.ProjectAPI{}, "post:FilterAccessLog")
+	commonapi.Router("/api/projects/:pid([0-9]+)/retention", &api.RetentionAPI{})
+	commonapi.Router("/api/projects/:pid([0-9]+)/retention/jobs", &api.RetentionAPI{}, "get:ListJobs;post:PostJob")
+	commonapi.Router("/api/projects/:pid([0-9]+)/retention/jobs/:id([0-9]+)", &api.RetentionAPI{}, "get:GetJob")
+	commonapi.Router("/api/projects/:pid([0-9]+)/retention/jobs/:id([0-9]+)/log", &api.RetentionAPI{}, "get:GetJobLog")
+	commonapi.Router("/api/projects/:pid([0-9]+)/webhooks", &api.WebhookAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/webhooks/:id([0-9]+)", &api.WebhookAPI{}, "get:Get;put:Put;delete:Delete")
+	commonapi.Router("/api/projects/:pid([0-9]+)/webhooks/:id([0-9]+)/deliveries", &api.WebhookAPI{}, "get:ListDeliveries")
+	commonapi.Router("/api/projects/:pid([0-9]+)/robots", &api.RobotAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/robots/:id([0-9]+)", &api.RobotAPI{}, "get:Get;delete:Delete")
+	commonapi.Router("/api/projects/:pid([0-9]+)/signing_keys", &api.SigningKeyAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/signing_keys/:id([0-9]+)", &api.SigningKeyAPI{}, "delete:Delete")
+	commonapi.Router("/api/projects/:pid([0-9]+)/signatures", &api.SignatureAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/cve_allowlist", &api.CVEAllowlistAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/cve_allowlist/:id([0-9]+)", &api.CVEAllowlistAPI{}, "delete:Delete")
+	commonapi.Router("/api/projects/:pid([0-9]+)/oidc_groups", &api.OIDCGroupAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/oidc_groups/:id([0-9]+)", &api.OIDCGroupAPI{}, "delete:Delete")
+	commonapi.Router("/api/projects/:pid([0-9]+)/ldap_groups", &api.LDAPGroupAPI{}, "get:List;post:Post")
+	commonapi.Router("/api/projects/:pid([0-9]+)/ldap_groups/:id([0-9]+)", &api.LDAPGroupAPI{}, "delete:Delete")
+	commonapi.Router("/api/ldap/ping", &api.LDAPAPI{}, "post:Ping")
+	commonapi.Router("/api/ldap/users/search", &api.LDAPAPI{}, "get:Search")
+	commonapi.Router("/api/ldap/users/import", &api.LDAPAPI{}, "post:ImportUsers")
+	commonapi.Router("/api/audit", &api.AuditAPI{}, "get:List")
+	commonapi.Router("/api/users/?:id", &api.UserAPI{})
+	commonapi.Router("/api/users/:id([0-9]+)/password", &api.UserAPI{}, "put:ChangePassword")
+	commonapi.Router("/api/users/:id([0-9]+)/cli_secret", &api.UserAPI{}, "post:GenerateCLISecret")
+	commonapi.Router("/api/internal/syncregistry", &api.InternalAPI{}, "post:SyncRegistry")
+	commonapi.Router("/api/repositories", &api.RepositoryAPI{})
+	commonapi.Router("/api/repositories/tags", &api.RepositoryAPI{}, "get:GetTags")
+	commonapi.Router("/api/repositories/manifests", &api.RepositoryAPI{}, "get:GetManifests")
+	commonapi.Router("/api/repositories/scan", &api.RepositoryAPI{}, "get:GetScan;post:Scan")
+	commonapi.Router("/api/repositories/scan/log", &api.RepositoryAPI{}, "get:GetScanLog")
+	commonapi.Router("/api/jobs/replication/", &api.RepJobAPI{}, "get:List")
+	commonapi.Router("/api/jobs/replication/queue", &api.RepJobAPI{}, "get:GetQueue")
+	commonapi.Router("/api/jobs/replication/:id([0-9]+)", &api.RepJobAPI{})
+	commonapi.Router("/api/jobs/replication/:id([0-9]+)/log", &api.RepJobAPI{}, "get:GetLog")
+	commonapi.Router("/api/policies/replication/:id([0-9]+)", &api.RepPolicyAPI{})
+	commonapi.Router("/api/policies/replication", &api.RepPolicyAPI{}, "get:List")
+	commonapi.Router("/api/policies/replication", &api.RepPolicyAPI{}, "post:Post")
+	commonapi.Router("/api/policies/replication/:id([0-9]+)/enablement", &api.RepPolicyAPI{}, "put:UpdateEnablement")
+	commonapi.Router("/api/targets/", &api.TargetAPI{}, "get:List")
+	commonapi.Router("/api/targets/", &api.TargetAPI{}, "post:Post")
+	commonapi.Router("/api/targets/:id([0-9]+)", &api.TargetAPI{})
+	commonapi.Router("/api/targets/:id([0-9]+)/policies/", &api.TargetAPI{}, "get:ListPolicies")
+	commonapi.Router("/api/targets/ping", &api.TargetAPI{}, "post:Ping")
+	commonapi.Router("/api/users/:id/sysadmin", &api.UserAPI{}, "put:ToggleUserAdminRole")
+	commonapi.Router("/api/repositories/top", &api.RepositoryAPI{}, "get:GetTopRepos")
+	commonapi.Router("/api/logs", &api.LogAPI{})
 
-	beego.Router("/api/systeminfo/volumes", &api.SystemInfoAPI{}, "get:GetVolumeInfo")
-	beego.Router("/api/systeminfo/getcert", &api.SystemInfoAPI{}, "get:GetCert")
+	commonapi.Router("/api/systeminfo/volumes", &api.SystemInfoAPI{}, "get:GetVolumeInfo")
+	commonapi.Router("/api/systeminfo/getcert", &api.SystemInfoAPI{}, "get:GetCert")
+	commonapi.Router("/api/system/gc", &api.GCAPI{}, "post:Post")
+	commonapi.Router("/api/system/gc/:id([0-9]+)", &api.GCAPI{}, "get:Get")
+	commonapi.Router("/api/system/gc/:id([0-9]+)/log", &api.GCAPI{}, "get:GetLog")
 	//external service that hosted on harbor process:
-	beego.Router("/service/notifications", &service.NotificationHandler{})
-	beego.Router("/service/token", &token.Handler{})
+	commonapi.Router("/service/notifications", &service.NotificationHandler{})
+	commonapi.Router("/service/token", &token.Handler{})
+
+	commonapi.Handler("/metrics", promhttp.Handler())
 }
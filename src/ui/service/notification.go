@@ -20,12 +20,15 @@ import (
 	"regexp"
 	"strings"
 
+	commonapi "github.com/vmware/harbor/src/common/api"
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils"
 	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/webhook"
 	"github.com/vmware/harbor/src/ui/api"
 	"github.com/vmware/harbor/src/ui/service/cache"
+	"github.com/vmware/harbor/src/ui/service/token"
 
 	"github.com/astaxie/beego"
 )
@@ -35,7 +38,7 @@ type NotificationHandler struct {
 	beego.Controller
 }
 
-const manifestPattern = `^application/vnd.docker.distribution.manifest.v\d\+(json|prettyjws)`
+const manifestPattern = `^application/vnd.docker.distribution.manifest.(list.)?v\d\+(json|prettyjws)`
 const vicPrefix = "vic/"
 
 // Post handles POST request, and records audit log or refreshes cache based on event.
@@ -54,6 +57,7 @@ func (n *NotificationHandler) Post() {
 		return
 	}
 
+	requestID := commonapi.RequestID(n.Ctx.Request)
 	for _, event := range events {
 		repository := event.Target.Repository
 
@@ -66,19 +70,46 @@ func (n *NotificationHandler) Post() {
 			user = "anonymous"
 		}
 
-		go func() {
-			if err := dao.AccessLog(user, project, repository, tag, action); err != nil {
-				log.Errorf("failed to add access log: %v", err)
-			}
-		}()
+		// the ID of the registry request is logged so the logs of registry can be correlated too
+		registryRequestID := ""
+		if event.Request != nil {
+			registryRequestID = event.Request.ID
+		}
+		commonapi.RequestLogger(requestID).WithFields(log.Fields{
+			"registry_request_id": registryRequestID,
+		}).Infof("received %s event of %s:%s by %s", action, repository, tag, user)
+
+		go publishWebhookEvent(project, &webhook.Event{
+			Type:       action,
+			OccurAt:    event.TimeStamp,
+			Operator:   user,
+			Project:    project,
+			Repository: repository,
+			Tag:        tag,
+			Digest:     event.Target.Digest,
+		})
+
+		// the digests of the tags checked by the policies gating the pull are read again
+		if action == "push" || action == "delete" {
+			token.InvalidateTagDigests(repository)
+		}
+
+		// the access log of deletion is recorded by the API which deletes the manifest
+		if action == "delete" {
+			go refreshRepositorySize(repository)
+			continue
+		}
+
+		go api.LogImageAccess(user, "", project, repository, tag, action)
 		if action == "push" {
 			go func() {
+				defer refreshRepositorySize(repository)
 				exist := dao.RepositoryExists(repository)
 				if exist {
 					return
 				}
 				log.Debugf("Add repository %s into DB.", repository)
-				repoRecord := models.RepoRecord{Name: repository, OwnerName: user, ProjectName: project}
+				repoRecord := models.RepoRecord{Name: repository, OwnerName: repositoryOwner(user), ProjectName: project}
 				if err := dao.AddRepository(repoRecord); err != nil {
 					log.Errorf("Error happens when adding repository: %v", err)
 				}
@@ -86,7 +117,8 @@ func (n *NotificationHandler) Post() {
 					log.Errorf("failed to refresh cache: %v", err)
 				}
 			}()
-			go api.TriggerReplicationByRepository(repository, []string{tag}, models.RepOpTransfer)
+			go api.TriggerReplicationByRepository(repository, []string{tag}, models.RepOpTransfer, user, requestID)
+			go api.TriggerScanOnPush(repository, tag)
 		}
 		if action == "pull" {
 			go func() {
@@ -99,6 +131,31 @@ func (n *NotificationHandler) Post() {
 	}
 }
 
+// refreshRepositorySize updates the size of the repository, which is counted in the storage
+// usage of the project
+func refreshRepositorySize(repository string) {
+	if err := api.RefreshRepositorySize(repository); err != nil {
+		log.Errorf("failed to refresh size of repository %s: %v", repository, err)
+	}
+}
+
+// publishWebhookEvent queues the event for the webhooks of the project
+func publishWebhookEvent(projectName string, event *webhook.Event) {
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("failed to get project %s: %v", projectName, err)
+		return
+	}
+	if project == nil {
+		log.Warningf("project %s not found", projectName)
+		return
+	}
+
+	if err = webhook.Publish(project.ProjectID, event); err != nil {
+		log.Errorf("failed to publish webhook event of %s: %v", event.Repository, err)
+	}
+}
+
 func filterEvents(notification *models.Notification) ([]*models.Event, error) {
 	events := []*models.Event{}
 
@@ -106,6 +163,13 @@ func filterEvents(notification *models.Notification) ([]*models.Event, error) {
 		log.Debugf("receive an event: \n----ID: %s \n----target: %s:%s \n----digest: %s \n----action: %s \n----mediatype: %s \n----user-agent: %s", event.ID, event.Target.Repository,
 			event.Target.Tag, event.Target.Digest, event.Action, event.Target.MediaType, event.Request.UserAgent)
 
+		//the media type is not set in the events of deletion
+		if event.Action == "delete" {
+			events = append(events, &event)
+			log.Debugf("add event to collect: %s", event.ID)
+			continue
+		}
+
 		isManifest, err := regexp.MatchString(manifestPattern, event.Target.MediaType)
 		if err != nil {
 			log.Errorf("failed to match the media type against pattern: %v", err)
@@ -139,3 +203,16 @@ func filterEvents(notification *models.Notification) ([]*models.Event, error) {
 func (n *NotificationHandler) Render() error {
 	return nil
 }
+
+// repositoryOwner returns the user who owns the repository pushed by the user or robot
+// account, the repository pushed by a robot account is owned by the creator of it
+func repositoryOwner(user string) string {
+	if !strings.HasPrefix(user, models.RobotPrefix) {
+		return user
+	}
+	creator, err := api.RobotCreator(user)
+	if err != nil {
+		log.Errorf("failed to get the creator of robot account %s: %v", user, err)
+	}
+	return creator
+}
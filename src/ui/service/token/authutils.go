@@ -25,7 +25,9 @@ import (
 	"time"
 
 	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth"
 	"github.com/vmware/harbor/src/ui/config"
 
 	"github.com/docker/distribution/registry/auth/token"
@@ -76,14 +78,25 @@ func GetResourceActions(scopes []string) []*token.ResourceActions {
 	return res
 }
 
-// FilterAccess modify the action list in access based on permission
-func FilterAccess(username string, a *token.ResourceActions) {
+// FilterAccess modify the action list in access based on permission, the "push" action
+// is dropped if it is requested but the project is over quota, and the error returned
+// tells the reason
+func FilterAccess(username string, a *token.ResourceActions) error {
 
 	if a.Type == "registry" && a.Name == "catalog" {
 		log.Infof("current access, type: %s, name:%s, actions:%v \n", a.Type, a.Name, a.Actions)
-		return
+		return nil
 	}
 
+	pushRequested := false
+	for _, action := range a.Actions {
+		if action == "push" {
+			pushRequested = true
+			break
+		}
+	}
+
+	var quotaErr error
 	//clear action list to assign to new acess element after perm check.
 	a.Actions = []string{}
 	if a.Type == "repository" {
@@ -91,15 +104,19 @@ func FilterAccess(username string, a *token.ResourceActions) {
 		repoLength := len(repoSplit)
 		if repoLength > 1 { //Only check the permission when the requested image has a namespace, i.e. project
 			var projectName string
+			repository := a.Name
 			registryURL := config.ExtRegistryURL()
 			if repoSplit[0] == registryURL {
 				projectName = repoSplit[1]
+				repository = strings.Join(repoSplit[1:], "/")
 				log.Infof("Detected Registry URL in Project Name. Assuming this is a notary request and setting Project Name as %s\n", projectName)
 			} else {
 				projectName = repoSplit[0]
 			}
 			var permission string
-			if len(username) > 0 {
+			if strings.HasPrefix(username, models.RobotPrefix) {
+				permission = robotPermission(username, projectName)
+			} else if len(username) > 0 {
 				isAdmin, err := dao.IsAdminRole(username)
 				if err != nil {
 					log.Errorf("Error occurred in IsAdminRole: %v", err)
@@ -108,7 +125,7 @@ func FilterAccess(username string, a *token.ResourceActions) {
 					exist, err := dao.ProjectExists(projectName)
 					if err != nil {
 						log.Errorf("Error occurred in CheckExistProject: %v", err)
-						return
+						return nil
 					}
 					if exist {
 						permission = "RWM"
@@ -120,12 +137,19 @@ func FilterAccess(username string, a *token.ResourceActions) {
 					permission, err = dao.GetPermission(username, projectName)
 					if err != nil {
 						log.Errorf("Error occurred in GetPermission: %v", err)
-						return
+						return nil
 					}
 				}
 			}
 			if strings.Contains(permission, "W") {
-				a.Actions = append(a.Actions, "push")
+				if pushRequested {
+					quotaErr = checkQuota(projectName, repository)
+				}
+				if quotaErr == nil {
+					a.Actions = append(a.Actions, "push")
+				} else {
+					log.Warningf("push to %s is denied: %v", a.Name, quotaErr)
+				}
 			}
 			if strings.Contains(permission, "M") {
 				a.Actions = append(a.Actions, "*")
@@ -136,15 +160,94 @@ func FilterAccess(username string, a *token.ResourceActions) {
 		}
 	}
 	log.Infof("current access, type: %s, name:%s, actions:%v \n", a.Type, a.Name, a.Actions)
+	return quotaErr
+}
+
+// robotPermission returns the permission of the robot account in the project in the
+// same form as dao.GetPermission. As the registry requires "*" to delete a manifest,
+// which covers pushing too, "M" is only granted when all actions are allowed.
+func robotPermission(fullName, projectName string) string {
+	robot, err := auth.GetRobot(fullName)
+	if err != nil {
+		log.Errorf("Error occurred in GetRobot: %v", err)
+		return ""
+	}
+	if robot == nil {
+		return ""
+	}
+
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("Error occurred in GetProjectByName: %v", err)
+		return ""
+	}
+	if project == nil || project.ProjectID != robot.ProjectID {
+		return ""
+	}
+
+	permission := ""
+	if robot.Allows(models.RobotActionPull) {
+		permission += "R"
+	}
+	if robot.Allows(models.RobotActionPush) {
+		permission += "W"
+	}
+	if robot.Allows(models.RobotActionPull) && robot.Allows(models.RobotActionPush) &&
+		robot.Allows(models.RobotActionDelete) {
+		permission += "M"
+	}
+	return permission
+}
+
+// checkQuota returns an error if the project is over its storage quota, or pushing to the
+// repository creates a new one while the project has reached its repository quota. As the
+// usage is updated after an image is pushed, the last image pushed may exceed the quota.
+func checkQuota(projectName, repository string) error {
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("Error occurred in GetProjectByName: %v", err)
+		return nil
+	}
+	if project == nil || (project.StorageLimit <= 0 && project.RepoLimit <= 0) {
+		return nil
+	}
+
+	size, count, err := dao.GetProjectUsage(project.ProjectID)
+	if err != nil {
+		log.Errorf("Error occurred in GetProjectUsage: %v", err)
+		return nil
+	}
+
+	if project.StorageLimit > 0 && size >= project.StorageLimit {
+		return fmt.Errorf("project %s is over its storage quota, %d of %d bytes used",
+			projectName, size, project.StorageLimit)
+	}
+
+	if project.RepoLimit > 0 && count >= int64(project.RepoLimit) && !dao.RepositoryExists(repository) {
+		return fmt.Errorf("project %s has reached its repository quota, %d of %d repositories used",
+			projectName, count, project.RepoLimit)
+	}
+
+	return nil
 }
 
 // GenTokenForUI is for the UI process to call, so it won't establish a https connection from UI to proxy.
+// The access is filtered in the same way as the one requested from the token service, so the images
+// gated by the policies of the project can't be pulled through the UI either.
 func GenTokenForUI(username string, service string, scopes []string) (token string, expiresIn int, issuedAt *time.Time, err error) {
 	access := GetResourceActions(scopes)
 	for _, a := range access {
-		FilterAccess(username, a)
+		if err := FilterAccess(username, a); err != nil {
+			return "", 0, nil, err
+		}
+		filterPullAccess(a)
+	}
+	token, expiresIn, issuedAt, err = MakeToken(username, service, access)
+	if err != nil {
+		return "", 0, nil, err
 	}
-	return MakeToken(username, service, access)
+	countIssuedToken(access)
+	return token, expiresIn, issuedAt, nil
 }
 
 // MakeToken makes a valid jwt token based on parms.
@@ -0,0 +1,43 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"github.com/docker/distribution/registry/auth/token"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var issuedTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "harbor_ui_tokens_issued_total",
+	Help: "The count of the tokens issued for the UI by the resource type and the action granted, " +
+		"the action is \"none\" if nothing is granted.",
+}, []string{"type", "action"})
+
+func init() {
+	prometheus.MustRegister(issuedTokens)
+}
+
+func countIssuedToken(access []*token.ResourceActions) {
+	for _, a := range access {
+		if len(a.Actions) == 0 {
+			issuedTokens.WithLabelValues(a.Type, "none").Inc()
+			continue
+		}
+		for _, action := range a.Actions {
+			issuedTokens.WithLabelValues(a.Type, action).Inc()
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+	svc_utils "github.com/vmware/harbor/src/ui/service/utils"
+
+	"github.com/docker/distribution/registry/auth/token"
+)
+
+// the max time to wait for the caching of a repository which hasn't been cached yet,
+// so that its first pull can be served
+const proxyWaitTimeout = 60 * time.Second
+
+// the time before the repository is cached again if the last job failed, e.g. it doesn't
+// exist in the target, it's shorter than the TTL of the project so that the errors are
+// retried soon while the pulls of the repository don't trigger a job every time
+const proxyFailureTTL = 5 * time.Minute
+
+// serialize the checking and triggering of proxy jobs, so that concurrent pulls of a
+// repository trigger only one job, the lock of a repository is picked by its hash so
+// that the pulls of most other repositories don't wait for it
+var proxyLocks [32]sync.Mutex
+
+func proxyLock(repository string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(repository))
+	return &proxyLocks[h.Sum32()%uint32(len(proxyLocks))]
+}
+
+// filterProxyAccess makes the repositories of proxy cache projects read only to clients,
+// and if the pull is granted, it starts caching the repository from the target of the
+// project when the repository hasn't been cached or its cache has expired.
+func filterProxyAccess(a *token.ResourceActions) {
+	if a.Type != "repository" {
+		return
+	}
+
+	projectName, _ := utils.ParseRepository(a.Name)
+	if len(projectName) == 0 {
+		return
+	}
+	project, err := dao.GetProjectByName(projectName)
+	if err != nil {
+		log.Errorf("Error occurred in GetProjectByName: %v", err)
+		return
+	}
+	if project == nil || !project.IsProxy() {
+		return
+	}
+
+	pull := false
+	for _, action := range a.Actions {
+		if action == "pull" {
+			pull = true
+		}
+	}
+	a.Actions = []string{}
+	if !pull {
+		return
+	}
+	a.Actions = append(a.Actions, "pull")
+
+	if err = syncProxyRepository(project, a.Name); err != nil {
+		log.Errorf("failed to cache %s: %v", a.Name, err)
+	}
+}
+
+// syncProxyRepository triggers a job to cache the repository if needed, and waits for
+// the job if the repository hasn't been cached
+func syncProxyRepository(project *models.Project, repository string) error {
+	lock := proxyLock(repository)
+	lock.Lock()
+	job, err := dao.GetLastProxyJob(repository)
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+	if proxyCacheExpired(project, job, time.Now()) {
+		id, err := triggerProxyJob(repository)
+		if err != nil {
+			lock.Unlock()
+			return err
+		}
+		log.Infof("proxy job %d for %s triggered", id, repository)
+		job = &models.RepJob{ID: id, Status: models.JobPending}
+	}
+	lock.Unlock()
+
+	if !proxyJobRunning(job) || dao.RepositoryExists(repository) {
+		return nil
+	}
+
+	return waitForProxyJob(job.ID, proxyWaitTimeout)
+}
+
+// proxyCacheExpired returns whether the repository needs to be cached again according to
+// the last job caching it: it has never been cached, or the last job finished longer ago
+// than the TTL of the project, the TTL is limited by proxyFailureTTL if the job didn't succeed
+func proxyCacheExpired(project *models.Project, job *models.RepJob, now time.Time) bool {
+	if job == nil {
+		return true
+	}
+	if proxyJobRunning(job) {
+		return false
+	}
+
+	ttl := project.ProxyTTL
+	if ttl <= 0 {
+		ttl = models.DefaultProxyTTL
+	}
+	expiration := time.Duration(ttl) * time.Second
+	if job.Status != models.JobFinished && expiration > proxyFailureTTL {
+		expiration = proxyFailureTTL
+	}
+	return job.UpdateTime.Add(expiration).Before(now)
+}
+
+func proxyJobRunning(job *models.RepJob) bool {
+	return job.Status == models.JobPending || job.Status == models.JobRunning ||
+		job.Status == models.JobRetrying
+}
+
+// waitForProxyJob waits until the job completes or the timeout is reached
+func waitForProxyJob(id int64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		job, err := dao.GetRepJob(id)
+		if err != nil {
+			return err
+		}
+		if job == nil || !proxyJobRunning(job) {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	log.Warningf("proxy job %d isn't completed in %v", id, timeout)
+	return nil
+}
+
+// triggerProxyJob calls the API of job service to start a job caching all tags
+// of the repository, it returns the ID of the job
+func triggerProxyJob(repository string) (int64, error) {
+	return svc_utils.CreateJob(fmt.Sprintf("%s/api/jobs/proxy", config.InternalJobServiceURL()), struct {
+		Repository string `json:"repository"`
+	}{
+		Repository: repository,
+	})
+}
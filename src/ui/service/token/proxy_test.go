@@ -0,0 +1,53 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestProxyCacheExpired(t *testing.T) {
+	now := time.Now()
+	project := &models.Project{ProxyTTL: 3600}
+
+	cases := []struct {
+		job     *models.RepJob
+		expired bool
+	}{
+		{nil, true},
+		{&models.RepJob{Status: models.JobRunning, UpdateTime: now.Add(-2 * time.Hour)}, false},
+		{&models.RepJob{Status: models.JobFinished, UpdateTime: now.Add(-30 * time.Minute)}, false},
+		{&models.RepJob{Status: models.JobFinished, UpdateTime: now.Add(-2 * time.Hour)}, true},
+		{&models.RepJob{Status: models.JobError, UpdateTime: now.Add(-time.Minute)}, false},
+		{&models.RepJob{Status: models.JobError, UpdateTime: now.Add(-10 * time.Minute)}, true},
+	}
+
+	for i, c := range cases {
+		if expired := proxyCacheExpired(project, c.job, now); expired != c.expired {
+			t.Errorf("unexpected result of case %d: %v != %v", i, expired, c.expired)
+		}
+	}
+
+	// the TTL of the project is shorter than proxyFailureTTL
+	project.ProxyTTL = 60
+	job := &models.RepJob{Status: models.JobError, UpdateTime: now.Add(-2 * time.Minute)}
+	if !proxyCacheExpired(project, job, now) {
+		t.Errorf("the cache should be expired after the TTL of the project")
+	}
+}
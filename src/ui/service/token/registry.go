@@ -0,0 +1,223 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	commonConfig "github.com/vmware/harbor/src/common/config"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/registry"
+	registry_error "github.com/vmware/harbor/src/common/utils/registry/error"
+	"github.com/vmware/harbor/src/ui/config"
+
+	"github.com/docker/distribution/registry/auth/token"
+)
+
+const (
+	// the subject of the tokens used by the token service itself to read the registry
+	tokenServiceName = "harbor-token-service"
+	// the service name of registry configured in the auth section of registry
+	registryService = "token-service"
+	// the digests of the tags cached expire after the period, as the notification of
+	// registry which invalidates them may be sent to another UI instance
+	tagDigestsTTL = 30 * time.Second
+)
+
+// tagDigestsCache caches the digests of the tags per repository, so the policies gating
+// the pull of images don't read every manifest of the repository from registry on each
+// token request. key: repository
+var tagDigestsCache = struct {
+	sync.Mutex
+	entries map[string]*tagDigestsEntry
+	// the generation of a repository is increased every time its entry is invalidated,
+	// the digests read before are not cached as they may miss the tag pushed
+	generations map[string]int64
+}{
+	entries:     map[string]*tagDigestsEntry{},
+	generations: map[string]int64{},
+}
+
+type tagDigestsEntry struct {
+	digests    map[string]string
+	expiration time.Time
+}
+
+// InvalidateTagDigests removes the digests of the tags of the repository from the cache,
+// it is called when the registry notifies that a manifest of the repository is pushed
+// or deleted
+func InvalidateTagDigests(repository string) {
+	tagDigestsCache.Lock()
+	defer tagDigestsCache.Unlock()
+
+	delete(tagDigestsCache.entries, repository)
+	tagDigestsCache.generations[repository]++
+}
+
+// pullGatedProject returns the project of the repository if the access contains the pull
+// action, which is limited by the policies gating the pull of images. The access requesting
+// push is limited as well, since the registry serves the images to anyone holding pull, the
+// push action is kept if the pull is denied. An error is returned if the project can't be
+// got, so that the pull is denied rather than granted without checking the policies.
+func pullGatedProject(a *token.ResourceActions) (*models.Project, error) {
+	if a.Type != "repository" {
+		return nil, nil
+	}
+
+	pull := false
+	for _, action := range a.Actions {
+		if action == "pull" {
+			pull = true
+		}
+	}
+	if !pull {
+		return nil, nil
+	}
+
+	projectName, _ := utils.ParseRepository(a.Name)
+	if len(projectName) == 0 {
+		return nil, nil
+	}
+	return dao.GetProjectByName(projectName)
+}
+
+// filterPullAccess drops the pull action if the repository violates any policy of the project
+// gating the pull of images. The digests of the tags are got from registry only once and
+// checked by all the policies.
+func filterPullAccess(a *token.ResourceActions) {
+	project, err := pullGatedProject(a)
+	if err != nil {
+		log.Errorf("failed to get the project of %s: %v", a.Name, err)
+		denyPull(a)
+		return
+	}
+	if project == nil || (project.RequireSignature == 0 &&
+		len(project.VulnerabilitySeverity) == 0 && project.BlockUnscanned == 0) {
+		return
+	}
+
+	digests, err := tagDigests(a.Name)
+	if err != nil {
+		// fail closed
+		log.Errorf("failed to get the tags of %s: %v", a.Name, err)
+		denyPull(a)
+		return
+	}
+
+	if !signaturesAllowPull(project, a.Name, digests) ||
+		!vulnerabilitiesAllowPull(project, a.Name, digests) {
+		denyPull(a)
+	}
+}
+
+// denyPull removes the pull action from the access
+func denyPull(a *token.ResourceActions) {
+	actions := []string{}
+	for _, action := range a.Actions {
+		if action != "pull" {
+			actions = append(actions, action)
+		}
+	}
+	a.Actions = actions
+}
+
+// tagDigests returns the digests of the manifests the tags of the repository refer to,
+// key: tag, value: digest. As the scope of token only contains the repository, the
+// policies gating the pull of images check all tags of the repository. The digests are
+// read from the cache, or from registry and cached if they are not in the cache.
+func tagDigests(repository string) (map[string]string, error) {
+	now := time.Now()
+	tagDigestsCache.Lock()
+	entry := tagDigestsCache.entries[repository]
+	generation := tagDigestsCache.generations[repository]
+	tagDigestsCache.Unlock()
+	if entry != nil && now.Before(entry.expiration) {
+		return entry.digests, nil
+	}
+
+	digests, err := readTagDigests(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	tagDigestsCache.Lock()
+	defer tagDigestsCache.Unlock()
+	if tagDigestsCache.generations[repository] == generation {
+		tagDigestsCache.entries[repository] = &tagDigestsEntry{
+			digests:    digests,
+			expiration: now.Add(tagDigestsTTL),
+		}
+	}
+	return digests, nil
+}
+
+// readTagDigests reads the digests of the tags of the repository from registry
+func readTagDigests(repository string) (map[string]string, error) {
+	client, err := registry.NewRepositoryWithModifiers(repository, config.InternalRegistryURL(),
+		!commonConfig.VerifyRemoteCert(), &pullTokenModifier{repository: repository})
+	if err != nil {
+		return nil, err
+	}
+
+	digests := map[string]string{}
+	tags, err := client.ListTag()
+	if err != nil {
+		if regErr, ok := err.(*registry_error.Error); ok && regErr.StatusCode == http.StatusNotFound {
+			return digests, nil
+		}
+		return nil, err
+	}
+
+	for _, tag := range tags {
+		digest, exist, err := client.ManifestExist(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			continue
+		}
+		digests[tag] = digest
+	}
+	return digests, nil
+}
+
+// pullTokenModifier authorizes the requests to registry with a token issued
+// by the token service itself, which grants pull of the repository
+type pullTokenModifier struct {
+	repository string
+}
+
+// Modify adds the bearer token to the request
+func (p *pullTokenModifier) Modify(req *http.Request) error {
+	tk, _, _, err := MakeToken(tokenServiceName, registryService, []*token.ResourceActions{
+		{
+			Type:    "repository",
+			Name:    p.repository,
+			Actions: []string{"pull"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	req.Header.Set(http.CanonicalHeaderKey("Authorization"), fmt.Sprintf("Bearer %s", tk))
+	return nil
+}
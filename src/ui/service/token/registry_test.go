@@ -0,0 +1,55 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTagDigestsCache(t *testing.T) {
+	repository := "library/test_tag_digests_cache"
+	cached := map[string]string{"latest": "sha256:0a1b2c"}
+
+	tagDigestsCache.Lock()
+	tagDigestsCache.entries[repository] = &tagDigestsEntry{
+		digests:    cached,
+		expiration: time.Now().Add(tagDigestsTTL),
+	}
+	generation := tagDigestsCache.generations[repository]
+	tagDigestsCache.Unlock()
+
+	// the cached digests are returned without reading registry
+	digests, err := tagDigests(repository)
+	if err != nil {
+		t.Fatalf("failed to get the digests of %s: %v", repository, err)
+	}
+	if len(digests) != 1 || digests["latest"] != cached["latest"] {
+		t.Errorf("unexpected digests: %v != %v", digests, cached)
+	}
+
+	InvalidateTagDigests(repository)
+
+	tagDigestsCache.Lock()
+	defer tagDigestsCache.Unlock()
+	if _, ok := tagDigestsCache.entries[repository]; ok {
+		t.Errorf("the digests of %s should be removed from the cache", repository)
+	}
+	if tagDigestsCache.generations[repository] != generation+1 {
+		t.Errorf("unexpected generation of %s: %d != %d", repository,
+			tagDigestsCache.generations[repository], generation+1)
+	}
+}
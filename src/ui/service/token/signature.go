@@ -0,0 +1,65 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// signaturesAllowPull returns whether the pull of the repository is allowed by the signature
+// policy of the project, it's denied if any tag of the repository isn't signed by a trusted
+// key of the project
+func signaturesAllowPull(project *models.Project, repository string, digests map[string]string) bool {
+	if project.RequireSignature == 0 {
+		return true
+	}
+
+	unsigned, err := unsignedTags(project.ProjectID, digests)
+	if err != nil {
+		// fail closed
+		log.Errorf("failed to check signatures of %s: %v", repository, err)
+		return false
+	}
+	if len(unsigned) != 0 {
+		log.Infof("pull of %s is denied as the tags %v are not signed", repository, unsigned)
+		return false
+	}
+	return true
+}
+
+// unsignedTags returns the tags whose manifest doesn't have any signature in the project,
+// the digests are the ones of the tags of the repository, key: tag, value: digest
+func unsignedTags(projectID int64, digests map[string]string) ([]string, error) {
+	list := []string{}
+	for _, digest := range digests {
+		list = append(list, digest)
+	}
+
+	signed, err := dao.GetSignedDigests(projectID, list)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned := []string{}
+	for tag, digest := range digests {
+		if !signed[digest] {
+			unsigned = append(unsigned, tag)
+		}
+	}
+	return unsigned, nil
+}
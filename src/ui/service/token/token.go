@@ -17,8 +17,10 @@ package token
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/vmware/harbor/src/common/api"
 	"github.com/vmware/harbor/src/ui/auth"
 	"github.com/vmware/harbor/src/common/models"
 	svc_utils "github.com/vmware/harbor/src/ui/service/utils"
@@ -51,23 +53,50 @@ func (h *Handler) Get() {
 	} else {
 		uid, password, _ = request.BasicAuth()
 		log.Debugf("uid for logging: %s", uid)
-		user := authenticate(uid, password)
-		if user == nil {
+		if strings.HasPrefix(uid, models.RobotPrefix) {
+			if robot := authenticateRobot(uid, password); robot != nil {
+				username = uid
+			}
+		} else if user := authenticate(uid, password); user != nil {
+			username = user.Username
+		}
+		if len(username) == 0 {
 			log.Warningf("login request with invalid credentials in token service, uid: %s", uid)
+			if len(uid) != 0 {
+				api.AuditLogin(h.Ctx, nil, uid)
+			}
 			if len(scopes) == 0 {
 				h.CustomAbort(http.StatusUnauthorized, "")
 			}
-		} else {
-			username = user.Username
 		}
 		log.Debugf("username for filtering access: %s.", username)
 		for _, a := range access {
-			FilterAccess(username, a)
+			if err := FilterAccess(username, a); err != nil {
+				h.denyAccess(err.Error())
+				return
+			}
+			filterProxyAccess(a)
+			filterPullAccess(a)
 		}
 	}
 	h.serveToken(username, service, access)
 }
 
+// denyAccess responds with an error in the format of registry API, so that the
+// docker client shows the message to user
+func (h *Handler) denyAccess(message string) {
+	h.Data["json"] = map[string]interface{}{
+		"errors": []map[string]string{
+			{
+				"code":    "DENIED",
+				"message": message,
+			},
+		},
+	}
+	h.Ctx.Output.SetStatus(http.StatusForbidden)
+	h.ServeJSON()
+}
+
 func (h *Handler) serveToken(username, service string, access []*token.ResourceActions) {
 	writer := h.Ctx.ResponseWriter
 	//create token
@@ -96,3 +125,12 @@ func authenticate(principal, password string) *models.User {
 	}
 	return user
 }
+
+func authenticateRobot(fullName, secret string) *models.Robot {
+	robot, err := auth.LoginRobot(fullName, secret)
+	if err != nil {
+		log.Errorf("Error occurred in LoginRobot: %v", err)
+		return nil
+	}
+	return robot
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// vulnerabilitiesAllowPull returns whether the pull of the repository is allowed by the
+// vulnerability policy of the project, it's denied if any tag of the repository has
+// vulnerabilities of the severity or higher which are not in the CVE allowlist of the
+// project, or it has never been scanned if the unscanned images are blocked
+func vulnerabilitiesAllowPull(project *models.Project, repository string, digests map[string]string) bool {
+	if len(project.VulnerabilitySeverity) == 0 && project.BlockUnscanned == 0 {
+		return true
+	}
+
+	violations, err := vulnerableTags(project, digests)
+	if err != nil {
+		// fail closed
+		log.Errorf("failed to check vulnerabilities of %s: %v", repository, err)
+		return false
+	}
+	if len(violations) != 0 {
+		log.Infof("pull of %s is denied by the vulnerability policy: %v", repository, violations)
+		return false
+	}
+	return true
+}
+
+// vulnerableTags returns the tags which violate the vulnerability policy of the project,
+// key: tag, value: the violation. The digests are the ones of the tags of the repository,
+// key: tag, value: digest.
+func vulnerableTags(project *models.Project, digests map[string]string) (map[string]string, error) {
+	allowed, err := dao.GetAllowedCVEs(project.ProjectID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	violations := map[string]string{}
+	// key: digest, value: the violation
+	checked := map[string]string{}
+	for tag, digest := range digests {
+		violation, ok := checked[digest]
+		if !ok {
+			result, err := dao.GetScanResult(digest)
+			if err != nil {
+				return nil, err
+			}
+			violation = checkVulnerabilities(project, result, allowed)
+			checked[digest] = violation
+		}
+		if len(violation) != 0 {
+			violations[tag] = violation
+		}
+	}
+	return violations, nil
+}
+
+// checkVulnerabilities returns why the scan result violates the vulnerability policy of
+// the project, the result is nil if the manifest has never been scanned. An empty string
+// is returned if there is no violation.
+func checkVulnerabilities(project *models.Project, result *models.ScanResult, allowed map[string]bool) string {
+	if result == nil {
+		if project.BlockUnscanned == 1 {
+			return "never scanned"
+		}
+		return ""
+	}
+
+	threshold := models.SeverityLevel(project.VulnerabilitySeverity)
+	if threshold <= models.SeverityLevel(models.SeverityNone) {
+		return ""
+	}
+
+	for _, v := range result.Vulnerabilities {
+		if allowed[v.ID] {
+			continue
+		}
+		if models.SeverityLevel(v.Severity) >= threshold {
+			return fmt.Sprintf("%s of %s %s is %s", v.ID, v.Package, v.Version, v.Severity)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+/*
+   Copyright (c) 2016 VMware, Inc. All Rights Reserved.
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"testing"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+func TestCheckVulnerabilities(t *testing.T) {
+	result := &models.ScanResult{
+		Vulnerabilities: []*models.Vulnerability{
+			{ID: "CVE-2017-3735", Package: "openssl", Severity: models.SeverityHigh},
+			{ID: "CVE-2016-2177", Package: "openssl", Severity: models.SeverityMedium},
+		},
+	}
+
+	cases := []struct {
+		project  *models.Project
+		result   *models.ScanResult
+		allowed  map[string]bool
+		violated bool
+	}{
+		{&models.Project{}, nil, nil, false},
+		{&models.Project{BlockUnscanned: 1}, nil, nil, true},
+		{&models.Project{BlockUnscanned: 1}, result, nil, false},
+		{&models.Project{VulnerabilitySeverity: models.SeverityHigh}, result, nil, true},
+		{&models.Project{VulnerabilitySeverity: models.SeverityCritical}, result, nil, false},
+		{&models.Project{VulnerabilitySeverity: models.SeverityHigh}, result,
+			map[string]bool{"CVE-2017-3735": true}, false},
+		{&models.Project{VulnerabilitySeverity: models.SeverityMedium}, result,
+			map[string]bool{"CVE-2017-3735": true}, true},
+		{&models.Project{VulnerabilitySeverity: models.SeverityLow}, &models.ScanResult{}, nil, false},
+	}
+
+	for i, c := range cases {
+		violation := checkVulnerabilities(c.project, c.result, c.allowed)
+		if (len(violation) != 0) != c.violated {
+			t.Errorf("unexpected violation of case %d: %q", i, violation)
+		}
+	}
+}
@@ -17,8 +17,15 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"path"
+	"strconv"
 
+	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils/log"
 	"github.com/vmware/harbor/src/ui/config"
 )
@@ -32,3 +39,53 @@ func VerifySecret(r *http.Request) bool {
 	}
 	return c != nil && c.Value == secret
 }
+
+// JobServiceError is returned if job service responds with an unexpected status code
+type JobServiceError struct {
+	StatusCode int
+	Detail     string
+}
+
+// Error returns the status code and the details as string
+func (e *JobServiceError) Error() string {
+	return fmt.Sprintf("%d %s", e.StatusCode, e.Detail)
+}
+
+// CreateJob calls the API of job service to create a job, it returns the ID
+// of the job which is in the "Location" header of the response
+func CreateJob(url string, data interface{}) (int64, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(b))
+	if err != nil {
+		return 0, err
+	}
+	req.AddCookie(&http.Cookie{
+		Name:  models.UISecretCookie,
+		Value: config.UISecret(),
+	})
+
+	// send the request through the transport directly so the redirection
+	// isn't followed, as the ID of the job is in "Location" header
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		b, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+		return 0, &JobServiceError{
+			StatusCode: resp.StatusCode,
+			Detail:     string(b),
+		}
+	}
+
+	return strconv.ParseInt(path.Base(resp.Header.Get(http.CanonicalHeaderKey("Location"))), 10, 64)
+}
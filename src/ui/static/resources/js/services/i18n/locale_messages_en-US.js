@@ -16,6 +16,7 @@ var locale_messages = {
   'sign_in':  'Sign In',
   'sign_up': 'Sign Up',
   'forgot_password': 'Forgot Password',
+  'sign_in_via_oidc': 'Sign In via OIDC Provider',
   'login_now': 'Login Now',
   'its_easy_to_get_started': 'It\'s easy to get started ...',
   'icon_label_1': 'Anonymous repository access',
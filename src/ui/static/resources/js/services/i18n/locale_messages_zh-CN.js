@@ -16,6 +16,7 @@ var locale_messages = {
   'sign_in': '登录',
   'sign_up': '注册',
   'forgot_password': '忘记密码',
+  'sign_in_via_oidc': '通过OIDC提供方登录',
   'login_now': '登录',
   'its_easy_to_get_started': '这很容易上手...',
   'icon_label_1': '匿名访问公开镜像仓库',
@@ -139,4 +139,218 @@ class Repository(Base):
     creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
     update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
 
+class RetentionPolicy(Base):
+    __tablename__ = "retention_policy"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False, unique=True)
+    keep_latest = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    keep_pattern = sa.Column(sa.String(256))
+    max_age_days = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    enabled = sa.Column(mysql.TINYINT(1), nullable=False, server_default=sa.text("'1'"))
+    cron_str = sa.Column(sa.String(256))
+    creator_id = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+class RetentionJob(Base):
+    __tablename__ = "retention_job"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    policy_id = sa.Column(sa.Integer, nullable=False)
+    status = sa.Column(sa.String(64), nullable=False)
+    dry_run = sa.Column(mysql.TINYINT(1), nullable=False, server_default=sa.text("'0'"))
+    result = sa.Column(sa.Text)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.Index('retention_job_policy', "policy_id"),)
+
+class GCJob(Base):
+    __tablename__ = "gc_job"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    status = sa.Column(sa.String(64), nullable=False)
+    dry_run = sa.Column(mysql.TINYINT(1), nullable=False, server_default=sa.text("'0'"))
+    blobs = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    unlinked_bytes = sa.Column(sa.BigInteger, nullable=False, server_default=sa.text("'0'"))
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+class Webhook(Base):
+    __tablename__ = "webhook"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False)
+    target_url = sa.Column(sa.String(256), nullable=False)
+    secret = sa.Column(sa.String(256))
+    event_types = sa.Column(sa.String(256), nullable=False)
+    enabled = sa.Column(mysql.TINYINT(1), nullable=False, server_default=sa.text("'1'"))
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+class WebhookDelivery(Base):
+    __tablename__ = "webhook_delivery"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    webhook_id = sa.Column(sa.Integer, nullable=False)
+    event_type = sa.Column(sa.String(64), nullable=False)
+    payload = sa.Column(sa.Text, nullable=False)
+    status = sa.Column(sa.String(64), nullable=False)
+    attempts = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    response_code = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    last_error = sa.Column(sa.String(512))
+    next_attempt_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.Index('webhook_delivery_webhook', "webhook_id"),
+        sa.Index('webhook_delivery_status', "status", "next_attempt_time"))
+
+class Robot(Base):
+    __tablename__ = "robot"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    name = sa.Column(sa.String(64), nullable=False)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False)
+    description = sa.Column(sa.String(1024))
+    secret = sa.Column(sa.String(40), nullable=False)
+    salt = sa.Column(sa.String(40), nullable=False)
+    actions = sa.Column(sa.String(64), nullable=False)
+    expires_at = sa.Column(mysql.TIMESTAMP, nullable=True)
+    creator_id = sa.Column(sa.Integer, sa.ForeignKey('user.user_id'), nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.UniqueConstraint('project_id', 'name'),)
+
+class SigningKey(Base):
+    __tablename__ = "signing_key"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False)
+    name = sa.Column(sa.String(64), nullable=False)
+    public_key = sa.Column(sa.Text, nullable=False)
+    creator_id = sa.Column(sa.Integer, sa.ForeignKey('user.user_id'), nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.UniqueConstraint('project_id', 'name'),)
+
+class Signature(Base):
+    __tablename__ = "signature"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, nullable=False)
+    digest = sa.Column(sa.String(128), nullable=False)
+    key_id = sa.Column(sa.Integer, sa.ForeignKey('signing_key.id'), nullable=False)
+    signature = sa.Column(sa.Text, nullable=False)
+    creator = sa.Column(sa.String(255), nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.UniqueConstraint('key_id', 'digest'), sa.Index('project_digest', "project_id", "digest"))
+
+class ScanJob(Base):
+    __tablename__ = "scan_job"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    repository = sa.Column(sa.String(256), nullable=False)
+    tag = sa.Column(sa.String(128), nullable=False)
+    digest = sa.Column(sa.String(128))
+    status = sa.Column(sa.String(64), nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.Index('scan_job_repo_tag', "repository", "tag"),)
+
+class ScanResult(Base):
+    __tablename__ = "scan_result"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    digest = sa.Column(sa.String(128), nullable=False, unique=True)
+    job_id = sa.Column(sa.Integer, nullable=False)
+    scanner = sa.Column(sa.String(64), nullable=False)
+    severity = sa.Column(sa.String(16), nullable=False)
+    vulnerabilities = sa.Column(mysql.MEDIUMTEXT, nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+class CVEAllowlist(Base):
+    __tablename__ = "cve_allowlist"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False)
+    cve_id = sa.Column(sa.String(64), nullable=False)
+    reason = sa.Column(sa.String(1024))
+    expires_at = sa.Column(mysql.TIMESTAMP, nullable=True)
+    creator_id = sa.Column(sa.Integer, nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.UniqueConstraint('project_id', 'cve_id'),)
+
+class OIDCUser(Base):
+    __tablename__ = "oidc_user"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    user_id = sa.Column(sa.Integer, sa.ForeignKey('user.user_id'), nullable=False, unique=True)
+    subject = sa.Column(sa.String(255), nullable=False, unique=True)
+    secret = sa.Column(sa.String(40), nullable=False, server_default=sa.text("''"))
+    salt = sa.Column(sa.String(40), nullable=False, server_default=sa.text("''"))
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    update_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"))
+
+class OIDCGroupRole(Base):
+    __tablename__ = "oidc_group_role"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False)
+    group_name = sa.Column(sa.String(128), nullable=False)
+    role = sa.Column(sa.Integer, sa.ForeignKey('role.role_id'), nullable=False)
+    creator_id = sa.Column(sa.Integer, nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.UniqueConstraint('project_id', 'group_name'),)
+
+class OIDCMemberGrant(Base):
+    __tablename__ = "oidc_member_grant"
+
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), primary_key=True)
+    user_id = sa.Column(sa.Integer, sa.ForeignKey('user.user_id'), primary_key=True)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+class ProjectLDAPGroup(Base):
+    __tablename__ = "project_ldap_group"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    project_id = sa.Column(sa.Integer, sa.ForeignKey('project.project_id'), nullable=False)
+    group_dn = sa.Column(sa.String(255), nullable=False)
+    role = sa.Column(sa.Integer, sa.ForeignKey('role.role_id'), nullable=False)
+    creator_id = sa.Column(sa.Integer, nullable=False)
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.UniqueConstraint('project_id', 'group_dn'),)
+
+class AuditLog(Base):
+    __tablename__ = "audit_log"
+
+    id = sa.Column(sa.Integer, primary_key=True)
+    actor_id = sa.Column(sa.Integer, nullable=False, server_default=sa.text("'0'"))
+    actor = sa.Column(sa.String(255), nullable=False, server_default=sa.text("''"))
+    resource_type = sa.Column(sa.String(64), nullable=False)
+    resource_id = sa.Column(sa.String(128), nullable=False, server_default=sa.text("''"))
+    action = sa.Column(sa.String(64), nullable=False)
+    before_summary = sa.Column(sa.String(1024), nullable=False, server_default=sa.text("''"))
+    after_summary = sa.Column(sa.String(1024), nullable=False, server_default=sa.text("''"))
+    source_ip = sa.Column(sa.String(64), nullable=False, server_default=sa.text("''"))
+    op_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
+    __table_args__ = (sa.Index('audit_log_optime', "op_time"),
+        sa.Index('audit_log_resource', "resource_type", "resource_id"))
+
+class JobServiceInstance(Base):
+    __tablename__ = "job_service_instance"
+
+    id = sa.Column(sa.String(64), primary_key=True)
+    heartbeat_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+    creation_time = sa.Column(mysql.TIMESTAMP, server_default = sa.text("CURRENT_TIMESTAMP"))
+
 
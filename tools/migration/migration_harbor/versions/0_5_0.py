@@ -0,0 +1,64 @@
+# Copyright (c) 2008-2016 VMware, Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""0.4.0 to 0.5.0: leases of job service instances
+
+Revision ID: 0.5.0
+Revises: 0.5.0_upload_session
+
+"""
+
+# revision identifiers, used by Alembic.
+revision = '0.5.0'
+down_revision = '0.5.0_upload_session'
+branch_labels = None
+depends_on = None
+
+from alembic import op
+from db_meta import *
+
+from sqlalchemy.dialects import mysql
+
+def upgrade():
+    """
+    update schema&data
+    """
+    bind = op.get_bind()
+    #add column last_scheduled_time to table replication_policy
+    op.add_column('replication_policy', sa.Column('last_scheduled_time', mysql.TIMESTAMP, nullable=True))
+    #add columns lease_owner and stop_requested to table replication_job
+    op.add_column('replication_job', sa.Column('lease_owner', sa.String(64), nullable=False, server_default=sa.text("''")))
+    op.add_column('replication_job', sa.Column('stop_requested', mysql.TINYINT(1), nullable=False, server_default=sa.text("'0'")))
+    #add column last_scheduled_time to table retention_policy
+    op.add_column('retention_policy', sa.Column('last_scheduled_time', mysql.TIMESTAMP, nullable=True))
+    #add columns of leases to table retention_job and create index retention_job_status (status) on it
+    op.add_column('retention_job', sa.Column('lease_expiration', mysql.TIMESTAMP, nullable=True))
+    op.add_column('retention_job', sa.Column('lease_owner', sa.String(64), nullable=False, server_default=sa.text("''")))
+    op.create_index('retention_job_status', 'retention_job', ['status'])
+    #add columns of leases to table gc_job and create index gc_job_status (status) on it
+    op.add_column('gc_job', sa.Column('lease_expiration', mysql.TIMESTAMP, nullable=True))
+    op.add_column('gc_job', sa.Column('lease_owner', sa.String(64), nullable=False, server_default=sa.text("''")))
+    op.create_index('gc_job_status', 'gc_job', ['status'])
+    #add columns of leases to table scan_job and create index scan_job_status (status) on it
+    op.add_column('scan_job', sa.Column('lease_expiration', mysql.TIMESTAMP, nullable=True))
+    op.add_column('scan_job', sa.Column('lease_owner', sa.String(64), nullable=False, server_default=sa.text("''")))
+    op.create_index('scan_job_status', 'scan_job', ['status'])
+    #create tables: job_service_instance
+    JobServiceInstance.__table__.create(bind)
+
+def downgrade():
+    """
+    Downgrade has been disabled.
+    """
+    pass
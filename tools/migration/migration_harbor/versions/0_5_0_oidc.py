@@ -0,0 +1,47 @@
+# Copyright (c) 2008-2016 VMware, Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""0.4.0 to 0.5.0: OpenID Connect users and group roles
+
+Revision ID: 0.5.0_oidc
+Revises: 0.5.0_vulnerability_policy
+
+"""
+
+# revision identifiers, used by Alembic.
+revision = '0.5.0_oidc'
+down_revision = '0.5.0_vulnerability_policy'
+branch_labels = None
+depends_on = None
+
+from alembic import op
+from db_meta import *
+
+from sqlalchemy.dialects import mysql
+
+def upgrade():
+    """
+    update schema&data
+    """
+    bind = op.get_bind()
+    #create tables: oidc_user, oidc_group_role, oidc_member_grant
+    OIDCUser.__table__.create(bind)
+    OIDCGroupRole.__table__.create(bind)
+    OIDCMemberGrant.__table__.create(bind)
+
+def downgrade():
+    """
+    Downgrade has been disabled.
+    """
+    pass
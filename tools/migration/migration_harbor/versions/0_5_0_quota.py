@@ -0,0 +1,48 @@
+# Copyright (c) 2008-2016 VMware, Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""0.4.0 to 0.5.0: project quotas
+
+Revision ID: 0.5.0_quota
+Revises: 0.5.0_gc
+
+"""
+
+# revision identifiers, used by Alembic.
+revision = '0.5.0_quota'
+down_revision = '0.5.0_gc'
+branch_labels = None
+depends_on = None
+
+from alembic import op
+from db_meta import *
+
+from sqlalchemy.dialects import mysql
+
+def upgrade():
+    """
+    update schema&data
+    """
+    bind = op.get_bind()
+    #add columns storage_limit and repo_limit to table project
+    op.add_column('project', sa.Column('storage_limit', sa.BigInteger, nullable=False, server_default=sa.text("'0'")))
+    op.add_column('project', sa.Column('repo_limit', sa.Integer, nullable=False, server_default=sa.text("'0'")))
+    #add column size to table repository
+    op.add_column('repository', sa.Column('size', sa.BigInteger, nullable=False, server_default=sa.text("'0'")))
+
+def downgrade():
+    """
+    Downgrade has been disabled.
+    """
+    pass
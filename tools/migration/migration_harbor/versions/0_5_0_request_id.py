@@ -0,0 +1,45 @@
+# Copyright (c) 2008-2016 VMware, Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""0.4.0 to 0.5.0: request IDs of replication jobs
+
+Revision ID: 0.5.0_request_id
+Revises: 0.5.0_audit_log
+
+"""
+
+# revision identifiers, used by Alembic.
+revision = '0.5.0_request_id'
+down_revision = '0.5.0_audit_log'
+branch_labels = None
+depends_on = None
+
+from alembic import op
+from db_meta import *
+
+from sqlalchemy.dialects import mysql
+
+def upgrade():
+    """
+    update schema&data
+    """
+    bind = op.get_bind()
+    #add column request_id to table replication_job
+    op.add_column('replication_job', sa.Column('request_id', sa.String(64), nullable=False, server_default=sa.text("''")))
+
+def downgrade():
+    """
+    Downgrade has been disabled.
+    """
+    pass
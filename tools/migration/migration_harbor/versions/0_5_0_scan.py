@@ -0,0 +1,48 @@
+# Copyright (c) 2008-2016 VMware, Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""0.4.0 to 0.5.0: vulnerability scanning
+
+Revision ID: 0.5.0_scan
+Revises: 0.5.0_signature
+
+"""
+
+# revision identifiers, used by Alembic.
+revision = '0.5.0_scan'
+down_revision = '0.5.0_signature'
+branch_labels = None
+depends_on = None
+
+from alembic import op
+from db_meta import *
+
+from sqlalchemy.dialects import mysql
+
+def upgrade():
+    """
+    update schema&data
+    """
+    bind = op.get_bind()
+    #add column scan_on_push to table project
+    op.add_column('project', sa.Column('scan_on_push', mysql.TINYINT(1), nullable=False, server_default=sa.text("'0'")))
+    #create tables: scan_job, scan_result
+    ScanJob.__table__.create(bind)
+    ScanResult.__table__.create(bind)
+
+def downgrade():
+    """
+    Downgrade has been disabled.
+    """
+    pass